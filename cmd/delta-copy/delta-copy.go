@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	"k8s.io/klog/v2"
+)
+
+// Summary written to the termination log.
+type Summary struct {
+	Disks []DiskSummary `json:"disks"`
+}
+
+// DiskSummary reports the bytes copied for a disk.
+type DiskSummary struct {
+	ID    string `json:"id"`
+	Bytes int64  `json:"bytes"`
+}
+
+func main() {
+	var specPath, terminationLog string
+
+	flag.StringVar(&specPath, "spec", "/etc/delta-copy/spec.json", "Path to the transfer spec")
+	flag.StringVar(&terminationLog, "termination-log", "/dev/termination-log", "Path to the termination log")
+
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	spec, err := deltacopy.ReadSpec(specPath)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	summary := Summary{}
+	ebsSnapshots := &snapshots{}
+	for _, disk := range spec.Disks {
+		var copied int64
+		if disk.Snapshot != "" {
+			copied, err = ebsSnapshots.Copy(disk)
+		} else {
+			copied, err = copyDisk(disk)
+		}
+		if err != nil {
+			fail(terminationLog, err)
+		}
+		summary.Disks = append(summary.Disks, DiskSummary{ID: disk.ID, Bytes: copied})
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	err = os.WriteFile(terminationLog, b, 0644)
+	if err != nil {
+		klog.Error(err)
+	}
+}
+
+// Copy the disk, or its changed extents, to the target.
+func copyDisk(disk deltacopy.Disk) (copied int64, err error) {
+	var source deltacopy.Source
+	if disk.URL != "" {
+		klog.Infof("Copying disk '%s' from '%s' to '%s', %d bytes.",
+			disk.ID, disk.URL, disk.Target, disk.Bytes())
+		source, err = openPrism(disk.URL)
+	} else {
+		klog.Infof("Copying disk '%s' from '%s' (%s) to '%s', %d bytes.",
+			disk.ID, disk.Source, disk.Format, disk.Target, disk.Bytes())
+		source, err = deltacopy.Open(disk.Source, disk.Format)
+	}
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = source.Close()
+	}()
+	target, sparse, err := openTarget(disk)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = target.Close()
+	}()
+	extents := disk.Extents
+	if disk.Full {
+		extents = []deltacopy.Extent{{Offset: 0, Length: disk.Capacity}}
+	}
+	var progress, reported int64
+	copier := deltacopy.Copier{
+		Sparse: sparse,
+		Progress: func(n int64) {
+			progress += n
+			if progress-reported >= 1024*1024*1024 {
+				reported = progress
+				klog.Infof("Disk '%s': copied %d/%d bytes.", disk.ID, progress, disk.Bytes())
+			}
+		},
+	}
+	copied, err = copier.Copy(source, target, extents)
+	if err != nil {
+		return
+	}
+	err = target.Sync()
+	if err != nil {
+		return
+	}
+	klog.Infof("Disk '%s': copied %d bytes.", disk.ID, copied)
+	return
+}
+
+// Open the target block device or raw file. A missing file is created
+// with the disk capacity. Reports whether the unwritten ranges of the
+// target are known to read back as zeroes, which is the case for a new
+// file and for the blank image of a full copy.
+func openTarget(disk deltacopy.Disk) (target *os.File, sparse bool, err error) {
+	info, err := os.Stat(disk.Target)
+	if err == nil && info.Mode()&os.ModeDevice != 0 {
+		target, err = os.OpenFile(disk.Target, os.O_WRONLY, 0)
+		return
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	created := err != nil
+	target, err = os.OpenFile(disk.Target, os.O_WRONLY|os.O_CREATE, 0660)
+	if err != nil {
+		return
+	}
+	sparse = created || disk.Full
+	if created || info.Size() < disk.Capacity {
+		err = target.Truncate(disk.Capacity)
+	}
+	return
+}
+
+// Log the error, write it to the termination log and exit.
+func fail(terminationLog string, err error) {
+	_ = os.WriteFile(terminationLog, []byte(err.Error()), 0644)
+	klog.Fatal(err)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/ebs"
+	"k8s.io/klog/v2"
+)
+
+// Credential options read from the environment, populated
+// from the EC2 populator secret.
+const (
+	envRegion          = "region"
+	envAccessKeyID     = "accessKeyId"
+	envSecretAccessKey = "secretAccessKey"
+)
+
+// EBS snapshot reads through the EBS direct APIs.
+type snapshots struct {
+	client *ebs.Client
+}
+
+// Copy the blocks of an EBS snapshot, or only the blocks changed since
+// the base snapshot, to the target. The client is created on first use.
+func (r *snapshots) Copy(disk deltacopy.Disk) (copied int64, err error) {
+	if r.client == nil {
+		region := os.Getenv(envRegion)
+		accessKeyID := os.Getenv(envAccessKeyID)
+		secretAccessKey := os.Getenv(envSecretAccessKey)
+		if region == "" || accessKeyID == "" || secretAccessKey == "" {
+			err = errors.New("the region and the AWS access keys must be set")
+			return
+		}
+		r.client = ebs.NewFromConfig(aws.Config{
+			Region:      region,
+			Credentials: credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, ""),
+		})
+	}
+	klog.Infof("Copying disk '%s' from snapshot '%s' (base '%s') to '%s'.",
+		disk.ID, disk.Snapshot, disk.BaseSnapshot, disk.Target)
+	target, _, err := openTarget(disk)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = target.Close()
+	}()
+	var reported int64
+	copier := &ebs.Copy{
+		API:            r.client,
+		SnapshotID:     disk.Snapshot,
+		BaseSnapshotID: disk.BaseSnapshot,
+		Volume:         target,
+		Progress: func(offset, size int64) {
+			if offset-reported >= 1024*1024*1024 || offset == size {
+				reported = offset
+				klog.Infof("Disk '%s': reached offset %d/%d bytes.", disk.ID, offset, size)
+			}
+		},
+	}
+	copied, err = copier.Run(context.Background())
+	if err != nil {
+		return
+	}
+	err = target.Sync()
+	if err != nil {
+		return
+	}
+	klog.Infof("Disk '%s': copied %d bytes.", disk.ID, copied)
+	return
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+)
+
+// Open a disk served by Prism Central. The credentials are read from
+// the environment, populated from the provider secret.
+func openPrism(url string) (source deltacopy.Source, err error) {
+	tlsConfig := &tls.Config{}
+	if insecure, pErr := strconv.ParseBool(os.Getenv("insecureSkipVerify")); pErr == nil && insecure {
+		tlsConfig.InsecureSkipVerify = true
+	} else if cacert := os.Getenv("cacert"); cacert != "" {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(cacert)) {
+			err = errors.New("failed to parse CA certificate")
+			return
+		}
+		tlsConfig.RootCAs = roots
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(os.Getenv("user") + ":" + os.Getenv("password")))
+	source = &deltacopy.HTTP{
+		URL: url,
+		Client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		Header: http.Header{"Authorization": []string{"Basic " + auth}},
+	}
+	return
+}
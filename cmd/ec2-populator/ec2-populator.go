@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/kubev2v/forklift/pkg/metrics"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/ebs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/klog/v2"
+)
+
+// Secret fields exposed as environment variables.
+const (
+	envRegion          = "region"
+	envAccessKeyID     = "accessKeyId"
+	envSecretAccessKey = "secretAccessKey"
+)
+
+type AppConfig struct {
+	region      string
+	snapshotID  string
+	crNamespace string
+	crName      string
+	secretName  string
+	ownerUID    string
+	pvcSize     int64
+	volumePath  string
+}
+
+func main() {
+	config := &AppConfig{}
+	flag.StringVar(&config.region, "region", "", "AWS region of the snapshot")
+	flag.StringVar(&config.snapshotID, "snapshot-id", "", "EBS snapshot ID")
+	flag.StringVar(&config.secretName, "secret-name", "", "secret containing AWS credentials")
+	flag.StringVar(&config.volumePath, "volume-path", "", "Path to populate")
+	flag.StringVar(&config.crName, "cr-name", "", "Custom Resource instance name")
+	flag.StringVar(&config.crNamespace, "cr-namespace", "", "Custom Resource instance namespace")
+	flag.StringVar(&config.ownerUID, "owner-uid", "", "Owner UID (usually PVC UID)")
+	flag.Int64Var(&config.pvcSize, "pvc-size", 0, "Size of pvc (in bytes)")
+	flag.Parse()
+
+	if config.pvcSize <= 0 {
+		klog.Fatal("pvc-size must be greater than 0")
+	}
+	if config.snapshotID == "" {
+		klog.Fatal("snapshot-id must be set")
+	}
+
+	certsDirectory, err := os.MkdirTemp("", "certsdir")
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	metrics.StartPrometheusEndpoint(certsDirectory)
+
+	populate(config)
+}
+
+func populate(config *AppConfig) {
+	client := createClient(config)
+	file := openFile(config.volumePath)
+	defer file.Close()
+
+	progressVec := createProgressCounter()
+	copier := &ebs.Copy{
+		API:        client,
+		SnapshotID: config.snapshotID,
+		Volume:     file,
+		Progress: func(offset, size int64) {
+			updateProgress(progressVec, config.ownerUID, offset, size)
+		},
+	}
+	klog.Info("Copying the snapshot: ", config.snapshotID)
+	written, err := copier.Run(context.Background())
+	if err != nil {
+		klog.Fatal(err)
+	}
+	if err = file.Sync(); err != nil {
+		klog.Fatal(err)
+	}
+	finalizeProgress(progressVec, config.ownerUID)
+	klog.Info("Copied ", written, " bytes from snapshot ", config.snapshotID)
+}
+
+func createClient(config *AppConfig) *ebs.Client {
+	region := config.region
+	if region == "" {
+		region = os.Getenv(envRegion)
+	}
+	accessKeyID := os.Getenv(envAccessKeyID)
+	secretAccessKey := os.Getenv(envSecretAccessKey)
+	klog.Info("Options:")
+	klog.Info(" - ", envRegion, " = ", region)
+	klog.Info(" - ", envAccessKeyID, " = ", accessKeyID)
+	klog.Info(" - ", envSecretAccessKey, " = ", strings.Repeat("*", len(secretAccessKey)))
+	if region == "" || accessKeyID == "" || secretAccessKey == "" {
+		klog.Fatal("the region and the AWS access keys must be set")
+	}
+
+	return ebs.NewFromConfig(aws.Config{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, ""),
+	})
+}
+
+func createProgressCounter() *prometheus.CounterVec {
+	progressVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ec2_volume_populator_progress",
+			Help: "Progress of volume population",
+		},
+		[]string{"ownerUID"},
+	)
+
+	if err := prometheus.Register(progressVec); err != nil {
+		klog.Error("Prometheus progress counter not registered:", err)
+	}
+
+	return progressVec
+}
+
+func openFile(volumePath string) *os.File {
+	flags := os.O_RDWR
+	if strings.HasSuffix(volumePath, "disk.img") {
+		flags |= os.O_CREATE
+	}
+	file, err := os.OpenFile(volumePath, flags, 0650)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return file
+}
+
+func updateProgress(progress *prometheus.CounterVec, ownerUID string, offset, size int64) {
+	if size <= 0 {
+		return
+	}
+
+	metric := &dto.Metric{}
+	if err := progress.WithLabelValues(ownerUID).Write(metric); err != nil {
+		klog.Errorf("updateProgress: failed to write metric; %v", err)
+		return
+	}
+
+	currentProgress := (float64(offset) / float64(size)) * 100
+	if currentProgress > *metric.Counter.Value {
+		progress.WithLabelValues(ownerUID).Add(currentProgress - *metric.Counter.Value)
+	}
+
+	klog.Info("Progress: ", int64(currentProgress), "%")
+}
+
+func finalizeProgress(progress *prometheus.CounterVec, ownerUID string) {
+	currentVal := progress.WithLabelValues(ownerUID)
+
+	var metric dto.Metric
+	if err := currentVal.Write(&metric); err != nil {
+		klog.Error("Error reading current progress:", err)
+		return
+	}
+
+	if metric.Counter != nil {
+		remainingProgress := 100 - *metric.Counter.Value
+		if remainingProgress > 0 {
+			currentVal.Add(remainingProgress)
+		}
+	}
+
+	klog.Info("Finished populating the volume. Progress: 100%")
+}
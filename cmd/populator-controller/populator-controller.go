@@ -49,6 +49,13 @@ var populators = map[string]populator{
 		imageVar:        "OPENSTACK_POPULATOR_IMAGE",
 		metricsEndpoint: ":8081",
 	},
+	"ec2": {
+		kind:            "Ec2VolumePopulator",
+		resource:        "ec2volumepopulators",
+		controllerFunc:  getEc2PopulatorPodArgs,
+		imageVar:        "EC2_POPULATOR_IMAGE",
+		metricsEndpoint: ":8083",
+	},
 	"vsphere-xcopy": {
 		kind:            "VSphereXcopyVolumePopulator",
 		resource:        "vspherexcopyvolumepopulators",
@@ -150,7 +157,24 @@ func getOpenstackPopulatorPodArgs(rawBlock bool, u *unstructured.Unstructured, _
 	return args, nil
 }
 
-func getVXPopulatorPodArgs(_ bool, u *unstructured.Unstructured, pvc corev1.PersistentVolumeClaim) ([]string, error) {
+func getEc2PopulatorPodArgs(rawBlock bool, u *unstructured.Unstructured, _ corev1.PersistentVolumeClaim) ([]string, error) {
+	var ec2Populator v1beta1.Ec2VolumePopulator
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &ec2Populator)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{}
+	args = append(args, "--volume-path="+getVolumePath(rawBlock))
+	args = append(args, "--region="+ec2Populator.Spec.Region)
+	args = append(args, "--snapshot-id="+ec2Populator.Spec.SnapshotID)
+	args = append(args, "--secret-name="+ec2Populator.Spec.SecretName)
+	args = append(args, "--cr-name="+ec2Populator.Name)
+	args = append(args, "--cr-namespace="+ec2Populator.Namespace)
+
+	return args, nil
+}
+
+func getVXPopulatorPodArgs(rawBlock bool, u *unstructured.Unstructured, pvc corev1.PersistentVolumeClaim) ([]string, error) {
 	var xcopy v1beta1.VSphereXcopyVolumePopulator
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &xcopy)
 	if nil != err {
@@ -169,6 +193,15 @@ func getVXPopulatorPodArgs(_ bool, u *unstructured.Unstructured, pvc corev1.Pers
 	if xcopy.Spec.MigrationHost != "" {
 		args = append(args, "--migration-host="+xcopy.Spec.MigrationHost)
 	}
+	if xcopy.Spec.DriverImage != "" {
+		args = append(args, "--storage-driver="+populator_machinery.ExternalDriverPath)
+	}
+	if xcopy.Spec.NFSServer != "" && xcopy.Spec.NFSPath != "" {
+		args = append(args, "--source-nfs-server="+xcopy.Spec.NFSServer)
+		args = append(args, "--source-nfs-path="+xcopy.Spec.NFSPath)
+		args = append(args, "--source-nfs-mount="+populator_machinery.SourceDatastorePath)
+		args = append(args, "--target-path="+getVolumePath(rawBlock))
+	}
 	return args, nil
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -9,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	populator_machinery "github.com/kubev2v/forklift/pkg/lib-volume-populator/populator-machinery"
 )
 
 func TestGetVXPopulatorPodArgs_MigrationHost(t *testing.T) {
@@ -73,3 +75,130 @@ func TestGetVXPopulatorPodArgs_MigrationHost(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVXPopulatorPodArgs_StorageDriver(t *testing.T) {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pvc", Namespace: "default"},
+	}
+
+	tests := []struct {
+		name        string
+		product     string
+		driverImage string
+		wantArg     bool
+	}{
+		{
+			name:        "external driver",
+			product:     "external",
+			driverImage: "quay.io/vendor/driver:1.0",
+			wantArg:     true,
+		},
+		{
+			name:    "built-in driver",
+			product: "ontap",
+			wantArg: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xcopy := &v1beta1.VSphereXcopyVolumePopulator{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-xcopy", Namespace: "default"},
+				Spec: v1beta1.VSphereXcopyVolumePopulatorSpec{
+					VmId:                 "vm-123",
+					VmdkPath:             "[ds] vm/disk.vmdk",
+					SecretName:           "secret",
+					StorageVendorProduct: tt.product,
+					DriverImage:          tt.driverImage,
+				},
+			}
+
+			raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(xcopy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := &unstructured.Unstructured{Object: raw}
+
+			args, err := getVXPopulatorPodArgs(false, u, pvc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := false
+			for _, arg := range args {
+				if arg == "--storage-driver="+populator_machinery.ExternalDriverPath {
+					found = true
+				}
+			}
+
+			if tt.wantArg != found {
+				t.Errorf("--storage-driver in args = %v, want %v: %v", found, tt.wantArg, args)
+			}
+		})
+	}
+}
+
+func TestGetVXPopulatorPodArgs_NFSDatastore(t *testing.T) {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pvc", Namespace: "default"},
+	}
+
+	tests := []struct {
+		name      string
+		nfsServer string
+		nfsPath   string
+		want      []string
+	}{
+		{
+			name:      "NFS datastore",
+			nfsServer: "nfs.example.com",
+			nfsPath:   "/vol/datastore1",
+			want: []string{
+				"--source-nfs-server=nfs.example.com",
+				"--source-nfs-path=/vol/datastore1",
+				"--source-nfs-mount=" + populator_machinery.SourceDatastorePath,
+				"--target-path=" + devicePath,
+			},
+		},
+		{
+			name: "VMFS datastore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xcopy := &v1beta1.VSphereXcopyVolumePopulator{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-xcopy", Namespace: "default"},
+				Spec: v1beta1.VSphereXcopyVolumePopulatorSpec{
+					VmId:                 "vm-123",
+					VmdkPath:             "[ds] vm/disk.vmdk",
+					SecretName:           "secret",
+					StorageVendorProduct: "ontap",
+					NFSServer:            tt.nfsServer,
+					NFSPath:              tt.nfsPath,
+				},
+			}
+
+			raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(xcopy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := &unstructured.Unstructured{Object: raw}
+
+			args, err := getVXPopulatorPodArgs(true, u, pvc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nfsArgs := []string{}
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--source-nfs-") || strings.HasPrefix(arg, "--target-path=") {
+					nfsArgs = append(nfsArgs, arg)
+				}
+			}
+			if strings.Join(nfsArgs, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("NFS args = %v, want %v", nfsArgs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	liburl "net/url"
+	"os"
+	"path"
+
+	"github.com/kubev2v/forklift/pkg/lib/vmdk"
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/klog/v2"
+)
+
+// Header carrying the token of a VirtualMachineExport.
+const exportTokenHeader = "x-kubevirt-export-token"
+
+// Summary written to the termination log.
+type Summary struct {
+	Disks []DiskSummary `json:"disks"`
+}
+
+// DiskSummary reports the bytes uploaded for a disk.
+type DiskSummary struct {
+	ID    string `json:"id"`
+	Bytes int64  `json:"bytes"`
+}
+
+func main() {
+	var specPath, terminationLog string
+
+	flag.StringVar(&specPath, "spec", "/etc/vmdk-upload/spec.json", "Path to the upload spec")
+	flag.StringVar(&terminationLog, "termination-log", "/dev/termination-log", "Path to the termination log")
+
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	spec, err := vmdk.ReadSpec(specPath)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	ctx := context.Background()
+	client, err := connect(ctx, spec)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	finder := find.NewFinder(client)
+	datacenter, err := finder.Datacenter(ctx, spec.Datacenter)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	finder.SetDatacenter(datacenter)
+	source, err := exportClient(spec)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	summary := Summary{}
+	for _, disk := range spec.Disks {
+		var uploaded int64
+		uploaded, err = upload(ctx, client, finder, datacenter, source, spec.Token, disk)
+		if err != nil {
+			fail(terminationLog, err)
+		}
+		summary.Disks = append(summary.Disks, DiskSummary{ID: disk.ID, Bytes: uploaded})
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		fail(terminationLog, err)
+	}
+	err = os.WriteFile(terminationLog, b, 0644)
+	if err != nil {
+		klog.Error(err)
+	}
+}
+
+// Log in to vSphere.
+func connect(ctx context.Context, spec *vmdk.Spec) (client *vim25.Client, err error) {
+	url, err := liburl.Parse(spec.URL)
+	if err != nil {
+		return
+	}
+	url.User = liburl.UserPassword(spec.User, spec.Password)
+	soapClient := soap.NewClient(url, spec.Insecure)
+	soapClient.SetThumbprint(url.Host, spec.Thumbprint)
+	client, err = vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return
+	}
+	err = session.NewManager(client).Login(ctx, url.User)
+	return
+}
+
+// Build the HTTP client reading the disks from the export server.
+func exportClient(spec *vmdk.Spec) (client *http.Client, err error) {
+	transport := &http.Transport{}
+	if spec.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(spec.CACert)) {
+			err = errors.New("failed to parse the export CA certificate")
+			return
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	client = &http.Client{Transport: transport}
+	return
+}
+
+// Upload a disk as the flat extent and the descriptor of a VMDK.
+// The image is padded with zeroes to whole sectors.
+func upload(
+	ctx context.Context,
+	client *vim25.Client,
+	finder *find.Finder,
+	datacenter *object.Datacenter,
+	source *http.Client,
+	token string,
+	disk vmdk.Disk) (uploaded int64, err error) {
+	datastore, err := finder.Datastore(ctx, disk.Datastore)
+	if err != nil {
+		return
+	}
+	err = object.NewFileManager(client).MakeDirectory(ctx, datastore.Path(path.Dir(disk.Path)), datacenter, true)
+	if err != nil && !fault.Is(err, &types.FileAlreadyExists{}) {
+		return
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, disk.URL, nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set(exportTokenHeader, token)
+	response, err := source.Do(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("reading disk '%s' failed: %s", disk.ID, response.Status)
+		return
+	}
+	if response.ContentLength < 0 {
+		err = fmt.Errorf("size of disk '%s' unknown", disk.ID)
+		return
+	}
+	capacity := vmdk.Capacity(response.ContentLength)
+	flat := vmdk.FlatPath(disk.Path)
+	klog.Infof("Uploading disk '%s' from '%s' to '[%s] %s', %d bytes.",
+		disk.ID, disk.URL, disk.Datastore, flat, capacity)
+	image := &progress{
+		Reader: io.MultiReader(
+			response.Body,
+			io.LimitReader(zeroes{}, capacity-response.ContentLength)),
+		disk:     disk.ID,
+		capacity: capacity,
+	}
+	err = datastore.Upload(ctx, image, flat, &soap.Upload{ContentLength: capacity})
+	if err != nil {
+		return
+	}
+	uploaded = image.read
+	if uploaded != capacity {
+		err = fmt.Errorf("disk '%s': read %d bytes, want %d", disk.ID, uploaded, capacity)
+		return
+	}
+	descriptor := []byte(vmdk.Descriptor(path.Base(flat), capacity))
+	err = datastore.Upload(
+		ctx,
+		bytes.NewReader(descriptor),
+		disk.Path,
+		&soap.Upload{ContentLength: int64(len(descriptor))})
+	if err != nil {
+		return
+	}
+	klog.Infof("Disk '%s': uploaded %d bytes.", disk.ID, uploaded)
+	return
+}
+
+// Reader logging the progress of a disk.
+type progress struct {
+	io.Reader
+	disk     string
+	capacity int64
+	read     int64
+	reported int64
+}
+
+func (r *progress) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.read += int64(n)
+	if r.read-r.reported >= 1024*1024*1024 {
+		r.reported = r.read
+		klog.Infof("Disk '%s': uploaded %d/%d bytes.", r.disk, r.read, r.capacity)
+	}
+	return
+}
+
+// Reader of zeroes.
+type zeroes struct{}
+
+func (zeroes) Read(p []byte) (n int, err error) {
+	clear(p)
+	n = len(p)
+	return
+}
+
+// Log the error, write it to the termination log and exit.
+func fail(terminationLog string, err error) {
+	_ = os.WriteFile(terminationLog, []byte(err.Error()), 0644)
+	klog.Fatal(err)
+}
@@ -0,0 +1,398 @@
+// Package external implements the populator storage API with an
+// out-of-process driver, so that storage vendors can ship drivers for
+// their arrays without changes to the populator.
+//
+// The driver is an executable invoked once per operation as
+// `<driver> <method>`, with the JSON encoded request on stdin. It writes
+// JSON lines to stdout: any number of progress lines followed by a
+// single result or error line. The protocol is described in
+// docs/external-drivers.md.
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/logger"
+	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/populator"
+	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/vmware"
+	"k8s.io/klog/v2"
+)
+
+// ProtocolVersion is the version of the driver protocol implemented by
+// the populator. Drivers report the version they implement in the
+// capabilities reply.
+const ProtocolVersion = 1
+
+// Driver methods.
+const (
+	MethodCapabilities        = "capabilities"
+	MethodEnsureClonnerIgroup = "ensure-clonner-igroup"
+	MethodMapTarget           = "map-target"
+	MethodUnmapTarget         = "unmap-target"
+	MethodMap                 = "map"
+	MethodUnMap               = "unmap"
+	MethodCurrentMappedGroups = "current-mapped-groups"
+	MethodResolvePVToLUN      = "resolve-pv-to-lun"
+	MethodVVolCopy            = "vvol-copy"
+	MethodRDMCopy             = "rdm-copy"
+)
+
+// maxLine is the longest line accepted on the driver output.
+const maxLine = 1024 * 1024
+
+// Capabilities reported by the driver.
+type Capabilities struct {
+	// Version of the protocol implemented by the driver.
+	Version int `json:"version"`
+	// The driver implements the vvol-copy method.
+	VVol bool `json:"vvol,omitempty"`
+	// The driver implements the rdm-copy method.
+	RDM bool `json:"rdm,omitempty"`
+	// Array metadata used for the metric labels.
+	ArrayInfo ArrayInfo `json:"arrayInfo"`
+}
+
+// ArrayInfo describes the storage array.
+type ArrayInfo struct {
+	Vendor  string `json:"vendor,omitempty"`
+	Product string `json:"product,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// LUN is the wire representation of populator.LUN.
+type LUN struct {
+	Name                string `json:"name,omitempty"`
+	NAA                 string `json:"naa,omitempty"`
+	SerialNumber        string `json:"serialNumber,omitempty"`
+	IQN                 string `json:"iqn,omitempty"`
+	ProviderID          string `json:"providerID,omitempty"`
+	VolumeHandle        string `json:"volumeHandle,omitempty"`
+	LDeviceID           string `json:"lDeviceID,omitempty"`
+	StorageSerialNumber string `json:"storageSerialNumber,omitempty"`
+	Protocol            string `json:"protocol,omitempty"`
+}
+
+// PersistentVolume is the wire representation of populator.PersistentVolume.
+type PersistentVolume struct {
+	Name             string            `json:"name"`
+	VolumeHandle     string            `json:"volumeHandle"`
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
+}
+
+// Request sent to the driver. Only the fields used by the method are set.
+type Request struct {
+	InitiatorGroup   string                   `json:"initiatorGroup,omitempty"`
+	ClonnerIqn       []string                 `json:"clonnerIqn,omitempty"`
+	LUN              *LUN                     `json:"lun,omitempty"`
+	Context          populator.MappingContext `json:"context,omitempty"`
+	PersistentVolume *PersistentVolume        `json:"persistentVolume,omitempty"`
+	VmId             string                   `json:"vmId,omitempty"`
+	SourceVMDKFile   string                   `json:"sourceVmdkFile,omitempty"`
+}
+
+// Reply is a line written by the driver.
+type Reply struct {
+	// Progress of a copy, in percent.
+	Progress *uint64 `json:"progress,omitempty"`
+	// Result of the method.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error reported by the driver.
+	Error string `json:"error,omitempty"`
+}
+
+// Driver is the storage API of an external driver.
+type Driver struct {
+	path         string
+	env          []string
+	capabilities Capabilities
+	log          klog.Logger
+}
+
+// vvolDriver is a driver implementing the VVol copy.
+type vvolDriver struct {
+	*Driver
+}
+
+// rdmDriver is a driver implementing the RDM copy.
+type rdmDriver struct {
+	*Driver
+}
+
+// fullDriver is a driver implementing both the VVol and RDM copies.
+type fullDriver struct {
+	*Driver
+}
+
+// Ensure the drivers implement the optional interfaces.
+var (
+	_ populator.StorageArrayInfoProvider = &Driver{}
+	_ populator.VVolCapable              = &vvolDriver{}
+	_ populator.RDMCapable               = &rdmDriver{}
+	_ populator.VVolCapable              = &fullDriver{}
+	_ populator.RDMCapable               = &fullDriver{}
+)
+
+// NewDriver queries the capabilities of the driver at path and returns
+// a storage API implementing the optional interfaces it supports. The
+// env entries are appended to the environment of the populator when the
+// driver is invoked.
+func NewDriver(path string, env []string) (populator.StorageApi, error) {
+	d := &Driver{
+		path: path,
+		env:  env,
+		log:  logger.New("external"),
+	}
+	err := d.call(MethodCapabilities, &Request{}, &d.capabilities, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the capabilities of storage driver %s: %w", path, err)
+	}
+	if d.capabilities.Version != ProtocolVersion {
+		return nil, fmt.Errorf("storage driver %s implements protocol version %d, expected %d",
+			path, d.capabilities.Version, ProtocolVersion)
+	}
+	d.log.Info("external storage driver loaded", "path", path,
+		"vvol", d.capabilities.VVol, "rdm", d.capabilities.RDM,
+		"vendor", d.capabilities.ArrayInfo.Vendor, "product", d.capabilities.ArrayInfo.Product)
+	switch {
+	case d.capabilities.VVol && d.capabilities.RDM:
+		return &fullDriver{d}, nil
+	case d.capabilities.VVol:
+		return &vvolDriver{d}, nil
+	case d.capabilities.RDM:
+		return &rdmDriver{d}, nil
+	default:
+		return d, nil
+	}
+}
+
+// GetStorageArrayInfo returns the array metadata reported by the driver.
+func (d *Driver) GetStorageArrayInfo() populator.StorageArrayInfo {
+	info := d.capabilities.ArrayInfo
+	return populator.StorageArrayInfo{
+		Vendor:  info.Vendor,
+		Product: info.Product,
+		Model:   info.Model,
+		Version: info.Version,
+	}
+}
+
+func (d *Driver) EnsureClonnerIgroup(initiatorGroup string, clonnerIqn []string) (populator.MappingContext, error) {
+	var mappingContext populator.MappingContext
+	err := d.call(
+		MethodEnsureClonnerIgroup,
+		&Request{InitiatorGroup: initiatorGroup, ClonnerIqn: clonnerIqn},
+		&mappingContext,
+		nil)
+	return mappingContext, err
+}
+
+func (d *Driver) MapTarget(targetLUN populator.LUN, context populator.MappingContext) (populator.LUN, error) {
+	return d.mapLUN(MethodMapTarget, "", targetLUN, context)
+}
+
+func (d *Driver) UnmapTarget(targetLUN populator.LUN, context populator.MappingContext) error {
+	return d.call(MethodUnmapTarget, &Request{LUN: toLUN(targetLUN), Context: context}, nil, nil)
+}
+
+func (d *Driver) Map(initiatorGroup string, targetLUN populator.LUN, context populator.MappingContext) (populator.LUN, error) {
+	return d.mapLUN(MethodMap, initiatorGroup, targetLUN, context)
+}
+
+func (d *Driver) UnMap(initiatorGroup string, targetLUN populator.LUN, context populator.MappingContext) error {
+	return d.call(
+		MethodUnMap,
+		&Request{InitiatorGroup: initiatorGroup, LUN: toLUN(targetLUN), Context: context},
+		nil,
+		nil)
+}
+
+func (d *Driver) CurrentMappedGroups(targetLUN populator.LUN, context populator.MappingContext) ([]string, error) {
+	var groups []string
+	err := d.call(MethodCurrentMappedGroups, &Request{LUN: toLUN(targetLUN), Context: context}, &groups, nil)
+	return groups, err
+}
+
+func (d *Driver) ResolvePVToLUN(persistentVolume populator.PersistentVolume) (populator.LUN, error) {
+	lun := LUN{}
+	err := d.call(MethodResolvePVToLUN, &Request{PersistentVolume: toPersistentVolume(persistentVolume)}, &lun, nil)
+	if err != nil {
+		return populator.LUN{}, err
+	}
+	return fromLUN(lun), nil
+}
+
+// VvolCopy runs the VVol copy in the driver. The driver connects to
+// vSphere with the credentials found in its environment.
+func (d *vvolDriver) VvolCopy(_ vmware.Client, vmId string, sourceVMDKFile string, persistentVolume populator.PersistentVolume, progress chan<- uint64) error {
+	return d.copy(MethodVVolCopy, vmId, sourceVMDKFile, persistentVolume, progress)
+}
+
+// RDMCopy runs the RDM copy in the driver. The driver connects to
+// vSphere with the credentials found in its environment.
+func (d *rdmDriver) RDMCopy(_ vmware.Client, vmId string, sourceVMDKFile string, persistentVolume populator.PersistentVolume, progress chan<- uint64) error {
+	return d.copy(MethodRDMCopy, vmId, sourceVMDKFile, persistentVolume, progress)
+}
+
+// VvolCopy runs the VVol copy in the driver.
+func (d *fullDriver) VvolCopy(_ vmware.Client, vmId string, sourceVMDKFile string, persistentVolume populator.PersistentVolume, progress chan<- uint64) error {
+	return d.copy(MethodVVolCopy, vmId, sourceVMDKFile, persistentVolume, progress)
+}
+
+// RDMCopy runs the RDM copy in the driver.
+func (d *fullDriver) RDMCopy(_ vmware.Client, vmId string, sourceVMDKFile string, persistentVolume populator.PersistentVolume, progress chan<- uint64) error {
+	return d.copy(MethodRDMCopy, vmId, sourceVMDKFile, persistentVolume, progress)
+}
+
+func (d *Driver) mapLUN(method, initiatorGroup string, targetLUN populator.LUN, context populator.MappingContext) (populator.LUN, error) {
+	lun := LUN{}
+	err := d.call(
+		method,
+		&Request{InitiatorGroup: initiatorGroup, LUN: toLUN(targetLUN), Context: context},
+		&lun,
+		nil)
+	if err != nil {
+		return targetLUN, err
+	}
+	return fromLUN(lun), nil
+}
+
+func (d *Driver) copy(method, vmId, sourceVMDKFile string, persistentVolume populator.PersistentVolume, progress chan<- uint64) error {
+	return d.call(
+		method,
+		&Request{VmId: vmId, SourceVMDKFile: sourceVMDKFile, PersistentVolume: toPersistentVolume(persistentVolume)},
+		nil,
+		progress)
+}
+
+// call invokes a method of the driver. The result, when not nil, is
+// decoded from the result line. Progress lines are forwarded to the
+// progress channel when not nil. The driver stderr is logged.
+func (d *Driver) call(method string, request *Request, result any, progress chan<- uint64) error {
+	log := d.log.WithValues("method", method)
+	in, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(d.path, method)
+	cmd.Env = append(os.Environ(), d.env...)
+	cmd.Stdin = bytes.NewReader(in)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	log.V(2).Info("invoking storage driver")
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start storage driver %s: %w", d.path, err)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		scanner.Buffer(nil, maxLine)
+		for scanner.Scan() {
+			log.Info(scanner.Text(), "source", "driver")
+		}
+	}()
+
+	var reply *Reply
+	readErr := d.read(stdout, progress, &reply)
+	// Drain the output so the driver does not block on writes.
+	_, _ = io.Copy(io.Discard, stdout)
+	wg.Wait()
+	waitErr := cmd.Wait()
+
+	switch {
+	case reply != nil && reply.Error != "":
+		return fmt.Errorf("storage driver %s failed: %s", method, reply.Error)
+	case waitErr != nil:
+		return fmt.Errorf("storage driver %s failed: %w", method, waitErr)
+	case readErr != nil:
+		return fmt.Errorf("storage driver %s returned an invalid reply: %w", method, readErr)
+	case reply == nil:
+		return fmt.Errorf("storage driver %s exited without a reply", method)
+	}
+	if result != nil && len(reply.Result) > 0 {
+		err = json.Unmarshal(reply.Result, result)
+		if err != nil {
+			return fmt.Errorf("storage driver %s returned an invalid result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// read the driver output up to the final reply. The reply is left nil
+// when the output ends without one.
+func (d *Driver) read(stdout io.Reader, progress chan<- uint64, reply **Reply) error {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, maxLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r := &Reply{}
+		err := json.Unmarshal(line, r)
+		if err != nil {
+			return err
+		}
+		if r.Progress != nil {
+			if progress != nil {
+				progress <- *r.Progress
+			}
+			continue
+		}
+		*reply = r
+		return nil
+	}
+	return scanner.Err()
+}
+
+func toLUN(lun populator.LUN) *LUN {
+	return &LUN{
+		Name:                lun.Name,
+		NAA:                 lun.NAA,
+		SerialNumber:        lun.SerialNumber,
+		IQN:                 lun.IQN,
+		ProviderID:          lun.ProviderID,
+		VolumeHandle:        lun.VolumeHandle,
+		LDeviceID:           lun.LDeviceID,
+		StorageSerialNumber: lun.StorageSerialNumber,
+		Protocol:            lun.Protocol,
+	}
+}
+
+func fromLUN(lun LUN) populator.LUN {
+	return populator.LUN{
+		Name:                lun.Name,
+		NAA:                 lun.NAA,
+		SerialNumber:        lun.SerialNumber,
+		IQN:                 lun.IQN,
+		ProviderID:          lun.ProviderID,
+		VolumeHandle:        lun.VolumeHandle,
+		LDeviceID:           lun.LDeviceID,
+		StorageSerialNumber: lun.StorageSerialNumber,
+		Protocol:            lun.Protocol,
+	}
+}
+
+func toPersistentVolume(pv populator.PersistentVolume) *PersistentVolume {
+	return &PersistentVolume{
+		Name:             pv.Name,
+		VolumeHandle:     pv.VolumeHandle,
+		VolumeAttributes: pv.VolumeAttributes,
+	}
+}
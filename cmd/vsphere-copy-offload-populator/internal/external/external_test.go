@@ -0,0 +1,227 @@
+package external
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/populator"
+)
+
+// fakeDriver writes a shell script driver answering each method with
+// the given output lines and returns its path. The request read on
+// stdin is saved to <method>.json next to the script.
+func fakeDriver(t *testing.T, replies map[string][]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := strings.Builder{}
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("cat > \"$(dirname \"$0\")/$1.json\"\n")
+	script.WriteString("echo \"driver invoked: $1\" >&2\n")
+	script.WriteString("case \"$1\" in\n")
+	for method, lines := range replies {
+		script.WriteString(method + ")\n")
+		for _, line := range lines {
+			if line == "exit" {
+				script.WriteString("  exit 3\n")
+				continue
+			}
+			script.WriteString("  echo '" + line + "'\n")
+		}
+		script.WriteString("  ;;\n")
+	}
+	script.WriteString("*)\n  echo '{\"error\":\"unsupported method\"}'\n  exit 2\n  ;;\nesac\n")
+	path := filepath.Join(dir, "driver")
+	err := os.WriteFile(path, []byte(script.String()), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func request(t *testing.T, driver, method string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(filepath.Dir(driver), method+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestNewDriver_Capabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities string
+		vvol         bool
+		rdm          bool
+	}{
+		{name: "vmdk only", capabilities: `{"result":{"version":1}}`},
+		{name: "vvol", capabilities: `{"result":{"version":1,"vvol":true}}`, vvol: true},
+		{name: "rdm", capabilities: `{"result":{"version":1,"rdm":true}}`, rdm: true},
+		{name: "vvol and rdm", capabilities: `{"result":{"version":1,"vvol":true,"rdm":true}}`, vvol: true, rdm: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := fakeDriver(t, map[string][]string{MethodCapabilities: {tt.capabilities}})
+			api, err := NewDriver(driver, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := api.(populator.VVolCapable); ok != tt.vvol {
+				t.Errorf("VVolCapable = %v, want %v", ok, tt.vvol)
+			}
+			if _, ok := api.(populator.RDMCapable); ok != tt.rdm {
+				t.Errorf("RDMCapable = %v, want %v", ok, tt.rdm)
+			}
+		})
+	}
+}
+
+func TestNewDriver_ArrayInfo(t *testing.T) {
+	driver := fakeDriver(t, map[string][]string{
+		MethodCapabilities: {`{"result":{"version":1,"arrayInfo":{"vendor":"Acme","product":"Array","model":"A1","version":"2.0"}}}`},
+	})
+	api, err := NewDriver(driver, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := api.(populator.StorageArrayInfoProvider).GetStorageArrayInfo()
+	want := populator.StorageArrayInfo{Vendor: "Acme", Product: "Array", Model: "A1", Version: "2.0"}
+	if info != want {
+		t.Errorf("array info = %+v, want %+v", info, want)
+	}
+}
+
+func TestNewDriver_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{name: "protocol version", lines: []string{`{"result":{"version":2}}`}, want: "protocol version 2"},
+		{name: "error reply", lines: []string{`{"error":"array unreachable"}`}, want: "array unreachable"},
+		{name: "no reply", lines: []string{}, want: "without a reply"},
+		{name: "exit status", lines: []string{"exit"}, want: "exit status 3"},
+		{name: "invalid reply", lines: []string{"not json"}, want: "invalid reply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := fakeDriver(t, map[string][]string{MethodCapabilities: tt.lines})
+			_, err := NewDriver(driver, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDriver_Mapping(t *testing.T) {
+	driver := fakeDriver(t, map[string][]string{
+		MethodCapabilities:        {`{"result":{"version":1}}`},
+		MethodEnsureClonnerIgroup: {`{"result":{"hostID":"h-1"}}`},
+		MethodResolvePVToLUN:      {`{"result":{"name":"vol-1","naa":"naa.600a0980"}}`},
+		MethodMap:                 {`{"result":{"name":"vol-1","naa":"naa.600a0980","lDeviceID":"12"}}`},
+		MethodCurrentMappedGroups: {`{"result":["esx-group"]}`},
+		MethodUnMap:               {`{"result":null}`},
+	})
+	api, err := NewDriver(driver, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mappingContext, err := api.EnsureClonnerIgroup("ocp-group", []string{"iqn.1994-05.com.redhat:node"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mappingContext["hostID"] != "h-1" {
+		t.Errorf("mapping context = %v", mappingContext)
+	}
+	if got := request(t, driver, MethodEnsureClonnerIgroup); !strings.Contains(got, `"clonnerIqn":["iqn.1994-05.com.redhat:node"]`) {
+		t.Errorf("request = %s", got)
+	}
+
+	lun, err := api.ResolvePVToLUN(populator.PersistentVolume{Name: "pv-1", VolumeHandle: "handle-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lun.Name != "vol-1" || lun.NAA != "naa.600a0980" {
+		t.Errorf("lun = %+v", lun)
+	}
+	if got := request(t, driver, MethodResolvePVToLUN); !strings.Contains(got, `"volumeHandle":"handle-1"`) {
+		t.Errorf("request = %s", got)
+	}
+
+	lun, err = api.Map("esx-group", lun, mappingContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lun.LDeviceID != "12" {
+		t.Errorf("lun = %+v", lun)
+	}
+	if got := request(t, driver, MethodMap); !strings.Contains(got, `"initiatorGroup":"esx-group"`) || !strings.Contains(got, `"context":{"hostID":"h-1"}`) {
+		t.Errorf("request = %s", got)
+	}
+
+	groups, err := api.CurrentMappedGroups(lun, mappingContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0] != "esx-group" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	err = api.UnMap("esx-group", lun, mappingContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.UnmapTarget(lun, mappingContext)
+	if err == nil || !strings.Contains(err.Error(), "unsupported method") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestDriver_VvolCopy(t *testing.T) {
+	driver := fakeDriver(t, map[string][]string{
+		MethodCapabilities: {`{"result":{"version":1,"vvol":true}}`},
+		MethodVVolCopy:     {`{"progress":10}`, `{"progress":50}`, `{"progress":100}`, `{"result":null}`},
+	})
+	api, err := NewDriver(driver, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := make(chan uint64, 10)
+	err = api.(populator.VVolCapable).VvolCopy(nil, "vm-1", "[ds] vm/vm.vmdk", populator.PersistentVolume{Name: "pv-1"}, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(progress)
+	var got []uint64
+	for p := range progress {
+		got = append(got, p)
+	}
+	if len(got) != 3 || got[0] != 10 || got[2] != 100 {
+		t.Errorf("progress = %v", got)
+	}
+	if req := request(t, driver, MethodVVolCopy); !strings.Contains(req, `"sourceVmdkFile":"[ds] vm/vm.vmdk"`) || !strings.Contains(req, `"vmId":"vm-1"`) {
+		t.Errorf("request = %s", req)
+	}
+}
+
+func TestDriver_Env(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "driver")
+	script := "#!/bin/sh\ncat > /dev/null\necho \"{\\\"result\\\":{\\\"version\\\":1,\\\"arrayInfo\\\":{\\\"vendor\\\":\\\"$STORAGE_HOSTNAME\\\"}}}\"\n"
+	err := os.WriteFile(path, []byte(script), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := NewDriver(path, []string{"STORAGE_HOSTNAME=array.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vendor := api.(populator.StorageArrayInfoProvider).GetStorageArrayInfo().Vendor; vendor != "array.example.com" {
+		t.Errorf("vendor = %q", vendor)
+	}
+}
@@ -22,6 +22,17 @@ type SSHConfig struct {
 	PublicKey  []byte
 }
 
+// NFSConfig holds the NFS datastore of the source VMDK for the NFS populator
+type NFSConfig struct {
+	// Server and ExportPath identify the export of the datastore
+	Server     string
+	ExportPath string
+	// MountPath is where the export is mounted read-only in the populator pod
+	MountPath string
+	// TargetPath is the block device or image file of the target volume
+	TargetPath string
+}
+
 // NewPopulator creates a Populator with an embedded CopyContext describing how the
 // copy will be performed (clone method, source disk sizes). StorageProtocol is
 // detected by the populator during Populate() and available via GetCopyContext().
@@ -33,6 +44,7 @@ func NewPopulator(
 	vmId string,
 	vmdkPath string,
 	sshConfig *SSHConfig,
+	nfsConfig *NFSConfig,
 ) (Populator, error) {
 	// Create vSphere client for type detection
 	vsphereClient, err := vmware.NewClient(vsphereHostname, vsphereUsername, vspherePassword)
@@ -61,6 +73,13 @@ func NewPopulator(
 			log.Info("using RDM populator")
 			return createRDMPopulator(storageApi, vsphereClient, sourceDiskCap, sourceDatastoreAlloc)
 		}
+
+	case resolver.DiskTypeVMDK:
+		// Copy from the export when the NFS datastore is mounted
+		if nfsConfig != nil {
+			log.Info("using NFS populator", "server", nfsConfig.Server, "export", nfsConfig.ExportPath)
+			return createNFSPopulator(*nfsConfig, sourceDiskCap, sourceDatastoreAlloc)
+		}
 	}
 
 	log.Info("using VMDK/Xcopy populator")
@@ -113,6 +132,12 @@ func createRDMPopulator(storageApi StorageApi, vmwareClient vmware.Client, sourc
 	return NewRDMPopulator(rdmApi, vmwareClient, copyCtx)
 }
 
+// createNFSPopulator creates NFS populator copying from the mounted export
+func createNFSPopulator(nfsConfig NFSConfig, sourceDiskCap, sourceDatastoreAlloc int64) (Populator, error) {
+	copyCtx := CopyContext{CloneMethod: "nfs-copy", StorageProtocol: "nfs", SourceDiskCapacityBytes: sourceDiskCap, SourceDiskDatastoreAllocatedBytes: sourceDatastoreAlloc}
+	return NewNFSPopulator(nfsConfig, copyCtx)
+}
+
 // createVMDKPopulator creates VMDK/Xcopy populator (default/fallback)
 func createVMDKPopulator(storageApi StorageApi, vmwareClient vmware.Client, sshConfig *SSHConfig, sourceDiskCap, sourceDatastoreAlloc int64) (Populator, error) {
 	vmdkApi, ok := storageApi.(VMDKCapable)
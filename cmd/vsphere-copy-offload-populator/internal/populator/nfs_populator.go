@@ -0,0 +1,285 @@
+package populator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxDescriptorSize bounds the read of a VMDK descriptor, which is a small text file
+	maxDescriptorSize = 64 * 1024
+	// sparseChunkSize is the unit the NFS copy reads and skips when it is all zeros
+	sparseChunkSize = 1024 * 1024
+)
+
+// extentLine matches an extent of a VMDK descriptor, e.g. `RW 41943040 VMFS "disk-1-flat.vmdk"`
+var extentLine = regexp.MustCompile(`^(RW|RDONLY|NOACCESS)\s+(\d+)\s+(\S+)\s+"([^"]+)"`)
+
+// NFSPopulator handles population of VMDKs on NFS datastores. The flat VMDK is
+// copied from the read-only mount of the datastore export into the target volume.
+type NFSPopulator struct {
+	config  NFSConfig
+	copyCtx CopyContext
+}
+
+func (p *NFSPopulator) GetCopyContext() CopyContext {
+	return p.copyCtx
+}
+
+// NewNFSPopulator creates a new NFS populator
+func NewNFSPopulator(config NFSConfig, copyCtx CopyContext) (Populator, error) {
+	if config.MountPath == "" {
+		return nil, fmt.Errorf("the NFS datastore %s:%s is not mounted", config.Server, config.ExportPath)
+	}
+	if config.TargetPath == "" {
+		return nil, fmt.Errorf("the target volume path is required to copy from the NFS datastore")
+	}
+	return &NFSPopulator{
+		config:  config,
+		copyCtx: copyCtx,
+	}, nil
+}
+
+// Populate copies the flat VMDK of sourceVMDKFile into the target volume
+func (p *NFSPopulator) Populate(ctx context.Context, vmId string, migrationHostId, sourceVMDKFile string, pv PersistentVolume, hostLocker Hostlocker, progress chan<- uint64, xcopyUsed chan<- int, quit chan error) (errFinal error) {
+	log := klog.Background().WithName("copy-offload").WithName("nfs")
+	defer func() {
+		r := recover()
+		if r != nil {
+			log.Info("recovered from panic", "panic", r)
+			quit <- fmt.Errorf("recovered failure: %v", r)
+			return
+		}
+		log.Info("NFS copy exiting", "err", errFinal)
+		quit <- errFinal
+	}()
+
+	log.Info("NFS copy started", "vm", vmId, "source", sourceVMDKFile, "target", pv.Name, "method", p.copyCtx.CloneMethod)
+
+	// NFS copy does not use xcopy
+	xcopyUsed <- 0
+
+	vmDisk, err := ParseVmdkPath(sourceVMDKFile)
+	if err != nil {
+		return err
+	}
+	flatFile, err := flatExtent(filepath.Join(p.config.MountPath, vmDisk.VmHomeDir, vmDisk.VmdkFile))
+	if err != nil {
+		return fmt.Errorf("failed to read the descriptor of %s: %w", sourceVMDKFile, err)
+	}
+	flatPath := filepath.Join(p.config.MountPath, vmDisk.VmHomeDir, flatFile)
+	info, err := os.Stat(flatPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat the flat VMDK of %s: %w", sourceVMDKFile, err)
+	}
+	log.Info("flat VMDK found", "file", flatFile, "bytes", info.Size())
+
+	if err := copySparse(ctx, flatPath, p.config.TargetPath, progress); err != nil {
+		log.Error(err, "NFS copy failed")
+		return fmt.Errorf("failed to copy VMDK from the NFS datastore: %w", err)
+	}
+	log.Info("NFS copy finished")
+	return nil
+}
+
+// flatExtent returns the file name of the single flat extent of the VMDK descriptor at
+// descriptorPath. Sparse, snapshot and raw device extents are not supported.
+func flatExtent(descriptorPath string) (string, error) {
+	f, err := os.Open(descriptorPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	var extents []string
+	scanner := bufio.NewScanner(io.LimitReader(f, maxDescriptorSize))
+	for scanner.Scan() {
+		match := extentLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		if extentType := match[3]; extentType != "VMFS" && extentType != "FLAT" {
+			return "", fmt.Errorf("extent %q of type %s is not a flat extent", match[4], extentType)
+		}
+		extents = append(extents, match[4])
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	switch len(extents) {
+	case 0:
+		return "", fmt.Errorf("no extent found in %s, it is not a VMDK descriptor", descriptorPath)
+	case 1:
+		return extents[0], nil
+	default:
+		return "", fmt.Errorf("%d extents found in %s, only single extent disks are supported", len(extents), descriptorPath)
+	}
+}
+
+// copySparse copies the file at srcPath into the block device or file at dstPath, skipping
+// the writes of the holes of the source and of the chunks that are all zeros. A file target
+// is truncated first so that the skipped ranges read as zeros. A block device may hold stale
+// data, so the skipped ranges are zeroed on it. Progress is reported in percent.
+func copySparse(ctx context.Context, srcPath, dstPath string, progress chan<- uint64) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	size := srcInfo.Size()
+
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dst.Close() }()
+	dstInfo, err := dst.Stat()
+	if err != nil {
+		return err
+	}
+	zeroed := false
+	if dstInfo.Mode().IsRegular() {
+		// A filesystem volume holds the disk as an image file, sized like the source.
+		// Dropping its content first makes the whole file read as zeros.
+		if err := dst.Truncate(0); err != nil {
+			return err
+		}
+		if err := dst.Truncate(size); err != nil {
+			return err
+		}
+		zeroed = true
+	}
+	if err := copyRanges(ctx, src, dst, size, zeroed, progress); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
+
+// copyRanges copies the data of src into dst. The holes and the chunks that are all zeros
+// are not written, and are zeroed on dst unless it is known to read as zeros.
+func copyRanges(ctx context.Context, src, dst *os.File, size int64, zeroed bool, progress chan<- uint64) error {
+	buf := make([]byte, sparseChunkSize)
+	var reported uint64
+	report := func(offset int64) {
+		if size == 0 {
+			return
+		}
+		if percent := uint64(offset * 100 / size); percent > reported {
+			reported = percent
+			progress <- percent
+		}
+	}
+	// written is the end of the last range written to dst, the range
+	// from written up to the next write is zeroed before the write
+	written := int64(0)
+	zero := &zeroer{dst: dst}
+	fill := func(end int64) error {
+		if !zeroed && written < end {
+			if err := zero.zeroRange(written, end); err != nil {
+				return fmt.Errorf("failed to zero %s at offset %d: %w", dst.Name(), written, err)
+			}
+		}
+		written = end
+		return nil
+	}
+
+	for offset := int64(0); offset < size; {
+		start, end := dataRange(src, offset, size)
+		for start < end {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n := min(end-start, sparseChunkSize)
+			chunk := buf[:n]
+			if _, err := src.ReadAt(chunk, start); err != nil {
+				return fmt.Errorf("failed to read %s at offset %d: %w", src.Name(), start, err)
+			}
+			if !isZero(chunk) {
+				if err := fill(start); err != nil {
+					return err
+				}
+				if _, err := dst.WriteAt(chunk, start); err != nil {
+					return fmt.Errorf("failed to write %s at offset %d: %w", dst.Name(), start, err)
+				}
+				written = start + n
+			}
+			start += n
+			report(start)
+		}
+		offset = end
+		report(offset)
+	}
+	return fill(size)
+}
+
+// zeroer zeroes ranges of a block device. The range is deallocated with fallocate, which
+// guarantees that it reads as zeros, and zeros are written when the device does not support it.
+type zeroer struct {
+	dst *os.File
+	// noPunch is set once fallocate failed, the next ranges are written
+	noPunch bool
+	zeros   []byte
+}
+
+func (z *zeroer) zeroRange(start, end int64) error {
+	if !z.noPunch {
+		err := unix.Fallocate(int(z.dst.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, start, end-start)
+		if err == nil {
+			return nil
+		}
+		klog.V(2).Infof("punching a hole in %s failed, writing zeros: %v", z.dst.Name(), err)
+		z.noPunch = true
+	}
+	if z.zeros == nil {
+		z.zeros = make([]byte, sparseChunkSize)
+	}
+	for start < end {
+		n := min(end-start, sparseChunkSize)
+		if _, err := z.dst.WriteAt(z.zeros[:n], start); err != nil {
+			return err
+		}
+		start += n
+	}
+	return nil
+}
+
+// dataRange returns the range of the next data region at or after offset. The whole
+// remainder of the file is data when the filesystem cannot report holes (e.g. NFSv3).
+func dataRange(f *os.File, offset, size int64) (int64, int64) {
+	fd := int(f.Fd())
+	start, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+	if errors.Is(err, unix.ENXIO) {
+		// no data after offset
+		return size, size
+	}
+	if err != nil {
+		return offset, size
+	}
+	end, err := unix.Seek(fd, start, unix.SEEK_HOLE)
+	if err != nil || end > size {
+		end = size
+	}
+	return start, end
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,299 @@
+package populator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlatExtent(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor string
+		want       string
+		wantErr    string
+	}{
+		{
+			name: "flat disk on NFS",
+			descriptor: `# Disk DescriptorFile
+version=1
+createType="vmfs"
+
+# Extent description
+RW 41943040 VMFS "disk-1-flat.vmdk"
+`,
+			want: "disk-1-flat.vmdk",
+		},
+		{
+			name:       "name with spaces",
+			descriptor: "RW 2048 FLAT \"my vm-flat.vmdk\" 0\n",
+			want:       "my vm-flat.vmdk",
+		},
+		{
+			name:       "snapshot delta",
+			descriptor: "RW 41943040 SESPARSE \"disk-1-000001-sesparse.vmdk\"\n",
+			wantErr:    "is not a flat extent",
+		},
+		{
+			name:       "split disk",
+			descriptor: "RW 4192256 FLAT \"disk-f001.vmdk\" 0\nRW 4192256 FLAT \"disk-f002.vmdk\" 0\n",
+			wantErr:    "only single extent disks are supported",
+		},
+		{
+			name:       "not a descriptor",
+			descriptor: "KDMV\x01\x00\x00\x00",
+			wantErr:    "it is not a VMDK descriptor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptorPath := filepath.Join(t.TempDir(), "disk-1.vmdk")
+			if err := os.WriteFile(descriptorPath, []byte(tt.descriptor), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := flatExtent(descriptorPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("flatExtent() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("flatExtent() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("flatExtent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopySparse(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "disk-1-flat.vmdk")
+	dstPath := filepath.Join(dir, "disk.img")
+
+	// 8 MiB source with data at the start, in the middle and a hole at the end
+	size := int64(8 * sparseChunkSize)
+	src, err := os.Create(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	head := bytes.Repeat([]byte{0xab}, 4096)
+	middle := bytes.Repeat([]byte{0xcd}, sparseChunkSize+512)
+	if _, err := src.WriteAt(head, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.WriteAt(middle, 3*sparseChunkSize+100); err != nil {
+		t.Fatal(err)
+	}
+	_ = src.Close()
+
+	progress := make(chan uint64, 101)
+	if err := copySparse(context.Background(), srcPath, dstPath, progress); err != nil {
+		t.Fatalf("copySparse() unexpected error: %v", err)
+	}
+	close(progress)
+
+	want, _ := os.ReadFile(srcPath)
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("target content differs from the source")
+	}
+
+	var last uint64
+	for p := range progress {
+		if p <= last {
+			t.Errorf("progress %d reported after %d", p, last)
+		}
+		last = p
+	}
+	if last != 100 {
+		t.Errorf("last progress = %d, want 100", last)
+	}
+}
+
+// sparseSource writes an 8 MiB source with data at the start, in the middle and a hole at the end
+func sparseSource(t *testing.T, path string) {
+	t.Helper()
+	src, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = src.Close() }()
+	if err := src.Truncate(8 * sparseChunkSize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.WriteAt(bytes.Repeat([]byte{0xab}, 4096), 0); err != nil {
+		t.Fatal(err)
+	}
+	// a chunk of zeros written as data
+	if _, err := src.WriteAt(make([]byte, sparseChunkSize), sparseChunkSize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.WriteAt(bytes.Repeat([]byte{0xcd}, sparseChunkSize+512), 3*sparseChunkSize+100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopySparseStaleTarget(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "disk-1-flat.vmdk")
+	dstPath := filepath.Join(dir, "disk.img")
+	sparseSource(t, srcPath)
+	// the target holds stale data, longer than the source
+	if err := os.WriteFile(dstPath, bytes.Repeat([]byte{0xff}, 9*sparseChunkSize), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copySparse(context.Background(), srcPath, dstPath, make(chan uint64, 101)); err != nil {
+		t.Fatalf("copySparse() unexpected error: %v", err)
+	}
+
+	want, _ := os.ReadFile(srcPath)
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("target content differs from the source")
+	}
+}
+
+func TestCopyRangesZeroesTarget(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "disk-1-flat.vmdk")
+	dstPath := filepath.Join(dir, "disk.img")
+	sparseSource(t, srcPath)
+	// a target that is not known to read as zeros, like a block device
+	size := int64(8 * sparseChunkSize)
+	if err := os.WriteFile(dstPath, bytes.Repeat([]byte{0xff}, int(size)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = src.Close() }()
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	if err := copyRanges(context.Background(), src, dst, size, false, make(chan uint64, 101)); err != nil {
+		t.Fatalf("copyRanges() unexpected error: %v", err)
+	}
+
+	want, _ := os.ReadFile(srcPath)
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("target content differs from the source")
+	}
+}
+
+func TestZeroRangeWrites(t *testing.T) {
+	dstPath := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(dstPath, bytes.Repeat([]byte{0xff}, 3*sparseChunkSize), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = dst.Close() }()
+
+	// the device does not support fallocate
+	z := &zeroer{dst: dst, noPunch: true}
+	if err := z.zeroRange(100, 2*sparseChunkSize+100); err != nil {
+		t.Fatalf("zeroRange() unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bytes.Repeat([]byte{0xff}, 3*sparseChunkSize)
+	copy(want[100:], make([]byte, 2*sparseChunkSize))
+	if !bytes.Equal(got, want) {
+		t.Error("range not zeroed")
+	}
+}
+
+func TestCopySparseCancelled(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "disk-1-flat.vmdk")
+	if err := os.WriteFile(srcPath, bytes.Repeat([]byte{1}, sparseChunkSize), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := copySparse(ctx, srcPath, filepath.Join(dir, "disk.img"), make(chan uint64, 101))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("copySparse() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNFSPopulatorCopy(t *testing.T) {
+	mount := t.TempDir()
+	if err := os.Mkdir(filepath.Join(mount, "vm-1"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	descriptor := "RW 8 VMFS \"disk-1-flat.vmdk\"\n"
+	if err := os.WriteFile(filepath.Join(mount, "vm-1", "disk-1.vmdk"), []byte(descriptor), 0600); err != nil {
+		t.Fatal(err)
+	}
+	flat := bytes.Repeat([]byte{1}, 4096)
+	if err := os.WriteFile(filepath.Join(mount, "vm-1", "disk-1-flat.vmdk"), flat, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(t.TempDir(), "disk.img")
+	p, err := NewNFSPopulator(NFSConfig{Server: "nfs.example.com", ExportPath: "/vol/ds1", MountPath: mount, TargetPath: target}, CopyContext{CloneMethod: "nfs-copy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pv := PersistentVolume{Name: "pv-1", VolumeHandle: "handle-1"}
+	progress := make(chan uint64, 101)
+	xcopyUsed := make(chan int, 1)
+	quit := make(chan error, 1)
+	if err := p.Populate(context.Background(), "vm-1", "", "[ds1] vm-1/disk-1.vmdk", pv, nil, progress, xcopyUsed, quit); err != nil {
+		t.Fatalf("Populate() unexpected error: %v", err)
+	}
+	if err := <-quit; err != nil {
+		t.Errorf("quit error = %v", err)
+	}
+
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, flat) {
+		t.Error("the target does not match the flat VMDK")
+	}
+}
+
+func TestNewNFSPopulatorRequiresTarget(t *testing.T) {
+	if _, err := NewNFSPopulator(NFSConfig{MountPath: "/mnt/source-datastore"}, CopyContext{}); err == nil {
+		t.Error("expected error without a target path when copying from the mount")
+	}
+	if _, err := NewNFSPopulator(NFSConfig{TargetPath: "/dev/block"}, CopyContext{}); err == nil {
+		t.Error("expected error without a mount of the NFS datastore")
+	}
+}
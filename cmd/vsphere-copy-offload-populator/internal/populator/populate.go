@@ -10,7 +10,7 @@ import (
 // Populated by the factory at construction time; StorageProtocol may be
 // updated by the populator during Populate() when adapter info is available.
 type CopyContext struct {
-	// CloneMethod is the method used for the copy: "vib", "ssh", "vvol", or "rdm".
+	// CloneMethod is the method used for the copy: "vib", "ssh", "vvol", "rdm" or "nfs-copy".
 	CloneMethod string
 	// StorageProtocol is the storage protocol when known (e.g. "iscsi", "fc"). May be empty.
 	StorageProtocol string
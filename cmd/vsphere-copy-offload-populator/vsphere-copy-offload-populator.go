@@ -19,6 +19,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/client-go/util/cert"
 
+	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/external"
 	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/flashsystem"
 	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/infinibox"
 	"github.com/kubev2v/forklift/cmd/vsphere-copy-offload-populator/internal/metrics"
@@ -65,6 +66,11 @@ var (
 	esxiCloneMethod            string
 	storageAPITimeoutSeconds   string
 	migrationHost              string
+	storageDriver              string
+	sourceNFSServer            string
+	sourceNFSPath              string
+	sourceNFSMount             string
+	targetPath                 string
 
 	// kube args
 	httpEndpoint string
@@ -83,34 +89,50 @@ func main() {
 
 	var storageApi populator.StorageApi
 	product := forklift.StorageVendorProduct(storageVendor)
-	switch product {
-	case forklift.StorageVendorProductVantara:
+	switch {
+	case storageDriver != "":
+		// Out-of-process driver shipped by the storage vendor.
+		sm, err := external.NewDriver(storageDriver, []string{
+			"STORAGE_HOSTNAME=" + storageHostname,
+			"STORAGE_USERNAME=" + storageUsername,
+			"STORAGE_PASSWORD=" + storagePassword,
+			"STORAGE_TOKEN=" + storageToken,
+			"STORAGE_SKIP_SSL_VERIFICATION=" + storageSkipSSLVerification,
+			"GOVMOMI_HOSTNAME=" + vsphereHostname,
+			"GOVMOMI_USERNAME=" + vsphereUsername,
+			"GOVMOMI_PASSWORD=" + vspherePassword,
+		})
+		if err != nil {
+			klog.Fatalf("failed to initialize external storage driver with %s", err)
+		}
+		storageApi = sm
+	case product == forklift.StorageVendorProductVantara:
 		sm, err := vantara.NewVantaraClonner(storageHostname, storageUsername, storagePassword)
 		if err != nil {
 			klog.Fatalf("failed to initialize Vantara storage mapper with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductOntap:
+	case product == forklift.StorageVendorProductOntap:
 		sm, err := ontap.NewNetappClonner(storageHostname, storageUsername, storagePassword)
 		if err != nil {
 			klog.Fatalf("failed to initialize Ontap storage mapper with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductFlashSystem:
+	case product == forklift.StorageVendorProductFlashSystem:
 		tokenCache := flashsystem.NewSecretTokenCache(clientSet, storageHostname)
 		sm, err := flashsystem.NewFlashSystemClonner(storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true", tokenCache)
 		if err != nil {
 			klog.Fatalf("failed to initialize flashsystem storage mapper with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductPrimera3Par:
+	case product == forklift.StorageVendorProductPrimera3Par:
 		sm, err := primera3par.NewPrimera3ParClonner(
 			storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true")
 		if err != nil {
 			klog.Fatalf("failed to initialize primera3par clonner with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductPureFlashArray:
+	case product == forklift.StorageVendorProductPureFlashArray:
 		apiTimeout, err := strconv.Atoi(storageAPITimeoutSeconds)
 		if err != nil && storageAPITimeoutSeconds != "" {
 			klog.Warningf("invalid value %q for storage-http-timeout-seconds, using default (30s): %v", storageAPITimeoutSeconds, err)
@@ -121,7 +143,7 @@ func main() {
 			klog.Fatalf("failed to initialize Pure FlashArray clonner with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductPowerFlex:
+	case product == forklift.StorageVendorProductPowerFlex:
 		systemId := os.Getenv(powerflex.SYSTEM_ID_ENV_KEY)
 		sm, err := powerflex.NewPowerflexClonner(
 			storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true", systemId)
@@ -129,21 +151,21 @@ func main() {
 			klog.Fatalf("failed to initialize PowerFlex clonner with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductPowerMax:
+	case product == forklift.StorageVendorProductPowerMax:
 		sm, err := powermax.NewPowermaxClonner(
 			storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true")
 		if err != nil {
 			klog.Fatalf("failed to initialize PowerMax clonner with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductPowerStore:
+	case product == forklift.StorageVendorProductPowerStore:
 		sm, err := powerstore.NewPowerstoreClonner(
 			storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true")
 		if err != nil {
 			klog.Fatalf("failed to initialize PowerStore clonner with %s", err)
 		}
 		storageApi = &sm
-	case forklift.StorageVendorProductInfinibox:
+	case product == forklift.StorageVendorProductInfinibox:
 		sm, err := infinibox.NewInfiniboxClonner(
 			storageHostname, storageUsername, storagePassword, storageSkipSSLVerification == "true")
 		if err != nil {
@@ -177,6 +199,17 @@ func main() {
 		}
 	}
 
+	// The populator controller mounts the export of NFS datastores in the pod
+	var nfsConfig *populator.NFSConfig
+	if sourceNFSServer != "" {
+		nfsConfig = &populator.NFSConfig{
+			Server:     sourceNFSServer,
+			ExportPath: sourceNFSPath,
+			MountPath:  sourceNFSMount,
+			TargetPath: targetPath,
+		}
+	}
+
 	// Select the appropriate populator based on disk type
 	p, err := populator.NewPopulator(
 		storageApi,
@@ -186,6 +219,7 @@ func main() {
 		sourceVmId,
 		sourceVMDKFile,
 		sshConfig,
+		nfsConfig,
 	)
 	if err != nil {
 		klog.Fatalf("Failed to initialize populator: %s", err)
@@ -228,7 +262,7 @@ func main() {
 	cloneLog := log.WithName("xcopy").WithName("clone")
 	log.Info("copy-offload started")
 
-	cloneMethod := copyCtx.CloneMethod // always set by factory: "vib", "ssh", "vvol", or "rdm"
+	cloneMethod := copyCtx.CloneMethod // always set by factory: "vib", "ssh", "vvol", "rdm" or "nfs-copy"
 	var copyStartTime time.Time
 	lastXcopyUsed := 0
 
@@ -372,7 +406,7 @@ func handleArgs() {
 	flag.StringVar(&secretName, "secret-name", "", "Secret name the populator controller uses it to mount env vars from it. Not for use internally")
 	flag.StringVar(&sourceVmId, "source-vm-id", "", "VM object id in vsphere")
 	flag.StringVar(&sourceVMDKFile, "source-vmdk", "", "File name to populate")
-	flag.StringVar(&storageVendor, "storage-vendor-product", os.Getenv("STORAGE_VENDOR"), "The storage vendor to work with. Current values: [flashsystem, infinibox, ontap, powerflex, powermax, powerstore, primera3par, pureFlashArray, vantara]")
+	flag.StringVar(&storageVendor, "storage-vendor-product", os.Getenv("STORAGE_VENDOR"), "The storage vendor to work with. Current values: [external, flashsystem, infinibox, ontap, powerflex, powermax, powerstore, primera3par, pureFlashArray, vantara]")
 	flag.StringVar(&targetNamespace, "target-namespace", "", "Contents to populate file with")
 	flag.StringVar(&storageHostname, "storage-hostname", os.Getenv("STORAGE_HOSTNAME"), "The storage vendor api hostname")
 	flag.StringVar(&storageUsername, "storage-username", os.Getenv("STORAGE_USERNAME"), "The storage vendor api username")
@@ -385,6 +419,11 @@ func handleArgs() {
 	flag.StringVar(&esxiCloneMethod, "esxi-clone-method", os.Getenv("ESXI_CLONE_METHOD"), "ESXi clone method: 'vib' (default) or 'ssh'")
 	flag.StringVar(&storageAPITimeoutSeconds, "storage-http-timeout-seconds", os.Getenv("STORAGE_HTTP_TIMEOUT_SECONDS"), "HTTP client timeout in seconds for storage API requests (default: 30)")
 	flag.StringVar(&migrationHost, "migration-host", "", "the ESXi host ID to use for the XCOPY operation")
+	flag.StringVar(&storageDriver, "storage-driver", os.Getenv("STORAGE_DRIVER"), "Path to an external storage driver executable. Overrides the built-in driver of the storage vendor")
+	flag.StringVar(&sourceNFSServer, "source-nfs-server", "", "The NFS server exporting the datastore of the source vmdk, when it is an NFS datastore")
+	flag.StringVar(&sourceNFSPath, "source-nfs-path", "", "The path of the NFS datastore export on the NFS server")
+	flag.StringVar(&sourceNFSMount, "source-nfs-mount", "", "Where the NFS datastore export is mounted read-only")
+	flag.StringVar(&targetPath, "target-path", "", "The block device or image file of the target volume, written when copying from the NFS datastore")
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	// Metrics args
@@ -29,14 +29,15 @@ const GlanceSource = "glance"
 
 // Conditions
 const (
-	ConditionExecuting = "Executing"
-	ConditionRunning   = "Running"
-	ConditionPending   = "Pending"
-	ConditionCanceled  = "Canceled"
-	ConditionSucceeded = "Succeeded"
-	ConditionFailed    = "Failed"
-	ConditionBlocked   = "Blocked"
-	ConditionDeleted   = "Deleted"
+	ConditionExecuting  = "Executing"
+	ConditionRunning    = "Running"
+	ConditionPending    = "Pending"
+	ConditionCanceled   = "Canceled"
+	ConditionSucceeded  = "Succeeded"
+	ConditionFailed     = "Failed"
+	ConditionBlocked    = "Blocked"
+	ConditionDeleted    = "Deleted"
+	ConditionRolledBack = "RolledBack"
 )
 
 // Condition categories
@@ -56,6 +57,20 @@ const (
 	PhasePreHook   = "PreHook"
 	PhasePostHook  = "PostHook"
 	PhaseCompleted = "Completed"
+	// Rolled back to the source after completion.
+	PhaseRolledBack = "RolledBack"
+)
+
+// Hook phases run at other points of the itinerary.
+const (
+	// Before the source VM is powered off.
+	PhasePrePowerOffHook = "PrePowerOffHook"
+	// After the disk transfer, before the guest conversion.
+	PhasePostTransferHook = "PostTransferHook"
+	// Before the cutover of a warm migration.
+	PhasePreCutoverHook = "PreCutoverHook"
+	// After the VM migration has failed.
+	PhaseFailureHook = "FailureHook"
 )
 
 // Warm and cold phases.
@@ -0,0 +1,49 @@
+package v1beta1
+
+import (
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var Ec2VolumePopulatorKind = "Ec2VolumePopulator"
+var Ec2VolumePopulatorResource = "ec2volumepopulators"
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName={ec2vp,ec2vps}
+type Ec2VolumePopulator struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Ec2VolumePopulatorSpec `json:"spec"`
+	// +optional
+	Status Ec2VolumePopulatorStatus `json:"status"`
+}
+
+type Ec2VolumePopulatorSpec struct {
+	// Region is the AWS region of the snapshot.
+	Region string `json:"region"`
+	// SnapshotID is the EBS snapshot read through the EBS direct APIs.
+	SnapshotID string `json:"snapshotId"`
+	// The secret name with the AWS credentials.
+	SecretName string `json:"secretName"`
+	// The network attachment definition that should be used for disk transfer.
+	TransferNetwork *core.ObjectReference `json:"transferNetwork,omitempty"`
+}
+
+type Ec2VolumePopulatorStatus struct {
+	// +optional
+	Progress string `json:"progress"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type Ec2VolumePopulatorList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []Ec2VolumePopulator `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Ec2VolumePopulator{}, &Ec2VolumePopulatorList{})
+}
@@ -85,6 +85,12 @@ type ForkliftControllerSpec struct {
 	// +kubebuilder:validation:Enum="true";"false"
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:radio:true","urn:alm:descriptor:com.tectonic.ui:radio:false"}
 	FeatureMCPServer string `json:"feature_mcp_server,omitempty"`
+	// Keep the inventory on a persistent volume across controller restarts.
+	// +optional
+	// +kubebuilder:default="false"
+	// +kubebuilder:validation:Enum="true";"false"
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:radio:true","urn:alm:descriptor:com.tectonic.ui:radio:false"}
+	FeatureInventoryPersistence string `json:"feature_inventory_persistence,omitempty"`
 	// Run VMware driver removal scripts during Windows vSphere conversion.
 	// +optional
 	// +kubebuilder:default="false"
@@ -248,6 +254,16 @@ type ForkliftControllerSpec struct {
 	// +kubebuilder:validation:Pattern="^[0-9]+(s|m|h)$"
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
 	InventoryRouteTimeout string `json:"inventory_route_timeout,omitempty"`
+	// Inventory persistent volume size.
+	// +optional
+	// +kubebuilder:default="10Gi"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
+	InventoryVolumeSize string `json:"inventory_volume_size,omitempty"`
+	// Inventory persistent volume storage class. Optional. If left empty, the default storage class is used.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
+	InventoryVolumeStorageClass string `json:"inventory_volume_storage_class,omitempty"`
 
 	// API Resource Configuration
 
@@ -519,6 +535,25 @@ type ForkliftControllerSpec struct {
 	// +kubebuilder:validation:Minimum=1
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
 	ControllerMaxPopulatorInflight *int32 `json:"controller_max_populator_inflight,omitempty"`
+	// Max concurrent disk migrations per source datastore (vSphere only).
+	// Format: "<default>,<datastore>=<limit>,..." where the datastore is matched
+	// by ID or name and a limit of 0 is unlimited. Example: "4,nfs-slow=1".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^\s*(([^,=]+=)?[0-9]+\s*)?(,\s*([^,=]+=)?[0-9]+\s*)*$`
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
+	ControllerMaxDatastoreDisksInflight string `json:"controller_max_datastore_disks_inflight,omitempty"`
+	// Max concurrent disk migrations per target storage class (vSphere only).
+	// Format: "<default>,<storage class>=<limit>,..." where a limit of 0 is unlimited.
+	// Example: "0,ocs-storagecluster-ceph-rbd=10".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^\s*(([^,=]+=)?[0-9]+\s*)?(,\s*([^,=]+=)?[0-9]+\s*)*$`
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
+	ControllerMaxStorageClassDisksInflight string `json:"controller_max_storage_class_disks_inflight,omitempty"`
+	// Max capacity of the disks migrated concurrently (vSphere only), e.g. "10Ti".
+	// Empty or 0 is unlimited.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:hidden"}
+	ControllerMaxBytesInflight string `json:"controller_max_bytes_inflight,omitempty"`
 	// Precopy interval in minutes.
 	// +optional
 	// +kubebuilder:default=60
@@ -1,6 +1,7 @@
 package v1beta1
 
 import (
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,6 +29,10 @@ type HookSpec struct {
 	// When specified, the hook will trigger an AAP job template instead of running a local playbook.
 	// +optional
 	AAP *AAPConfig `json:"aap,omitempty"`
+	// Policy applied when the hook fails. Defaults to failing the VM migration.
+	// May be overridden by the hook reference on the plan.
+	// +optional
+	FailurePolicy *plan.HookFailurePolicy `json:"failurePolicy,omitempty"`
 }
 
 // AAPConfig defines configuration for executing hooks via Ansible Automation Platform.
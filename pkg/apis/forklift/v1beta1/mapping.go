@@ -37,7 +37,8 @@ type DestinationNetwork struct {
 	// - pod: Use the Kubernetes pod network
 	// - multus: Use a Multus additional network
 	// - ignored: Network is excluded from mapping
-	// +kubebuilder:validation:Enum=pod;multus;ignored
+	// - vsphere: Use a vSphere network (vSphere destination only)
+	// +kubebuilder:validation:Enum=pod;multus;ignored;vsphere
 	Type string `json:"type"`
 	// The namespace (multus only).
 	Namespace string `json:"namespace,omitempty"`
@@ -72,12 +73,13 @@ type OffloadPlugin struct {
 
 // CsiVolumeImport uses the CSI driver's native import capability to migrate VVol/RDM disks.
 // The controller creates a PVC with import annotations; the CSI driver clones the source array
-// volume directly — no populator pod, no CR, no service account is launched.
+// volume directly — no populator pod, no CR, no service account is launched. For drivers
+// without an import annotation, the PVC is bound to a pre-created PV adopting the source volume.
 type CsiVolumeImport struct {
 	// SecretRef is the name of the secret with storage credentials, in the source provider's namespace.
 	SecretRef string `json:"secretRef"`
 	// StorageVendorProduct identifies the storage array vendor.
-	// +kubebuilder:validation:Enum=primera3par;ontap
+	// +kubebuilder:validation:Enum=primera3par;ontap;pureFlashArray;powerstore;powermax;flashsystem
 	StorageVendorProduct StorageVendorProduct `json:"storageVendorProduct"`
 }
 
@@ -95,6 +97,9 @@ const (
 	StorageVendorProductPowerMax       StorageVendorProduct = "powermax"
 	StorageVendorProductPowerStore     StorageVendorProduct = "powerstore"
 	StorageVendorProductInfinibox      StorageVendorProduct = "infinibox"
+	// StorageVendorProductExternal selects an out-of-process driver
+	// shipped in the image referenced by the DriverImage field.
+	StorageVendorProductExternal StorageVendorProduct = "external"
 )
 
 func StorageVendorProducts() []StorageVendorProduct {
@@ -108,6 +113,7 @@ func StorageVendorProducts() []StorageVendorProduct {
 		StorageVendorProductPowerMax,
 		StorageVendorProductPowerStore,
 		StorageVendorProductInfinibox,
+		StorageVendorProductExternal,
 	}
 }
 
@@ -118,12 +124,22 @@ type VSphereXcopyPluginConfig struct {
 	// The secret should reside in the same namespace where the source provider is.
 	SecretRef string `json:"secretRef"`
 	// StorageVendorProduct the string identifier of the storage vendor product
-	// +kubebuilder:validation:Enum=flashsystem;vantara;ontap;primera3par;pureFlashArray;powerflex;powermax;powerstore;infinibox
+	// +kubebuilder:validation:Enum=flashsystem;vantara;ontap;primera3par;pureFlashArray;powerflex;powermax;powerstore;infinibox;external
 	StorageVendorProduct StorageVendorProduct `json:"storageVendorProduct"`
+	// DriverImage is the image shipping an out-of-process storage driver.
+	// Required when the storage vendor product is 'external'. The driver
+	// protocol is described in cmd/vsphere-copy-offload-populator/docs/external-drivers.md.
+	// +optional
+	DriverImage string `json:"driverImage,omitempty"`
 	// DedicatedMigrationHosts is a list of ESXi host IDs to use for
 	// XCOPY migrations instead of the VM's registered host.
 	// +optional
 	DedicatedMigrationHosts []string `json:"dedicatedMigrationHosts,omitempty"`
+	// CopyFromNFSExport copies the VMDKs of NFS datastores from the
+	// datastore export mounted in the populator pod rather than
+	// cloning them with vmkfstools on the ESXi host.
+	// +optional
+	CopyFromNFSExport bool `json:"copyFromNfsExport,omitempty"`
 }
 
 // Mapped storage.
@@ -139,7 +155,10 @@ type StoragePair struct {
 // Mapped storage destination.
 type DestinationStorage struct {
 	// A storage class.
+	// +optional
 	StorageClass string `json:"storageClass"`
+	// A datastore name (vSphere destination only).
+	Datastore string `json:"datastore,omitempty"`
 	// Volume mode.
 	// +kubebuilder:validation:Enum=Filesystem;Block
 	VolumeMode core.PersistentVolumeMode `json:"volumeMode,omitempty"`
@@ -17,6 +17,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
@@ -34,6 +37,165 @@ type VMCutover struct {
 	Cutover meta.Time `json:"cutover"`
 }
 
+// Recurring window in which VMs may be started.
+// The window opens on the listed days at the start time and
+// closes at the end time, on the next day when the end time
+// is not after the start time. Example: weeknights 22:00-04:00.
+type MigrationWindow struct {
+	// Days of the week the window opens. Every day when empty.
+	// +optional
+	Days []Weekday `json:"days,omitempty"`
+	// Time the window opens, formatted HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// Time the window closes, formatted HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// IANA time zone of the start and end times. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Day of the week.
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type Weekday string
+
+// Validate the window.
+func (r *MigrationWindow) Validate() (err error) {
+	_, err = r.location()
+	if err != nil {
+		return
+	}
+	_, _, err = r.clock(r.Start)
+	if err != nil {
+		return
+	}
+	_, _, err = r.clock(r.End)
+	if err != nil {
+		return
+	}
+	for _, day := range r.Days {
+		if _, found := r.weekday(day); !found {
+			err = fmt.Errorf("day %q not valid", day)
+			return
+		}
+	}
+	return
+}
+
+// Window open at the time.
+func (r *MigrationWindow) Open(t time.Time) (open bool, err error) {
+	// The window opened on the previous day may still be open.
+	for d := -1; d <= 0; d++ {
+		var opens, closes time.Time
+		var found bool
+		opens, closes, found, err = r.interval(t, d)
+		if err != nil {
+			return
+		}
+		if found && !t.Before(opens) && t.Before(closes) {
+			open = true
+			return
+		}
+	}
+	return
+}
+
+// Next time the window opens after the time.
+func (r *MigrationWindow) Next(t time.Time) (next time.Time, err error) {
+	for d := 0; d <= 7; d++ {
+		var opens time.Time
+		var found bool
+		opens, _, found, err = r.interval(t, d)
+		if err != nil {
+			return
+		}
+		if found && opens.After(t) {
+			next = opens
+			return
+		}
+	}
+	err = fmt.Errorf("window never opens")
+	return
+}
+
+// Interval of the window opened on the day at the offset from the time.
+// Found is false when the window does not open on that day.
+func (r *MigrationWindow) interval(t time.Time, offset int) (opens, closes time.Time, found bool, err error) {
+	location, err := r.location()
+	if err != nil {
+		return
+	}
+	startH, startM, err := r.clock(r.Start)
+	if err != nil {
+		return
+	}
+	endH, endM, err := r.clock(r.End)
+	if err != nil {
+		return
+	}
+	local := t.In(location)
+	year, month, day := local.Date()
+	opens = time.Date(year, month, day+offset, startH, startM, 0, 0, location)
+	if !r.opensOn(opens.Weekday()) {
+		return
+	}
+	found = true
+	closes = time.Date(year, month, day+offset, endH, endM, 0, 0, location)
+	if !closes.After(opens) {
+		closes = time.Date(year, month, day+offset+1, endH, endM, 0, 0, location)
+	}
+	return
+}
+
+// Window opens on the day of the week.
+func (r *MigrationWindow) opensOn(weekday time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, day := range r.Days {
+		if d, found := r.weekday(day); found && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse the day of the week.
+func (r *MigrationWindow) weekday(day Weekday) (weekday time.Weekday, found bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == string(day) {
+			weekday = d
+			found = true
+			return
+		}
+	}
+	return
+}
+
+// Location of the time zone.
+func (r *MigrationWindow) location() (location *time.Location, err error) {
+	location = time.UTC
+	if r.TimeZone != "" {
+		location, err = time.LoadLocation(r.TimeZone)
+		if err != nil {
+			err = fmt.Errorf("time zone %q not valid: %w", r.TimeZone, err)
+		}
+	}
+	return
+}
+
+// Parse the HH:MM time of day.
+func (r *MigrationWindow) clock(s string) (hour, minute int, err error) {
+	parsed, err := time.Parse("15:04", s)
+	if err != nil {
+		err = fmt.Errorf("time %q not valid, expected HH:MM", s)
+		return
+	}
+	hour, minute = parsed.Hour(), parsed.Minute()
+	return
+}
+
 // MigrationSpec defines the desired state of Migration
 type MigrationSpec struct {
 	// Reference to the associated Plan.
@@ -46,12 +208,57 @@ type MigrationSpec struct {
 	// Per-VM cutover times. Overrides spec.cutover for the listed VMs.
 	// +optional
 	VMCutover []VMCutover `json:"vmCutover,omitempty"`
+	// Date and time before which no VM is started.
+	// +optional
+	Start *meta.Time `json:"start,omitempty"`
+	// Recurring windows in which VMs may be started. Outside of the
+	// windows, VMs are not started and warm precopies are paused.
+	// VMs not started within a window wait for the next one.
+	// VMs may be started at any time when empty.
+	// +optional
+	Windows []MigrationWindow `json:"windows,omitempty"`
 	// ResumeConversion skips disk copy and runs only the virt-v2v
 	// conversion step, reusing PVCs from a previous failed migration.
 	// Valid for any plan whose disk copy and conversion are separate
 	// phases and whose disk copy completed before conversion failed.
 	// +optional
 	ResumeConversion bool `json:"resumeConversion,omitempty"`
+	// Rollback the VMs migrated by the plan back to the source
+	// instead of migrating the VMs.
+	// +optional
+	Rollback *Rollback `json:"rollback,omitempty"`
+}
+
+// Rollback of the VMs migrated by a plan.
+// The target VMs are stopped and deleted unless kept, the migration
+// resources are deleted and the source VMs running before the
+// migration are powered on.
+type Rollback struct {
+	// VMs rolled back. All the VMs migrated by the plan when empty.
+	// +optional
+	VMs []ref.Ref `json:"vms,omitempty"`
+	// KeepTarget keeps the stopped target VMs and their disks.
+	// +optional
+	KeepTarget bool `json:"keepTarget,omitempty"`
+}
+
+// Includes indicates whether a VM is rolled back.
+func (r *Rollback) Includes(ref ref.Ref) bool {
+	if len(r.VMs) == 0 {
+		return true
+	}
+	if ref.ID == "" {
+		return false
+	}
+	for _, vm := range r.VMs {
+		// the refs might not have all been resolved
+		// successfully, so skip over any VMs that
+		// don't have an ID set.
+		if vm.ID != "" && vm.ID == ref.ID {
+			return true
+		}
+	}
+	return false
 }
 
 // CutoverFor returns the effective cutover time for a VM.
@@ -67,6 +274,53 @@ func (r *MigrationSpec) CutoverFor(vmRef ref.Ref) *meta.Time {
 	return r.Cutover
 }
 
+// Determine whether VMs may be started at the time: after the start
+// time and within one of the windows. When not allowed, returns the
+// next time VMs may be started.
+func (r *MigrationSpec) Allowed(t time.Time) (allowed bool, next time.Time, err error) {
+	if r.Start != nil && t.Before(r.Start.Time) {
+		var within bool
+		within, next, err = r.within(r.Start.Time)
+		if within {
+			next = r.Start.Time
+		}
+		return
+	}
+	allowed, next, err = r.within(t)
+	return
+}
+
+// Determine whether the time is within one of the windows.
+// When not, returns the next time a window opens.
+func (r *MigrationSpec) within(t time.Time) (within bool, next time.Time, err error) {
+	if len(r.Windows) == 0 {
+		within = true
+		return
+	}
+	for i := range r.Windows {
+		window := &r.Windows[i]
+		var open bool
+		open, err = window.Open(t)
+		if err != nil {
+			return
+		}
+		if open {
+			within = true
+			next = time.Time{}
+			return
+		}
+		var opens time.Time
+		opens, err = window.Next(t)
+		if err != nil {
+			return
+		}
+		if next.IsZero() || opens.Before(next) {
+			next = opens
+		}
+	}
+	return
+}
+
 // Canceled indicates whether a VM ref is present
 // in the list of VM refs to be canceled.
 func (r *MigrationSpec) Canceled(ref ref.Ref) (found bool) {
@@ -139,3 +393,9 @@ func init() {
 func (r *Migration) IsResumeConversion() bool {
 	return r.Spec.ResumeConversion
 }
+
+// IsRollback returns true when the migration rolls back the VMs
+// migrated by the plan.
+func (r *Migration) IsRollback() bool {
+	return r.Spec.Rollback != nil
+}
@@ -0,0 +1,106 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMigrationWindowAllowed(t *testing.T) {
+	t.Parallel()
+	at := func(s string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	weeknights := MigrationWindow{
+		Days:  []Weekday{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"},
+		Start: "22:00",
+		End:   "04:00",
+	}
+	start := meta.NewTime(at("2026-10-21T00:00:00Z"))
+	cases := []struct {
+		name    string
+		spec    MigrationSpec
+		now     string
+		allowed bool
+		next    string
+	}{
+		{"no windows", MigrationSpec{}, "2026-10-17T12:00:00Z", true, ""},
+		// 2026-10-19 is a Monday.
+		{"window open", MigrationSpec{Windows: []MigrationWindow{weeknights}}, "2026-10-19T23:00:00Z", true, ""},
+		{"window open after midnight", MigrationSpec{Windows: []MigrationWindow{weeknights}}, "2026-10-20T03:59:00Z", true, ""},
+		{"window closed", MigrationSpec{Windows: []MigrationWindow{weeknights}}, "2026-10-20T04:00:00Z", false, "2026-10-20T22:00:00Z"},
+		{"Friday night open on Saturday", MigrationSpec{Windows: []MigrationWindow{weeknights}}, "2026-10-24T02:00:00Z", true, ""},
+		{"weekend closed", MigrationSpec{Windows: []MigrationWindow{weeknights}}, "2026-10-24T12:00:00Z", false, "2026-10-26T22:00:00Z"},
+		{"before start", MigrationSpec{Start: &start}, "2026-10-17T12:00:00Z", false, "2026-10-21T00:00:00Z"},
+		{"after start", MigrationSpec{Start: &start}, "2026-10-21T00:00:00Z", true, ""},
+		{"start within window", MigrationSpec{Start: &start, Windows: []MigrationWindow{weeknights}}, "2026-10-17T12:00:00Z", false, "2026-10-21T00:00:00Z"},
+		{"start outside window", MigrationSpec{Start: &start, Windows: []MigrationWindow{{Start: "20:00", End: "21:00"}}}, "2026-10-17T12:00:00Z", false, "2026-10-21T20:00:00Z"},
+		{"time zone", MigrationSpec{Windows: []MigrationWindow{{Start: "22:00", End: "23:00", TimeZone: "Europe/Paris"}}}, "2026-10-17T20:30:00Z", true, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			allowed, next, err := tc.spec.Allowed(at(tc.now))
+			if err != nil {
+				t.Fatalf("Allowed() error = %v", err)
+			}
+			if allowed != tc.allowed {
+				t.Errorf("Allowed() = %v, want %v", allowed, tc.allowed)
+			}
+			if tc.next != "" && !next.Equal(at(tc.next)) {
+				t.Errorf("next = %v, want %v", next, tc.next)
+			}
+		})
+	}
+}
+
+func TestMigrationWindowValidate(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		window MigrationWindow
+		valid  bool
+	}{
+		{"valid", MigrationWindow{Days: []Weekday{"Sunday"}, Start: "00:00", End: "23:59", TimeZone: "America/New_York"}, true},
+		{"time not valid", MigrationWindow{Start: "24:00", End: "01:00"}, false},
+		{"day not valid", MigrationWindow{Days: []Weekday{"Someday"}, Start: "01:00", End: "02:00"}, false},
+		{"time zone not valid", MigrationWindow{Start: "01:00", End: "02:00", TimeZone: "Mars/Olympus"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.window.Validate(); (err == nil) != tc.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestRollbackIncludes(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name     string
+		rollback Rollback
+		vm       ref.Ref
+		included bool
+	}{
+		{"all VMs", Rollback{}, ref.Ref{ID: "vm-1"}, true},
+		{"listed", Rollback{VMs: []ref.Ref{{ID: "vm-2"}, {ID: "vm-1"}}}, ref.Ref{ID: "vm-1"}, true},
+		{"not listed", Rollback{VMs: []ref.Ref{{ID: "vm-2"}}}, ref.Ref{ID: "vm-1"}, false},
+		{"not resolved", Rollback{VMs: []ref.Ref{{Name: "vm"}}}, ref.Ref{ID: "vm-1", Name: "vm"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if included := tc.rollback.Includes(tc.vm); included != tc.included {
+				t.Errorf("Includes() = %v, want %v", included, tc.included)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package v1beta1
+
+import (
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MigrationBudget specification.
+type MigrationBudgetSpec struct {
+	// Maximum number of VMs migrated at once by the plans
+	// within the budget.
+	// +kubebuilder:validation:Minimum=1
+	MaxInFlight int `json:"maxInFlight"`
+	// Source provider the budget applies to. The namespace defaults
+	// to the namespace of the budget.
+	// Applies to all the providers when not set.
+	// +optional
+	Provider *core.ObjectReference `json:"provider,omitempty" ref:"Provider"`
+	// Target namespace the budget applies to.
+	// Applies to all the target namespaces when not set.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MigrationBudget is the Schema for the migrationbudgets API.
+// Concurrency limit shared by the plans migrating from the
+// provider and/or to the target namespace.
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="MaxInFlight",type="integer",JSONPath=".spec.maxInFlight"
+// +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider.name"
+// +kubebuilder:printcolumn:name="TargetNamespace",type="string",JSONPath=".spec.targetNamespace"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type MigrationBudget struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            MigrationBudgetSpec `json:"spec,omitempty"`
+}
+
+// The budget applies to the plan. Only the plans of the
+// namespace of the budget are within the budget.
+func (r *MigrationBudget) Selects(plan *Plan) bool {
+	if plan.Namespace != r.Namespace {
+		return false
+	}
+	if r.Spec.Provider != nil {
+		namespace := r.Spec.Provider.Namespace
+		if namespace == "" {
+			namespace = r.Namespace
+		}
+		source := plan.Spec.Provider.Source
+		if source.Name != r.Spec.Provider.Name || source.Namespace != namespace {
+			return false
+		}
+	}
+	if r.Spec.TargetNamespace != "" && r.Spec.TargetNamespace != plan.Spec.TargetNamespace {
+		return false
+	}
+
+	return true
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MigrationBudgetList contains a list of MigrationBudget.
+type MigrationBudgetList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []MigrationBudget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MigrationBudget{}, &MigrationBudgetList{})
+}
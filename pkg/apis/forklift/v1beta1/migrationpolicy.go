@@ -0,0 +1,79 @@
+package v1beta1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Severity of a migration policy rule.
+const (
+	// A violated rule blocks the plan.
+	PolicySeverityCritical = "Critical"
+	// A violated rule raises a warning on the plan.
+	PolicySeverityWarning = "Warning"
+)
+
+// MigrationPolicy specification.
+type MigrationPolicySpec struct {
+	// Rules evaluated for the VMs of the plans in the namespace.
+	Rules []MigrationPolicyRule `json:"rules"`
+}
+
+// Migration policy rule.
+// The expression is a rego query evaluated with the input:
+//   - vm: the VM in the provider inventory.
+//   - planVM: the VM as listed on the plan, including its hooks.
+//   - plan: the name, namespace and spec of the plan.
+//
+// The rule is violated when the query is true, for example:
+// `input.vm.cpuCount > 64`.
+type MigrationPolicyRule struct {
+	// Rule name.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Rego query, violated when true.
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+	// Severity of a violation.
+	// Critical (default) blocks the plan, Warning raises a warning.
+	// +kubebuilder:validation:Enum=Critical;Warning
+	// +optional
+	Severity string `json:"severity,omitempty"`
+	// Message reported for the VMs violating the rule.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Type of the source provider the rule applies to.
+	// Applies to all the providers when not set.
+	// +optional
+	ProviderType *ProviderType `json:"providerType,omitempty"`
+}
+
+// Returns true when a violation blocks the plan.
+func (r *MigrationPolicyRule) Critical() bool {
+	return r.Severity != PolicySeverityWarning
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MigrationPolicy is the Schema for the migrationpolicies API.
+// Rules validating the VMs of the plans in the namespace.
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type MigrationPolicy struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            MigrationPolicySpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MigrationPolicyList contains a list of MigrationPolicy.
+type MigrationPolicyList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []MigrationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MigrationPolicy{}, &MigrationPolicyList{})
+}
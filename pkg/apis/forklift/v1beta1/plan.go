@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"context"
+	"time"
 
 	k8snet "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
@@ -26,6 +27,7 @@ import (
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cnv "kubevirt.io/api/core/v1"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,6 +44,16 @@ const (
 	MigrationOnlyConversion MigrationType = "conversion"
 )
 
+// MigrationOrder defines the order in which pending VMs are scheduled.
+type MigrationOrder string
+
+const (
+	// Smallest VMs (by remaining disk capacity) first.
+	MigrationOrderSmallestFirst MigrationOrder = "SmallestFirst"
+	// Largest VMs (by remaining disk capacity) first.
+	MigrationOrderLargestFirst MigrationOrder = "LargestFirst"
+)
+
 const (
 	// namespaceLabelPrimaryUDN is the label key used to identify namespaces with primary user-defined networks
 	namespaceLabelPrimaryUDN = "k8s.ovn.org/primary-user-defined-network"
@@ -346,6 +358,16 @@ type PlanSpec struct {
 	// +optional
 	// +kubebuilder:validation:Enum=cold;warm;live;conversion
 	Type MigrationType `json:"type,omitempty"`
+	// MigrationOrder determines the order in which pending VMs are started.
+	// VMs with a higher priority are always started first; VMs with the same
+	// priority are ordered by the total capacity of the disks left to transfer.
+	// - "SmallestFirst": smallest VMs are started first.
+	// - "LargestFirst": largest VMs are started first.
+	// - empty (default): VMs are started in the order listed on the plan.
+	// Only supported by vSphere source providers.
+	// +optional
+	// +kubebuilder:validation:Enum=SmallestFirst;LargestFirst
+	MigrationOrder MigrationOrder `json:"migrationOrder,omitempty"`
 	// TargetPowerState specifies the desired power state of the target VM after migration.
 	// - "on": Target VM will be powered on after migration
 	// - "off": Target VM will be powered off after migration
@@ -397,6 +419,15 @@ type PlanSpec struct {
 	//       - environment
 	// +optional
 	TagMapping *TagMapping `json:"tagMapping,omitempty"`
+	// AutoCutover starts the cutover of each VM of a warm migration
+	// once its precopies converged, without waiting for the cutover time.
+	// +optional
+	AutoCutover *AutoCutover `json:"autoCutover,omitempty"`
+	// DryRun renders the resources the migration would create for each VM
+	// into ConfigMaps in the plan namespace instead of migrating the VMs.
+	// Migrations of the plan are not started while it is set.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // Find a planned VM.
@@ -499,8 +530,12 @@ func (p *Plan) ShouldUseV2vForTransfer(vmRef ref.Ref) (bool, error) {
 			return false, nil
 		}
 		return true, nil
-	case Ova, HyperV:
+	case Ova:
 		return true, nil
+	case HyperV:
+		// Warm migrations copy the disks with the delta copy pod
+		// and convert them in place after cutover.
+		return !p.IsWarm(), nil
 	default:
 		return false, nil
 	}
@@ -566,6 +601,10 @@ func (r *Plan) IsSourceProviderOCP() bool {
 
 func (r *Plan) IsSourceProviderVSphere() bool { return r.Provider.Source.Type() == VSphere }
 
+func (r *Plan) IsSourceProviderHyperV() bool { return r.Provider.Source.Type() == HyperV }
+
+func (r *Plan) IsSourceProviderNutanix() bool { return r.Provider.Source.Type() == Nutanix }
+
 func (r *Plan) ShouldRunPreflightInspection() bool {
 	return r.IsSourceProviderVSphere() &&
 		r.IsWarm() &&
@@ -652,3 +691,110 @@ type TagMapping struct {
 	// +optional
 	LabelTags []string `json:"labelTags,omitempty"`
 }
+
+// Default number of converged precopies.
+const DefaultAutoCutoverPrecopies = 3
+
+// Automatic warm cutover policy.
+// A precopy converged when it transferred at most MaxDeltaBytes or
+// finished within MaxPrecopySeconds. The cutover of a VM starts once
+// its last precopies all converged, or at the cutover time of the
+// migration, whichever comes first.
+type AutoCutover struct {
+	// Number of consecutive precopies that must have converged.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=3
+	Precopies int `json:"precopies,omitempty"`
+	// Maximum bytes transferred by a converged precopy. e.g. "500Mi".
+	// Only applies to the providers that measure the changes of
+	// the precopies.
+	// +optional
+	MaxDeltaBytes *resource.Quantity `json:"maxDeltaBytes,omitempty"`
+	// Maximum duration in seconds of a converged precopy.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxPrecopySeconds int `json:"maxPrecopySeconds,omitempty"`
+	// Windows within which the automatic cutover may start.
+	// The cutover may start at any time when not set.
+	// +optional
+	Windows []MigrationWindow `json:"windows,omitempty"`
+}
+
+// Validate the policy.
+func (r *AutoCutover) Validate() (err error) {
+	if r.Precopies < 0 {
+		err = liberr.New("precopies must not be negative")
+		return
+	}
+	if r.MaxDeltaBytes == nil && r.MaxPrecopySeconds == 0 {
+		err = liberr.New("either maxDeltaBytes or maxPrecopySeconds must be set")
+		return
+	}
+	if r.MaxDeltaBytes != nil && r.MaxDeltaBytes.Sign() < 0 {
+		err = liberr.New("maxDeltaBytes must not be negative")
+		return
+	}
+	if r.MaxPrecopySeconds < 0 {
+		err = liberr.New("maxPrecopySeconds must not be negative")
+		return
+	}
+	for i := range r.Windows {
+		err = r.Windows[i].Validate()
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Determine whether the last precopies converged.
+// Only the completed precopies are considered.
+func (r *AutoCutover) Converged(precopies []plan.Precopy) bool {
+	n := r.Precopies
+	if n == 0 {
+		n = DefaultAutoCutoverPrecopies
+	}
+	for i := len(precopies) - 1; i >= 0 && n > 0; i-- {
+		precopy := &precopies[i]
+		if precopy.End == nil {
+			continue
+		}
+		if !r.converged(precopy) {
+			return false
+		}
+		n--
+	}
+	return n == 0
+}
+
+// Determine whether the cutover may start at the time.
+func (r *AutoCutover) Open(t time.Time) (open bool, err error) {
+	if len(r.Windows) == 0 {
+		open = true
+		return
+	}
+	for i := range r.Windows {
+		open, err = r.Windows[i].Open(t)
+		if err != nil || open {
+			return
+		}
+	}
+	return
+}
+
+// Determine whether a completed precopy converged.
+func (r *AutoCutover) converged(precopy *plan.Precopy) bool {
+	if r.MaxDeltaBytes != nil && precopy.TransferredBytes != nil {
+		if *precopy.TransferredBytes <= r.MaxDeltaBytes.Value() {
+			return true
+		}
+	}
+	if r.MaxPrecopySeconds > 0 && precopy.Start != nil {
+		duration := precopy.End.Sub(precopy.Start.Time)
+		if duration <= time.Duration(r.MaxPrecopySeconds)*time.Second {
+			return true
+		}
+	}
+	return false
+}
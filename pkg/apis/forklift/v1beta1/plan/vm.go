@@ -13,9 +13,40 @@ import (
 // Plan hook.
 type HookRef struct {
 	// Pipeline step.
+	// One of: PreHook, PrePowerOffHook, PostTransferHook, PreCutoverHook (warm only), PostHook, FailureHook.
 	Step string `json:"step"`
 	// Hook reference.
 	Hook core.ObjectReference `json:"hook" ref:"Hook"`
+	// Failure policy overriding the policy of the hook.
+	// +optional
+	FailurePolicy *HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// Hook failure actions.
+const (
+	// Fail the VM migration.
+	HookFailureFail = "Fail"
+	// Record a warning on the pipeline step and continue the VM migration.
+	HookFailureContinue = "Continue"
+)
+
+// Policy applied when a hook fails.
+type HookFailurePolicy struct {
+	// Action once the hook has failed and has no retries left.
+	// Fail (default) fails the VM migration, Continue records a
+	// warning on the pipeline step and continues the migration.
+	// +kubebuilder:validation:Enum=Fail;Continue
+	// +optional
+	Action string `json:"action,omitempty"`
+	// Number of times the hook is retried after it failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Retries int `json:"retries,omitempty"`
+	// Delay in seconds before the first retry, doubled for each following retry.
+	// Defaults to 30.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Backoff int64 `json:"backoff,omitempty"`
 }
 
 // TargetPowerState defines the desired power state of the target VM after migration
@@ -203,6 +234,27 @@ type VM struct {
 	//
 	// +optional
 	SCSIReservation *bool `json:"scsiReservation,omitempty"`
+	// Priority of the VM migration.
+	// Pending VMs with a higher priority are started before VMs with a lower
+	// priority, regardless of the plan migrationOrder. Defaults to 0.
+	// Only supported by vSphere source providers.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// Effective failure policy of a hook, the policy of the
+// reference takes precedence over the policy of the hook.
+func (r *HookRef) Policy(hook *HookFailurePolicy) (policy HookFailurePolicy) {
+	switch {
+	case r.FailurePolicy != nil:
+		policy = *r.FailurePolicy
+	case hook != nil:
+		policy = *hook
+	}
+	if policy.Action == "" {
+		policy.Action = HookFailureFail
+	}
+	return
 }
 
 // Find a Hook for the specified step.
@@ -245,11 +297,35 @@ type VMStatus struct {
 	// without re-copying disks.
 	// +optional
 	DisksCopied bool `json:"disksCopied,omitempty"`
+	// Structured results written by the hooks of the VM.
+	// +optional
+	HookResults []HookResult `json:"hookResults,omitempty"`
 
 	// Conditions.
 	libcnd.Conditions `json:",inline"`
 }
 
+// Structured result written by a hook. The result of a hook changes
+// the steps that follow it.
+type HookResult struct {
+	// Pipeline step of the hook.
+	Step string `json:"step"`
+	// Labels added to the target VM.
+	// +optional
+	TargetLabels map[string]string `json:"targetLabels,omitempty"`
+	// Power state of the target VM. Overrides the power state of the VM and plan.
+	// +optional
+	// +kubebuilder:validation:Enum=on;off;auto
+	TargetPowerState TargetPowerState `json:"targetPowerState,omitempty"`
+	// Skip the guest conversion. Applies only when the disks are not
+	// copied by virt-v2v.
+	// +optional
+	SkipGuestConversion bool `json:"skipGuestConversion,omitempty"`
+	// Values passed to the hooks that follow.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
 // Warm Migration status
 type Warm struct {
 	Successes           int        `json:"successes"`
@@ -275,6 +351,9 @@ type Precopy struct {
 	CreateTaskId string      `json:"createTaskId,omitempty"`
 	RemoveTaskId string      `json:"removeTaskId,omitempty"`
 	Deltas       []DiskDelta `json:"deltas,omitempty"`
+	// Bytes transferred by the precopy.
+	// Not set when the provider cannot measure the changes.
+	TransferredBytes *int64 `json:"transferredBytes,omitempty"`
 }
 
 func (r *Precopy) WithDeltas(deltas map[string]string) {
@@ -317,6 +396,65 @@ func (r *VMStatus) AddError(reason ...string) {
 	r.Error.Add(reason...)
 }
 
+// Set the result of a hook, replacing the previous result of the step.
+func (r *VMStatus) SetHookResult(result HookResult) {
+	for i := range r.HookResults {
+		if r.HookResults[i].Step == result.Step {
+			r.HookResults[i] = result
+			return
+		}
+	}
+	r.HookResults = append(r.HookResults, result)
+}
+
+// Labels of the target VM set by the hooks. The
+// labels of later hooks take precedence.
+func (r *VMStatus) HookTargetLabels() (labels map[string]string) {
+	for _, result := range r.HookResults {
+		for k, v := range result.TargetLabels {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[k] = v
+		}
+	}
+	return
+}
+
+// Power state of the target VM set by the hooks, or
+// empty when no hook has set it.
+func (r *VMStatus) HookTargetPowerState() (state TargetPowerState) {
+	for _, result := range r.HookResults {
+		if result.TargetPowerState != "" {
+			state = result.TargetPowerState
+		}
+	}
+	return
+}
+
+// Power state of the target VM. The power state set by the hooks takes
+// precedence over the power state of the VM, then of the plan.
+func (r *VMStatus) ResolveTargetPowerState(plan TargetPowerState) (state TargetPowerState) {
+	state = r.HookTargetPowerState()
+	if state == "" {
+		state = r.TargetPowerState
+	}
+	if state == "" {
+		state = plan
+	}
+	return
+}
+
+// Returns true when a hook asked to skip the guest conversion.
+func (r *VMStatus) HookSkipsGuestConversion() bool {
+	for _, result := range r.HookResults {
+		if result.SkipGuestConversion {
+			return true
+		}
+	}
+	return false
+}
+
 // Reflect pipeline.
 func (r *VMStatus) ReflectPipeline() {
 	nStarted := 0
@@ -57,10 +57,30 @@ func (in *Error) DeepCopy() *Error {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookFailurePolicy) DeepCopyInto(out *HookFailurePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookFailurePolicy.
+func (in *HookFailurePolicy) DeepCopy() *HookFailurePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HookFailurePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HookRef) DeepCopyInto(out *HookRef) {
 	*out = *in
 	out.Hook = in.Hook
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(HookFailurePolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookRef.
@@ -73,6 +93,35 @@ func (in *HookRef) DeepCopy() *HookRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookResult) DeepCopyInto(out *HookResult) {
+	*out = *in
+	if in.TargetLabels != nil {
+		in, out := &in.TargetLabels, &out.TargetLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookResult.
+func (in *HookResult) DeepCopy() *HookResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HookResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Map) DeepCopyInto(out *Map) {
 	*out = *in
@@ -140,6 +189,11 @@ func (in *Precopy) DeepCopyInto(out *Precopy) {
 		*out = make([]DiskDelta, len(*in))
 		copy(*out, *in)
 	}
+	if in.TransferredBytes != nil {
+		in, out := &in.TransferredBytes, &out.TransferredBytes
+		*out = new(int64)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Precopy.
@@ -312,7 +366,9 @@ func (in *VM) DeepCopyInto(out *VM) {
 	if in.Hooks != nil {
 		in, out := &in.Hooks, &out.Hooks
 		*out = make([]HookRef, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	out.LUKS = in.LUKS
 	if in.MigrateSharedDisks != nil {
@@ -378,6 +434,13 @@ func (in *VMStatus) DeepCopyInto(out *VMStatus) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.HookResults != nil {
+		in, out := &in.HookResults, &out.HookResults
+		*out = make([]HookResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Conditions.DeepCopyInto(&out.Conditions)
 }
 
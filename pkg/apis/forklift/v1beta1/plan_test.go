@@ -0,0 +1,85 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAutoCutoverConverged(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	precopy := func(minutes int, bytes int64) plan.Precopy {
+		start := meta.NewTime(base)
+		end := meta.NewTime(base.Add(time.Duration(minutes) * time.Minute))
+		return plan.Precopy{Start: &start, End: &end, TransferredBytes: &bytes}
+	}
+	unmeasured := func(minutes int) plan.Precopy {
+		p := precopy(minutes, 0)
+		p.TransferredBytes = nil
+		return p
+	}
+	running := plan.Precopy{Start: &meta.Time{Time: base}}
+	maxBytes := resource.MustParse("100Mi")
+	mi := int64(1024 * 1024)
+	cases := []struct {
+		name      string
+		policy    AutoCutover
+		precopies []plan.Precopy
+		converged bool
+	}{
+		{"no precopies", AutoCutover{MaxDeltaBytes: &maxBytes}, nil, false},
+		{"bytes converged", AutoCutover{Precopies: 2, MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{precopy(60, 900*mi), precopy(10, 90*mi), precopy(10, 100*mi)}, true},
+		{"bytes not converged", AutoCutover{Precopies: 2, MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{precopy(10, 90*mi), precopy(10, 101*mi)}, false},
+		{"too few precopies", AutoCutover{Precopies: 3, MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{precopy(10, mi), precopy(10, mi)}, false},
+		{"default precopies", AutoCutover{MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{precopy(10, mi), precopy(10, mi), precopy(10, mi)}, true},
+		{"duration converged", AutoCutover{Precopies: 2, MaxPrecopySeconds: 300},
+			[]plan.Precopy{unmeasured(4), unmeasured(5)}, true},
+		{"duration not converged", AutoCutover{Precopies: 2, MaxPrecopySeconds: 300},
+			[]plan.Precopy{unmeasured(4), unmeasured(6)}, false},
+		{"either criterion", AutoCutover{Precopies: 2, MaxDeltaBytes: &maxBytes, MaxPrecopySeconds: 300},
+			[]plan.Precopy{precopy(4, 900*mi), precopy(30, mi)}, true},
+		{"not measured", AutoCutover{Precopies: 1, MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{unmeasured(1)}, false},
+		{"running precopy ignored", AutoCutover{Precopies: 2, MaxDeltaBytes: &maxBytes},
+			[]plan.Precopy{precopy(10, mi), precopy(10, mi), running}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if converged := tc.policy.Converged(tc.precopies); converged != tc.converged {
+				t.Errorf("Converged() = %v, want %v", converged, tc.converged)
+			}
+		})
+	}
+}
+
+func TestAutoCutoverValidate(t *testing.T) {
+	t.Parallel()
+	maxBytes := resource.MustParse("1Gi")
+	cases := []struct {
+		name   string
+		policy AutoCutover
+		valid  bool
+	}{
+		{"bytes", AutoCutover{MaxDeltaBytes: &maxBytes}, true},
+		{"duration", AutoCutover{Precopies: 2, MaxPrecopySeconds: 60}, true},
+		{"no criterion", AutoCutover{Precopies: 2}, false},
+		{"window not valid", AutoCutover{MaxPrecopySeconds: 60, Windows: []MigrationWindow{{Start: "25:00", End: "01:00"}}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.policy.Validate(); (err == nil) != tc.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
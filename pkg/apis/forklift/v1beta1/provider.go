@@ -98,6 +98,16 @@ const (
 	ESXiCloneMethodSSH = "ssh"
 )
 
+// EC2 transfer mode setting key and values.
+// The volume mode creates EBS volumes from the snapshots and requires the
+// target cluster to run in AWS with the EBS CSI driver. The ebsDirect mode
+// streams the snapshots through the EBS direct APIs into PVCs on any storage.
+const (
+	EC2TransferMode          = "transferMode"
+	EC2TransferModeVolume    = "volume"
+	EC2TransferModeEBSDirect = "ebsDirect"
+)
+
 // Hyper-V management type setting key and values.
 const (
 	ManagementType   = "managementType"
@@ -220,6 +230,11 @@ func (p *Provider) UseVddkAioOptimization() bool {
 	return parseBool
 }
 
+// Whether this EC2 provider transfers disks through the EBS direct APIs.
+func (p *Provider) IsEC2EBSDirect() bool {
+	return p.Type() == EC2 && p.Spec.Settings[EC2TransferMode] == EC2TransferModeEBSDirect
+}
+
 // Whether this Hyper-V provider is configured for Failover Cluster mode.
 func (p *Provider) IsHyperVCluster() bool {
 	return p.Type() == HyperV && p.Spec.Settings[ManagementType] == HyperVCluster
@@ -35,6 +35,18 @@ type VSphereXcopyVolumePopulatorSpec struct {
 	// If empty, the host where the source VM is registered will be used.
 	// +optional
 	MigrationHost string `json:"migrationHost,omitempty"`
+	// DriverImage is the image shipping an out-of-process storage driver,
+	// used when the storage vendor product is 'external'.
+	// +optional
+	DriverImage string `json:"driverImage,omitempty"`
+	// NFSServer is the server exporting the NFS datastore of the vmdk.
+	// When set, the export is mounted read-only in the populator pod and
+	// the disk is copied from the export instead of with XCOPY.
+	// +optional
+	NFSServer string `json:"nfsServer,omitempty"`
+	// NFSPath is the path of the NFS datastore export on NFSServer.
+	// +optional
+	NFSPath string `json:"nfsPath,omitempty"`
 }
 
 type VSphereXcopyVolumePopulatorStatus struct {
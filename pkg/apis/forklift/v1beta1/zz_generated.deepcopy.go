@@ -47,6 +47,33 @@ func (in *AAPConfig) DeepCopy() *AAPConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoCutover) DeepCopyInto(out *AutoCutover) {
+	*out = *in
+	if in.MaxDeltaBytes != nil {
+		in, out := &in.MaxDeltaBytes, &out.MaxDeltaBytes
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]MigrationWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoCutover.
+func (in *AutoCutover) DeepCopy() *AutoCutover {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoCutover)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Connection) DeepCopyInto(out *Connection) {
 	*out = *in
@@ -290,6 +317,100 @@ func (in *DiskRef) DeepCopy() *DiskRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2VolumePopulator) DeepCopyInto(out *Ec2VolumePopulator) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2VolumePopulator.
+func (in *Ec2VolumePopulator) DeepCopy() *Ec2VolumePopulator {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2VolumePopulator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2VolumePopulator) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2VolumePopulatorList) DeepCopyInto(out *Ec2VolumePopulatorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Ec2VolumePopulator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2VolumePopulatorList.
+func (in *Ec2VolumePopulatorList) DeepCopy() *Ec2VolumePopulatorList {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2VolumePopulatorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Ec2VolumePopulatorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2VolumePopulatorSpec) DeepCopyInto(out *Ec2VolumePopulatorSpec) {
+	*out = *in
+	if in.TransferNetwork != nil {
+		in, out := &in.TransferNetwork, &out.TransferNetwork
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2VolumePopulatorSpec.
+func (in *Ec2VolumePopulatorSpec) DeepCopy() *Ec2VolumePopulatorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2VolumePopulatorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ec2VolumePopulatorStatus) DeepCopyInto(out *Ec2VolumePopulatorStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ec2VolumePopulatorStatus.
+func (in *Ec2VolumePopulatorStatus) DeepCopy() *Ec2VolumePopulatorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(Ec2VolumePopulatorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ForkliftController) DeepCopyInto(out *ForkliftController) {
 	*out = *in
@@ -561,6 +682,11 @@ func (in *HookSpec) DeepCopyInto(out *HookSpec) {
 		*out = new(AAPConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(plan.HookFailurePolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookSpec.
@@ -903,6 +1029,84 @@ func (in *Migration) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationBudget) DeepCopyInto(out *MigrationBudget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationBudget.
+func (in *MigrationBudget) DeepCopy() *MigrationBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MigrationBudget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationBudgetList) DeepCopyInto(out *MigrationBudgetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MigrationBudget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationBudgetList.
+func (in *MigrationBudgetList) DeepCopy() *MigrationBudgetList {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationBudgetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MigrationBudgetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationBudgetSpec) DeepCopyInto(out *MigrationBudgetSpec) {
+	*out = *in
+	if in.Provider != nil {
+		in, out := &in.Provider, &out.Provider
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationBudgetSpec.
+func (in *MigrationBudgetSpec) DeepCopy() *MigrationBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationList) DeepCopyInto(out *MigrationList) {
 	*out = *in
@@ -935,6 +1139,106 @@ func (in *MigrationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationPolicy) DeepCopyInto(out *MigrationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPolicy.
+func (in *MigrationPolicy) DeepCopy() *MigrationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MigrationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationPolicyList) DeepCopyInto(out *MigrationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MigrationPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPolicyList.
+func (in *MigrationPolicyList) DeepCopy() *MigrationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MigrationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationPolicyRule) DeepCopyInto(out *MigrationPolicyRule) {
+	*out = *in
+	if in.ProviderType != nil {
+		in, out := &in.ProviderType, &out.ProviderType
+		*out = new(ProviderType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPolicyRule.
+func (in *MigrationPolicyRule) DeepCopy() *MigrationPolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationPolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationPolicySpec) DeepCopyInto(out *MigrationPolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]MigrationPolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPolicySpec.
+func (in *MigrationPolicySpec) DeepCopy() *MigrationPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 	*out = *in
@@ -955,6 +1259,22 @@ func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]MigrationWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(Rollback)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSpec.
@@ -995,6 +1315,26 @@ func (in *MigrationStatus) DeepCopy() *MigrationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationWindow) DeepCopyInto(out *MigrationWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationWindow.
+func (in *MigrationWindow) DeepCopy() *MigrationWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkMap) DeepCopyInto(out *NetworkMap) {
 	*out = *in
@@ -1603,6 +1943,11 @@ func (in *PlanSpec) DeepCopyInto(out *PlanSpec) {
 		*out = new(TagMapping)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutoCutover != nil {
+		in, out := &in.AutoCutover, &out.AutoCutover
+		*out = new(AutoCutover)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanSpec.
@@ -1774,6 +2119,26 @@ func (in *ProviderStatus) DeepCopy() *ProviderStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rollback) DeepCopyInto(out *Rollback) {
+	*out = *in
+	if in.VMs != nil {
+		in, out := &in.VMs, &out.VMs
+		*out = make([]ref.Ref, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rollback.
+func (in *Rollback) DeepCopy() *Rollback {
+	if in == nil {
+		return nil
+	}
+	out := new(Rollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotStatus) DeepCopyInto(out *SnapshotStatus) {
 	*out = *in
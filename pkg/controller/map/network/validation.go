@@ -28,9 +28,10 @@ const (
 
 // Reasons
 const (
-	NotSet    = "NotSet"
-	NotFound  = "NotFound"
-	Ambiguous = "Ambiguous"
+	NotSet       = "NotSet"
+	NotFound     = "NotFound"
+	Ambiguous    = "Ambiguous"
+	NotSupported = "NotSupported"
 )
 
 // Statuses
@@ -44,6 +45,7 @@ const (
 	Pod     = "pod"
 	Multus  = "multus"
 	Ignored = "ignored"
+	VSphere = "vsphere"
 )
 
 // Validate the mp resource.
@@ -151,11 +153,32 @@ func (r *Reconciler) validateDestination(mp *api.NetworkMap) (err error) {
 	list := mp.Spec.Map
 	notFound := []string{}
 	ambiguous := []string{}
+	notSupported := []string{}
+	vsphere := provider.Type() == api.VSphere
 next:
 	for _, entry := range list {
+		// vSphere networks are only mapped on a vSphere destination.
+		if entry.Destination.Type != Ignored && (entry.Destination.Type == VSphere) != vsphere {
+			notSupported = append(
+				notSupported,
+				entry.Destination.Type)
+			continue
+		}
 		switch entry.Destination.Type {
 		case Ignored, Pod:
 			continue next
+		case VSphere:
+			_, pErr := inventory.Network(&refapi.Ref{Name: entry.Destination.Name})
+			if pErr != nil {
+				if errors.As(pErr, &web.NotFoundError{}) {
+					notFound = append(
+						notFound,
+						entry.Destination.Name)
+				} else {
+					err = pErr
+					return
+				}
+			}
 		case Multus:
 			if entry.Destination.Namespace == "" {
 				ambiguous = append(
@@ -189,7 +212,7 @@ next:
 			Status:   True,
 			Reason:   NotFound,
 			Category: Critical,
-			Message:  "Destination network (NAD) not found.",
+			Message:  "Destination network not found.",
 			Items:    notFound,
 		})
 	}
@@ -203,6 +226,16 @@ next:
 			Items:    ambiguous,
 		})
 	}
+	if len(notSupported) > 0 {
+		mp.Status.SetCondition(libcnd.Condition{
+			Type:     DestinationNetworkNotValid,
+			Status:   True,
+			Reason:   NotSupported,
+			Category: Critical,
+			Message:  "Destination network type not supported by the destination provider.",
+			Items:    notSupported,
+		})
+	}
 
 	return
 }
@@ -464,7 +464,7 @@ var _ = Describe("ProviderPredicate", func() {
 var _ = Describe("StorageVendorProducts", func() {
 	It("should return all storage vendor products", func() {
 		products := api.StorageVendorProducts()
-		Expect(products).To(HaveLen(9))
+		Expect(products).To(HaveLen(10))
 		Expect(products).To(ContainElement(api.StorageVendorProductFlashSystem))
 		Expect(products).To(ContainElement(api.StorageVendorProductVantara))
 		Expect(products).To(ContainElement(api.StorageVendorProductOntap))
@@ -474,5 +474,52 @@ var _ = Describe("StorageVendorProducts", func() {
 		Expect(products).To(ContainElement(api.StorageVendorProductPowerMax))
 		Expect(products).To(ContainElement(api.StorageVendorProductPowerStore))
 		Expect(products).To(ContainElement(api.StorageVendorProductInfinibox))
+		Expect(products).To(ContainElement(api.StorageVendorProductExternal))
+	})
+})
+
+var _ = Describe("Offload plugin validation", func() {
+	storageMap := func(product api.StorageVendorProduct, image string) *api.StorageMap {
+		return &api.StorageMap{
+			Spec: api.StorageMapSpec{
+				Map: []api.StoragePair{
+					{
+						Source: ref.Ref{ID: "datastore-1"},
+						OffloadPlugin: &api.OffloadPlugin{
+							VSphereXcopyPluginConfig: &api.VSphereXcopyPluginConfig{
+								SecretRef:            "secret",
+								StorageVendorProduct: product,
+								DriverImage:          image,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("should accept an external driver with an image", func() {
+		mp := storageMap(api.StorageVendorProductExternal, "quay.io/vendor/driver:latest")
+		(&Reconciler{}).validateOffloadPlugin(mp)
+		Expect(mp.Status.HasCondition(OffloadPluginNotValid)).To(BeFalse())
+	})
+
+	It("should require an image for an external driver", func() {
+		mp := storageMap(api.StorageVendorProductExternal, "")
+		(&Reconciler{}).validateOffloadPlugin(mp)
+		Expect(mp.Status.HasCondition(OffloadPluginNotValid)).To(BeTrue())
+		Expect(mp.Status.FindCondition(OffloadPluginNotValid).Reason).To(Equal(NotSet))
+	})
+
+	It("should reject an image for a built-in driver", func() {
+		mp := storageMap(api.StorageVendorProductOntap, "quay.io/vendor/driver:latest")
+		(&Reconciler{}).validateOffloadPlugin(mp)
+		Expect(mp.Status.FindCondition(OffloadPluginNotValid).Reason).To(Equal(NotValid))
+	})
+
+	It("should accept a built-in driver without an image", func() {
+		mp := storageMap(api.StorageVendorProductOntap, "")
+		(&Reconciler{}).validateOffloadPlugin(mp)
+		Expect(mp.Status.HasCondition(OffloadPluginNotValid)).To(BeFalse())
 	})
 })
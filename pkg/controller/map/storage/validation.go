@@ -14,6 +14,7 @@ import (
 const (
 	SourceStorageNotValid      = "SourceStorageNotValid"
 	DestinationStorageNotValid = "DestinationStorageNotValid"
+	OffloadPluginNotValid      = "OffloadPluginNotValid"
 )
 
 // Categories
@@ -30,6 +31,7 @@ const (
 	NotSet    = "NotSet"
 	NotFound  = "NotFound"
 	Ambiguous = "Ambiguous"
+	NotValid  = "NotValid"
 )
 
 // Statuses
@@ -53,6 +55,7 @@ func (r *Reconciler) validate(mp *api.StorageMap) error {
 		validation.DestinationProviderNotReady) {
 		return nil
 	}
+	r.validateOffloadPlugin(mp)
 	mp.Referenced.Provider.Source = pv.Referenced.Source
 	mp.Referenced.Provider.Destination = pv.Referenced.Destination
 	err = r.validateSource(mp)
@@ -140,11 +143,15 @@ func (r *Reconciler) validateDestination(mp *api.StorageMap) (err error) {
 	notValid := []string{}
 	list := mp.Spec.Map
 	for _, entry := range list {
+		// A vSphere destination stores the disks on a datastore.
 		name := entry.Destination.StorageClass
+		if provider.Type() == api.VSphere {
+			name = entry.Destination.Datastore
+		}
 		_, pErr := inventory.Storage(&refapi.Ref{Name: name})
 		if pErr != nil {
 			if errors.As(pErr, &web.NotFoundError{}) {
-				notValid = append(notValid, entry.Destination.StorageClass)
+				notValid = append(notValid, name)
 			} else {
 				err = pErr
 				return
@@ -164,3 +171,44 @@ func (r *Reconciler) validateDestination(mp *api.StorageMap) (err error) {
 
 	return
 }
+
+// Validate the offload plugin configurations. An external storage
+// driver must reference the image shipping it, and the driver image
+// is only used by external drivers.
+func (r *Reconciler) validateOffloadPlugin(mp *api.StorageMap) {
+	notSet := []string{}
+	notValid := []string{}
+	for _, entry := range mp.Spec.Map {
+		if entry.OffloadPlugin == nil || entry.OffloadPlugin.VSphereXcopyPluginConfig == nil {
+			continue
+		}
+		config := entry.OffloadPlugin.VSphereXcopyPluginConfig
+		external := config.StorageVendorProduct == api.StorageVendorProductExternal
+		switch {
+		case external && config.DriverImage == "":
+			notSet = append(notSet, entry.Source.String())
+		case !external && config.DriverImage != "":
+			notValid = append(notValid, entry.Source.String())
+		}
+	}
+	if len(notSet) > 0 {
+		mp.Status.SetCondition(libcnd.Condition{
+			Type:     OffloadPluginNotValid,
+			Status:   True,
+			Reason:   NotSet,
+			Category: Critical,
+			Message:  "Offload plugin: `driverImage` required by the external storage vendor product.",
+			Items:    notSet,
+		})
+	}
+	if len(notValid) > 0 {
+		mp.Status.SetCondition(libcnd.Condition{
+			Type:     OffloadPluginNotValid,
+			Status:   True,
+			Reason:   NotValid,
+			Category: Critical,
+			Message:  "Offload plugin: `driverImage` is only supported by the external storage vendor product.",
+			Items:    notValid,
+		})
+	}
+}
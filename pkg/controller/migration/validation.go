@@ -3,8 +3,10 @@ package migration
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	refapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancnt "github.com/kubev2v/forklift/pkg/controller/plan"
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
@@ -16,15 +18,16 @@ import (
 
 // Types
 const (
-	PlanNotValid = "PlanNotValid"
-	PlanNotReady = "PlanNotReady"
-	VMNotFound   = "VMNotFound"
-	VMNotUnique  = "VMNotUnique"
-	Running      = "Running"
-	Executing    = plancnt.Executing
-	Succeeded    = plancnt.Succeeded
-	Failed       = plancnt.Failed
-	Canceled     = plancnt.Canceled
+	PlanNotValid   = "PlanNotValid"
+	PlanNotReady   = "PlanNotReady"
+	VMNotFound     = "VMNotFound"
+	VMNotUnique    = "VMNotUnique"
+	WindowNotValid = "WindowNotValid"
+	Running        = "Running"
+	Executing      = plancnt.Executing
+	Succeeded      = plancnt.Succeeded
+	Failed         = plancnt.Failed
+	Canceled       = plancnt.Canceled
 )
 
 // Categories
@@ -41,6 +44,7 @@ const (
 	NotSet    = "NotSet"
 	NotFound  = "NotFound"
 	Ambiguous = "Ambiguous"
+	NotValid  = "NotValid"
 )
 
 // Statuses
@@ -51,6 +55,7 @@ const (
 
 // Validate the migration resource.
 func (r *Reconciler) validate(migration *api.Migration) (plan *api.Plan, err error) {
+	r.validateWindows(migration)
 	newCnd := libcnd.Condition{
 		Type:     PlanNotValid,
 		Status:   True,
@@ -124,7 +129,11 @@ func (r *Reconciler) validate(migration *api.Migration) (plan *api.Plan, err err
 	if err != nil {
 		return
 	}
-	for _, ref := range migration.Spec.Cancel {
+	refs := append([]refapi.Ref{}, migration.Spec.Cancel...)
+	if migration.Spec.Rollback != nil {
+		refs = append(refs, migration.Spec.Rollback.VMs...)
+	}
+	for _, ref := range refs {
 		_, err = inventory.VM(&ref)
 		if err != nil {
 			if errors.As(err, &web.NotFoundError{}) {
@@ -150,3 +159,26 @@ func (r *Reconciler) validate(migration *api.Migration) (plan *api.Plan, err err
 
 	return
 }
+
+// Validate the migration windows.
+func (r *Reconciler) validateWindows(migration *api.Migration) {
+	notValid := libcnd.Condition{
+		Type:     WindowNotValid,
+		Status:   True,
+		Reason:   NotValid,
+		Category: Critical,
+		Message:  "The migration `windows` are not valid.",
+		Items:    []string{},
+	}
+	for i := range migration.Spec.Windows {
+		window := &migration.Spec.Windows[i]
+		if err := window.Validate(); err != nil {
+			notValid.Items = append(
+				notValid.Items,
+				fmt.Sprintf("[%d] %s", i, err.Error()))
+		}
+	}
+	if len(notValid.Items) > 0 {
+		migration.Status.SetCondition(notValid)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	core "k8s.io/api/core/v1"
 	cnv "kubevirt.io/api/core/v1"
@@ -190,6 +191,11 @@ type Builder interface {
 	LunPersistentVolumeClaims(vmRef ref.Ref) (pvcs []core.PersistentVolumeClaim, err error)
 	// check whether the builder supports Volume Populators
 	SupportsVolumePopulators() bool
+	// check whether the builder transfers warm precopies with the delta copy pod
+	SupportsDeltaCopy() bool
+	// Build the disks transferred by the delta copy pod. The disk ID must
+	// match the PVC identifier and the task name of the disk.
+	DeltaCopyDisks(vmRef ref.Ref) (disks []deltacopy.Disk, err error)
 	// Build populator volumes
 	PopulatorVolumes(vmRef ref.Ref, annotations map[string]string, secretName string) ([]*core.PersistentVolumeClaim, error)
 	// Transferred bytes
@@ -251,6 +257,9 @@ type Client interface {
 	PreTransferActions(vmRef ref.Ref) (ready bool, err error)
 	// Get disk deltas for a VM snapshot.
 	GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc util.HostsFunc) (map[string]string, error)
+	// Get the extents of each disk changed in a snapshot since the baseline deltas
+	// of a previous snapshot. A nil map, or a missing disk, requires a full copy.
+	GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (map[string][]deltacopy.Extent, error)
 }
 
 // Validator API.
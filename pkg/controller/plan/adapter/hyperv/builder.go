@@ -14,6 +14,7 @@ import (
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/provider/model/hyperv"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/hyperv"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	core "k8s.io/api/core/v1"
@@ -515,6 +516,40 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return false
 }
 
+// Warm migrations transfer the disks with the delta copy pod.
+func (r *Builder) SupportsDeltaCopy() bool {
+	return r.Plan.IsWarm()
+}
+
+// Build the delta copy disks, read from the SMB share.
+func (r *Builder) DeltaCopyDisks(vmRef ref.Ref) (disks []deltacopy.Disk, err error) {
+	vm := &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	for _, disk := range vm.Disks {
+		disks = append(disks, deltacopy.Disk{
+			ID:       disk.ID,
+			Source:   disk.SMBPath,
+			Format:   qemuFormat(disk.Format),
+			Capacity: disk.Capacity,
+		})
+	}
+	return
+}
+
+// Map the Hyper-V disk format to the qemu format name.
+func qemuFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "vhd":
+		return "vpc"
+	default:
+		return strings.ToLower(format)
+	}
+}
+
 func (r *Builder) PopulatorVolumes(_ ref.Ref, _ map[string]string, _ string) ([]*core.PersistentVolumeClaim, error) {
 	return nil, planbase.VolumePopulatorNotSupportedError
 }
@@ -164,3 +164,22 @@ func TestBuildPairKey(t *testing.T) {
 		})
 	}
 }
+
+func TestQemuFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"vhdx", "vhdx"},
+		{"VHDX", "vhdx"},
+		{"vhd", "vpc"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			if got := qemuFormat(tc.format); got != tc.expected {
+				t.Errorf("qemuFormat(%q) = %q, want %q", tc.format, got, tc.expected)
+			}
+		})
+	}
+}
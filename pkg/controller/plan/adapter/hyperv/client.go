@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
@@ -12,6 +13,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/model/hyperv"
 	"github.com/kubev2v/forklift/pkg/controller/provider/web/hyperv"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	"github.com/kubev2v/forklift/pkg/lib/hyperv/driver"
 	ps "github.com/kubev2v/forklift/pkg/lib/hyperv/powershell"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
@@ -20,6 +22,13 @@ import (
 
 var log = logging.WithName("hyperv|client")
 
+// Time given to a checkpoint to be converted to a reference point
+// before it is removed when the migration is finalized.
+const (
+	checkpointConversionRetries  = 60
+	checkpointConversionInterval = 10 * time.Second
+)
+
 // HyperV VM Client
 type Client struct {
 	*plancontext.Context
@@ -42,6 +51,16 @@ func (r *Client) connect() (driver.HyperVDriver, error) {
 		r.driver = nil
 	}
 
+	drv, err := r.newDriver()
+	if err != nil {
+		return nil, err
+	}
+	r.driver = drv
+	return drv, nil
+}
+
+// Build and connect a driver that is not shared with the client.
+func (r *Client) newDriver() (driver.HyperVDriver, error) {
 	username, password := hvutil.HyperVCredentials(r.Source.Secret)
 	host := r.Source.Provider.Spec.URL
 	port := hvutil.WinRMPort(r.Source.Provider.Spec.Settings)
@@ -50,12 +69,82 @@ func (r *Client) connect() (driver.HyperVDriver, error) {
 	if err := drv.Connect(); err != nil {
 		return nil, fmt.Errorf("WinRM connect failed: %w", err)
 	}
-	r.driver = drv
 	return drv, nil
 }
 
-func (r *Client) Finalize(_ []*planapi.VMStatus, _ string) {
-	// No source-side cleanup required for HyperV migrations.
+// Find the VM and the cluster node it must be managed on.
+// The node is empty for standalone hosts.
+func (r *Client) findVM(vmRef ref.Ref) (vm *hyperv.VM, node string, err error) {
+	vm = &hyperv.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		return
+	}
+	if r.Source.Provider.IsHyperVCluster() {
+		node = vm.Host
+	}
+	return
+}
+
+// Remove the checkpoints and RCT reference points left on the source
+// by warm migrations. Called once all the VMs have completed.
+func (r *Client) Finalize(vms []*planapi.VMStatus, _ string) {
+	if !r.Plan.IsWarm() {
+		return
+	}
+	drv, err := r.newDriver()
+	if err != nil {
+		log.Error(err, "Failed to clean up warm migration reference points.")
+		return
+	}
+	defer func() { _ = drv.Close() }()
+	for _, vmStatus := range vms {
+		if vmStatus.Warm == nil || len(vmStatus.Warm.Precopies) == 0 {
+			continue
+		}
+		vm, node, err := r.findVM(vmStatus.Ref)
+		if err != nil {
+			log.Error(err, "Failed to find VM.", "vm", vmStatus.String())
+			continue
+		}
+		var rctIDs []string
+		for _, precopy := range vmStatus.Warm.Precopies {
+			if precopy.Snapshot != "" {
+				r.removeCheckpoint(drv, vm, precopy.Snapshot, node)
+			}
+			if precopy.RemoveTaskId != "" {
+				rctIDs = append(rctIDs, strings.Split(precopy.RemoveTaskId, ",")...)
+			}
+		}
+		err = drv.RemoveReferencePoints(rctIDs, node)
+		if err != nil {
+			log.Error(err, "Failed to remove reference points.", "vm", vm.Name)
+			continue
+		}
+		log.Info("Removed warm migration reference points.", "vm", vm.Name, "count", len(rctIDs))
+	}
+}
+
+// Remove a checkpoint left behind. A checkpoint being converted to a
+// reference point is given time to complete the conversion first.
+func (r *Client) removeCheckpoint(drv driver.HyperVDriver, vm *hyperv.VM, checkpointID, node string) {
+	for i := 0; i < checkpointConversionRetries; i++ {
+		checkpoint, err := drv.GetCheckpoint(vm.Name, checkpointID, node)
+		if err != nil {
+			log.Error(err, "Failed to get checkpoint.", "vm", vm.Name, "checkpoint", checkpointID)
+			return
+		}
+		if checkpoint == nil {
+			return
+		}
+		time.Sleep(checkpointConversionInterval)
+	}
+	err := drv.RemoveCheckpoint(vm.Name, checkpointID, node)
+	if err != nil {
+		log.Error(err, "Failed to remove checkpoint.", "vm", vm.Name, "checkpoint", checkpointID)
+		return
+	}
+	log.Info("Removed checkpoint.", "vm", vm.Name, "checkpoint", checkpointID)
 }
 
 func (r *Client) DetachDisks(_ ref.Ref) error {
@@ -201,20 +290,89 @@ func (r *Client) PoweredOff(vmRef ref.Ref) (bool, error) {
 	return state == planapi.VMPowerStateOff, nil
 }
 
-func (r *Client) CreateSnapshot(_ ref.Ref, _ util.HostsFunc) (string, string, error) {
-	return "", "", nil
+// Create a recovery checkpoint. The RCT IDs of its disks are the
+// baseline of the next precopy.
+func (r *Client) CreateSnapshot(vmRef ref.Ref, _ util.HostsFunc) (snapshot string, taskId string, err error) {
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	snapshot, err = drv.CreateRecoveryCheckpoint(vm.Name, node)
+	if err != nil {
+		return
+	}
+	log.Info("Created checkpoint.", "vm", vm.Name, "checkpoint", snapshot)
+	return
 }
 
-func (r *Client) RemoveSnapshot(_ ref.Ref, _ string, _ util.HostsFunc) (string, error) {
-	return "", nil
+// Convert the checkpoint to a reference point. The RCT IDs of the reference
+// point are returned as the task ID so they can be destroyed when the
+// migration is finalized.
+func (r *Client) RemoveSnapshot(vmRef ref.Ref, snapshot string, _ util.HostsFunc) (taskId string, err error) {
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	checkpoint, err := drv.GetCheckpoint(vm.Name, snapshot, node)
+	if err != nil || checkpoint == nil {
+		return
+	}
+	var rctIDs []string
+	for _, disk := range checkpoint.Disks {
+		if disk.RctId != "" {
+			rctIDs = append(rctIDs, disk.RctId)
+		}
+	}
+	err = drv.ConvertToReferencePoint(checkpoint.Id, node)
+	if err != nil {
+		return
+	}
+	taskId = strings.Join(rctIDs, ",")
+	log.Info("Converting checkpoint to reference point.", "vm", vm.Name, "checkpoint", snapshot)
+	return
 }
 
-func (r *Client) CheckSnapshotReady(_ ref.Ref, _ planapi.Precopy, _ util.HostsFunc) (bool, string, error) {
-	return true, "", nil
+func (r *Client) CheckSnapshotReady(vmRef ref.Ref, precopy planapi.Precopy, _ util.HostsFunc) (ready bool, snapshotId string, err error) {
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	checkpoint, err := drv.GetCheckpoint(vm.Name, precopy.Snapshot, node)
+	if err != nil {
+		return
+	}
+	ready = checkpoint != nil
+	return
 }
 
-func (r *Client) CheckSnapshotRemove(_ ref.Ref, _ planapi.Precopy, _ util.HostsFunc) (bool, error) {
-	return true, nil
+// The checkpoint is removed once the reference point conversion is complete.
+func (r *Client) CheckSnapshotRemove(vmRef ref.Ref, precopy planapi.Precopy, _ util.HostsFunc) (removed bool, err error) {
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	checkpoint, err := drv.GetCheckpoint(vm.Name, precopy.Snapshot, node)
+	if err != nil {
+		return
+	}
+	removed = checkpoint == nil
+	return
 }
 
 func (r *Client) SetCheckpoints(_ ref.Ref, _ []planapi.Precopy, _ []cdi.DataVolume, _ bool, _ util.HostsFunc) error {
@@ -225,6 +383,75 @@ func (r *Client) PreTransferActions(_ ref.Ref) (bool, error) {
 	return true, nil
 }
 
-func (r *Client) GetSnapshotDeltas(_ ref.Ref, _ string, _ util.HostsFunc) (s map[string]string, err error) {
+// Get the RCT ID of each disk in the checkpoint, keyed by disk ID.
+func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, _ util.HostsFunc) (s map[string]string, err error) {
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	checkpoint, err := drv.GetCheckpoint(vm.Name, snapshot, node)
+	if err != nil {
+		return
+	}
+	if checkpoint == nil {
+		err = fmt.Errorf("checkpoint %s of VM %s not found", snapshot, vm.Name)
+		return
+	}
+	s = checkpointDeltas(vm, checkpoint)
+	return
+}
+
+// Map the RCT IDs of the checkpoint disks to the inventory disks.
+func checkpointDeltas(vm *hyperv.VM, checkpoint *driver.CheckpointData) map[string]string {
+	deltas := make(map[string]string)
+	for _, cpDisk := range checkpoint.Disks {
+		if cpDisk.RctId == "" {
+			continue
+		}
+		for _, disk := range vm.Disks {
+			if strings.EqualFold(cpDisk.Path, disk.WindowsPath) {
+				deltas[disk.ID] = cpDisk.RctId
+				break
+			}
+		}
+	}
+	return deltas
+}
+
+// Get the extents of each disk changed since the RCT IDs of the baseline.
+// Disks without a baseline are copied in full.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, _ string, baseline map[string]string, _ util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	if len(baseline) == 0 {
+		return
+	}
+	vm, node, err := r.findVM(vmRef)
+	if err != nil {
+		return
+	}
+	drv, err := r.connect()
+	if err != nil {
+		return
+	}
+	extents = make(map[string][]deltacopy.Extent)
+	for _, disk := range vm.Disks {
+		rctID, found := baseline[disk.ID]
+		if !found {
+			continue
+		}
+		var changes []driver.DiskChange
+		changes, err = drv.GetVirtualDiskChanges(disk.WindowsPath, rctID, disk.Capacity, node)
+		if err != nil {
+			return
+		}
+		diskExtents := make([]deltacopy.Extent, 0, len(changes))
+		for _, change := range changes {
+			diskExtents = append(diskExtents, deltacopy.Extent{Offset: change.Offset, Length: change.Length})
+		}
+		extents[disk.ID] = diskExtents
+	}
 	return
 }
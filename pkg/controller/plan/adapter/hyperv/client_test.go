@@ -6,6 +6,7 @@ import (
 	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/model/hyperv"
 	"github.com/kubev2v/forklift/pkg/controller/provider/web/hyperv"
+	"github.com/kubev2v/forklift/pkg/lib/hyperv/driver"
 )
 
 func TestParseStateString(t *testing.T) {
@@ -56,3 +57,34 @@ func TestPowerStateFromInventory(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckpointDeltas(t *testing.T) {
+	vm := &hyperv.VM{}
+	vm.Disks = []model.Disk{
+		{Base: model.Base{ID: "vm-1-disk-0"}, WindowsPath: `C:\VMs\vm-1\disk0.vhdx`},
+		{Base: model.Base{ID: "vm-1-disk-1"}, WindowsPath: `C:\VMs\vm-1\disk1.vhdx`},
+		{Base: model.Base{ID: "vm-1-disk-2"}, WindowsPath: `C:\VMs\vm-1\disk2.vhdx`},
+	}
+	checkpoint := &driver.CheckpointData{
+		Id: "cp-1",
+		Disks: []driver.CheckpointDiskData{
+			{Path: `c:\vms\VM-1\disk0.vhdx`, RctId: "rct:0"},
+			{Path: `C:\VMs\vm-1\disk1.vhdx`, RctId: ""},
+			{Path: `C:\VMs\vm-1\disk2.vhdx`, RctId: "rct:2"},
+			{Path: `C:\VMs\other\disk.vhdx`, RctId: "rct:3"},
+		},
+	}
+	got := checkpointDeltas(vm, checkpoint)
+	expected := map[string]string{
+		"vm-1-disk-0": "rct:0",
+		"vm-1-disk-2": "rct:2",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("checkpointDeltas() = %v, want %v", got, expected)
+	}
+	for disk, rctID := range expected {
+		if got[disk] != rctID {
+			t.Errorf("checkpointDeltas()[%q] = %q, want %q", disk, got[disk], rctID)
+		}
+	}
+}
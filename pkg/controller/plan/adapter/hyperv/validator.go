@@ -18,14 +18,14 @@ type Validator struct {
 	*plancontext.Context
 }
 
-// HyperV only supports cold migration
+// HyperV supports warm migration using Resilient Change Tracking (RCT).
 func (r *Validator) WarmMigration() bool {
-	return false
+	return true
 }
 
 func (r *Validator) MigrationType() bool {
 	switch r.Plan.Spec.Type {
-	case api.MigrationCold, "":
+	case api.MigrationCold, api.MigrationWarm, "":
 		return true
 	default:
 		return false
@@ -176,7 +176,18 @@ func (r *Validator) SharedDisks(_ ref.Ref, _ client.Client) (bool, string, strin
 }
 
 // NO-OP
-func (r *Validator) ChangeTrackingEnabled(_ ref.Ref) (bool, error) {
+// Warm migration requires RCT to be enabled on every disk.
+func (r *Validator) ChangeTrackingEnabled(vmRef ref.Ref) (bool, error) {
+	vm := &hyperv.VM{}
+	err := r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		return false, liberr.Wrap(err, "vm", vmRef.String())
+	}
+	for _, disk := range vm.Disks {
+		if !disk.RCTEnabled {
+			return false, nil
+		}
+	}
 	return true, nil
 }
 
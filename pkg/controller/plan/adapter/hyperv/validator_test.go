@@ -309,3 +309,60 @@ func TestPVCNameTemplate_UsesInventoryVMIdentity(t *testing.T) {
 		t.Error("expected ok=true: PVC name template should be valid when TargetVmName resolves to spec.targetName")
 	}
 }
+
+func TestMigrationType_Warm(t *testing.T) {
+	tests := []struct {
+		name     string
+		planType api.MigrationType
+		expected bool
+	}{
+		{"default", "", true},
+		{"cold", api.MigrationCold, true},
+		{"warm", api.MigrationWarm, true},
+		{"live", api.MigrationLive, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := &api.Plan{}
+			plan.Spec.Type = tc.planType
+			v := &Validator{Context: &plancontext.Context{Plan: plan}}
+			if got := v.MigrationType(); got != tc.expected {
+				t.Errorf("MigrationType() with type %q = %v, want %v", tc.planType, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestChangeTrackingEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		rct      []bool
+		expected bool
+	}{
+		{"no disks", nil, true},
+		{"all disks tracked", []bool{true, true}, true},
+		{"one disk untracked", []bool{true, false}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := makeVM("vm-1", "")
+			for _, rct := range tc.rct {
+				vm.Disks = append(vm.Disks, model.Disk{RCTEnabled: rct})
+			}
+			v := &Validator{
+				Context: &plancontext.Context{
+					Source: plancontext.Source{
+						Inventory: &stubInventory{vms: map[string]*hyperv.VM{"vm-1": vm}},
+					},
+				},
+			}
+			ok, err := v.ChangeTrackingEnabled(ref.Ref{ID: "vm-1"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tc.expected {
+				t.Errorf("ChangeTrackingEnabled() = %v, want %v", ok, tc.expected)
+			}
+		})
+	}
+}
@@ -1,41 +1,414 @@
 package nutanix
 
 import (
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"path"
+
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	"github.com/kubev2v/forklift/pkg/controller/base"
 	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	prism "github.com/kubev2v/forklift/pkg/controller/provider/container/nutanix"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/nutanix"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
+	"github.com/kubev2v/forklift/pkg/lib/util"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
 	cnv "kubevirt.io/api/core/v1"
 	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
+// Disk adapter types.
+const (
+	AdapterSCSI = "SCSI"
+	AdapterSATA = "SATA"
+	AdapterIDE  = "IDE"
+)
+
+// Boot types.
+const (
+	BootUEFI       = "UEFI"
+	BootSecureBoot = "SECURE_BOOT"
+)
+
+// NIC models.
+const (
+	NicE1000 = "E1000"
+)
+
 type Builder struct {
 	*plancontext.Context
 }
 
-func (r *Builder) Secret(_ ref.Ref, _, _ *core.Secret) error {
+// Build the DataVolume credential secret. The disk images are
+// downloaded with the Prism credentials.
+func (r *Builder) Secret(_ ref.Ref, in, object *core.Secret) error {
+	object.StringData = map[string]string{
+		"accessKeyId": string(in.Data["user"]),
+		"secretKey":   string(in.Data["password"]),
+	}
 	return nil
 }
 
-func (r *Builder) ConfigMap(_ ref.Ref, _ *core.Secret, _ *core.ConfigMap) error {
-	return nil
+// Build the DataVolume certificate config map. CDI has no option to
+// skip the verification of HTTP sources, so the certificate of Prism is
+// fetched when the provider skips the verification.
+func (r *Builder) ConfigMap(_ ref.Ref, in *core.Secret, object *core.ConfigMap) (err error) {
+	if cacert, found := util.GetCACert(in); found && len(cacert) > 0 {
+		object.BinaryData["ca.pem"] = cacert
+		return
+	}
+	if !base.GetInsecureSkipVerifyFlag(in) {
+		return
+	}
+	cacert, err := r.fetchPrismCACert()
+	if err != nil {
+		r.Log.Error(err, "Failed to fetch the certificate of Prism")
+		err = nil
+		return
+	}
+	object.BinaryData["ca.pem"] = cacert
+	return
 }
 
-func (r *Builder) VirtualMachine(_ ref.Ref, _ *cnv.VirtualMachineSpec, _ []*core.PersistentVolumeClaim, _ bool, _ bool) error {
-	// TODO: map disks, firmware, networks, CPU, and memory from Nutanix inventory
-	return nil
+// Fetch the certificate of Prism.
+func (r *Builder) fetchPrismCACert() (cert []byte, err error) {
+	prismURL, err := url.Parse(r.Source.Provider.Spec.URL)
+	if err != nil {
+		err = liberr.Wrap(err, "url", r.Source.Provider.Spec.URL)
+		return
+	}
+	insecure := &core.Secret{
+		Data: map[string][]byte{
+			"insecureSkipVerify": []byte("true"),
+		},
+	}
+	crt, err := util.GetTlsCertificate(prismURL, insecure)
+	if err != nil {
+		err = liberr.Wrap(err, "url", r.Source.Provider.Spec.URL)
+		return
+	}
+	if crt == nil {
+		err = liberr.New("no certificate returned by Prism", "url", r.Source.Provider.Spec.URL)
+		return
+	}
+	cert = pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: crt.Raw,
+	})
+	return
 }
 
-func (r *Builder) DataVolumes(_ ref.Ref, _ *core.Secret, _ *core.ConfigMap, _ *cdi.DataVolume, _ *core.ConfigMap) (dvs []cdi.DataVolume, err error) {
-	// TODO: build CDI HTTP import DataVolumes from catalog image file URLs
-	return nil, nil
+// Build the KubeVirt VM from the AHV CPU, memory, firmware, disk
+// and NIC configuration.
+func (r *Builder) VirtualMachine(vmRef ref.Ref, object *cnv.VirtualMachineSpec, persistentVolumeClaims []*core.PersistentVolumeClaim, usesInstanceType bool, _ bool) (err error) {
+	vm := &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	if object.Template == nil {
+		object.Template = &cnv.VirtualMachineInstanceTemplateSpec{}
+	}
+	err = r.mapDisks(vm, persistentVolumeClaims, object)
+	if err != nil {
+		return
+	}
+	r.mapFirmware(vm, object)
+	r.mapClock(vm, object)
+	r.mapInput(object)
+	r.mapNetworks(vm, object)
+	if !usesInstanceType {
+		r.mapCPU(vmRef, vm, object)
+		r.mapMemory(vm, object)
+	}
+	return
 }
 
-func (r *Builder) Tasks(_ ref.Ref) (tasks []*plan.Task, err error) {
-	// TODO: build per-disk progress tasks from inventory (skip CD-ROM)
-	return nil, nil
+// Map the VM disks to the PVCs the disk images were imported into.
+func (r *Builder) mapDisks(vm *model.VM, pvcs []*core.PersistentVolumeClaim, object *cnv.VirtualMachineSpec) (err error) {
+	claims := make(map[string]*core.PersistentVolumeClaim)
+	for _, pvc := range pvcs {
+		claims[pvc.Annotations[planbase.AnnDiskSource]] = pvc
+	}
+	var kVolumes []cnv.Volume
+	var kDisks []cnv.Disk
+	for i, disk := range vmDisks(vm) {
+		pvc, found := claims[disk.UUID]
+		if !found {
+			err = liberr.New("PVC not found for disk", "vm", vm.Name, "disk", disk.UUID)
+			return
+		}
+		volumeName := fmt.Sprintf("vol-%d", i)
+		kVolumes = append(kVolumes, cnv.Volume{
+			Name: volumeName,
+			VolumeSource: cnv.VolumeSource{
+				PersistentVolumeClaim: &cnv.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: core.PersistentVolumeClaimVolumeSource{
+						ClaimName: pvc.Name,
+					},
+				},
+			},
+		})
+		kDisk := cnv.Disk{
+			Name: volumeName,
+			DiskDevice: cnv.DiskDevice{
+				Disk: &cnv.DiskTarget{
+					Bus: diskBus(disk.AdapterType),
+				},
+			},
+			Serial: planbase.DiskSerial(disk.UUID, vm.ID, i),
+		}
+		if i == 0 {
+			kDisk.BootOrder = ptr.To(uint(1))
+		}
+		kDisks = append(kDisks, kDisk)
+	}
+	object.Template.Spec.Volumes = kVolumes
+	object.Template.Spec.Domain.Devices.Disks = kDisks
+	return
+}
+
+// KubeVirt disk bus of an AHV disk adapter. AHV SCSI disks are
+// virtio-scsi; KubeVirt has no IDE bus, so IDE disks are attached
+// to the SATA bus.
+func diskBus(adapterType string) cnv.DiskBus {
+	switch adapterType {
+	case AdapterSCSI:
+		return cnv.DiskBusSCSI
+	case AdapterSATA, AdapterIDE:
+		return cnv.DiskBusSATA
+	default:
+		return cnv.DiskBusVirtio
+	}
+}
+
+func (r *Builder) mapFirmware(vm *model.VM, object *cnv.VirtualMachineSpec) {
+	firmware := &cnv.Firmware{
+		Serial: vm.UUID,
+	}
+	switch vm.BootType {
+	case BootUEFI, BootSecureBoot:
+		secureBoot := vm.BootType == BootSecureBoot
+		firmware.Bootloader = &cnv.Bootloader{
+			EFI: &cnv.EFI{
+				SecureBoot: &secureBoot,
+			},
+		}
+		if secureBoot {
+			object.Template.Spec.Domain.Features = &cnv.Features{
+				SMM: &cnv.FeatureState{
+					Enabled: &secureBoot,
+				},
+			}
+		}
+	default:
+		firmware.Bootloader = &cnv.Bootloader{BIOS: &cnv.BIOS{}}
+	}
+	object.Template.Spec.Domain.Firmware = firmware
+}
+
+func (r *Builder) mapClock(vm *model.VM, object *cnv.VirtualMachineSpec) {
+	if vm.HardwareClockTZ == "" {
+		return
+	}
+	if object.Template.Spec.Domain.Clock == nil {
+		object.Template.Spec.Domain.Clock = &cnv.Clock{}
+	}
+	tz := cnv.ClockOffsetTimezone(vm.HardwareClockTZ)
+	object.Template.Spec.Domain.Clock.ClockOffset.Timezone = &tz
+}
+
+func (r *Builder) mapInput(object *cnv.VirtualMachineSpec) {
+	object.Template.Spec.Domain.Devices.Inputs = []cnv.Input{
+		{
+			Type: "tablet",
+			Name: "tablet",
+			Bus:  cnv.InputBusVirtio,
+		},
+	}
+}
+
+// Map the NICs through the network map, keeping their MAC addresses.
+func (r *Builder) mapNetworks(vm *model.VM, object *cnv.VirtualMachineSpec) {
+	var kNetworks []cnv.Network
+	var kInterfaces []cnv.Interface
+	pool := planbase.NewNADPool()
+	for _, nic := range vm.NICs {
+		pairs := planbase.FindAllMappingsForNICRef(ref.Ref{ID: nic.SubnetUUID}, r.Map.Network)
+		mapped, allocated := planbase.AllocateNetwork(pool, pairs)
+		if !allocated || mapped.Destination.Type == planbase.Ignored {
+			continue
+		}
+		networkName := fmt.Sprintf("net-%d", len(kNetworks))
+		kNetwork := cnv.Network{Name: networkName}
+		kInterface := cnv.Interface{
+			Name:       networkName,
+			Model:      nicModel(nic.Model),
+			MacAddress: nic.MACAddress,
+		}
+		switch mapped.Destination.Type {
+		case planbase.Multus:
+			kNetwork.Multus = &cnv.MultusNetwork{
+				NetworkName: path.Join(mapped.Destination.Namespace, mapped.Destination.Name),
+			}
+			kInterface.Bridge = &cnv.InterfaceBridge{}
+		default:
+			kNetwork.Pod = &cnv.PodNetwork{}
+			kInterface.Masquerade = &cnv.InterfaceMasquerade{}
+		}
+		kNetworks = append(kNetworks, kNetwork)
+		kInterfaces = append(kInterfaces, kInterface)
+	}
+	object.Template.Spec.Networks = kNetworks
+	object.Template.Spec.Domain.Devices.Interfaces = kInterfaces
+}
+
+// KubeVirt interface model of an AHV NIC model.
+func nicModel(nicType string) string {
+	if nicType == NicE1000 {
+		return "e1000"
+	}
+	return "virtio"
+}
+
+func (r *Builder) mapCPU(vmRef ref.Ref, vm *model.VM, object *cnv.VirtualMachineSpec) {
+	object.Template.Spec.Domain.CPU = &cnv.CPU{
+		Sockets: uint32(max(vm.NumSockets, 1)),
+		Cores:   uint32(max(vm.NumVcpusPerSocket, 1)),
+		Threads: uint32(max(vm.NumThreadsPerCore, 1)),
+	}
+	if enableNestedVirt := r.NestedVirtualizationSetting(vmRef, false); enableNestedVirt != nil {
+		policy := "optional"
+		if !*enableNestedVirt {
+			policy = "disable"
+		}
+		object.Template.Spec.Domain.CPU.Features = append(object.Template.Spec.Domain.CPU.Features,
+			cnv.CPUFeature{Name: "vmx", Policy: policy},
+			cnv.CPUFeature{Name: "svm", Policy: policy},
+		)
+	}
+}
+
+func (r *Builder) mapMemory(vm *model.VM, object *cnv.VirtualMachineSpec) {
+	memory := resource.NewQuantity(vm.MemorySizeMiB*1024*1024, resource.BinarySI)
+	object.Template.Spec.Domain.Memory = &cnv.Memory{Guest: memory}
+}
+
+// Build the DataVolumes. Cold migrations import the disk images created
+// from the VM disks over HTTP. Warm migrations copy the disks into blank
+// DataVolumes with the delta copy pod.
+func (r *Builder) DataVolumes(vmRef ref.Ref, secret *core.Secret, configMap *core.ConfigMap, dvTemplate *cdi.DataVolume, _ *core.ConfigMap) (dvs []cdi.DataVolume, err error) {
+	vm := &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	for i, disk := range vmDisks(vm) {
+		pair, found := r.Context.Map.Storage.FindStorage(disk.StorageContainerUUID)
+		if !found {
+			err = liberr.New("storage container not mapped", "vm", vmRef.String(), "disk", disk.UUID, "storageContainer", disk.StorageContainerUUID)
+			return
+		}
+		storageClassName := pair.Destination.StorageClass
+		dv := dvTemplate.DeepCopy()
+		dv.Spec = cdi.DataVolumeSpec{
+			Source: r.dataVolumeSource(vm, disk, secret, configMap),
+			Storage: &cdi.StorageSpec{
+				Resources: core.VolumeResourceRequirements{
+					Requests: core.ResourceList{
+						core.ResourceStorage: *resource.NewQuantity(disk.DiskSizeBytes, resource.BinarySI),
+					},
+				},
+				StorageClassName: &storageClassName,
+			},
+		}
+		if pair.Destination.AccessMode != "" {
+			dv.Spec.Storage.AccessModes = []core.PersistentVolumeAccessMode{pair.Destination.AccessMode}
+		}
+		if pair.Destination.VolumeMode != "" {
+			dv.Spec.Storage.VolumeMode = &pair.Destination.VolumeMode
+		}
+		if dv.ObjectMeta.Annotations == nil {
+			dv.ObjectMeta.Annotations = make(map[string]string)
+		}
+		dv.ObjectMeta.Annotations[planbase.AnnDiskSource] = disk.UUID
+		templateData := &api.PVCNameTemplateData{
+			VmName:       vmRef.Name,
+			TargetVmName: planbase.ResolveTargetVmName(r.Plan, vmRef.ID, vmRef.Name),
+			PlanName:     r.Plan.Name,
+			DiskIndex:    i,
+			VmId:         vmRef.ID,
+			DiskId:       disk.UUID,
+		}
+		pvcNameTemplate := planbase.GetPVCNameTemplate(r.Plan, vmRef.ID)
+		err = planbase.SetPVCNameOnObject(&dv.ObjectMeta, pvcNameTemplate, planbase.GetPVCNameTemplateUseGenerateName(r.Plan), templateData)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vmRef.String(), "disk", disk.UUID, "diskIndex", i)
+			return
+		}
+		dvs = append(dvs, *dv)
+	}
+	return
+}
+
+// Source of the DataVolume of a disk.
+func (r *Builder) dataVolumeSource(vm *model.VM, disk model.Disk, secret *core.Secret, configMap *core.ConfigMap) *cdi.DataVolumeSource {
+	if r.Plan.IsWarm() {
+		return &cdi.DataVolumeSource{
+			Blank: &cdi.DataVolumeBlankImage{},
+		}
+	}
+	image := imageUUID(r.Migration.UID, vm.UUID, disk.UUID)
+	return &cdi.DataVolumeSource{
+		HTTP: &cdi.DataVolumeSourceHTTP{
+			URL:           prism.ImageFileURL(r.Source.Provider.Spec.URL, image),
+			SecretRef:     secret.Name,
+			CertConfigMap: configMap.Name,
+		},
+	}
+}
+
+// Build a progress task for each disk.
+func (r *Builder) Tasks(vmRef ref.Ref) (tasks []*plan.Task, err error) {
+	vm := &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	for _, disk := range vmDisks(vm) {
+		tasks = append(tasks, &plan.Task{
+			Name: disk.UUID,
+			Progress: libitr.Progress{
+				Total: disk.DiskSizeBytes / 0x100000,
+			},
+			Annotations: map[string]string{
+				"unit": "MB",
+			},
+		})
+	}
+	return
+}
+
+// The VM disks, excluding CD-ROMs.
+func vmDisks(vm *model.VM) (disks []model.Disk) {
+	for _, disk := range vm.Disks {
+		if disk.IsCdrom || disk.DeviceType == "CDROM" {
+			continue
+		}
+		disks = append(disks, disk)
+	}
+	return
 }
 
 func (r *Builder) TemplateLabels(_ ref.Ref) (labels map[string]string, err error) {
@@ -73,6 +446,46 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return false
 }
 
+// Warm migrations transfer the disks with the delta copy pod.
+func (r *Builder) SupportsDeltaCopy() bool {
+	return r.Plan.IsWarm()
+}
+
+// Build the delta copy disks, read from the recovery point
+// of the latest precopy.
+func (r *Builder) DeltaCopyDisks(vmRef ref.Ref) (disks []deltacopy.Disk, err error) {
+	vm := &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	status, found := r.Plan.Status.Migration.FindVM(vmRef)
+	if !found || status.Warm == nil || len(status.Warm.Precopies) == 0 {
+		err = liberr.New("no precopy found", "vm", vmRef.String())
+		return
+	}
+	deltas := status.Warm.Precopies[len(status.Warm.Precopies)-1].DeltaMap()
+	for _, disk := range vmDisks(vm) {
+		delta, found := deltas[disk.UUID]
+		if !found {
+			err = liberr.New("no recovery point found for disk", "vm", vmRef.String(), "disk", disk.UUID)
+			return
+		}
+		var vmRecoveryPoint prism.VMRecoveryPointRef
+		vmRecoveryPoint, err = prism.ParseVMRecoveryPointRef(delta)
+		if err != nil {
+			return
+		}
+		disks = append(disks, deltacopy.Disk{
+			ID:       disk.UUID,
+			URL:      prism.DiskDataURL(r.Source.Provider.Spec.URL, vmRecoveryPoint, disk.UUID),
+			Capacity: disk.DiskSizeBytes,
+		})
+	}
+	return
+}
+
 func (r *Builder) PopulatorVolumes(_ ref.Ref, _ map[string]string, _ string) ([]*core.PersistentVolumeClaim, error) {
 	return nil, planbase.VolumePopulatorNotSupportedError
 }
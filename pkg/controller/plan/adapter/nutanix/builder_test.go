@@ -0,0 +1,131 @@
+package nutanix
+
+import (
+	"testing"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/nutanix"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cnv "kubevirt.io/api/core/v1"
+)
+
+func TestDiskBus(t *testing.T) {
+	cases := map[string]cnv.DiskBus{
+		AdapterSCSI: cnv.DiskBusSCSI,
+		AdapterSATA: cnv.DiskBusSATA,
+		AdapterIDE:  cnv.DiskBusSATA,
+		"PCI":       cnv.DiskBusVirtio,
+	}
+	for adapterType, want := range cases {
+		if got := diskBus(adapterType); got != want {
+			t.Errorf("diskBus(%q) = %s, want %s", adapterType, got, want)
+		}
+	}
+}
+
+func TestMapFirmware(t *testing.T) {
+	builder := &Builder{}
+	cases := []struct {
+		bootType   string
+		efi        bool
+		secureBoot bool
+	}{
+		{"LEGACY", false, false},
+		{BootUEFI, true, false},
+		{BootSecureBoot, true, true},
+	}
+	for _, c := range cases {
+		vm := &model.VM{}
+		vm.BootType = c.bootType
+		object := &cnv.VirtualMachineSpec{Template: &cnv.VirtualMachineInstanceTemplateSpec{}}
+		builder.mapFirmware(vm, object)
+		bootloader := object.Template.Spec.Domain.Firmware.Bootloader
+		if (bootloader.EFI != nil) != c.efi {
+			t.Errorf("%s: EFI = %v", c.bootType, bootloader.EFI)
+			continue
+		}
+		if c.efi && *bootloader.EFI.SecureBoot != c.secureBoot {
+			t.Errorf("%s: SecureBoot = %v", c.bootType, *bootloader.EFI.SecureBoot)
+		}
+		if c.secureBoot && object.Template.Spec.Domain.Features.SMM == nil {
+			t.Errorf("%s: SMM not enabled", c.bootType)
+		}
+	}
+}
+
+func TestMapDisksAndNetworks(t *testing.T) {
+	vm := &model.VM{}
+	vm.ID = "vm-1"
+	vm.UUID = "vm-1"
+	vm.Disks = []model.Disk{
+		{UUID: "disk-1", AdapterType: AdapterSCSI},
+		{UUID: "cdrom-1", DeviceType: "CDROM", IsCdrom: true},
+		{UUID: "disk-2", AdapterType: AdapterIDE},
+	}
+	vm.NICs = []model.NIC{
+		{SubnetUUID: "subnet-1", MACAddress: "50:6b:8d:00:00:01", Model: NicE1000},
+		{SubnetUUID: "subnet-2", MACAddress: "50:6b:8d:00:00:02"},
+		{SubnetUUID: "subnet-3", MACAddress: "50:6b:8d:00:00:03"},
+	}
+	networkMap := &api.NetworkMap{}
+	networkMap.Spec.Map = []api.NetworkPair{
+		{Source: refOf("subnet-1"), Destination: api.DestinationNetwork{Type: planbase.Pod}},
+		{Source: refOf("subnet-2"), Destination: api.DestinationNetwork{Type: planbase.Multus, Namespace: "ns", Name: "nad"}},
+		{Source: refOf("subnet-3"), Destination: api.DestinationNetwork{Type: planbase.Ignored}},
+	}
+	builder := &Builder{Context: &plancontext.Context{}}
+	builder.Map.Network = networkMap
+	pvcs := []*core.PersistentVolumeClaim{
+		pvcOf("pvc-2", "disk-2"),
+		pvcOf("pvc-1", "disk-1"),
+	}
+	object := &cnv.VirtualMachineSpec{Template: &cnv.VirtualMachineInstanceTemplateSpec{}}
+	if err := builder.mapDisks(vm, pvcs, object); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disks := object.Template.Spec.Domain.Devices.Disks
+	if len(disks) != 2 || disks[0].Disk.Bus != cnv.DiskBusSCSI || disks[1].Disk.Bus != cnv.DiskBusSATA {
+		t.Fatalf("unexpected disks: %+v", disks)
+	}
+	if disks[0].BootOrder == nil || *disks[0].BootOrder != 1 {
+		t.Errorf("first disk is not the boot disk")
+	}
+	if claim := object.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName; claim != "pvc-1" {
+		t.Errorf("first volume claim = %s", claim)
+	}
+	if err := builder.mapDisks(vm, pvcs[:1], object); err == nil {
+		t.Error("expected an error for a disk without PVC")
+	}
+
+	builder.mapNetworks(vm, object)
+	interfaces := object.Template.Spec.Domain.Devices.Interfaces
+	if len(interfaces) != 2 {
+		t.Fatalf("unexpected interfaces: %+v", interfaces)
+	}
+	if interfaces[0].Model != "e1000" || interfaces[0].Masquerade == nil || interfaces[0].MacAddress != "50:6b:8d:00:00:01" {
+		t.Errorf("unexpected pod interface: %+v", interfaces[0])
+	}
+	if interfaces[1].Model != "virtio" || interfaces[1].Bridge == nil {
+		t.Errorf("unexpected multus interface: %+v", interfaces[1])
+	}
+	if network := object.Template.Spec.Networks[1].Multus; network == nil || network.NetworkName != "ns/nad" {
+		t.Errorf("unexpected multus network: %+v", network)
+	}
+}
+
+func refOf(id string) api.NetworkSourceRef {
+	return api.NetworkSourceRef{Ref: ref.Ref{ID: id}}
+}
+
+func pvcOf(name, diskUUID string) *core.PersistentVolumeClaim {
+	return &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{planbase.AnnDiskSource: diskUUID},
+		},
+	}
+}
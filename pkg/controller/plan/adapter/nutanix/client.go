@@ -1,81 +1,437 @@
 package nutanix
 
 import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
+	prism "github.com/kubev2v/forklift/pkg/controller/provider/container/nutanix"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/nutanix"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
+	"github.com/kubev2v/forklift/pkg/settings"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 // Client performs source-side Nutanix migration actions.
+//
+// Cold migrations import the disks from disk images created from the VM
+// disks once the VM is powered off. The images are named after the VM
+// and disk, and their UUID is derived from the migration so the images
+// of a migration are found again without being recorded. They are
+// deleted when the migration is finalized.
+//
+// Warm migration precopies are Prism Central recovery points. The delta
+// copy pod reads the disks of a recovery point, copying only the regions
+// changed since the recovery point of the previous precopy. A recovery
+// point is therefore kept until the next precopy has been transferred.
 type Client struct {
 	*plancontext.Context
 	log logging.LevelLogger
+	// Prism client.
+	prism *prism.Client
 }
 
 func (r *Client) connect() error {
 	r.log = r.Log.WithName("client")
-	// TODO: wire container/nutanix.Client for PreTransferActions and power management
+	r.prism = prism.NewClient(r.Source.Provider, r.Source.Secret)
 	return nil
 }
 
 func (r *Client) Close() {}
 
-func (r *Client) Finalize(_ []*planapi.VMStatus, _ string) {
-	// TODO: delete temporary catalog images created during PreTransferActions
+// Remove the disk images created by cold migrations and the
+// recovery points left by warm migrations.
+func (r *Client) Finalize(vms []*planapi.VMStatus, _ string) {
+	backoff := wait.Backoff{
+		Duration: 3 * time.Second,
+		Factor:   1.5,
+		Steps:    settings.Settings.CleanupRetries,
+	}
+	for _, vm := range vms {
+		if vm.Warm == nil {
+			if vm.MarkedStarted() {
+				r.removeImages(vm, backoff)
+			}
+			continue
+		}
+		for _, precopy := range vm.Warm.Precopies {
+			err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+				return r.removeRecoveryPoint(precopy)
+			})
+			if err != nil {
+				r.log.Error(err, "removing the recovery point",
+					"vm", vm.String(), "recoveryPoint", precopy.Snapshot, "task", precopy.CreateTaskId)
+			}
+		}
+	}
+}
+
+// Remove the disk images of a cold migrated VM.
+func (r *Client) removeImages(vm *planapi.VMStatus, backoff wait.Backoff) {
+	source, err := r.getVM(vm.Ref)
+	if err != nil {
+		r.log.Error(err, "removing the disk images", "vm", vm.String())
+		return
+	}
+	for _, disk := range vmDisks(source) {
+		image := imageUUID(r.Migration.UID, source.UUID, disk.UUID)
+		err = wait.ExponentialBackoff(backoff, func() (bool, error) {
+			return r.removeImage(image)
+		})
+		if err != nil {
+			r.log.Error(err, "removing the disk image",
+				"vm", vm.String(), "disk", disk.UUID, "image", image)
+		}
+	}
+}
+
+// Remove a disk image. Reports whether it is gone.
+func (r *Client) removeImage(imageUUID string) (removed bool, err error) {
+	err = r.prism.DeleteImage(imageUUID)
+	if err != nil {
+		return
+	}
+	found, err := r.prism.GetDiskImage(imageUUID)
+	if err != nil {
+		return
+	}
+	removed = found == nil
+	return
+}
+
+// Remove the recovery point of a precopy, including one whose creation
+// was not yet known to have completed. Reports whether it is gone.
+func (r *Client) removeRecoveryPoint(precopy planapi.Precopy) (removed bool, err error) {
+	recoveryPoint := precopy.Snapshot
+	if recoveryPoint == "" {
+		if precopy.CreateTaskId == "" {
+			removed = true
+			return
+		}
+		var done bool
+		recoveryPoint, done, err = r.prism.RecoveryPointTask(precopy.CreateTaskId)
+		if err != nil {
+			// A failed task left nothing to remove.
+			r.log.Info("Recovery point was not created.", "task", precopy.CreateTaskId, "reason", err.Error())
+			removed = true
+			err = nil
+			return
+		}
+		if !done {
+			return
+		}
+	}
+	err = r.prism.DeleteRecoveryPoint(recoveryPoint)
+	if err != nil {
+		return
+	}
+	found, err := r.prism.GetRecoveryPoint(recoveryPoint)
+	if err != nil {
+		return
+	}
+	removed = found == nil
+	return
 }
 
 func (r *Client) DetachDisks(_ ref.Ref) error {
 	return nil
 }
 
-func (r *Client) PowerState(_ ref.Ref) (planapi.VMPowerState, error) {
-	// TODO: read from inventory (ON/OFF) or Nutanix API once web client is wired
-	return planapi.VMPowerStateUnknown, nil
+// Find the VM in the inventory.
+func (r *Client) getVM(vmRef ref.Ref) (vm *model.VM, err error) {
+	vm = &model.VM{}
+	err = r.Source.Inventory.Find(vm, vmRef)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+	}
+	return
+}
+
+func (r *Client) PowerState(vmRef ref.Ref) (state planapi.VMPowerState, err error) {
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	power, err := r.prism.VMPowerState(vm.UUID)
+	if err != nil {
+		return
+	}
+	switch power {
+	case prism.PowerStateOn:
+		state = planapi.VMPowerStateOn
+	case prism.PowerStateOff:
+		state = planapi.VMPowerStateOff
+	default:
+		state = planapi.VMPowerStateUnknown
+	}
+	return
 }
 
-func (r *Client) PowerOn(_ ref.Ref) error {
-	// TODO: Nutanix VM power-on API
-	return nil
+func (r *Client) PowerOn(vmRef ref.Ref) (err error) {
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	err = r.prism.PowerOnVM(vm.UUID)
+	return
 }
 
-func (r *Client) PowerOff(_ ref.Ref) error {
-	// TODO: Nutanix VM power-off API
-	return nil
+func (r *Client) PowerOff(vmRef ref.Ref) (err error) {
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	err = r.prism.PowerOffVM(vm.UUID)
+	return
+}
+
+func (r *Client) PoweredOff(vmRef ref.Ref) (off bool, err error) {
+	state, err := r.PowerState(vmRef)
+	if err != nil {
+		return
+	}
+	off = state == planapi.VMPowerStateOff
+	return
 }
 
-func (r *Client) PoweredOff(_ ref.Ref) (bool, error) {
-	// TODO: poll Nutanix API or refreshed inventory after PowerOff
-	return false, nil
+// Create a recovery point of the VM. The recovery point is identified
+// by the task creating it until the task completes.
+func (r *Client) CreateSnapshot(vmRef ref.Ref, _ util.HostsFunc) (snapshot string, taskId string, err error) {
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	name := recoveryPointName(vm.UUID, time.Now().Unix())
+	taskId, err = r.prism.CreateRecoveryPoint(name, vm.UUID)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+		return
+	}
+	r.log.Info("Creating recovery point.", "vm", vmRef.String(), "name", name, "task", taskId)
+	return
 }
 
-func (r *Client) CreateSnapshot(_ ref.Ref, _ util.HostsFunc) (string, string, error) {
-	return "", "", nil
+// Remove the recovery points no longer needed once the precopy has
+// been transferred.
+func (r *Client) RemoveSnapshot(vmRef ref.Ref, snapshot string, _ util.HostsFunc) (taskId string, err error) {
+	for _, recoveryPoint := range r.removable(vmRef, snapshot) {
+		err = r.prism.DeleteRecoveryPoint(recoveryPoint)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vmRef.String(), "recoveryPoint", recoveryPoint)
+			return
+		}
+	}
+	return
 }
 
-func (r *Client) RemoveSnapshot(_ ref.Ref, _ string, _ util.HostsFunc) (string, error) {
-	return "", nil
+// Check whether the recovery point has been created. Returns the
+// recovery point ID, which replaces the task as the precopy snapshot.
+func (r *Client) CheckSnapshotReady(vmRef ref.Ref, precopy planapi.Precopy, _ util.HostsFunc) (ready bool, snapshotId string, err error) {
+	snapshotId, ready, err = r.prism.RecoveryPointTask(precopy.CreateTaskId)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vmRef.String())
+	}
+	return
 }
 
-func (r *Client) CheckSnapshotReady(_ ref.Ref, _ planapi.Precopy, _ util.HostsFunc) (bool, string, error) {
-	return true, "", nil
+// Check whether the recovery points removed with the precopy are gone.
+func (r *Client) CheckSnapshotRemove(vmRef ref.Ref, precopy planapi.Precopy, _ util.HostsFunc) (removed bool, err error) {
+	for _, recoveryPoint := range r.removable(vmRef, precopy.Snapshot) {
+		var found *prism.RecoveryPoint
+		found, err = r.prism.GetRecoveryPoint(recoveryPoint)
+		if err != nil || found != nil {
+			return
+		}
+	}
+	removed = true
+	return
 }
 
-func (r *Client) CheckSnapshotRemove(_ ref.Ref, _ planapi.Precopy, _ util.HostsFunc) (bool, error) {
-	return true, nil
+// Recovery points removed with the precopy snapshot: the one of the
+// previous precopy, and the snapshot itself unless it is still needed as
+// the reference of the next precopy.
+func (r *Client) removable(vmRef ref.Ref, snapshot string) (recoveryPoints []string) {
+	keep := false
+	var previous string
+	if vm, found := r.Plan.Status.Migration.FindVM(vmRef); found && vm.Warm != nil {
+		switch vm.Phase {
+		case api.PhaseRemovePreviousSnapshot, api.PhaseWaitForPreviousSnapshotRemoval,
+			api.PhaseRemovePenultimateSnapshot, api.PhaseWaitForPenultimateSnapshotRemoval:
+			keep = true
+		}
+		for i, precopy := range vm.Warm.Precopies {
+			if precopy.Snapshot == snapshot {
+				if i > 0 {
+					previous = vm.Warm.Precopies[i-1].Snapshot
+				}
+				break
+			}
+		}
+	}
+	if previous != "" {
+		recoveryPoints = append(recoveryPoints, previous)
+	}
+	if !keep && snapshot != "" {
+		recoveryPoints = append(recoveryPoints, snapshot)
+	}
+	return
 }
 
 func (r *Client) SetCheckpoints(_ ref.Ref, _ []planapi.Precopy, _ []cdi.DataVolume, _ bool, _ util.HostsFunc) error {
 	return nil
 }
 
-func (r *Client) GetSnapshotDeltas(_ ref.Ref, _ string, _ util.HostsFunc) (s map[string]string, err error) {
+// Get the VM recovery point of each disk, keyed by disk UUID.
+func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, _ util.HostsFunc) (s map[string]string, err error) {
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	vmRecoveryPoint, err := r.vmRecoveryPoint(vm, snapshot)
+	if err != nil {
+		return
+	}
+	s = make(map[string]string)
+	for _, disk := range vmDisks(vm) {
+		s[disk.UUID] = vmRecoveryPoint.String()
+	}
+	return
+}
+
+// Get the regions of each disk changed since the baseline recovery point.
+// Disks without a baseline are copied in full.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, _ util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	if len(baseline) == 0 {
+		return
+	}
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	vmRecoveryPoint, err := r.vmRecoveryPoint(vm, snapshot)
+	if err != nil {
+		return
+	}
+	extents = make(map[string][]deltacopy.Extent)
+	for _, disk := range vmDisks(vm) {
+		delta, found := baseline[disk.UUID]
+		if !found {
+			continue
+		}
+		var reference prism.VMRecoveryPointRef
+		reference, err = prism.ParseVMRecoveryPointRef(delta)
+		if err != nil {
+			return
+		}
+		var regions []prism.ChangedRegion
+		regions, err = r.prism.ChangedRegions(vmRecoveryPoint, disk.UUID, reference)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vmRef.String(), "disk", disk.UUID)
+			return
+		}
+		extents[disk.UUID] = changedExtents(regions)
+	}
+	return
+}
+
+// Map the changed regions to extents. Zeroed regions are copied
+// as well, since the target may hold data of an earlier precopy.
+func changedExtents(regions []prism.ChangedRegion) []deltacopy.Extent {
+	extents := make([]deltacopy.Extent, 0, len(regions))
+	for _, region := range regions {
+		extents = append(extents, deltacopy.Extent{Offset: region.Offset, Length: region.Length})
+	}
+	return extents
+}
+
+// Get the VM recovery point within the recovery point.
+func (r *Client) vmRecoveryPoint(vm *model.VM, recoveryPointID string) (vmRecoveryPoint prism.VMRecoveryPointRef, err error) {
+	recoveryPoint, err := r.prism.GetRecoveryPoint(recoveryPointID)
+	if err != nil {
+		return
+	}
+	if recoveryPoint == nil {
+		err = liberr.New("recovery point not found", "vm", vm.Name, "recoveryPoint", recoveryPointID)
+		return
+	}
+	vmRecoveryPoint, found := recoveryPoint.FindVM(vm.UUID)
+	if !found {
+		err = liberr.New("recovery point does not capture the VM", "vm", vm.Name, "recoveryPoint", recoveryPointID)
+	}
+	return
+}
+
+// Create a disk image of each VM disk for a cold migration, the VM
+// being powered off. Reports ready once every image is complete.
+func (r *Client) PreTransferActions(vmRef ref.Ref) (ready bool, err error) {
+	if r.Plan.IsWarm() {
+		ready = true
+		return
+	}
+	vm, err := r.getVM(vmRef)
+	if err != nil {
+		return
+	}
+	ready = true
+	for _, disk := range vmDisks(vm) {
+		id := imageUUID(r.Migration.UID, vm.UUID, disk.UUID)
+		var image *prism.DiskImage
+		image, err = r.prism.GetDiskImage(id)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vmRef.String(), "disk", disk.UUID)
+			return
+		}
+		if image == nil {
+			name := imageName(vm.UUID, disk.UUID)
+			err = r.prism.CreateDiskImage(id, name, disk.UUID)
+			if err != nil {
+				err = liberr.Wrap(err, "vm", vmRef.String(), "disk", disk.UUID)
+				return
+			}
+			r.log.Info("Creating disk image.", "vm", vmRef.String(), "disk", disk.UUID, "image", id)
+			ready = false
+			continue
+		}
+		switch image.State {
+		case prism.ImageComplete:
+		case prism.ImageError:
+			err = liberr.New(
+				"disk image creation failed",
+				"vm", vmRef.String(),
+				"disk", disk.UUID,
+				"image", id,
+				"errors", strings.Join(image.Messages, "; "))
+			return
+		default:
+			ready = false
+		}
+	}
 	return
 }
 
-func (r *Client) PreTransferActions(_ ref.Ref) (ready bool, err error) {
-	// TODO: create catalog images from VM disks and wait until COMPLETE
-	return true, nil
+// Name of the recovery point of a precopy.
+func recoveryPointName(vmID string, created int64) string {
+	return fmt.Sprintf("forklift-migration-vm-%s-precopy-%d", vmID, created)
+}
+
+// Name of the disk image of a VM disk.
+func imageName(vmID, diskID string) string {
+	return fmt.Sprintf("forklift-migration-vm-%s-disk-%s", vmID, diskID)
+}
+
+// UUID of the disk image of a VM disk. The UUID is unique to the
+// migration so an image left by an earlier migration is never imported.
+func imageUUID(migration types.UID, vmID, diskID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(path.Join(string(migration), vmID, diskID))).String()
 }
@@ -0,0 +1,77 @@
+package nutanix
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	prism "github.com/kubev2v/forklift/pkg/controller/provider/container/nutanix"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+)
+
+func TestRemovableRecoveryPoints(t *testing.T) {
+	vmRef := ref.Ref{ID: "vm-1"}
+	tests := []struct {
+		name     string
+		phase    string
+		snapshot string
+		expected []string
+	}{
+		{"first precopy is kept", api.PhaseRemovePreviousSnapshot, "rp-1", nil},
+		{"previous precopy is removed", api.PhaseRemovePreviousSnapshot, "rp-2", []string{"rp-1"}},
+		{"wait for previous removal", api.PhaseWaitForPreviousSnapshotRemoval, "rp-2", []string{"rp-1"}},
+		{"penultimate precopy is kept", api.PhaseRemovePenultimateSnapshot, "rp-2", []string{"rp-1"}},
+		{"final precopy is removed", api.PhaseRemoveFinalSnapshot, "rp-3", []string{"rp-2", "rp-3"}},
+		{"unknown snapshot", api.PhaseRemoveFinalSnapshot, "rp-9", []string{"rp-9"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &api.Plan{}
+			p.Status.Migration.VMs = []*planapi.VMStatus{
+				{
+					VM:    planapi.VM{Ref: vmRef},
+					Phase: tc.phase,
+					Warm: &planapi.Warm{
+						Precopies: []planapi.Precopy{
+							{Snapshot: "rp-1"},
+							{Snapshot: "rp-2"},
+							{Snapshot: "rp-3"},
+						},
+					},
+				},
+			}
+			client := &Client{Context: &plancontext.Context{Plan: p}}
+			got := client.removable(vmRef, tc.snapshot)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("removable(%q) = %v, want %v", tc.snapshot, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestChangedExtents(t *testing.T) {
+	regions := []prism.ChangedRegion{
+		{Offset: 0, Length: 4096, RegionType: prism.RegionRegular},
+		{Offset: 1048576, Length: 65536, RegionType: prism.RegionZeroed},
+	}
+	expected := []deltacopy.Extent{
+		{Offset: 0, Length: 4096},
+		{Offset: 1048576, Length: 65536},
+	}
+	if got := changedExtents(regions); !reflect.DeepEqual(got, expected) {
+		t.Errorf("changedExtents() = %v, want %v", got, expected)
+	}
+}
+
+func TestImageUUID(t *testing.T) {
+	id := imageUUID("migration-1", "vm-1", "disk-1")
+	if id != imageUUID("migration-1", "vm-1", "disk-1") {
+		t.Error("imageUUID() is not deterministic")
+	}
+	if id == imageUUID("migration-2", "vm-1", "disk-1") || id == imageUUID("migration-1", "vm-1", "disk-2") {
+		t.Error("imageUUID() collides across migrations or disks")
+	}
+}
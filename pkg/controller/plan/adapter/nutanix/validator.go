@@ -5,6 +5,9 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	webbase "github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/nutanix"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -12,21 +15,34 @@ type Validator struct {
 	*plancontext.Context
 }
 
+// Warm migrations precopy the disks from Prism Central recovery points.
 func (r *Validator) WarmMigration() bool {
-	return false
+	return true
 }
 
 func (r *Validator) MigrationType() bool {
 	switch r.Plan.Spec.Type {
-	case api.MigrationCold, "":
+	case api.MigrationCold, api.MigrationWarm, "":
 		return true
 	default:
 		return false
 	}
 }
 
-func (r *Validator) StorageMapped(_ ref.Ref) (bool, error) {
-	// TODO: validate storage container mappings against VM disks
+// Validate that the storage container of each disk is mapped.
+func (r *Validator) StorageMapped(vmRef ref.Ref) (bool, error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return false, err
+	}
+	if r.Context.Map.Storage == nil {
+		return false, nil
+	}
+	for _, disk := range vmDisks(vm) {
+		if _, found := r.Context.Map.Storage.FindStorage(disk.StorageContainerUUID); !found {
+			return false, nil
+		}
+	}
 	return true, nil
 }
 
@@ -34,8 +50,20 @@ func (r *Validator) DirectStorage(_ ref.Ref) (bool, error) {
 	return true, nil
 }
 
-func (r *Validator) NetworksMapped(_ ref.Ref) (bool, error) {
-	// TODO: validate subnet mappings against VM NICs
+// Validate that the subnet of each NIC is mapped.
+func (r *Validator) NetworksMapped(vmRef ref.Ref) (bool, error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return false, err
+	}
+	if r.Context.Map.Network == nil {
+		return false, nil
+	}
+	for _, nic := range vm.NICs {
+		if _, found := r.Context.Map.Network.FindNetwork(nic.SubnetUUID); !found {
+			return false, nil
+		}
+	}
 	return true, nil
 }
 
@@ -43,9 +71,16 @@ func (r *Validator) MaintenanceMode(_ ref.Ref) (bool, error) {
 	return true, nil
 }
 
-func (r *Validator) NICNetworkRefs(_ ref.Ref) ([]ref.Ref, error) {
-	// TODO: return one ref per VM NIC subnet
-	return nil, nil
+func (r *Validator) NICNetworkRefs(vmRef ref.Ref) (refs []ref.Ref, err error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return
+	}
+	refs = make([]ref.Ref, 0, len(vm.NICs))
+	for _, nic := range vm.NICs {
+		refs = append(refs, ref.Ref{ID: nic.SubnetUUID})
+	}
+	return
 }
 
 func (r *Validator) StaticIPs(_ ref.Ref) (bool, error) {
@@ -76,18 +111,60 @@ func (r *Validator) VMMigrationType(_ ref.Ref) (bool, error) {
 	return true, nil
 }
 
-func (r *Validator) InvalidDiskSizes(_ ref.Ref) ([]string, error) {
-	// TODO: return disk UUIDs with invalid sizes
-	return nil, nil
+func (r *Validator) InvalidDiskSizes(vmRef ref.Ref) ([]string, error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return nil, err
+	}
+	var invalid []string
+	for _, disk := range vmDisks(vm) {
+		if disk.DiskSizeBytes <= 0 {
+			invalid = append(invalid, disk.UUID)
+		}
+	}
+	return invalid, nil
 }
 
-func (r *Validator) MacConflicts(_ ref.Ref) ([]planbase.MacConflict, error) {
-	// TODO: check source NIC MACs against destination inventory
-	return nil, nil
+func (r *Validator) MacConflicts(vmRef ref.Ref) ([]planbase.MacConflict, error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return nil, err
+	}
+	destinationVMs, err := planbase.GetDestinationVMsFromInventory(r.Destination.Inventory, webbase.Param{
+		Key:   webbase.DetailParam,
+		Value: "all",
+	})
+	if err != nil {
+		return nil, liberr.Wrap(err, "fetching destination VMs for MAC conflict check")
+	}
+	var sourceMacs []string
+	for _, nic := range vm.NICs {
+		sourceMacs = append(sourceMacs, nic.MACAddress)
+	}
+	return planbase.CheckMacConflicts(sourceMacs, destinationVMs), nil
 }
 
-func (r *Validator) PVCNameTemplate(_ ref.Ref, _ string) (bool, error) {
-	// TODO: validate PVC name template against VM disks
+// Validate that the template produces valid PVC names for each disk.
+func (r *Validator) PVCNameTemplate(vmRef ref.Ref, pvcNameTemplate string) (bool, error) {
+	vm, err := planbase.FindSourceVM[model.VM](r.Source.Inventory, vmRef)
+	if err != nil {
+		return false, err
+	}
+	targetVmName := planbase.ResolveTargetVmName(r.Plan, vm.ID, vm.Name)
+	for i, disk := range vmDisks(vm) {
+		testData := &api.PVCNameTemplateData{
+			VmName:       vm.Name,
+			TargetVmName: targetVmName,
+			PlanName:     r.Plan.Name,
+			DiskIndex:    i,
+			VmId:         vm.ID,
+			DiskId:       disk.UUID,
+		}
+		_, err = planbase.ValidatePVCNameTemplate(pvcNameTemplate, testData)
+		if err != nil {
+			return false, liberr.Wrap(err, "vm", vmRef.String(), "diskIndex", i, "disk", disk.UUID)
+		}
+	}
 	return true, nil
 }
 
@@ -15,6 +15,7 @@ import (
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/ocp"
 	ocpclient "github.com/kubev2v/forklift/pkg/lib/client/openshift"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	core "k8s.io/api/core/v1"
@@ -664,6 +665,16 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return false
 }
 
+// check whether the builder transfers disks with the delta copy pod
+func (r *Builder) SupportsDeltaCopy() bool {
+	return false
+}
+
+// Build delta copy disks. No-op for this provider.
+func (r *Builder) DeltaCopyDisks(_ ref.Ref) (disks []deltacopy.Disk, err error) {
+	return
+}
+
 func (r *Builder) PopulatorVolumes(vmRef ref.Ref, annotations map[string]string, secretName string) (pvcs []*core.PersistentVolumeClaim, err error) {
 	err = planbase.VolumePopulatorNotSupportedError
 	return
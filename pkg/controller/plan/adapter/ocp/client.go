@@ -8,6 +8,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"github.com/kubev2v/forklift/pkg/settings"
 	core "k8s.io/api/core/v1"
@@ -54,6 +55,11 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc uti
 	return
 }
 
+// Get changed extents for a VM snapshot. No-op for this provider.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	return
+}
+
 // Finalize implements base.Client
 func (r *Client) Finalize(vms []*planapi.VMStatus, planName string) {
 	for _, vm := range vms {
@@ -11,6 +11,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/openstack"
 	libclient "github.com/kubev2v/forklift/pkg/lib/client/openstack"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"github.com/kubev2v/forklift/pkg/settings"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -139,6 +140,11 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc uti
 	return
 }
 
+// Get changed extents for a VM snapshot. No-op for this provider.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	return
+}
+
 // Close connections to the provider API.
 func (r *Client) Close() {
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	libweb "github.com/kubev2v/forklift/pkg/lib/inventory/web"
 	core "k8s.io/api/core/v1"
 	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
@@ -60,6 +61,11 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc uti
 	return
 }
 
+// Get changed extents for a VM snapshot. No-op for this provider.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	return
+}
+
 // Check if a snapshot is ready to transfer, to avoid importer restarts.
 func (r *Client) CheckSnapshotReady(vmRef ref.Ref, precopy planapi.Precopy, hosts util.HostsFunc) (ready bool, snapshotId string, err error) {
 	return
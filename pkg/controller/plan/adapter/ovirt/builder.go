@@ -17,6 +17,7 @@ import (
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	utils "github.com/kubev2v/forklift/pkg/controller/plan/util"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/ovirt"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	util "github.com/kubev2v/forklift/pkg/lib/util"
@@ -738,6 +739,16 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return !r.Context.Plan.IsWarm() && r.Context.Plan.Provider.Destination.IsHost()
 }
 
+// check whether the builder transfers disks with the delta copy pod
+func (r *Builder) SupportsDeltaCopy() bool {
+	return false
+}
+
+// Build delta copy disks. No-op for this provider.
+func (r *Builder) DeltaCopyDisks(_ ref.Ref) (disks []deltacopy.Disk, err error) {
+	return
+}
+
 func (r *Builder) PopulatorVolumes(vmRef ref.Ref, annotations map[string]string, secretName string) (pvcs []*core.PersistentVolumeClaim, err error) {
 	workload := &model.Workload{}
 	err = r.Source.Inventory.Find(workload, vmRef)
@@ -15,6 +15,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/ovirt"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libutil "github.com/kubev2v/forklift/pkg/lib/util"
 	"github.com/kubev2v/forklift/pkg/settings"
@@ -120,6 +121,11 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc uti
 	return
 }
 
+// Get changed extents for a VM snapshot. No-op for this provider.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	return
+}
+
 // Set DataVolume checkpoints.
 func (r *Client) SetCheckpoints(vmRef ref.Ref, precopies []planapi.Precopy, datavolumes []cdi.DataVolume, final bool, hostsFunc util.HostsFunc) (err error) {
 	n := len(precopies)
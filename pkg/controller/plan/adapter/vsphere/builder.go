@@ -31,6 +31,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	libref "github.com/kubev2v/forklift/pkg/lib/ref"
@@ -1448,6 +1449,16 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return false
 }
 
+// check whether the builder transfers disks with the delta copy pod
+func (r *Builder) SupportsDeltaCopy() bool {
+	return false
+}
+
+// Build delta copy disks. No-op for this provider.
+func (r *Builder) DeltaCopyDisks(_ ref.Ref) (disks []deltacopy.Disk, err error) {
+	return
+}
+
 // PopulatorVolumes creates PVC in case the their are needed for the disks
 // in context, and according to the offload plugin configuration in the StorageMap
 func (r *Builder) PopulatorVolumes(vmRef ref.Ref, annotations map[string]string, secretName string) (pvcs []*core.PersistentVolumeClaim, err error) {
@@ -1583,13 +1594,24 @@ func (r *Builder) PopulatorVolumes(vmRef ref.Ref, annotations map[string]string,
 					naaDS.ID = effectiveMapped.Source.ID
 					naaDS.Name = effectiveMapped.Source.Name
 				}
-				naa := r.lookupDatastoreNAA(naaDS, dsNaaMap)
+				// Disks on NFS datastores are copied from the export of the
+				// datastore when requested, there is no LUN to look the NAA
+				// up from. Otherwise they are cloned with vmkfstools.
+				var nfsExportServer, nfsExportPath string
+				if !disk.RDM && ds.NasRemotePath != "" && effectiveMapped.OffloadPlugin.VSphereXcopyPluginConfig.CopyFromNFSExport {
+					nfsExportServer, nfsExportPath = nfsServer(ds), ds.NasRemotePath
+				}
+				naa := ""
+				if nfsExportPath == "" {
+					naa = r.lookupDatastoreNAA(naaDS, dsNaaMap)
+				}
 				storageClass := effectiveMapped.Destination.StorageClass
 				r.Log.Info(fmt.Sprintf("getting storage mapping by storage class %q and datastore %v datastore name %s datastore", storageClass, disk.Datastore, disk.Datastore))
 				vsphereInstance := r.Context.Plan.Provider.Source.GetName()
 				migrationHosts := effectiveMapped.OffloadPlugin.VSphereXcopyPluginConfig.DedicatedMigrationHosts
 				storageVendorProduct := effectiveMapped.OffloadPlugin.VSphereXcopyPluginConfig.StorageVendorProduct
 				storageVendorSecretRef := effectiveMapped.OffloadPlugin.VSphereXcopyPluginConfig.SecretRef
+				driverImage := effectiveMapped.OffloadPlugin.VSphereXcopyPluginConfig.DriverImage
 
 				r.Log.Info(fmt.Sprintf("vsphere provider %v storage vendor product %v storage secret name %v ", vsphereInstance, storageVendorProduct, storageVendorSecretRef))
 
@@ -1728,6 +1750,9 @@ func (r *Builder) PopulatorVolumes(vmRef ref.Ref, annotations map[string]string,
 						SecretName:           diskSecretName,
 						StorageVendorProduct: string(storageVendorProduct),
 						MigrationHost:        migrationHost,
+						DriverImage:          driverImage,
+						NFSServer:            nfsExportServer,
+						NFSPath:              nfsExportPath,
 					},
 				}
 				createdPVC := &core.PersistentVolumeClaim{}
@@ -1814,6 +1839,15 @@ func (r *Builder) lookupDatastoreNAA(ds *model.Datastore, cache map[string]strin
 	return naa
 }
 
+// nfsServer returns the server exporting an NFS datastore. NFS 4.1
+// datastores may list several server addresses, the last one is used.
+func nfsServer(ds *model.Datastore) string {
+	if n := len(ds.NasRemoteHostNames); n > 0 {
+		return ds.NasRemoteHostNames[n-1]
+	}
+	return ds.NasRemoteHost
+}
+
 // buildCsiImportPVC creates a PVC with CSI import annotations for VVol/RDM disks.
 // The CSI driver reads the annotation and clones the named source array volume; no
 // populator pod, CR, or service account is created.
@@ -1932,6 +1966,15 @@ func (r *Builder) buildCsiImportPVC(
 	if mapped.Destination.AccessMode != "" {
 		pvc.Spec.AccessModes = []core.PersistentVolumeAccessMode{mapped.Destination.AccessMode}
 	}
+	// Vendors without an import annotation adopt the source volume through a static PV,
+	// pre-created with the "volume" label selected here, so the driver does not provision.
+	if _, found := vendorAnnotations[resolver.AnnCsiVolumeHandle]; found {
+		pvc.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"volume": "csi-import-" + r.generatePopulatorSuffix(string(r.Migration.UID), vmRef.ID, disk.Key, disk.File, diskIndex),
+			},
+		}
+	}
 
 	if err = r.setPVCNameFromTemplate(&pvc.ObjectMeta, vm, diskIndex, disk); err != nil {
 		return nil, liberr.Wrap(err)
@@ -2807,12 +2850,7 @@ func (r *Builder) NetAppShiftPVCs(vmRef ref.Ref, labels map[string]string) (pvcs
 			return
 		}
 		ann[planbase.AnnNfsPath] = ds.NasRemotePath
-		n := len(ds.NasRemoteHostNames)
-		if n > 0 {
-			ann[planbase.AnnNfsServer] = ds.NasRemoteHostNames[n-1]
-		} else {
-			ann[planbase.AnnNfsServer] = ds.NasRemoteHost
-		}
+		ann[planbase.AnnNfsServer] = nfsServer(ds)
 
 		if disk.Shared {
 			pvc.Labels[Shareable] = "true"
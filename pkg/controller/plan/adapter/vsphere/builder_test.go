@@ -434,6 +434,74 @@ var _ = Describe("vSphere builder", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(populator.Spec.MigrationHost).To(BeElementOf("host1", "host2"))
 		})
+
+		DescribeTable("should copy the disks of NFS datastores from the export only when requested", func(copyFromNFSExport bool, server, path string) {
+			builder := createBuilder(
+				&core.Secret{
+					ObjectMeta: meta.ObjectMeta{Name: "test-secret", Namespace: "test"},
+				},
+			)
+			vm := model.VM{
+				VM1: model.VM1{
+					VM0: model.VM0{
+						ID:   "test-vm-id",
+						Name: "test",
+					},
+					Disks: []vsphere.Disk{
+						{
+							Datastore: vsphere.Ref{ID: "ds-1"},
+							File:      "[datastore1] vm-123/vm-123.vmdk",
+							Bus:       vsphere.SCSI,
+							Capacity:  1024 * 1024 * 1024,
+							Key:       2000,
+						},
+					},
+				},
+			}
+
+			dsMap := []v1beta1.StoragePair{
+				{
+					Source: ref.Ref{ID: "ds-1"},
+					Destination: v1beta1.DestinationStorage{
+						StorageClass: "test-sc",
+					},
+					OffloadPlugin: &v1beta1.OffloadPlugin{
+						VSphereXcopyPluginConfig: &v1beta1.VSphereXcopyPluginConfig{
+							StorageVendorProduct: "test-vendor",
+							SecretRef:            "test-secret",
+							CopyFromNFSExport:    copyFromNFSExport,
+						},
+					},
+				},
+			}
+			storageMap := v1beta1.StorageMap{
+				Spec: v1beta1.StorageMapSpec{
+					Map: dsMap,
+				},
+			}
+			builder.Source.Inventory = &mockInventory{
+				ds: model.Datastore{
+					Resource:      model.Resource{ID: "ds-1"},
+					NasRemoteHost: "10.0.0.10",
+					NasRemotePath: "/vol/ds1",
+				},
+				vm: vm,
+			}
+			builder.Map.Storage = &storageMap
+
+			pvcs, err := builder.PopulatorVolumes(ref.Ref{ID: vm.ID}, map[string]string{"test-annotation": "true"}, "test-secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pvcs).To(HaveLen(1))
+
+			populator := &v1beta1.VSphereXcopyVolumePopulator{}
+			err = builder.Destination.Get(context.TODO(), client.ObjectKey{Name: pvcs[0].Name, Namespace: pvcs[0].Namespace}, populator)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(populator.Spec.NFSServer).To(Equal(server))
+			Expect(populator.Spec.NFSPath).To(Equal(path))
+		},
+			Entry("cloned with vmkfstools by default", false, "", ""),
+			Entry("copied from the export", true, "10.0.0.10", "/vol/ds1"),
+		)
 	})
 
 	Context("formatHostAddress", func() {
@@ -1610,3 +1678,12 @@ func createBuilder(objs ...runtime.Object) *Builder {
 		},
 	}
 }
+
+var _ = DescribeTable("NFS datastore server", func(ds *model.Datastore, expected string) {
+	Expect(nfsServer(ds)).To(Equal(expected))
+},
+	Entry("NFS 3 datastore", &model.Datastore{NasRemoteHost: "10.0.0.10", NasRemotePath: "/vol/ds1"}, "10.0.0.10"),
+	Entry("NFS 4.1 datastore with several addresses",
+		&model.Datastore{NasRemoteHost: "10.0.0.10", NasRemoteHostNames: []string{"10.0.0.10", "10.0.0.11"}, NasRemotePath: "/vol/ds1"},
+		"10.0.0.11"),
+)
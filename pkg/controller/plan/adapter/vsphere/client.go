@@ -13,6 +13,7 @@ import (
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"github.com/kubev2v/forklift/pkg/storage/resolver"
 	"github.com/vmware/govmomi"
@@ -21,6 +22,7 @@ import (
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
@@ -299,16 +301,8 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshotId string, hosts util.
 
 	changeIdMapping = make(map[string]string)
 	for _, device := range snapshot.Config.Hardware.Device {
-		vDevice := device.GetVirtualDevice()
-		switch dev := vDevice.Backing.(type) {
-		case *types.VirtualDiskFlatVer2BackingInfo:
-			changeIdMapping[trimBackingFileName(dev.FileName)] = dev.ChangeId
-		case *types.VirtualDiskSparseVer2BackingInfo:
-			changeIdMapping[trimBackingFileName(dev.FileName)] = dev.ChangeId
-		case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
-			changeIdMapping[trimBackingFileName(dev.FileName)] = dev.ChangeId
-		case *types.VirtualDiskRawDiskVer2BackingInfo:
-			changeIdMapping[trimBackingFileName(dev.DescriptorFileName)] = dev.ChangeId
+		if fileName, changeId, found := changeTracking(device); found {
+			changeIdMapping[fileName] = changeId
 		}
 	}
 
@@ -320,6 +314,102 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshotId string, hosts util.
 	return
 }
 
+// Get the backing file name and the change ID of a disk.
+func changeTracking(device types.BaseVirtualDevice) (fileName string, changeId string, found bool) {
+	found = true
+	switch dev := device.GetVirtualDevice().Backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		fileName, changeId = dev.FileName, dev.ChangeId
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		fileName, changeId = dev.FileName, dev.ChangeId
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		fileName, changeId = dev.FileName, dev.ChangeId
+	case *types.VirtualDiskRawDiskVer2BackingInfo:
+		fileName, changeId = dev.DescriptorFileName, dev.ChangeId
+	default:
+		found = false
+	}
+	fileName = trimBackingFileName(fileName)
+	return
+}
+
+// Get changed extents for a VM snapshot. The vSphere precopies are
+// transferred by CDI, the extents are only used to measure the precopies.
+// Disks without a baseline change ID report their allocated extents.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshotId string, baseline map[string]string, hosts util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	vm, err := r.getVM(vmRef, hosts)
+	if err != nil {
+		return
+	}
+
+	snapshotRef := types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: snapshotId}
+	var snapshot mo.VirtualMachineSnapshot
+	err = vm.Properties(
+		context.TODO(),
+		snapshotRef,
+		[]string{"config.hardware.device"},
+		&snapshot)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vm.Reference().Value, "snapshot", snapshotId)
+		return
+	}
+
+	extents = make(map[string][]deltacopy.Extent)
+	for _, device := range snapshot.Config.Hardware.Device {
+		disk, isDisk := device.(*types.VirtualDisk)
+		if !isDisk {
+			continue
+		}
+		fileName, _, found := changeTracking(disk)
+		if !found {
+			continue
+		}
+		changeId := baseline[fileName]
+		if changeId == "" {
+			changeId = "*"
+		}
+		var diskExtents []deltacopy.Extent
+		diskExtents, err = r.changedAreas(vm, snapshotRef, disk, changeId)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vm.Reference().Value, "snapshot", snapshotId, "disk", fileName)
+			return
+		}
+		extents[fileName] = diskExtents
+	}
+
+	return
+}
+
+// Query the areas of a disk changed since the change ID.
+func (r *Client) changedAreas(vm *object.VirtualMachine, snapshot types.ManagedObjectReference, disk *types.VirtualDisk, changeId string) (extents []deltacopy.Extent, err error) {
+	offset := int64(0)
+	for offset < disk.CapacityInBytes {
+		var response *types.QueryChangedDiskAreasResponse
+		response, err = methods.QueryChangedDiskAreas(
+			context.TODO(),
+			vm.Client(),
+			&types.QueryChangedDiskAreas{
+				This:        vm.Reference(),
+				Snapshot:    &snapshot,
+				DeviceKey:   disk.Key,
+				StartOffset: offset,
+				ChangeId:    changeId,
+			})
+		if err != nil {
+			return
+		}
+		info := response.Returnval
+		for _, area := range info.ChangedArea {
+			extents = append(extents, deltacopy.Extent{Offset: area.Start, Length: area.Length})
+		}
+		if info.Length == 0 {
+			break
+		}
+		offset = info.StartOffset + info.Length
+	}
+	return
+}
+
 // Check if a snapshot is removed
 func (r *Client) CheckSnapshotRemove(vmRef ref.Ref, precopy planapi.Precopy, hosts util.HostsFunc) (bool, error) {
 	r.Log.Info("Check Snapshot Remove", "vmRef", vmRef, "precopy", precopy)
@@ -560,29 +650,40 @@ func nullableHosts() (hosts map[string]*v1beta1.Host, err error) {
 }
 
 // Connect to the vSphere API.
-func (r *Client) connect() error {
+func (r *Client) connect() (err error) {
 	r.Close()
-	url, err := liburl.Parse(r.Source.Provider.Spec.URL)
+	r.client, err = Connect(r.Source.Provider, r.Source.Secret)
+	return
+}
+
+// Connect to the vCenter or ESXi host of a provider and log in
+// with the credentials of its secret.
+func Connect(provider *v1beta1.Provider, secret *core.Secret) (client *govmomi.Client, err error) {
+	url, err := liburl.Parse(provider.Spec.URL)
 	if err != nil {
-		return liberr.Wrap(err)
+		err = liberr.Wrap(err)
+		return
 	}
-	url.User = liburl.UserPassword(r.user(), r.password())
-	soapClient := soap.NewClient(url, base.GetInsecureSkipVerifyFlag(r.Source.Secret))
-	soapClient.SetThumbprint(url.Host, r.thumbprint())
+	url.User = liburl.UserPassword(string(secret.Data["user"]), string(secret.Data["password"]))
+	soapClient := soap.NewClient(url, base.GetInsecureSkipVerifyFlag(secret))
+	soapClient.SetThumbprint(url.Host, provider.Status.Fingerprint)
 	vimClient, err := vim25.NewClient(context.TODO(), soapClient)
 	if err != nil {
-		return liberr.Wrap(err)
+		err = liberr.Wrap(err)
+		return
 	}
-	r.client = &govmomi.Client{
+	client = &govmomi.Client{
 		SessionManager: session.NewManager(vimClient),
 		Client:         vimClient,
 	}
-	err = r.client.Login(context.TODO(), url.User)
+	err = client.Login(context.TODO(), url.User)
 	if err != nil {
-		return liberr.Wrap(err)
+		client = nil
+		err = liberr.Wrap(err)
+		return
 	}
 
-	return nil
+	return
 }
 
 func (r *Client) user() string {
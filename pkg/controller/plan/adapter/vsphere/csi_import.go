@@ -7,8 +7,12 @@ import (
 
 	forklift "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/flashsystem"
 	"github.com/kubev2v/forklift/pkg/storage/resolver/hpe"
 	"github.com/kubev2v/forklift/pkg/storage/resolver/ontap"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/powermax"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/powerstore"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/pure"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -47,6 +51,15 @@ func newCsiImportPlugin(product forklift.StorageVendorProduct, secretData map[st
 		backendUUID := string(secretData["TRIDENT_BACKEND_UUID"])
 		driverType := string(secretData["TRIDENT_DRIVER"])
 		return ontap.NewOntapImporter(host, user, pass, svm, backendUUID, driverType, skipSSL, k8sClient, storageClass)
+	case forklift.StorageVendorProductPureFlashArray:
+		return pure.NewPureImporter(host, user, pass, skipSSL)
+	case forklift.StorageVendorProductPowerStore:
+		return powerstore.NewPowerStoreImporter(host, user, pass, skipSSL)
+	case forklift.StorageVendorProductPowerMax:
+		symmetrixID := string(secretData["POWERMAX_SYMMETRIX_ID"])
+		return powermax.NewPowerMaxImporter(host, user, pass, symmetrixID, skipSSL)
+	case forklift.StorageVendorProductFlashSystem:
+		return flashsystem.NewFlashSystemImporter(host, user, pass, skipSSL)
 	default:
 		return nil, fmt.Errorf("CSI import not supported for vendor %q", product)
 	}
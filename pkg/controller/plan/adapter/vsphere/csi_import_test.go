@@ -4,8 +4,13 @@ import (
 	"testing"
 
 	forklift "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/flashsystem"
 	"github.com/kubev2v/forklift/pkg/storage/resolver/hpe"
 	"github.com/kubev2v/forklift/pkg/storage/resolver/ontap"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/powermax"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/powerstore"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/pure"
 )
 
 func TestNewCsiImportPluginUnknownVendor(t *testing.T) {
@@ -56,6 +61,44 @@ func TestNewCsiImportPluginOntap(t *testing.T) {
 	}
 }
 
+func TestNewCsiImportPluginStaticVendors(t *testing.T) {
+	secret := map[string][]byte{
+		"STORAGE_HOSTNAME":      []byte("https://host"),
+		"STORAGE_USERNAME":      []byte("user"),
+		"STORAGE_PASSWORD":      []byte("pass"),
+		"POWERMAX_SYMMETRIX_ID": []byte("000120001234"),
+	}
+	tests := []struct {
+		product forklift.StorageVendorProduct
+		check   func(resolver.CsiImportPlugin) bool
+	}{
+		{forklift.StorageVendorProductPureFlashArray, func(p resolver.CsiImportPlugin) bool { _, ok := p.(*pure.PureImporter); return ok }},
+		{forklift.StorageVendorProductPowerStore, func(p resolver.CsiImportPlugin) bool { _, ok := p.(*powerstore.PowerStoreImporter); return ok }},
+		{forklift.StorageVendorProductPowerMax, func(p resolver.CsiImportPlugin) bool { _, ok := p.(*powermax.PowerMaxImporter); return ok }},
+		{forklift.StorageVendorProductFlashSystem, func(p resolver.CsiImportPlugin) bool { _, ok := p.(*flashsystem.FlashSystemImporter); return ok }},
+	}
+	for _, tt := range tests {
+		plugin, err := newCsiImportPlugin(tt.product, secret, nil, "")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.product, err)
+		}
+		if !tt.check(plugin) {
+			t.Errorf("%s: unexpected plugin type %T", tt.product, plugin)
+		}
+	}
+}
+
+func TestNewCsiImportPluginPowerMaxMissingSymmetrixID(t *testing.T) {
+	_, err := newCsiImportPlugin(forklift.StorageVendorProductPowerMax, map[string][]byte{
+		"STORAGE_HOSTNAME": []byte("https://host:8443"),
+		"STORAGE_USERNAME": []byte("user"),
+		"STORAGE_PASSWORD": []byte("pass"),
+	}, nil, "")
+	if err == nil {
+		t.Fatal("expected error for missing POWERMAX_SYMMETRIX_ID, got nil")
+	}
+}
+
 func TestNewCsiImportPluginMissingStorageKeys(t *testing.T) {
 	_, err := newCsiImportPlugin(forklift.StorageVendorProductOntap, map[string][]byte{}, nil, "")
 	if err == nil {
@@ -191,6 +191,8 @@ type Destination struct {
 	Provider *api.Provider
 	// Provider API client.
 	Inventory web.Client
+	// Provider Secret (non-openshift destination).
+	Secret *core.Secret
 }
 
 // Build.
@@ -206,7 +208,25 @@ func (r *Destination) build(ctx *Context) (err error) {
 			"namespace", ctx.Plan.Spec.Provider.Destination.Namespace)
 		return
 	}
-	if !r.Provider.IsHost() {
+	if r.Provider.Type() != api.OpenShift {
+		// The VMs are created through the provider API. The
+		// client is used for the transfer pods, which run on
+		// the host cluster.
+		ref := r.Provider.Spec.Secret
+		r.Secret = &core.Secret{}
+		err = ctx.Get(
+			context.TODO(),
+			k8sclient.ObjectKey{
+				Namespace: ref.Namespace,
+				Name:      ref.Name,
+			},
+			r.Secret)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		r.Client = ctx.Client
+	} else if !r.Provider.IsHost() {
 		ref := r.Provider.Spec.Secret
 		secret := &core.Secret{}
 		err = ctx.Get(
@@ -158,6 +158,17 @@ func Add(mgr manager.Manager) error {
 		log.Trace(err)
 		return err
 	}
+	// MigrationPolicy.
+	err = cnt.Watch(
+		source.Kind(mgr.GetCache(), &api.MigrationPolicy{},
+			handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, a *api.MigrationPolicy) []reconcile.Request {
+				return RequestForMigrationPolicy(ctx, mgr.GetClient(), a)
+			}),
+			&MigrationPolicyPredicate{}))
+	if err != nil {
+		log.Trace(err)
+		return err
+	}
 	// Migration.
 	err = cnt.Watch(
 		source.Kind(mgr.GetCache(), &api.Migration{},
@@ -222,12 +233,20 @@ func (r Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (r
 		return
 	}
 
-	// Don't reconcile succeeded plans unless they need archiving.
+	// Don't reconcile succeeded plans unless they need archiving
+	// or rolling back.
 	if plan.Status.HasCondition(Succeeded) && !plan.Spec.Archived {
-		r.Log.V(1).Info("Skipping reconcile of succeeded plan.", "plan", plan.Name)
-		result.RequeueAfter = 0
-		err = nil
-		return
+		rollback, rErr := r.rollbackPending(plan)
+		if rErr != nil {
+			err = rErr
+			return
+		}
+		if !rollback {
+			r.Log.V(1).Info("Skipping reconcile of succeeded plan.", "plan", plan.Name)
+			result.RequeueAfter = 0
+			err = nil
+			return
+		}
 	}
 
 	// Postpone as needed.
@@ -419,6 +438,15 @@ func (r *Reconciler) execute(plan *api.Plan) (reQ time.Duration, err error) {
 	if err != nil {
 		return
 	}
+	//
+	// Dry-run.
+	// Render the resources instead of starting the migrations.
+	if migration == nil && plan.Spec.DryRun {
+		dryRun := DryRun{Context: ctx}
+		reQ, err = dryRun.Run()
+		return
+	}
+	plan.Status.DeleteCondition(DryRunRendered, DryRunFailed)
 	if migration != nil {
 		ctx.SetMigration(migration)
 		r.matchSnapshot(ctx)
@@ -475,6 +503,9 @@ func (r *Reconciler) execute(plan *api.Plan) (reQ time.Duration, err error) {
 		ctx.SetMigration(migration)
 		snapshot = r.newSnapshot(ctx)
 		plan.Status.DeleteCondition(Failed, Canceled)
+		if !migration.IsRollback() {
+			plan.Status.DeleteCondition(RolledBack)
+		}
 		r.Log.Info(
 			"Found (new) migration.",
 			"migration",
@@ -495,7 +526,7 @@ func (r *Reconciler) execute(plan *api.Plan) (reQ time.Duration, err error) {
 	//
 	// Run the migration.
 	snapshot.BeginStagingConditions()
-	runner = Migration{Context: ctx}
+	runner = Migration{Context: ctx, recorder: r.EventRecorder}
 	reQ, err = runner.Run()
 	if err != nil {
 		return
@@ -743,6 +774,21 @@ func (r *Reconciler) activeMigration(plan *api.Plan) (migration *api.Migration,
 	return
 }
 
+// Determine whether a rollback of the plan is pending.
+func (r *Reconciler) rollbackPending(plan *api.Plan) (pending bool, err error) {
+	list, err := r.pendingMigrations(plan)
+	if err != nil {
+		return
+	}
+	for _, migration := range list {
+		if migration.IsRollback() {
+			pending = true
+			return
+		}
+	}
+	return
+}
+
 // Sorted list of pending migrations.
 func (r *Reconciler) pendingMigrations(plan *api.Plan) (list []*api.Migration, err error) {
 	all := &api.MigrationList{}
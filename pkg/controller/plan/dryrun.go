@@ -0,0 +1,408 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	"github.com/kubev2v/forklift/pkg/controller/base"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web"
+	"github.com/kubev2v/forklift/pkg/lib/client/dryrun"
+	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/settings"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+)
+
+// Dry-run.
+const (
+	// Plan generation rendered into a dry-run ConfigMap.
+	AnnDryRunGeneration = "forklift.konveyor.io/dry-run-generation"
+	// ConfigMap key of the itinerary.
+	DryRunItineraryKey = "itinerary.yaml"
+	// ConfigMap key of the rendering error.
+	DryRunErrorKey = "error"
+)
+
+// Rendered itinerary of a VM.
+type DryRunItinerary struct {
+	// Phases of the VM migration.
+	Phases []string `json:"phases"`
+	// Pipeline reported in the VM status.
+	Pipeline []*plan.Step `json:"pipeline"`
+}
+
+// Dry-run of a plan.
+// The resources the migration would create for each VM are rendered
+// by the adapters through a dry-run client and stored in a ConfigMap
+// per VM in the plan namespace. Nothing else is created.
+type DryRun struct {
+	*plancontext.Context
+}
+
+// Render the VMs not rendered for the current generation of the plan.
+func (r *DryRun) Run() (reQ time.Duration, err error) {
+	existing, err := r.configMaps()
+	if err != nil {
+		return
+	}
+	for id, configMap := range existing {
+		if _, found := r.Plan.Spec.FindVM(ref.Ref{ID: id}); !found {
+			err = r.Delete(context.TODO(), configMap)
+			if err != nil {
+				err = liberr.Wrap(err)
+				return
+			}
+			delete(existing, id)
+		}
+	}
+	generation := strconv.FormatInt(r.Plan.Generation, 10)
+	runner, err := r.runner()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if runner.provider != nil {
+			runner.provider.Close()
+		}
+	}()
+	failed := []string{}
+	for _, vm := range r.Plan.Spec.VMs {
+		configMap, found := existing[vm.ID]
+		if found && configMap.Annotations[AnnDryRunGeneration] == generation {
+			if _, hasError := configMap.Data[DryRunErrorKey]; hasError {
+				failed = append(failed, vm.String())
+			}
+			continue
+		}
+		var data map[string]string
+		data, err = r.render(runner, vm)
+		if err != nil {
+			if errors.As(err, &web.ProviderNotReadyError{}) {
+				r.Log.Info("Dry-run postponed, provider not ready.", "vm", vm.String())
+				reQ = base.SlowReQ
+				err = nil
+				continue
+			}
+			data = map[string]string{DryRunErrorKey: err.Error()}
+			failed = append(failed, vm.String())
+			err = nil
+		}
+		if !found {
+			configMap, err = r.newConfigMap(vm)
+			if err != nil {
+				return
+			}
+		}
+		configMap.Annotations[AnnDryRunGeneration] = generation
+		configMap.Data = data
+		if found {
+			err = r.Update(context.TODO(), configMap)
+		} else {
+			err = r.Create(context.TODO(), configMap)
+		}
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		r.Log.Info(
+			"Dry-run rendered.",
+			"configMap",
+			path.Join(
+				configMap.Namespace,
+				configMap.Name),
+			"vm",
+			vm.String())
+	}
+	r.setConditions(failed)
+	return
+}
+
+// Build the migration runner rendering through dry-run clients.
+// The clients are replaced for each VM, see render().
+func (r *DryRun) runner() (runner *Migration, err error) {
+	ctx := *r.Context
+	ctx.Migration = &api.Migration{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: r.Plan.Namespace,
+			Name:      r.Plan.Name + "-dry-run",
+			UID:       uuid.NewUUID(),
+		},
+	}
+	runner = &Migration{Context: &ctx}
+	r.overlay(runner)
+	err = runner.init()
+	return
+}
+
+// Replace the clients of the runner with new dry-run clients.
+func (r *DryRun) overlay(runner *Migration) (destination *dryrun.Client) {
+	destination = dryrun.New(r.Destination.Client)
+	runner.Destination.Client = destination
+	if r.Destination.Provider.IsHost() {
+		runner.Context.Client = destination
+	} else {
+		runner.Context.Client = dryrun.New(r.Context.Client)
+	}
+	return
+}
+
+// Render the resources of a VM.
+func (r *DryRun) render(runner *Migration, vm plan.VM) (data map[string]string, err error) {
+	destination := r.overlay(runner)
+	status := &plan.VMStatus{VM: vm}
+	if r.Plan.IsWarm() {
+		status.Warm = &plan.Warm{}
+	}
+	status.Pipeline, err = runner.migrator.Pipeline(vm)
+	if err != nil {
+		return
+	}
+	phases, err := runner.migrator.Itinerary(vm).List()
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	itinerary := DryRunItinerary{Pipeline: status.Pipeline}
+	for _, phase := range phases {
+		itinerary.Phases = append(itinerary.Phases, phase.Name)
+	}
+	if status.TargetName != "" {
+		status.NewName = status.TargetName
+	} else if errs := k8svalidation.IsDNS1123Subdomain(status.Name); len(errs) > 0 {
+		status.NewName, err = runner.kubevirt.changeVmNameDNS1123(status.Name, r.Plan.Spec.TargetNamespace)
+		if err != nil {
+			return
+		}
+	}
+	err = runner.kubevirt.EnsureNamespace()
+	if err != nil {
+		return
+	}
+	for _, phase := range itinerary.Phases {
+		status.Phase = phase
+		switch phase {
+		case api.PhaseCreateDataVolumes:
+			err = r.renderVolumes(runner, destination, status)
+		case api.PhaseCreateGuestConversionPod:
+			err = r.renderConversion(runner, status)
+		case api.PhaseCreateVM:
+			err = runner.kubevirt.EnsureVM(status)
+		}
+		if err != nil {
+			return
+		}
+	}
+	data, err = manifests(destination.Objects())
+	if err != nil {
+		return
+	}
+	content, err := yaml.Marshal(itinerary)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	data[DryRunItineraryKey] = string(content)
+	return
+}
+
+// Render the volumes of a VM.
+// The PVCs CDI would create for the DataVolumes are added to the
+// dry-run client so the resources built next can reference them.
+func (r *DryRun) renderVolumes(runner *Migration, destination *dryrun.Client, vm *plan.VMStatus) (err error) {
+	csiPVCs, err := runner.kubevirt.CsiImportPVCs(vm)
+	if err != nil {
+		return
+	}
+	if len(csiPVCs) > 0 {
+		err = runner.ensurer.PersistentVolumeClaims(vm, csiPVCs)
+		if err != nil {
+			return
+		}
+	}
+	if runner.builder.SupportsVolumePopulators() {
+		_, err = runner.kubevirt.PopulatorVolumes(vm.Ref)
+		if err != nil {
+			return
+		}
+	}
+	if !r.Plan.IsWarm() && runner.builder.SupportsVolumePopulators() {
+		return
+	}
+	dataVolumes, err := runner.kubevirt.DataVolumes(vm)
+	if err != nil {
+		return
+	}
+	err = runner.kubevirt.EnsureDataVolumes(vm, dataVolumes)
+	if err != nil {
+		return
+	}
+	for i := range dataVolumes {
+		err = destination.Add(dataVolumePVC(&dataVolumes[i]))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Render the guest conversion of a VM.
+func (r *DryRun) renderConversion(runner *Migration, vm *plan.VMStatus) (err error) {
+	step, found := vm.FindStep(runner.migrator.Step(vm))
+	if !found {
+		step = &plan.Step{}
+	}
+	if settings.Settings.UseConversionCR {
+		var convType api.ConversionType
+		convType, err = runner.kubevirt.ResolveConversionType(vm)
+		if err != nil {
+			return
+		}
+		_, err = runner.kubevirt.EnsureConversion(
+			vm, convType, r.Plan.Name, r.Plan.Namespace, string(r.Plan.UID), runner.Migration, step)
+		return
+	}
+	_, err = runner.kubevirt.EnsureGuestConversionPod(vm, step)
+	return
+}
+
+// Set the dry-run conditions.
+func (r *DryRun) setConditions(failed []string) {
+	r.Plan.Status.SetCondition(libcnd.Condition{
+		Type:     DryRunRendered,
+		Status:   True,
+		Category: api.CategoryAdvisory,
+		Message: fmt.Sprintf(
+			"The resources of %d VM(s) are rendered into ConfigMaps labeled %s=%s.",
+			len(r.Plan.Spec.VMs)-len(failed),
+			kResource,
+			ResourceDryRun),
+		Durable: true,
+	})
+	r.Plan.Status.DeleteCondition(DryRunFailed)
+	if len(failed) > 0 {
+		r.Plan.Status.SetCondition(libcnd.Condition{
+			Type:     DryRunFailed,
+			Status:   True,
+			Category: api.CategoryWarn,
+			Message:  "The resources of some VMs could not be rendered.",
+			Items:    failed,
+			Durable:  true,
+		})
+	}
+}
+
+// List the dry-run ConfigMaps of the plan by VM ID.
+func (r *DryRun) configMaps() (configMaps map[string]*core.ConfigMap, err error) {
+	list := &core.ConfigMapList{}
+	err = r.List(
+		context.TODO(),
+		list,
+		&client.ListOptions{
+			LabelSelector: k8slabels.SelectorFromSet(map[string]string{
+				kPlan:     string(r.Plan.UID),
+				kResource: ResourceDryRun,
+			}),
+			Namespace: r.Plan.Namespace,
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	configMaps = make(map[string]*core.ConfigMap)
+	for i := range list.Items {
+		configMap := &list.Items[i]
+		if configMap.Annotations == nil {
+			configMap.Annotations = make(map[string]string)
+		}
+		configMaps[configMap.Labels[kVM]] = configMap
+	}
+	return
+}
+
+// Build the dry-run ConfigMap of a VM.
+func (r *DryRun) newConfigMap(vm plan.VM) (configMap *core.ConfigMap, err error) {
+	configMap = &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:    r.Plan.Namespace,
+			GenerateName: r.Plan.Name + "-dry-run-",
+			Labels: map[string]string{
+				kPlan:     string(r.Plan.UID),
+				kPlanName: r.Plan.Name,
+				kVM:       vm.ID,
+				kResource: ResourceDryRun,
+			},
+			Annotations: map[string]string{},
+		},
+	}
+	err = k8sutil.SetOwnerReference(r.Plan, configMap, scheme.Scheme)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+	return
+}
+
+// PVC CDI creates for a DataVolume.
+func dataVolumePVC(dv *cdi.DataVolume) (pvc *core.PersistentVolumeClaim) {
+	pvc = &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   dv.Namespace,
+			Name:        dv.Name,
+			Labels:      dv.Labels,
+			Annotations: dv.Annotations,
+		},
+	}
+	if dv.Spec.PVC != nil {
+		pvc.Spec = *dv.Spec.PVC
+	} else if dv.Spec.Storage != nil {
+		pvc.Spec.AccessModes = dv.Spec.Storage.AccessModes
+		pvc.Spec.VolumeMode = dv.Spec.Storage.VolumeMode
+		pvc.Spec.StorageClassName = dv.Spec.Storage.StorageClassName
+		pvc.Spec.Resources = dv.Spec.Storage.Resources
+	}
+	return
+}
+
+// Render objects as YAML documents keyed by lowercase kind.
+// Secret data is redacted.
+func manifests(objects []client.Object) (data map[string]string, err error) {
+	documents := make(map[string][]string)
+	for _, object := range objects {
+		if secret, cast := object.(*core.Secret); cast {
+			for key := range secret.Data {
+				secret.Data[key] = []byte{}
+			}
+			secret.StringData = nil
+		}
+		var content []byte
+		content, err = yaml.Marshal(object)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		key := strings.ToLower(object.GetObjectKind().GroupVersionKind().Kind) + ".yaml"
+		documents[key] = append(documents[key], string(content))
+	}
+	data = make(map[string]string)
+	for key, list := range documents {
+		data[key] = strings.Join(list, "---\n")
+	}
+	return
+}
@@ -0,0 +1,66 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDryRunManifests(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	secret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "secret"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	secret.SetGroupVersionKind(core.SchemeGroupVersion.WithKind("Secret"))
+	first := &core.ConfigMap{ObjectMeta: meta.ObjectMeta{Name: "first"}}
+	first.SetGroupVersionKind(core.SchemeGroupVersion.WithKind("ConfigMap"))
+	second := &core.ConfigMap{ObjectMeta: meta.ObjectMeta{Name: "second"}}
+	second.SetGroupVersionKind(core.SchemeGroupVersion.WithKind("ConfigMap"))
+
+	data, err := manifests([]client.Object{secret, first, second})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.HaveLen(2))
+	g.Expect(data["secret.yaml"]).To(gomega.ContainSubstring("password: \"\""))
+	g.Expect(data["secret.yaml"]).ToNot(gomega.ContainSubstring("c2VjcmV0"))
+	g.Expect(data["configmap.yaml"]).To(gomega.MatchRegexp("(?s)name: first.*---\n.*name: second"))
+}
+
+func TestDryRunDataVolumePVC(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	storageClass := "fast"
+	mode := core.PersistentVolumeBlock
+	dv := &cdi.DataVolume{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "vm-disk-0",
+			Labels:      map[string]string{kVM: "vm-1"},
+			Annotations: map[string]string{"forklift.konveyor.io/disk-source": "[ds] vm/disk.vmdk"},
+		},
+		Spec: cdi.DataVolumeSpec{
+			Storage: &cdi.StorageSpec{
+				StorageClassName: &storageClass,
+				VolumeMode:       &mode,
+				Resources: core.VolumeResourceRequirements{
+					Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		},
+	}
+
+	pvc := dataVolumePVC(dv)
+	g.Expect(pvc.Name).To(gomega.Equal(dv.Name))
+	g.Expect(pvc.Namespace).To(gomega.Equal(dv.Namespace))
+	g.Expect(pvc.Labels).To(gomega.Equal(dv.Labels))
+	g.Expect(pvc.Annotations).To(gomega.Equal(dv.Annotations))
+	g.Expect(*pvc.Spec.StorageClassName).To(gomega.Equal(storageClass))
+	g.Expect(*pvc.Spec.VolumeMode).To(gomega.Equal(mode))
+	g.Expect(pvc.Spec.Resources.Requests.Storage().String()).To(gomega.Equal("10Gi"))
+}
@@ -4,7 +4,10 @@ import (
 	"cmp"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,9 +21,11 @@ import (
 	"gopkg.in/yaml.v2"
 	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	k8sutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -39,6 +44,30 @@ const (
 	ResourceHookConfig = "hook-config"
 )
 
+// Step annotations recording the hook retries.
+const (
+	// Number of retries of the hook.
+	AnnHookRetries = "retries"
+	// Time of the next retry of the hook.
+	AnnHookRetryAt = "retryAt"
+)
+
+// Hook result.
+const (
+	// Path of the file the hook writes its structured (JSON) result to.
+	// The file is the termination message of the container, limited to 4KiB.
+	HookResultPath = "/tmp/result/result.json"
+	// Environment variable holding the path of the result file.
+	HookResultEnv = "HOOK_RESULT_PATH"
+)
+
+// defaultHookBackoffSeconds is the delay before the first retry of a failed hook
+// when the failure policy does not set one.
+const defaultHookBackoffSeconds int64 = 30
+
+// maxHookBackoffShift limits the doubling of the retry delay.
+const maxHookBackoffShift = 10
+
 // defaultAAPJobPollSeconds is used when spec.aap.timeout and spec.deadline are 0 and ForkliftController aap_timeout is unset.
 const defaultAAPJobPollSeconds int64 = 3600
 
@@ -49,6 +78,8 @@ type HookRunner struct {
 	vm *planapi.VMStatus
 	// Hook.
 	hook *api.Hook
+	// Failure policy.
+	policy planapi.HookFailurePolicy
 }
 
 // Run.
@@ -67,11 +98,17 @@ func (r *HookRunner) Run(vm *planapi.VMStatus) (err error) {
 			}
 			return
 		}
+		r.policy = ref.Policy(r.hook.Spec.FailurePolicy)
 	} else {
 		step.MarkedCompleted()
 		return
 	}
 
+	// Wait for the next retry of a failed hook.
+	if r.retryPending(step) {
+		return
+	}
+
 	// Check if this is an AAP job template hook
 	if r.hook.Spec.AAP != nil {
 		err = r.runAAPJob(step)
@@ -94,12 +131,19 @@ func (r *HookRunner) Run(vm *planapi.VMStatus) (err error) {
 		})
 	}
 	if conditions.HasCondition("Failed") {
-		step.AddError(conditions.FindCondition("Failed").Message)
-		step.MarkCompleted()
+		err = r.failed(step, job, conditions.FindCondition("Failed").Message)
 	} else if int(job.Status.Failed) > Settings.Migration.HookRetry {
-		step.AddError("Retry limit exceeded.")
-		step.MarkCompleted()
+		err = r.failed(step, job, "Retry limit exceeded.")
 	} else if job.Status.Succeeded > 0 {
+		var reason string
+		reason, err = r.recordResult(job)
+		if err != nil {
+			return
+		}
+		if reason != "" {
+			err = r.failed(step, job, reason)
+			return
+		}
 		step.Progress.Completed = 1
 		step.MarkCompleted()
 	}
@@ -107,6 +151,151 @@ func (r *HookRunner) Run(vm *planapi.VMStatus) (err error) {
 	return
 }
 
+// Record the structured result written by the hook on the VM status.
+// Returns the reason of the failure when the result cannot be parsed.
+func (r *HookRunner) recordResult(job *batch.Job) (reason string, err error) {
+	list := core.PodList{}
+	err = r.Client.List(
+		context.TODO(),
+		&list,
+		&client.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{batch.JobNameLabel: job.Name}),
+			Namespace:     job.Namespace,
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	message := ""
+	for _, pod := range list.Items {
+		if pod.Status.Phase != core.PodSucceeded {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil {
+				message = strings.TrimSpace(status.State.Terminated.Message)
+			}
+		}
+	}
+	if message == "" {
+		return
+	}
+	result := planapi.HookResult{}
+	err = json.Unmarshal([]byte(message), &result)
+	if err != nil {
+		reason = fmt.Sprintf("Hook result is not valid: %s", err.Error())
+		err = nil
+		return
+	}
+	switch result.TargetPowerState {
+	case "", planapi.TargetPowerStateOn, planapi.TargetPowerStateOff, planapi.TargetPowerStateAuto:
+	default:
+		reason = fmt.Sprintf("Hook result is not valid: unknown target power state '%s'.", result.TargetPowerState)
+		return
+	}
+	for key, value := range result.TargetLabels {
+		if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+			reason = fmt.Sprintf("Hook result is not valid: target label key '%s': %s", key, strings.Join(errs, "; "))
+			return
+		}
+		if errs := k8svalidation.IsValidLabelValue(value); len(errs) > 0 {
+			reason = fmt.Sprintf("Hook result is not valid: target label '%s' value '%s': %s", key, value, strings.Join(errs, "; "))
+			return
+		}
+	}
+	result.Step = r.vm.Phase
+	r.vm.SetHookResult(result)
+	if result.SkipGuestConversion {
+		r.skipConversion()
+	}
+	r.Log.Info(
+		"Hook result recorded.",
+		"vm",
+		r.vm.String(),
+		"step",
+		result.Step)
+	return
+}
+
+// Mark the conversion step completed when the guest conversion
+// is skipped by a hook result.
+func (r *HookRunner) skipConversion() {
+	step, found := r.vm.FindStep(ImageConversion)
+	if !found || step.MarkedStarted() {
+		return
+	}
+	if _, useV2v := r.vm.FindStep(DiskTransferV2v); useV2v {
+		return
+	}
+	step.MarkStarted()
+	step.Progress.Completed = step.Progress.Total
+	step.AddWarning("Guest conversion skipped by hook.")
+	step.MarkCompleted()
+	step.Phase = api.StepCompleted
+}
+
+// Apply the failure policy of the hook. The hook is run again after
+// the backoff while it has retries left, then the step either fails
+// or records a warning and completes so that the migration continues.
+func (r *HookRunner) failed(step *planapi.Step, job *batch.Job, reason string) (err error) {
+	retries, _ := strconv.Atoi(step.Annotations[AnnHookRetries])
+	if retries < r.policy.Retries {
+		retries++
+		backoff := r.policy.Backoff
+		if backoff == 0 {
+			backoff = defaultHookBackoffSeconds
+		}
+		delay := time.Duration(backoff) * time.Second << min(retries-1, maxHookBackoffShift)
+		retryAt := time.Now().Add(delay)
+		if step.Annotations == nil {
+			step.Annotations = map[string]string{}
+		}
+		step.Annotations[AnnHookRetries] = strconv.Itoa(retries)
+		step.Annotations[AnnHookRetryAt] = retryAt.Format(time.RFC3339)
+		step.AddWarning(fmt.Sprintf("Hook failed (retry %d of %d): %s", retries, r.policy.Retries, reason))
+		if job != nil {
+			err = r.Client.Delete(
+				context.TODO(),
+				job,
+				client.PropagationPolicy(meta.DeletePropagationBackground))
+			if err != nil && !k8serr.IsNotFound(err) {
+				err = liberr.Wrap(err)
+				return
+			}
+			err = nil
+		}
+		r.Log.Info(
+			"Hook failed, retrying.",
+			"vm",
+			r.vm.String(),
+			"step",
+			step.Name,
+			"retry",
+			retries,
+			"retryAt",
+			retryAt)
+		return
+	}
+	switch r.policy.Action {
+	case planapi.HookFailureContinue:
+		step.AddWarning(fmt.Sprintf("Hook failed, migration continued: %s", reason))
+		step.Progress.Completed = 1
+	default:
+		step.AddError(reason)
+	}
+	step.MarkCompleted()
+	return
+}
+
+// Returns true while waiting for the next retry of a failed hook.
+func (r *HookRunner) retryPending(step *planapi.Step) bool {
+	retryAt, err := time.Parse(time.RFC3339, step.Annotations[AnnHookRetryAt])
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(retryAt)
+}
+
 // Ensure the job.
 func (r *HookRunner) ensureJob() (job *batch.Job, err error) {
 	mp, err := r.ensureConfigMap()
@@ -125,7 +314,14 @@ func (r *HookRunner) ensureJob() (job *batch.Job, err error) {
 		err = liberr.Wrap(err)
 		return
 	}
-	if len(list.Items) == 0 {
+	// Jobs of failed attempts are being deleted before a retry.
+	var found []batch.Job
+	for i := range list.Items {
+		if list.Items[i].DeletionTimestamp == nil {
+			found = append(found, list.Items[i])
+		}
+	}
+	if len(found) == 0 {
 		job, err = r.job(mp)
 		if err != nil {
 			return
@@ -142,7 +338,7 @@ func (r *HookRunner) ensureJob() (job *batch.Job, err error) {
 				job.Namespace,
 				job.Name))
 	} else {
-		job = &list.Items[0]
+		job = &found[0]
 		r.Log.V(1).Info(
 			"Found (hook) job.",
 			"job",
@@ -212,6 +408,14 @@ func (r *HookRunner) template(mp *core.ConfigMap) (template *core.PodTemplateSpe
 							core.ResourceMemory: resource.MustParse(Settings.Migration.HooksContainerLimitsMemory),
 						},
 					},
+					Env: []core.EnvVar{
+						{
+							Name:  HookResultEnv,
+							Value: HookResultPath,
+						},
+					},
+					TerminationMessagePath:   HookResultPath,
+					TerminationMessagePolicy: core.TerminationMessageReadFile,
 					VolumeMounts: []core.VolumeMount{
 						{
 							Name:      "hook",
@@ -410,8 +614,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 	m := Settings.Migration
 
 	if aapConfig == nil {
-		step.AddError("Hook AAP configuration is missing")
-		step.MarkCompleted()
+		err = r.failed(step, nil, "Hook AAP configuration is missing")
 		return
 	}
 
@@ -419,8 +622,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 		strings.TrimSpace(aapConfig.TokenSecret.Name) != ""
 	useCluster := strings.TrimSpace(m.AAPURL) != "" && strings.TrimSpace(m.AAPTokenSecretName) != ""
 	if !useHook && !useCluster {
-		step.AddError("AAP is not configured: set ForkliftController aap_url and aap_token_secret_name, or spec.aap.url and spec.aap.tokenSecret")
-		step.MarkCompleted()
+		err = r.failed(step, nil, "AAP is not configured: set ForkliftController aap_url and aap_token_secret_name, or spec.aap.url and spec.aap.tokenSecret")
 		return
 	}
 
@@ -448,9 +650,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 		err = tokErr
 	}
 	if err != nil {
-		step.AddError(err.Error())
-		step.MarkCompleted()
-		err = nil
+		err = r.failed(step, nil, err.Error())
 		return
 	}
 
@@ -461,8 +661,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 
 	transport, tlsErr := aap.TLSTransportFromSettings(context.TODO(), r.Client, m.AAPInsecureSkipVerify, m.AAPCASecretName)
 	if tlsErr != nil {
-		step.AddError(tlsErr.Error())
-		step.MarkCompleted()
+		err = r.failed(step, nil, tlsErr.Error())
 		return
 	}
 
@@ -481,9 +680,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 	// Launch the job
 	jobID, err := aapClient.LaunchJob(context.TODO(), aapConfig.JobTemplateID, extraVars)
 	if err != nil {
-		step.AddError(err.Error())
-		step.MarkCompleted()
-		err = nil
+		err = r.failed(step, nil, err.Error())
 		return
 	}
 
@@ -525,9 +722,7 @@ func (r *HookRunner) runAAPJob(step *planapi.Step) (err error) {
 
 	if err != nil {
 		r.Log.Error(err, "AAP job failed", "jobId", jobID)
-		step.AddError(err.Error())
-		step.MarkCompleted()
-		err = nil
+		err = r.failed(step, nil, err.Error())
 		return
 	}
 
@@ -1,14 +1,19 @@
 package plan
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/lib/aap"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/logging"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -333,3 +338,238 @@ func TestAAPLaunchFailureIsTerminalViaReflectPipeline(t *testing.T) {
 		t.Fatal("expected Failed condition")
 	}
 }
+
+func TestHookRefPolicy(t *testing.T) {
+	t.Parallel()
+
+	hookPolicy := &planapi.HookFailurePolicy{Action: planapi.HookFailureContinue, Retries: 2, Backoff: 10}
+	refPolicy := &planapi.HookFailurePolicy{Retries: 1}
+
+	tests := []struct {
+		name string
+		ref  planapi.HookRef
+		hook *planapi.HookFailurePolicy
+		want planapi.HookFailurePolicy
+	}{
+		{
+			name: "default",
+			want: planapi.HookFailurePolicy{Action: planapi.HookFailureFail},
+		},
+		{
+			name: "hook",
+			hook: hookPolicy,
+			want: *hookPolicy,
+		},
+		{
+			name: "ref overrides hook",
+			ref:  planapi.HookRef{FailurePolicy: refPolicy},
+			hook: hookPolicy,
+			want: planapi.HookFailurePolicy{Action: planapi.HookFailureFail, Retries: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.ref.Policy(tt.hook); got != tt.want {
+				t.Fatalf("policy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newHookRunnerForFailureTest(t *testing.T, policy planapi.HookFailurePolicy, objects ...client.Object) *HookRunner {
+	t.Helper()
+	scheme := hookTestScheme(t)
+	if err := batch.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme batch: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return &HookRunner{
+		Context: &plancontext.Context{
+			Client: c,
+			Log:    logging.WithName("hookTest"),
+		},
+		vm:     &planapi.VMStatus{},
+		policy: policy,
+	}
+}
+
+func TestHookRunnerFailedRetry(t *testing.T) {
+	t.Parallel()
+
+	job := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{Name: "hook-job", Namespace: testHookNamespace},
+	}
+	runner := newHookRunnerForFailureTest(
+		t,
+		planapi.HookFailurePolicy{Action: planapi.HookFailureFail, Retries: 2, Backoff: 10},
+		job)
+	step := &planapi.Step{Task: planapi.Task{Name: api.PhasePreHook}}
+
+	before := time.Now()
+	if err := runner.failed(step, job, "boom"); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if step.MarkedCompleted() || step.HasError() {
+		t.Fatal("step must not complete while retries are left")
+	}
+	if step.Annotations[AnnHookRetries] != "1" {
+		t.Fatalf("retries = %q, want 1", step.Annotations[AnnHookRetries])
+	}
+	if !runner.retryPending(step) {
+		t.Fatal("expected a pending retry")
+	}
+	retryAt, err := time.Parse(time.RFC3339, step.Annotations[AnnHookRetryAt])
+	if err != nil {
+		t.Fatalf("retryAt: %v", err)
+	}
+	if retryAt.Before(before.Add(9 * time.Second)) {
+		t.Fatalf("retryAt = %v, expected the backoff to be applied", retryAt)
+	}
+	if !step.HasWarning() || !strings.Contains(step.Warning.Reasons[0], "boom") {
+		t.Fatalf("expected a warning recording the failure, got %+v", step.Warning)
+	}
+	err = runner.Client.Get(t.Context(), client.ObjectKeyFromObject(job), &batch.Job{})
+	if !k8serr.IsNotFound(err) {
+		t.Fatalf("expected the failed job to be deleted, got %v", err)
+	}
+
+	// Second retry doubles the backoff.
+	if err = runner.failed(step, nil, "boom"); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	retryAt, _ = time.Parse(time.RFC3339, step.Annotations[AnnHookRetryAt])
+	if step.Annotations[AnnHookRetries] != "2" || retryAt.Before(before.Add(19*time.Second)) {
+		t.Fatalf("unexpected second retry: %v", step.Annotations)
+	}
+
+	// Retries exhausted.
+	if err = runner.failed(step, nil, "boom"); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if !step.MarkedCompleted() || !step.HasError() {
+		t.Fatal("expected the step to fail once the retries are exhausted")
+	}
+}
+
+func TestHookRunnerFailedContinue(t *testing.T) {
+	t.Parallel()
+
+	runner := newHookRunnerForFailureTest(t, planapi.HookFailurePolicy{Action: planapi.HookFailureContinue})
+	step := &planapi.Step{Task: planapi.Task{Name: api.PhasePostHook}}
+
+	if err := runner.failed(step, nil, "notification failed"); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if !step.MarkedCompleted() || step.HasError() {
+		t.Fatal("expected the step to complete without error")
+	}
+	if !step.HasWarning() {
+		t.Fatal("expected a warning recording the failure")
+	}
+	if runner.retryPending(step) {
+		t.Fatal("unexpected pending retry")
+	}
+}
+
+func hookResultPod(job *batch.Job, message string) *core.Pod {
+	return &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      job.Name + "-pod",
+			Namespace: job.Namespace,
+			Labels:    map[string]string{batch.JobNameLabel: job.Name},
+		},
+		Status: core.PodStatus{
+			Phase: core.PodSucceeded,
+			ContainerStatuses: []core.ContainerStatus{
+				{
+					Name: "hook",
+					State: core.ContainerState{
+						Terminated: &core.ContainerStateTerminated{Message: message},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHookRunnerRecordResult(t *testing.T) {
+	t.Parallel()
+
+	job := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{Name: "hook-job", Namespace: testHookNamespace},
+	}
+	message := `{"targetLabels":{"app":"db"},"targetPowerState":"off","skipGuestConversion":true,"values":{"owner":"team-a"}}`
+	runner := newHookRunnerForFailureTest(t, planapi.HookFailurePolicy{}, hookResultPod(job, message))
+	runner.vm.Phase = api.PhasePreHook
+	runner.vm.Pipeline = []*planapi.Step{
+		{Task: planapi.Task{Name: api.PhasePreHook}},
+		{Task: planapi.Task{Name: ImageConversion, Phase: api.StepPending}},
+	}
+
+	reason, err := runner.recordResult(job)
+	if err != nil || reason != "" {
+		t.Fatalf("recordResult: reason=%q err=%v", reason, err)
+	}
+	if len(runner.vm.HookResults) != 1 || runner.vm.HookResults[0].Step != api.PhasePreHook {
+		t.Fatalf("unexpected hook results: %+v", runner.vm.HookResults)
+	}
+	if runner.vm.HookTargetLabels()["app"] != "db" {
+		t.Fatalf("unexpected target labels: %v", runner.vm.HookTargetLabels())
+	}
+	if state := runner.vm.ResolveTargetPowerState(planapi.TargetPowerStateOn); state != planapi.TargetPowerStateOff {
+		t.Fatalf("target power state = %q, want off", state)
+	}
+	conversion, _ := runner.vm.FindStep(ImageConversion)
+	if !conversion.MarkedCompleted() || conversion.Phase != api.StepCompleted {
+		t.Fatal("expected the skipped conversion step to be completed")
+	}
+}
+
+func TestHookRunnerRecordResultInvalid(t *testing.T) {
+	t.Parallel()
+
+	job := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{Name: "hook-job", Namespace: testHookNamespace},
+	}
+	for _, message := range []string{
+		"not json",
+		`{"targetPowerState":"sleep"}`,
+		`{"targetLabels":{"not a key!":"db"}}`,
+		`{"targetLabels":{"app":"not a value!"}}`,
+	} {
+		runner := newHookRunnerForFailureTest(t, planapi.HookFailurePolicy{}, hookResultPod(job, message))
+		runner.vm.Phase = api.PhasePostTransferHook
+
+		reason, err := runner.recordResult(job)
+		if err != nil {
+			t.Fatalf("recordResult: %v", err)
+		}
+		if reason == "" {
+			t.Fatalf("expected %q to be rejected", message)
+		}
+		if len(runner.vm.HookResults) != 0 {
+			t.Fatalf("unexpected hook results: %+v", runner.vm.HookResults)
+		}
+	}
+}
+
+func TestHookRunnerTemplateResultPath(t *testing.T) {
+	defer savedHookRunnerSettings(t)()
+
+	runner := newHookRunnerForTemplateTest("", "", "")
+	template := runner.template(&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Name: testHookCMName}})
+	container := template.Spec.Containers[0]
+	if container.TerminationMessagePath != HookResultPath {
+		t.Fatalf("termination message path = %q, want %q", container.TerminationMessagePath, HookResultPath)
+	}
+	found := false
+	for _, env := range container.Env {
+		if env.Name == HookResultEnv && env.Value == HookResultPath {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %s in the container environment", HookResultEnv)
+	}
+}
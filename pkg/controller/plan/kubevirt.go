@@ -38,15 +38,18 @@ import (
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
 	ctrlutil "github.com/kubev2v/forklift/pkg/controller/util"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libref "github.com/kubev2v/forklift/pkg/lib/ref"
 	"github.com/kubev2v/forklift/pkg/settings"
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
 	template "github.com/openshift/api/template/v1"
 	"github.com/openshift/library-go/pkg/template/generator"
 	"github.com/openshift/library-go/pkg/template/templateprocessing"
 	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -139,6 +142,8 @@ const (
 	kV2V = "isV2V"
 	// Resource label
 	kResource = "resource"
+	// Precopy label (value=precopy index)
+	kPrecopy = "precopy"
 )
 
 // Resource labels
@@ -146,6 +151,7 @@ const (
 	ResourceVMConfig      = "vm-config"
 	ResourceVDDKConfig    = "vddk-config"
 	ResourceWaitForReboot = "wait-for-reboot"
+	ResourceDryRun        = "dry-run"
 )
 
 // User
@@ -154,12 +160,22 @@ const (
 	qemuUser = int64(107)
 )
 
+// Delta copy
+const (
+	// Maximum number of extents passed to a delta copy pod, shared
+	// by the disks, to keep the spec within the ConfigMap size limit.
+	deltaCopyMaxExtents = 16384
+	// Mount path of the delta copy spec.
+	deltaCopySpecPath = "/etc/delta-copy"
+)
+
 // Labels
 const (
 	OvaPVCLabel    = "nfs-pvc"
 	OvaPVLabel     = "nfs-pv"
 	HyperVPVCLabel = "smb-pvc"
 	HyperVPVLabel  = "smb-pv"
+	CsiImportLabel = "csi-import"
 )
 
 // Vddk v2v conf
@@ -1199,6 +1215,253 @@ func (r *KubeVirt) DeleteWaitForRebootPod(vm *plan.VMStatus) error {
 	return nil
 }
 
+// EnsureDeltaCopyPod creates the pod copying the disks, or their changed
+// extents, of a precopy from the source storage to the target PVCs. The
+// extents are keyed by disk ID; disks without extents are copied in full.
+func (r *KubeVirt) EnsureDeltaCopyPod(vm *plan.VMStatus, precopy int, disks []deltacopy.Disk, extents map[string][]deltacopy.Extent) (err error) {
+	existing, err := r.GetDeltaCopyPod(vm, precopy)
+	if err != nil || existing != nil {
+		return
+	}
+	img := getVirtV2vImage(r.Plan)
+	if img == "" {
+		err = liberr.New("virt-v2v image is not set; cannot create delta copy pod")
+		return
+	}
+	pvcs, err := r.getPVCs(vm.Ref)
+	if err != nil {
+		return
+	}
+	pvcsByDisk := make(map[string]*core.PersistentVolumeClaim)
+	for _, pvc := range pvcs {
+		pvcsByDisk[r.Builder.ResolvePersistentVolumeClaimIdentifier(pvc)] = pvc
+	}
+
+	var volumes []core.Volume
+	var mounts []core.VolumeMount
+	var devices []core.VolumeDevice
+	spec := deltacopy.Spec{}
+	maxExtents := deltaCopyMaxExtents
+	if len(disks) > 0 {
+		maxExtents = deltaCopyMaxExtents / len(disks)
+	}
+	for i, disk := range disks {
+		pvc, found := pvcsByDisk[disk.ID]
+		if !found {
+			err = liberr.New("PVC not found for disk.", "vm", vm.String(), "disk", disk.ID)
+			return
+		}
+		volumes = append(volumes, core.Volume{
+			Name: pvc.Name,
+			VolumeSource: core.VolumeSource{
+				PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvc.Name,
+				},
+			},
+		})
+		if pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == core.PersistentVolumeBlock {
+			disk.Target = fmt.Sprintf("/dev/block%v", i)
+			devices = append(devices, core.VolumeDevice{
+				Name:       pvc.Name,
+				DevicePath: disk.Target,
+			})
+		} else {
+			mountPath := fmt.Sprintf("/mnt/disks/disk%v", i)
+			disk.Target = path.Join(mountPath, "disk.img")
+			mounts = append(mounts, core.VolumeMount{
+				Name:      pvc.Name,
+				MountPath: mountPath,
+			})
+		}
+		diskExtents, found := extents[disk.ID]
+		if found {
+			disk.Extents = deltacopy.Reduce(diskExtents, maxExtents)
+		} else {
+			disk.Full = true
+		}
+		spec.Disks = append(spec.Disks, disk)
+	}
+	if r.Source.Provider.Type() == api.Ova || r.Source.Provider.Type() == api.HyperV {
+		providerVol, providerMount, pErr := r.providerStorageVolume(vm)
+		if pErr != nil {
+			err = pErr
+			return
+		}
+		volumes = append(volumes, providerVol)
+		mounts = append(mounts, providerMount)
+	}
+	args := []string{"-spec", path.Join(deltaCopySpecPath, "spec.json")}
+	var envFrom []core.EnvFromSource
+	var env []core.EnvVar
+	if spec.ReadsURLs() {
+		// Disks are read from the provider with its credentials.
+		var secret *core.Secret
+		secret, err = r.ensureSecret(vm.Ref, r.copyDataFromProviderSecret, r.deltaCopyLabels(vm.Ref, false))
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		envFrom, env = secretEnvironment(secret.Name)
+	}
+
+	labels := r.deltaCopyLabels(vm.Ref, false)
+	labels[kPrecopy] = strconv.Itoa(precopy)
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	configMap := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: r.getGeneratedName(vm) + "delta-copy-",
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Data: map[string]string{
+			"spec.json": string(specJSON),
+		},
+	}
+	err = r.Destination.Create(context.TODO(), configMap)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	volumes = append(volumes, core.Volume{
+		Name: "spec",
+		VolumeSource: core.VolumeSource{
+			ConfigMap: &core.ConfigMapVolumeSource{
+				LocalObjectReference: core.LocalObjectReference{
+					Name: configMap.Name,
+				},
+			},
+		},
+	})
+	mounts = append(mounts, core.VolumeMount{
+		Name:      "spec",
+		MountPath: deltaCopySpecPath,
+		ReadOnly:  true,
+	})
+
+	nonRoot := true
+	allowPrivilegeEscalation := false
+	user := qemuUser
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: r.getGeneratedName(vm) + "delta-copy-",
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Spec: core.PodSpec{
+			SecurityContext: &core.PodSecurityContext{
+				RunAsNonRoot:   &nonRoot,
+				FSGroup:        &user,
+				SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+			},
+			RestartPolicy:      core.RestartPolicyOnFailure,
+			ServiceAccountName: resolveServiceAccount(r.Plan),
+			Containers: []core.Container{
+				{
+					Name:          "delta-copy",
+					Image:         img,
+					Command:       []string{"/usr/local/bin/delta-copy"},
+					Args:          args,
+					EnvFrom:       envFrom,
+					Env:           env,
+					VolumeMounts:  mounts,
+					VolumeDevices: devices,
+					SecurityContext: &core.SecurityContext{
+						AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+						RunAsUser:                &user,
+						Capabilities:             &core.Capabilities{Drop: []core.Capability{"ALL"}},
+					},
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+	err = r.Destination.Create(context.TODO(), pod)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.Log.Info("Created delta copy pod.", "pod", path.Join(pod.Namespace, pod.Name), "vm", vm.String(), "precopy", precopy)
+	return
+}
+
+// Environment exposing the credentials of a secret. The CA bundle is
+// mapped explicitly because dotted keys (ca.crt) are dropped by EnvFrom.
+func secretEnvironment(secretName string) (envFrom []core.EnvFromSource, env []core.EnvVar) {
+	optional := true
+	envFrom = []core.EnvFromSource{
+		{
+			SecretRef: &core.SecretEnvSource{
+				LocalObjectReference: core.LocalObjectReference{Name: secretName},
+			},
+		},
+	}
+	env = []core.EnvVar{
+		{
+			Name: "cacert",
+			ValueFrom: &core.EnvVarSource{
+				SecretKeyRef: &core.SecretKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: secretName},
+					Key:                  "ca.crt",
+					Optional:             &optional,
+				},
+			},
+		},
+	}
+	return
+}
+
+// GetDeltaCopyPod returns the delta copy pod of a precopy, or nil when not found.
+func (r *KubeVirt) GetDeltaCopyPod(vm *plan.VMStatus, precopy int) (*core.Pod, error) {
+	labels := r.deltaCopyLabels(vm.Ref, false)
+	labels[kPrecopy] = strconv.Itoa(precopy)
+	list, err := r.GetPodsWithLabels(labels)
+	if err != nil {
+		return nil, liberr.Wrap(err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	return &list.Items[0], nil
+}
+
+// DeleteDeltaCopyPods removes the delta copy pods and their spec config maps.
+func (r *KubeVirt) DeleteDeltaCopyPods(vm *plan.VMStatus) (err error) {
+	labels := r.deltaCopyLabels(vm.Ref, true)
+	list, err := r.GetPodsWithLabels(labels)
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	for _, object := range list.Items {
+		err = r.DeleteObject(&object, vm, "Deleted delta copy pod.", "pod")
+		if err != nil {
+			return
+		}
+	}
+	configMaps := &core.ConfigMapList{}
+	err = r.Destination.Client.List(
+		context.TODO(),
+		configMaps,
+		&client.ListOptions{
+			LabelSelector: k8slabels.SelectorFromSet(labels),
+			Namespace:     r.Plan.Spec.TargetNamespace,
+		},
+	)
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	for _, object := range configMaps.Items {
+		err = r.DeleteObject(&object, vm, "Deleted delta copy config map.", "configMap")
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
 // EnsureGuestInspectionPod resolves all data and creates the inspection pod
 // via conversion.EnsureVirtV2vPod.
 func (r *KubeVirt) EnsureGuestInspectionPod(vm *plan.VMStatus, step *plan.Step) (ready bool, err error) {
@@ -1794,6 +2057,43 @@ func (r *KubeVirt) DeleteConfigMap(vm *plan.VMStatus) (err error) {
 	return
 }
 
+// Stop the VirtualMachine CR on the destination cluster.
+func (r *KubeVirt) StopVM(vm *plan.VMStatus) (err error) {
+	vmLabels := r.vmAllButMigrationLabels(vm.Ref)
+	list := &cnv.VirtualMachineList{}
+	err = r.Destination.Client.List(
+		context.TODO(),
+		list,
+		&client.ListOptions{
+			LabelSelector: k8slabels.SelectorFromSet(vmLabels),
+			Namespace:     r.Plan.Spec.TargetNamespace,
+		},
+	)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	for i := range list.Items {
+		object := &list.Items[i]
+		halted := cnv.RunStrategyHalted
+		object.Spec.RunStrategy = &halted
+		object.Spec.Running = nil
+		err = r.Destination.Client.Update(context.TODO(), object)
+		if err != nil {
+			return liberr.Wrap(err)
+		}
+		r.Log.Info(
+			"Stopped Kubevirt VM.",
+			"vm",
+			path.Join(
+				object.Namespace,
+				object.Name),
+			"source",
+			vm.String())
+	}
+	return
+}
+
 // Delete the VirtualMachine CR on the destination cluster.
 func (r *KubeVirt) DeleteVM(vm *plan.VMStatus) (err error) {
 	vmLabels := r.vmAllButMigrationLabels(vm.Ref)
@@ -1927,6 +2227,151 @@ func (r *KubeVirt) CsiImportPVCs(vm *plan.VMStatus) ([]core.PersistentVolumeClai
 	return r.Builder.CsiImportPVCs(vm.Ref, labels)
 }
 
+// EnsureCsiImportPersistentVolumes ensures the static PVs adopting the source array
+// volumes of the CSI import PVCs whose vendor resolved a CSI volume handle. The PV
+// carries the "volume" label selected by the PVC, the CSI driver and the secrets
+// are those of the PVC StorageClass.
+func (r *KubeVirt) EnsureCsiImportPersistentVolumes(vm *plan.VMStatus, pvcs []core.PersistentVolumeClaim) (err error) {
+	pvs := []core.PersistentVolume{}
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		if _, found := pvc.Annotations[resolver.AnnCsiVolumeHandle]; !found {
+			continue
+		}
+		if pvc.Spec.StorageClassName == nil {
+			err = liberr.New("CSI import PVC has no storage class.", "disk", pvc.Annotations[planbase.AnnDiskSource])
+			return
+		}
+		sc := &storagev1.StorageClass{}
+		err = r.Destination.Client.Get(context.TODO(), client.ObjectKey{Name: *pvc.Spec.StorageClassName}, sc)
+		if err != nil {
+			err = liberr.Wrap(err, "storageClass", *pvc.Spec.StorageClassName)
+			return
+		}
+		pvs = append(pvs, r.csiImportPersistentVolume(vm.Ref, pvc, sc))
+	}
+	if len(pvs) == 0 {
+		return
+	}
+	err = r.EnsurePersistentVolume(vm.Ref, pvs)
+	return
+}
+
+// csiImportPersistentVolume builds the static PV adopting the source array volume of a CSI import PVC.
+// The PV is retained when the PVC is deleted, as the volume still backs the source VM disk.
+func (r *KubeVirt) csiImportPersistentVolume(vmRef ref.Ref, pvc *core.PersistentVolumeClaim, sc *storagev1.StorageClass) core.PersistentVolume {
+	labels := r.vmLabels(vmRef)
+	labels[kUse] = CsiImportLabel
+	if pvc.Spec.Selector != nil {
+		labels["volume"] = pvc.Spec.Selector.MatchLabels["volume"]
+	}
+	attributes := map[string]string{}
+	for k, v := range pvc.Annotations {
+		if name, found := strings.CutPrefix(k, resolver.AnnCsiVolumeAttributePrefix); found {
+			attributes[name] = v
+		}
+	}
+	secretRef := func(kind string) *core.SecretReference {
+		name := sc.Parameters["csi.storage.k8s.io/"+kind+"-secret-name"]
+		if name == "" {
+			return nil
+		}
+		namespace := sc.Parameters["csi.storage.k8s.io/"+kind+"-secret-namespace"]
+		return &core.SecretReference{
+			Name:      name,
+			Namespace: strings.ReplaceAll(namespace, "${pvc.namespace}", pvc.Namespace),
+		}
+	}
+	return core.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: "csi-import-",
+			Labels:       labels,
+			Annotations: map[string]string{
+				planbase.AnnDiskSource: pvc.Annotations[planbase.AnnDiskSource],
+			},
+		},
+		Spec: core.PersistentVolumeSpec{
+			Capacity: core.ResourceList{
+				core.ResourceStorage: pvc.Spec.Resources.Requests[core.ResourceStorage],
+			},
+			AccessModes:                   pvc.Spec.AccessModes,
+			VolumeMode:                    pvc.Spec.VolumeMode,
+			StorageClassName:              sc.Name,
+			PersistentVolumeReclaimPolicy: core.PersistentVolumeReclaimRetain,
+			PersistentVolumeSource: core.PersistentVolumeSource{
+				CSI: &core.CSIPersistentVolumeSource{
+					Driver:                     sc.Provisioner,
+					VolumeHandle:               pvc.Annotations[resolver.AnnCsiVolumeHandle],
+					VolumeAttributes:           attributes,
+					ControllerPublishSecretRef: secretRef("controller-publish"),
+					NodeStageSecretRef:         secretRef("node-stage"),
+					NodePublishSecretRef:       secretRef("node-publish"),
+					ControllerExpandSecretRef:  secretRef("controller-expand"),
+				},
+			},
+		},
+	}
+}
+
+// DeleteCsiImportPersistentVolumes deletes the static PVs adopting the source array
+// volumes of the VM, created by any migration of the plan, that are no longer claimed.
+// The PVs bound to an existing PVC back the migrated VM and are kept. The array
+// volumes are retained as the PVs have the Retain reclaim policy.
+func (r *KubeVirt) DeleteCsiImportPersistentVolumes(vm *plan.VMStatus) (err error) {
+	labels := r.vmAllButMigrationLabels(vm.Ref)
+	labels[kUse] = CsiImportLabel
+	list := &core.PersistentVolumeList{}
+	err = r.Destination.Client.List(
+		context.TODO(),
+		list,
+		&client.ListOptions{
+			LabelSelector: k8slabels.SelectorFromSet(labels),
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	for i := range list.Items {
+		pv := &list.Items[i]
+		var claimed bool
+		claimed, err = r.csiImportPersistentVolumeClaimed(pv)
+		if err != nil {
+			return
+		}
+		if claimed {
+			continue
+		}
+		err = r.DeleteObject(pv, vm, "Deleted CSI import PV.", "pv")
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Whether the PV is bound to a PVC that exists and is not being deleted.
+func (r *KubeVirt) csiImportPersistentVolumeClaimed(pv *core.PersistentVolume) (claimed bool, err error) {
+	claimRef := pv.Spec.ClaimRef
+	if claimRef == nil {
+		return
+	}
+	pvc := &core.PersistentVolumeClaim{}
+	err = r.Destination.Client.Get(
+		context.TODO(),
+		client.ObjectKey{Namespace: claimRef.Namespace, Name: claimRef.Name},
+		pvc)
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			err = nil
+		} else {
+			err = liberr.Wrap(err)
+		}
+		return
+	}
+	claimed = (claimRef.UID == "" || claimRef.UID == pvc.UID) && pvc.DeletionTimestamp == nil
+	return
+}
+
 // Whether a VDDK extra-args ConfigMap is needed for AIO tuning or node selector propagation.
 func (r *KubeVirt) needsVddkConfigMap() bool {
 	return r.Source.Provider.UseVddkAioOptimization() || len(r.Plan.Spec.ConvertorNodeSelector) > 0
@@ -2946,6 +3391,8 @@ func (r *KubeVirt) virtualMachine(vm *plan.VMStatus, sortVolumesByLibvirt bool)
 	if len(r.Plan.Spec.TargetLabels) > 0 {
 		maps.Copy(object.Spec.Template.ObjectMeta.Labels, r.Plan.Spec.TargetLabels)
 	}
+	// Set the labels written by the hooks of the VM
+	maps.Copy(object.Spec.Template.ObjectMeta.Labels, vm.HookTargetLabels())
 
 	// Set the target node name if specified in the plan
 	if len(r.Plan.Spec.TargetNodeSelector) > 0 {
@@ -2964,7 +3411,7 @@ func (r *KubeVirt) virtualMachine(vm *plan.VMStatus, sortVolumesByLibvirt bool)
 	// Set the 'app' label for identification of the virtual machine instance(s)
 	object.Spec.Template.ObjectMeta.Labels["app"] = r.getNewVMName(vm)
 
-	err = r.setVmLabels(object)
+	err = r.setVmLabels(vm, object)
 	if err != nil {
 		return
 	}
@@ -3088,12 +3535,17 @@ func (r *KubeVirt) setInstanceType(vm *plan.VMStatus, object *cnv.VirtualMachine
 	return
 }
 
-func (r *KubeVirt) setVmLabels(object *cnv.VirtualMachine) (err error) {
+func (r *KubeVirt) setVmLabels(vm *plan.VMStatus, object *cnv.VirtualMachine) (err error) {
 	if object.ObjectMeta.Labels == nil {
 		object.ObjectMeta.Labels = make(map[string]string)
 	}
 	if r.Plan.Provider.Source.RequiresConversion() {
-		object.ObjectMeta.Labels["guestConverted"] = strconv.FormatBool(!r.Plan.Spec.SkipGuestConversion)
+		converted := !r.Plan.Spec.SkipGuestConversion
+		if converted && vm.HookSkipsGuestConversion() {
+			// The conversion cannot be skipped when virt-v2v copies the disks.
+			_, converted = vm.FindStep(DiskTransferV2v)
+		}
+		object.ObjectMeta.Labels["guestConverted"] = strconv.FormatBool(converted)
 	}
 	return
 }
@@ -3561,50 +4013,12 @@ func (r *KubeVirt) podVolumeMounts(vmVolumes []cnv.Volume, vddkConfigmap *core.C
 
 	switch r.Source.Provider.Type() {
 	case api.Ova, api.HyperV:
-		var pvc *core.PersistentVolumeClaim
-		var volumeName, mountPath string
-
-		if r.Source.Provider.Type() == api.Ova {
-			// OVA: Static NFS PV/PVC
-			pv := r.BuildPVForNFS(vm)
-			pv, err = r.EnsurePVForNFS(pv)
-			if err != nil {
-				return
-			}
-			pvc = r.BuildPVCForNFS(pv, vm)
-			volumeName = "store-pv"
-			mountPath = "/ova"
-		} else {
-			// HyperV: Static SMB CSI PV/PVC
-			pv := r.BuildPVForSMB(vm)
-			pv, err = r.EnsurePVForSMB(pv)
-			if err != nil {
-				return
-			}
-			pvc = r.BuildPVCForSMB(pv, vm)
-			volumeName = "hyperv-storage"
-			mountPath = "/hyperv"
-		}
-
-		// Ensure PVC exists (common logic)
-		pvc, err = r.EnsureProviderStoragePVC(pvc, r.Source.Provider.Type())
+		var providerVol core.Volume
+		var providerMount core.VolumeMount
+		providerVol, providerMount, err = r.providerStorageVolume(vm)
 		if err != nil {
 			return
 		}
-
-		// Mount provider storage (common pattern)
-		providerVol := core.Volume{
-			Name: volumeName,
-			VolumeSource: core.VolumeSource{
-				PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
-					ClaimName: pvc.Name,
-				},
-			},
-		}
-		providerMount := core.VolumeMount{
-			Name:      volumeName,
-			MountPath: mountPath,
-		}
 		volumes = append(volumes, providerVol)
 		mounts = append(mounts, providerMount)
 		extraVolumes = append(extraVolumes, providerVol)
@@ -3713,6 +4127,55 @@ func (r *KubeVirt) podVolumeMounts(vmVolumes []cnv.Volume, vddkConfigmap *core.C
 	return
 }
 
+// Build the volume and mount of the provider storage holding the
+// source disks of OVA and HyperV VMs.
+func (r *KubeVirt) providerStorageVolume(vm *plan.VMStatus) (volume core.Volume, mount core.VolumeMount, err error) {
+	var pvc *core.PersistentVolumeClaim
+	var volumeName, mountPath string
+
+	if r.Source.Provider.Type() == api.Ova {
+		// OVA: Static NFS PV/PVC
+		pv := r.BuildPVForNFS(vm)
+		pv, err = r.EnsurePVForNFS(pv)
+		if err != nil {
+			return
+		}
+		pvc = r.BuildPVCForNFS(pv, vm)
+		volumeName = "store-pv"
+		mountPath = "/ova"
+	} else {
+		// HyperV: Static SMB CSI PV/PVC
+		pv := r.BuildPVForSMB(vm)
+		pv, err = r.EnsurePVForSMB(pv)
+		if err != nil {
+			return
+		}
+		pvc = r.BuildPVCForSMB(pv, vm)
+		volumeName = "hyperv-storage"
+		mountPath = "/hyperv"
+	}
+
+	// Ensure PVC exists (common logic)
+	pvc, err = r.EnsureProviderStoragePVC(pvc, r.Source.Provider.Type())
+	if err != nil {
+		return
+	}
+
+	volume = core.Volume{
+		Name: volumeName,
+		VolumeSource: core.VolumeSource{
+			PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+				ClaimName: pvc.Name,
+			},
+		},
+	}
+	mount = core.VolumeMount{
+		Name:      volumeName,
+		MountPath: mountPath,
+	}
+	return
+}
+
 // DiskRefsFromPodVolumeMounts calls podVolumeMounts and converts the
 // PVC-backed volumes into DiskRef entries for a Conversion CR.
 func (r *KubeVirt) DiskRefsFromPodVolumeMounts(vmVolumes []cnv.Volume, pvcs []*core.PersistentVolumeClaim, vm *plan.VMStatus, podType int) (refs []api.DiskRef, err error) {
@@ -3957,6 +4420,17 @@ func (r *KubeVirt) inspectionLabels(vmRef ref.Ref) (labels map[string]string) {
 	return
 }
 
+// Labels for a delta copy pod.
+func (r *KubeVirt) deltaCopyLabels(vmRef ref.Ref, filterOutMigrationLabel bool) (labels map[string]string) {
+	if filterOutMigrationLabel {
+		labels = r.vmAllButMigrationLabels(vmRef)
+	} else {
+		labels = r.vmLabels(vmRef)
+	}
+	labels[kApp] = "delta-copy"
+	return
+}
+
 func (r *KubeVirt) waitForRebootLabels(vmRef ref.Ref) map[string]string {
 	labels := r.vmLabels(vmRef)
 	labels[kApp] = "forklift-wait-for-reboot"
@@ -4686,10 +5160,7 @@ func (r *KubeVirt) IsCopyOffload(pvcs []*core.PersistentVolumeClaim) bool {
 // determineRunStrategy determines the appropriate run strategy based on the target power state configuration
 func (r *KubeVirt) determineRunStrategy(vm *plan.VMStatus) cnv.VirtualMachineRunStrategy {
 	// Determine the target power state based on plan configuration
-	targetPowerState := vm.TargetPowerState
-	if targetPowerState == "" {
-		targetPowerState = r.Plan.Spec.TargetPowerState
-	}
+	targetPowerState := vm.ResolveTargetPowerState(r.Plan.Spec.TargetPowerState)
 
 	if settings.Settings.WindowsWaitForReboot &&
 		targetPowerState != plan.TargetPowerStateOff {
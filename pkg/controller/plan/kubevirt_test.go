@@ -11,12 +11,17 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	convctx "github.com/kubev2v/forklift/pkg/controller/conversion/context"
 	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
+	"github.com/kubev2v/forklift/pkg/controller/plan/adapter/hyperv"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
 	ginkgo "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
@@ -606,6 +611,46 @@ var _ = ginkgo.Describe("kubevirt tests", func() {
 		})
 	})
 
+	ginkgo.Describe("StopVM", func() {
+		ginkgo.It("should halt the target VM of the plan", func() {
+			running := true
+			target := &cnv.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "migrated-vm",
+					Namespace: "target-ns",
+					Labels: map[string]string{
+						kPlan:     "plan-uid",
+						kVM:       "vm-1",
+						kResource: ResourceVMConfig,
+					},
+				},
+				Spec: cnv.VirtualMachineSpec{Running: &running},
+			}
+			kubevirt := createKubeVirt()
+			target.Labels[kPlanName] = kubevirt.Plan.Name
+			target.Labels[kPlanNamespace] = kubevirt.Plan.Namespace
+			other := target.DeepCopy()
+			other.Name = "other-vm"
+			other.Labels[kVM] = "vm-2"
+			Expect(kubevirt.Destination.Client.Create(context.TODO(), target)).To(Succeed())
+			Expect(kubevirt.Destination.Client.Create(context.TODO(), other)).To(Succeed())
+			kubevirt.Plan.UID = "plan-uid"
+			kubevirt.Plan.Spec.TargetNamespace = "target-ns"
+			vm := &plan.VMStatus{}
+			vm.ID = "vm-1"
+
+			Expect(kubevirt.StopVM(vm)).To(Succeed())
+
+			stopped := &cnv.VirtualMachine{}
+			Expect(kubevirt.Destination.Client.Get(context.TODO(), client.ObjectKeyFromObject(target), stopped)).To(Succeed())
+			Expect(stopped.Spec.Running).To(BeNil())
+			Expect(*stopped.Spec.RunStrategy).To(Equal(cnv.RunStrategyHalted))
+			untouched := &cnv.VirtualMachine{}
+			Expect(kubevirt.Destination.Client.Get(context.TODO(), client.ObjectKeyFromObject(other), untouched)).To(Succeed())
+			Expect(*untouched.Spec.Running).To(BeTrue())
+		})
+	})
+
 	ginkgo.Describe("CleanupCopiedConfigMaps", func() {
 		ginkgo.It("should delete extra-v2v-conf and customization-scripts ConfigMaps by name", func() {
 			extraCM := &v1.ConfigMap{
@@ -935,6 +980,7 @@ func createKubeVirt(objs ...runtime.Object) *KubeVirt {
 	scheme := runtime.NewScheme()
 	_ = v1.AddToScheme(scheme)
 	_ = k8snet.AddToScheme(scheme)
+	_ = cnv.AddToScheme(scheme)
 	v1beta1.SchemeBuilder.AddToScheme(scheme)
 	client := fake.NewClientBuilder().
 		WithScheme(scheme).
@@ -1180,3 +1226,226 @@ var _ = ginkgo.Describe("PVC name template", func() {
 		})
 	})
 })
+
+var _ = ginkgo.Describe("delta copy pod", func() {
+	ginkgo.BeforeEach(func() {
+		Settings.Migration.VirtV2vImage = "quay.io/kubev2v/forklift-virt-v2v:latest"
+	})
+
+	newPVC := func(name, disk string, mode v1.PersistentVolumeMode) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "target-ns",
+				Labels: map[string]string{
+					"migration": "test",
+					"vmID":      "vm-1",
+				},
+				Annotations: map[string]string{
+					planbase.AnnDiskSource: disk,
+				},
+			},
+			Spec: v1.PersistentVolumeClaimSpec{VolumeMode: &mode},
+		}
+	}
+	vm := &plan.VMStatus{VM: plan.VM{Ref: ref.Ref{ID: "vm-1"}}}
+
+	newKV := func() *KubeVirt {
+		kv := createKubeVirtWithProvider(v1beta1.OpenStack,
+			newPVC("pvc-0", "disk-0", v1.PersistentVolumeBlock),
+			newPVC("pvc-1", "disk-1", v1.PersistentVolumeFilesystem))
+		kv.Builder = &hyperv.Builder{Context: kv.Context}
+		return kv
+	}
+	disks := []deltacopy.Disk{
+		{ID: "disk-0", Source: "/hyperv/disk-0.vhdx", Format: "vhdx", Capacity: 1024},
+		{ID: "disk-1", Source: "/hyperv/disk-1.vhdx", Format: "vhdx", Capacity: 2048},
+	}
+
+	ginkgo.It("creates the pod with the spec of the precopy", func() {
+		kv := newKV()
+		extents := map[string][]deltacopy.Extent{
+			"disk-0": {{Offset: 512, Length: 10}, {Offset: 0, Length: 10}},
+		}
+		Expect(kv.EnsureDeltaCopyPod(vm, 2, disks, extents)).To(Succeed())
+
+		pod, err := kv.GetDeltaCopyPod(vm, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod).ToNot(BeNil())
+		Expect(pod.Spec.RestartPolicy).To(Equal(v1.RestartPolicyOnFailure))
+		Expect(pod.Spec.Containers[0].VolumeDevices).To(ConsistOf(
+			v1.VolumeDevice{Name: "pvc-0", DevicePath: "/dev/block0"}))
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			v1.VolumeMount{Name: "pvc-1", MountPath: "/mnt/disks/disk1"}))
+
+		configMaps := &v1.ConfigMapList{}
+		Expect(kv.Destination.List(context.TODO(), configMaps)).To(Succeed())
+		Expect(configMaps.Items).To(HaveLen(1))
+		spec := deltacopy.Spec{}
+		Expect(json.Unmarshal([]byte(configMaps.Items[0].Data["spec.json"]), &spec)).To(Succeed())
+		Expect(spec.Disks).To(HaveLen(2))
+		Expect(spec.Disks[0].Target).To(Equal("/dev/block0"))
+		Expect(spec.Disks[0].Full).To(BeFalse())
+		Expect(spec.Disks[0].Extents).To(Equal([]deltacopy.Extent{{Offset: 0, Length: 10}, {Offset: 512, Length: 10}}))
+		Expect(spec.Disks[1].Target).To(Equal("/mnt/disks/disk1/disk.img"))
+		Expect(spec.Disks[1].Full).To(BeTrue())
+
+		other, err := kv.GetDeltaCopyPod(vm, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(other).To(BeNil())
+	})
+
+	ginkgo.It("does not create a second pod for the same precopy", func() {
+		kv := newKV()
+		Expect(kv.EnsureDeltaCopyPod(vm, 1, disks, nil)).To(Succeed())
+		Expect(kv.EnsureDeltaCopyPod(vm, 1, disks, nil)).To(Succeed())
+		pods := &v1.PodList{}
+		Expect(kv.Destination.List(context.TODO(), pods)).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+	})
+
+	ginkgo.It("deletes the pods and config maps", func() {
+		kv := newKV()
+		Expect(kv.EnsureDeltaCopyPod(vm, 1, disks, nil)).To(Succeed())
+		Expect(kv.DeleteDeltaCopyPods(vm)).To(Succeed())
+		pods := &v1.PodList{}
+		Expect(kv.Destination.List(context.TODO(), pods)).To(Succeed())
+		Expect(pods.Items).To(BeEmpty())
+		configMaps := &v1.ConfigMapList{}
+		Expect(kv.Destination.List(context.TODO(), configMaps)).To(Succeed())
+		Expect(configMaps.Items).To(BeEmpty())
+	})
+
+	ginkgo.It("fails when a disk has no PVC", func() {
+		kv := newKV()
+		missing := append(disks, deltacopy.Disk{ID: "disk-2"})
+		Expect(kv.EnsureDeltaCopyPod(vm, 1, missing, nil)).ToNot(Succeed())
+	})
+})
+
+var _ = ginkgo.Describe("EnsureCsiImportPersistentVolumes", func() {
+	vm := &plan.VMStatus{VM: plan.VM{Ref: ref.Ref{ID: "vm-1"}}}
+	block := v1.PersistentVolumeBlock
+	scName := "ibm-block"
+
+	newKV := func() *KubeVirt {
+		kv := createKubeVirt()
+		scheme := runtime.NewScheme()
+		_ = v1.AddToScheme(scheme)
+		_ = storagev1.AddToScheme(scheme)
+		kv.Destination.Client = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRuntimeObjects(&storagev1.StorageClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: scName},
+				Provisioner: "block.csi.ibm.com",
+				Parameters: map[string]string{
+					"pool": "Pool0",
+					"csi.storage.k8s.io/controller-publish-secret-name":      "fs-secret",
+					"csi.storage.k8s.io/controller-publish-secret-namespace": "ibm-block-csi",
+					"csi.storage.k8s.io/node-stage-secret-name":              "fs-secret",
+					"csi.storage.k8s.io/node-stage-secret-namespace":         "${pvc.namespace}",
+				},
+			}).
+			Build()
+		return kv
+	}
+
+	newPVC := func(annotations map[string]string) v1.PersistentVolumeClaim {
+		return v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "target",
+				Annotations: annotations,
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				VolumeMode:       &block,
+				AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"volume": "csi-import-1a2b3c4d"},
+				},
+				Resources: v1.VolumeResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("500Gi")},
+				},
+			},
+		}
+	}
+
+	listPVs := func(kv *KubeVirt) []v1.PersistentVolume {
+		pvs := &v1.PersistentVolumeList{}
+		Expect(kv.Destination.List(context.TODO(), pvs)).To(Succeed())
+		return pvs.Items
+	}
+
+	ginkgo.It("creates a PV adopting the volume handle resolved by the vendor", func() {
+		kv := newKV()
+		pvc := newPVC(map[string]string{
+			planbase.AnnDiskSource:                             "[ds] vm/vm_1.vmdk",
+			resolver.AnnCsiVolumeHandle:                        "SVC:5;600507681081804CB800000000001074",
+			resolver.AnnCsiVolumeAttributePrefix + "pool_name": "Pool0",
+		})
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{pvc})).To(Succeed())
+
+		pvs := listPVs(kv)
+		Expect(pvs).To(HaveLen(1))
+		pv := pvs[0]
+		Expect(pv.Labels).To(HaveKeyWithValue("volume", "csi-import-1a2b3c4d"))
+		Expect(pv.Spec.StorageClassName).To(Equal(scName))
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(v1.PersistentVolumeReclaimRetain))
+		Expect(*pv.Spec.VolumeMode).To(Equal(v1.PersistentVolumeBlock))
+		Expect(pv.Spec.Capacity.Storage().String()).To(Equal("500Gi"))
+		Expect(pv.Spec.CSI.Driver).To(Equal("block.csi.ibm.com"))
+		Expect(pv.Spec.CSI.VolumeHandle).To(Equal("SVC:5;600507681081804CB800000000001074"))
+		Expect(pv.Spec.CSI.VolumeAttributes).To(Equal(map[string]string{"pool_name": "Pool0"}))
+		Expect(pv.Spec.CSI.ControllerPublishSecretRef).To(Equal(&v1.SecretReference{Name: "fs-secret", Namespace: "ibm-block-csi"}))
+		Expect(pv.Spec.CSI.NodeStageSecretRef).To(Equal(&v1.SecretReference{Name: "fs-secret", Namespace: "target"}))
+		Expect(pv.Spec.CSI.NodePublishSecretRef).To(BeNil())
+	})
+
+	ginkgo.It("does not create a second PV for the same disk", func() {
+		kv := newKV()
+		pvc := newPVC(map[string]string{resolver.AnnCsiVolumeHandle: "SVC:5;600507681081804CB800000000001074"})
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{pvc})).To(Succeed())
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{pvc})).To(Succeed())
+		Expect(listPVs(kv)).To(HaveLen(1))
+	})
+
+	ginkgo.It("skips PVCs imported with a driver annotation", func() {
+		kv := newKV()
+		pvc := newPVC(map[string]string{"csi.hpe.com/importVolAsClone": "vol-1"})
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{pvc})).To(Succeed())
+		Expect(listPVs(kv)).To(BeEmpty())
+	})
+
+	ginkgo.It("fails when the storage class does not exist", func() {
+		kv := newKV()
+		pvc := newPVC(map[string]string{resolver.AnnCsiVolumeHandle: "SVC:5;600507681081804CB800000000001074"})
+		missing := "missing"
+		pvc.Spec.StorageClassName = &missing
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{pvc})).ToNot(Succeed())
+	})
+
+	ginkgo.It("deletes the PVs no longer claimed and keeps the bound ones", func() {
+		kv := newKV()
+		bound := newPVC(map[string]string{resolver.AnnCsiVolumeHandle: "SVC:5;600507681081804CB800000000001074"})
+		released := newPVC(map[string]string{resolver.AnnCsiVolumeHandle: "SVC:5;600507681081804CB800000000001075"})
+		released.Spec.Selector.MatchLabels["volume"] = "csi-import-5e6f7a8b"
+		Expect(kv.EnsureCsiImportPersistentVolumes(vm, []v1.PersistentVolumeClaim{bound, released})).To(Succeed())
+
+		claim := bound.DeepCopy()
+		claim.Name = "vm-1-disk-0"
+		claim.UID = "claim-uid"
+		Expect(kv.Destination.Create(context.TODO(), claim)).To(Succeed())
+		for _, pv := range listPVs(kv) {
+			pv.Spec.ClaimRef = &v1.ObjectReference{Namespace: "target", Name: "vm-1-disk-0"}
+			if pv.Labels["volume"] == "csi-import-5e6f7a8b" {
+				pv.Spec.ClaimRef.Name = "deleted"
+			}
+			Expect(kv.Destination.Update(context.TODO(), &pv)).To(Succeed())
+		}
+
+		Expect(kv.DeleteCsiImportPersistentVolumes(vm)).To(Succeed())
+		pvs := listPVs(kv)
+		Expect(pvs).To(HaveLen(1))
+		Expect(pvs[0].Labels).To(HaveKeyWithValue("volume", "csi-import-1a2b3c4d"))
+	})
+})
@@ -27,6 +27,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	"github.com/kubev2v/forklift/pkg/settings"
 	batchv1 "k8s.io/api/batch/v1"
@@ -35,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -76,6 +78,8 @@ type Migration struct {
 	converter *adapter.Converter
 	// vm migrator
 	migrator migrator.Migrator
+	// Event recorder.
+	recorder record.EventRecorder
 }
 
 // Type of migration.
@@ -96,6 +100,10 @@ func (r *Migration) Run() (reQ time.Duration, err error) {
 		err = liberr.Wrap(err)
 		return
 	}
+	if r.Migration.IsRollback() {
+		reQ, err = r.rollback()
+		return
+	}
 	err = r.begin()
 	if err != nil {
 		err = liberr.Wrap(err)
@@ -121,7 +129,15 @@ func (r *Migration) Run() (reQ time.Duration, err error) {
 			return
 		}
 	}
-	for {
+	allowed, next, err := r.Migration.Spec.Allowed(time.Now())
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if !allowed {
+		r.markWindowQueuedVMs(next)
+	}
+	for allowed {
 		var hasNext bool
 		var vm *plan.VMStatus
 		vm, hasNext, err = r.scheduler.Next()
@@ -493,6 +509,10 @@ func (r *Migration) cleanup(vm *plan.VMStatus, failOnErr func(error) bool, force
 			return err
 		}
 	}
+	r.Log.Info("Deleting unclaimed CSI import PVs.", "vm", vm.String())
+	if err := r.kubevirt.DeleteCsiImportPersistentVolumes(vm); failOnErr(err) {
+		return err
+	}
 
 	r.Log.Info("Deleting importer pods.", "vm", vm.String())
 	if err := r.deleteImporterPods(vm); failOnErr(err) {
@@ -536,6 +556,10 @@ func (r *Migration) cleanup(vm *plan.VMStatus, failOnErr func(error) bool, force
 			}
 		}
 	}
+	r.Log.Info("Deleting delta copy pods.", "vm", vm.String())
+	if err := r.kubevirt.DeleteDeltaCopyPods(vm); failOnErr(err) {
+		return err
+	}
 	r.Log.Info("Deleting preflight inspection pod.", "vm", vm.String())
 	if err := r.kubevirt.DeletePreflightInspectionPod(vm); failOnErr(err) {
 		return err
@@ -570,7 +594,7 @@ func (r *Migration) cleanup(vm *plan.VMStatus, failOnErr func(error) bool, force
 		return err
 	}
 
-	if !r.IsResumeConversion() {
+	if !r.IsResumeConversion() && !r.Migration.IsRollback() {
 		r.removeLastWarmSnapshot(vm)
 	}
 
@@ -989,7 +1013,8 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 			}
 
 			r.NextPhase(vm)
-		case api.PhasePreHook, api.PhasePostHook:
+		case api.PhasePreHook, api.PhasePostHook, api.PhasePrePowerOffHook,
+			api.PhasePostTransferHook, api.PhasePreCutoverHook:
 			runner := HookRunner{Context: r.Context}
 			err = runner.Run(vm)
 			if err != nil {
@@ -1003,6 +1028,20 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 			} else {
 				vm.Phase = api.PhaseCompleted
 			}
+		case api.PhaseFailureHook:
+			// The VM is completed as failed once the hook has run,
+			// see the handling of the VM error below.
+			runner := HookRunner{Context: r.Context}
+			err = runner.Run(vm)
+			if err != nil {
+				return
+			}
+			if step, found := vm.FindStep(api.PhaseFailureHook); found {
+				step.Phase = api.StepRunning
+				if step.MarkedCompleted() {
+					step.Phase = api.StepCompleted
+				}
+			}
 		case api.PhaseCreateDataVolumes:
 			step, found := vm.FindStep(r.migrator.Step(vm))
 			if !found {
@@ -1036,6 +1075,16 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 			}
 			var resolvedCsiPVCs []*core.PersistentVolumeClaim
 			if len(csiPVCs) > 0 {
+				if csiErr = r.kubevirt.EnsureCsiImportPersistentVolumes(vm, csiPVCs); csiErr != nil {
+					if !errors.As(csiErr, &web.ProviderNotReadyError{}) {
+						step.AddError(csiErr.Error())
+						err = nil
+						break
+					} else {
+						err = csiErr
+						return
+					}
+				}
 				if csiErr = r.ensurer.PersistentVolumeClaims(vm, csiPVCs); csiErr != nil {
 					if !errors.As(csiErr, &web.ProviderNotReadyError{}) {
 						step.AddError(csiErr.Error())
@@ -1251,10 +1300,7 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 			step.MarkStarted()
 			step.Phase = api.StepRunning
 
-			targetPS := vm.TargetPowerState
-			if targetPS == "" {
-				targetPS = r.Plan.Spec.TargetPowerState
-			}
+			targetPS := vm.ResolveTargetPowerState(r.Plan.Spec.TargetPowerState)
 			if !settings.Settings.WindowsWaitForReboot ||
 				targetPS == plan.TargetPowerStateOff {
 				r.NextPhase(vm)
@@ -1339,7 +1385,9 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 
 			warmJumpStartDone := r.builder.SupportsVolumePopulators() && r.Plan.IsWarm() && vm.Warm.Successes > 0
 
-			if r.builder.SupportsVolumePopulators() && !warmJumpStartDone {
+			if r.builder.SupportsDeltaCopy() {
+				err = r.updateDeltaCopyProgress(vm, step)
+			} else if r.builder.SupportsVolumePopulators() && !warmJumpStartDone {
 				err = r.updatePopulatorCopyProgress(vm, step)
 			} else {
 				// Fallback to non-volume populator path
@@ -1357,6 +1405,7 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 					n := len(vm.Warm.Precopies)
 					vm.Warm.Precopies[n-1].End = &now
 					vm.Warm.NextPrecopyAt = &next
+					r.measurePrecopy(vm)
 					vm.Warm.Successes++
 				}
 				r.NextPhase(vm)
@@ -1415,8 +1464,14 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 		case api.PhaseCopyingPaused:
 			cutover := r.Migration.Spec.CutoverFor(vm.Ref)
 			if cutover != nil && !cutover.After(time.Now()) {
-				vm.Phase = api.PhaseStorePowerState
+				vm.Phase = planmigrbase.CutoverPhase(vm)
+			} else if r.autoCutover(vm) {
+				vm.Phase = planmigrbase.CutoverPhase(vm)
 			} else if vm.Warm.NextPrecopyAt != nil && !vm.Warm.NextPrecopyAt.After(time.Now()) {
+				// Precopies are paused outside of the migration windows.
+				if allowed, _, wErr := r.Migration.Spec.Allowed(time.Now()); wErr != nil || !allowed {
+					break
+				}
 				r.NextPhase(vm)
 			}
 		case api.PhaseRemovePreviousSnapshot, api.PhaseRemovePenultimateSnapshot, api.PhaseRemoveFinalSnapshot:
@@ -1492,6 +1547,18 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 				if snapshotId != "" {
 					vm.Warm.Precopies[len(vm.Warm.Precopies)-1].Snapshot = snapshotId
 				}
+				if vm.Phase == api.PhaseWaitForFinalSnapshot && r.builder.SupportsDeltaCopy() {
+					// The delta copy pod transfers the final precopy from its deltas.
+					n := len(vm.Warm.Precopies)
+					var deltas map[string]string
+					deltas, err = r.provider.GetSnapshotDeltas(vm.Ref, vm.Warm.Precopies[n-1].Snapshot, r.kubevirt.loadHosts)
+					if err != nil {
+						step.AddError(err.Error())
+						err = nil
+						break
+					}
+					vm.Warm.Precopies[n-1].WithDeltas(deltas)
+				}
 				r.NextPhase(vm)
 			}
 		case api.PhaseStoreInitialSnapshotDeltas, api.PhaseStoreSnapshotDeltas:
@@ -1594,7 +1661,11 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 				vm.AddError(fmt.Sprintf("Step '%s' not found", r.migrator.Step(vm)))
 				break
 			}
-			err = r.updateCopyProgress(vm, step)
+			if r.builder.SupportsDeltaCopy() {
+				err = r.updateDeltaCopyProgress(vm, step)
+			} else {
+				err = r.updateCopyProgress(vm, step)
+			}
 			if err != nil {
 				return
 			}
@@ -1833,6 +1904,9 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 		}, false)
 
 	} else if vm.Error != nil {
+		if r.runFailureHook(vm) {
+			return
+		}
 		vm.Phase = api.PhaseCompleted
 
 		// Failed warm migration can't follow its planned itinerary to snapshot removal phase
@@ -1854,6 +1928,30 @@ func (r *Migration) execute(vm *plan.VMStatus) (err error) {
 	return
 }
 
+// runFailureHook moves a failed VM to the failure hook phase.
+// Returns true while the hook has not completed.
+func (r *Migration) runFailureHook(vm *plan.VMStatus) bool {
+	if vm.HasCondition(api.ConditionFailed) {
+		return false
+	}
+	step, found := vm.FindStep(api.PhaseFailureHook)
+	if !found || step.MarkedCompleted() || step.HasError() {
+		return false
+	}
+	if vm.Phase != api.PhaseFailureHook {
+		r.Log.Info(
+			"Migration [FAILED], running failure hook.",
+			"vm",
+			vm.String(),
+			"phase",
+			vm.Phase)
+		vm.Phase = api.PhaseFailureHook
+		step.MarkStarted()
+		step.Phase = api.StepRunning
+	}
+	return true
+}
+
 // Matches by AnnDiskSource, not name — CSI import PVC names may be unset until creation.
 func resolveCsiPVCs(csiSpecs []core.PersistentVolumeClaim, migrationPVCs []*core.PersistentVolumeClaim) []*core.PersistentVolumeClaim {
 	wanted := make(map[string]bool, len(csiSpecs))
@@ -1873,24 +1971,24 @@ func resolveCsiPVCs(csiSpecs []core.PersistentVolumeClaim, migrationPVCs []*core
 
 const schedulerQueuedReasonFmt = "Waiting to start migration: in-flight limit reached (max %d)."
 
+const budgetQueuedReasonFmt = "Waiting to start migration: migration budget %s exhausted (max %d)."
+
+const windowQueuedReasonFmt = "Waiting to start migration: outside of the migration windows (next opening at %s)."
+
+// Mark the VMs not started for lack of scheduler capacity. The
+// migration budget blocking the plan is reported when the plan is
+// held back by a budget rather than by the provider scheduler.
 func (r *Migration) markSchedulerQueuedVMs() {
 	limit := settings.Settings.MaxInFlight
-	queued := 0
-
-	for i := range r.Plan.Status.Migration.VMs {
-		vm := r.Plan.Status.Migration.VMs[i]
-		if vm.MarkedStarted() || vm.MarkedCompleted() || vm.HasCondition(api.ConditionCanceled) {
-			continue
-		}
-		queued++
-		vm.SetCondition(libcnd.Condition{
-			Type:     api.ConditionPending,
-			Status:   True,
-			Category: api.CategoryAdvisory,
-			Message:  fmt.Sprintf(schedulerQueuedReasonFmt, limit),
-			Durable:  true,
-		})
+	message := fmt.Sprintf(schedulerQueuedReasonFmt, limit)
+	blocking := "in-flight limit"
+	if budgeted, cast := r.scheduler.(*scheduler.Budgeted); cast && budgeted.Exhausted != nil {
+		budget := path.Join(budgeted.Exhausted.Namespace, budgeted.Exhausted.Name)
+		limit = budgeted.Exhausted.Spec.MaxInFlight
+		message = fmt.Sprintf(budgetQueuedReasonFmt, budget, limit)
+		blocking = "migration budget " + budget
 	}
+	queued := r.markQueuedVMs("", message)
 
 	if queued == 0 {
 		return
@@ -1901,13 +1999,75 @@ func (r *Migration) markSchedulerQueuedVMs() {
 		Status:   True,
 		Category: api.CategoryRequired,
 		Message: fmt.Sprintf(
-			"%d VM(s) waiting to start migration (in-flight limit: %d).",
+			"%d VM(s) waiting to start migration (%s: %d).",
 			queued,
+			blocking,
 			limit),
 	})
 	r.Log.Info("VMs waiting for scheduler capacity.", "count", queued, "limit", limit)
 }
 
+// Mark the VMs not started outside of the migration windows.
+// The VMs are started once the next window opens.
+func (r *Migration) markWindowQueuedVMs(next time.Time) {
+	opening := next.UTC().Format(time.RFC3339)
+	queued := r.markQueuedVMs(OutsideWindow, fmt.Sprintf(windowQueuedReasonFmt, opening))
+	if queued == 0 {
+		return
+	}
+
+	r.Plan.Status.SetCondition(libcnd.Condition{
+		Type:     libcnd.Ready,
+		Status:   True,
+		Category: api.CategoryRequired,
+		Message: fmt.Sprintf(
+			"%d VM(s) waiting for the migration window opening at %s.",
+			queued,
+			opening),
+	})
+	r.Log.Info("VMs waiting for the migration window.", "count", queued, "next", opening)
+}
+
+// Determine whether the precopies of the VM converged as defined
+// by the automatic cutover policy of the plan and the cutover may
+// start.
+func (r *Migration) autoCutover(vm *plan.VMStatus) bool {
+	policy := r.Plan.Spec.AutoCutover
+	if policy == nil || !policy.Converged(vm.Warm.Precopies) {
+		return false
+	}
+	open, err := policy.Open(time.Now())
+	if err != nil {
+		r.Log.Error(err, "Automatic cutover windows not valid.", "vm", vm.String())
+		return false
+	}
+	if open {
+		r.Log.Info("Precopies converged, starting the cutover.", "vm", vm.String())
+	}
+	return open
+}
+
+// Mark the VMs not started as pending.
+// Returns the number of VMs marked.
+func (r *Migration) markQueuedVMs(reason, message string) (queued int) {
+	for i := range r.Plan.Status.Migration.VMs {
+		vm := r.Plan.Status.Migration.VMs[i]
+		if vm.MarkedStarted() || vm.MarkedCompleted() || vm.HasCondition(api.ConditionCanceled) {
+			continue
+		}
+		queued++
+		vm.SetCondition(libcnd.Condition{
+			Type:     api.ConditionPending,
+			Status:   True,
+			Reason:   reason,
+			Category: api.CategoryAdvisory,
+			Message:  message,
+			Durable:  true,
+		})
+	}
+	return
+}
+
 func (r *Migration) resetPrecopyTasks(vm *plan.VMStatus, step *plan.Step) {
 	step.Completed = nil
 	for _, task := range step.Tasks {
@@ -2205,6 +2365,98 @@ func (r *Migration) updateCopyProgress(vm *plan.VMStatus, step *plan.Step) (err
 	return
 }
 
+// Update the progress of a precopy transferred by the delta copy pod. (DiskTransfer, Cutover)
+// The pod is created once the blank DataVolumes are ready and copies the
+// extents changed since the previous precopy, or the whole disks.
+func (r *Migration) updateDeltaCopyProgress(vm *plan.VMStatus, step *plan.Step) (err error) {
+	dvs, err := r.kubevirt.getDVs(vm)
+	if err != nil {
+		return
+	}
+	for _, dv := range dvs {
+		if dv.Status.Phase != cdi.Succeeded {
+			step.Phase = api.StepPending
+			step.Reason = "Waiting for the DataVolumes to be ready"
+			return
+		}
+	}
+	n := len(vm.Warm.Precopies)
+	pod, err := r.kubevirt.GetDeltaCopyPod(vm, n)
+	if err != nil {
+		return
+	}
+	if pod == nil {
+		var disks []deltacopy.Disk
+		disks, err = r.builder.DeltaCopyDisks(vm.Ref)
+		if err != nil {
+			return
+		}
+		var baseline map[string]string
+		if n > 1 {
+			baseline = vm.Warm.Precopies[n-2].DeltaMap()
+		}
+		var extents map[string][]deltacopy.Extent
+		extents, err = r.provider.GetChangedExtents(vm.Ref, vm.Warm.Precopies[n-1].Snapshot, baseline, r.kubevirt.loadHosts)
+		if err != nil {
+			return
+		}
+		err = r.kubevirt.EnsureDeltaCopyPod(vm, n, disks, extents)
+		if err != nil {
+			return
+		}
+		transferred := deltaCopyBytes(disks, extents)
+		vm.Warm.Precopies[n-1].TransferredBytes = &transferred
+		step.Phase = api.StepPending
+		step.Reason = "Waiting for the delta copy pod"
+		return
+	}
+
+	switch pod.Status.Phase {
+	case core.PodSucceeded:
+		for _, task := range step.Tasks {
+			r.setTaskCompleted(task)
+		}
+		step.ReflectTasks()
+		step.Phase = api.StepCompleted
+		step.Reason = ""
+		err = r.kubevirt.DeleteDeltaCopyPods(vm)
+	case core.PodFailed:
+		msg, ok := terminationMessage(pod)
+		if !ok {
+			msg = "Delta copy pod failed."
+		}
+		for _, task := range step.Tasks {
+			task.AddError(msg)
+			task.MarkCompleted()
+		}
+		step.ReflectTasks()
+	case core.PodRunning:
+		if len(pod.Status.ContainerStatuses) > 0 {
+			vm.Warm.Failures = int(pod.Status.ContainerStatuses[0].RestartCount)
+		}
+		if restartLimitExceeded(pod) {
+			msg, _ := terminationMessage(pod)
+			for _, task := range step.Tasks {
+				task.AddError(msg)
+				task.MarkCompleted()
+			}
+			step.ReflectTasks()
+			break
+		}
+		for _, task := range step.Tasks {
+			task.Phase = api.StepRunning
+			task.MarkStarted()
+		}
+		step.ReflectTasks()
+		step.Phase = api.StepRunning
+		step.Reason = ""
+	default:
+		step.Phase = api.StepPending
+		step.Reason = "Waiting for the delta copy pod"
+	}
+	return
+}
+
 // Wait for guest conversion to complete, and update the ImageConversion pipeline step.
 func (r *Migration) updateConversionProgress(vm *plan.VMStatus, step *plan.Step) error {
 	pod, err := r.kubevirt.GetGuestConversionPod(vm)
@@ -2337,6 +2589,54 @@ func (r *Migration) updateConversionProgressV2vMonitor(pod *core.Pod, step *plan
 	return
 }
 
+// Record the bytes transferred by the last precopy when the
+// provider can measure the changes of the snapshot. Precopies
+// transferred by the delta copy pod are measured when the pod
+// is created.
+func (r *Migration) measurePrecopy(vm *plan.VMStatus) {
+	n := len(vm.Warm.Precopies)
+	precopy := &vm.Warm.Precopies[n-1]
+	if precopy.TransferredBytes != nil || precopy.Snapshot == "" {
+		return
+	}
+	var baseline map[string]string
+	if n > 1 {
+		baseline = vm.Warm.Precopies[n-2].DeltaMap()
+	}
+	extents, err := r.provider.GetChangedExtents(vm.Ref, precopy.Snapshot, baseline, r.kubevirt.loadHosts)
+	if err != nil {
+		r.Log.Info(
+			"Could not measure the precopy.",
+			"vm",
+			vm.String(),
+			"error",
+			err.Error())
+		return
+	}
+	if extents == nil {
+		return
+	}
+	var transferred int64
+	for _, diskExtents := range extents {
+		transferred += deltacopy.Total(diskExtents)
+	}
+	precopy.TransferredBytes = &transferred
+}
+
+// Bytes read by the delta copy of the disks.
+// Disks without extents are copied whole.
+func deltaCopyBytes(disks []deltacopy.Disk, extents map[string][]deltacopy.Extent) (total int64) {
+	for _, disk := range disks {
+		if diskExtents, found := extents[disk.ID]; found {
+			disk.Extents = diskExtents
+		} else {
+			disk.Full = true
+		}
+		total += disk.Bytes()
+	}
+	return
+}
+
 func (r *Migration) setDataVolumeCheckpoints(vm *plan.VMStatus) (err error) {
 	disks, err := r.kubevirt.getDVs(vm)
 	if err != nil {
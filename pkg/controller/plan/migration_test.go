@@ -10,7 +10,9 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	"github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	"github.com/kubev2v/forklift/pkg/controller/plan/scheduler"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
 	"github.com/kubev2v/forklift/pkg/settings"
 	ginkgo "github.com/onsi/ginkgo/v2"
@@ -378,6 +380,123 @@ func TestMarkSchedulerQueuedVMs(t *testing.T) {
 	g.Expect(ready.Message).To(gomega.Equal("1 VM(s) waiting to start migration (in-flight limit: 20)."))
 }
 
+func TestMarkBudgetQueuedVMs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	queuedVM := &plan.VMStatus{Phase: api.PhaseStarted}
+	budget := &api.MigrationBudget{
+		ObjectMeta: meta.ObjectMeta{Namespace: "team-a", Name: "vsphere"},
+	}
+	budget.Spec.MaxInFlight = 4
+
+	m := &Migration{
+		Context: &plancontext.Context{
+			Plan: &api.Plan{
+				Status: api.PlanStatus{
+					Migration: plan.MigrationStatus{
+						VMs: []*plan.VMStatus{queuedVM},
+					},
+				},
+			},
+			Log: logging.WithName("test"),
+		},
+		scheduler: &scheduler.Budgeted{Exhausted: budget},
+	}
+
+	m.markSchedulerQueuedVMs()
+
+	pending := queuedVM.FindCondition(api.ConditionPending)
+	g.Expect(pending).NotTo(gomega.BeNil())
+	g.Expect(pending.Message).To(gomega.Equal("Waiting to start migration: migration budget team-a/vsphere exhausted (max 4)."))
+
+	ready := m.Plan.Status.FindCondition(libcnd.Ready)
+	g.Expect(ready).NotTo(gomega.BeNil())
+	g.Expect(ready.Message).To(gomega.Equal("1 VM(s) waiting to start migration (migration budget team-a/vsphere: 4)."))
+}
+
+func TestMarkWindowQueuedVMs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	queuedVM := &plan.VMStatus{Phase: api.PhaseStarted}
+	runningVM := &plan.VMStatus{Phase: api.PhaseStarted}
+	runningVM.MarkStarted()
+
+	m := &Migration{
+		Context: &plancontext.Context{
+			Plan: &api.Plan{
+				Status: api.PlanStatus{
+					Migration: plan.MigrationStatus{
+						VMs: []*plan.VMStatus{queuedVM, runningVM},
+					},
+				},
+			},
+			Log: logging.WithName("test"),
+		},
+	}
+
+	m.markWindowQueuedVMs(time.Date(2026, 10, 20, 22, 0, 0, 0, time.UTC))
+
+	pending := queuedVM.FindCondition(api.ConditionPending)
+	g.Expect(pending).NotTo(gomega.BeNil())
+	g.Expect(pending.Reason).To(gomega.Equal(OutsideWindow))
+	g.Expect(pending.Message).To(gomega.Equal(
+		"Waiting to start migration: outside of the migration windows (next opening at 2026-10-20T22:00:00Z)."))
+	g.Expect(runningVM.HasCondition(api.ConditionPending)).To(gomega.BeFalse())
+
+	ready := m.Plan.Status.FindCondition(libcnd.Ready)
+	g.Expect(ready).NotTo(gomega.BeNil())
+	g.Expect(ready.Message).To(gomega.Equal("1 VM(s) waiting for the migration window opening at 2026-10-20T22:00:00Z."))
+}
+
+func TestAutoCutover(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	start := meta.NewTime(time.Now().Add(-2 * time.Minute))
+	end := meta.NewTime(time.Now())
+	vm := &plan.VMStatus{}
+	vm.Warm = &plan.Warm{
+		Precopies: []plan.Precopy{{Start: &start, End: &end}},
+	}
+	m := &Migration{
+		Context: &plancontext.Context{
+			Plan: &api.Plan{},
+			Log:  logging.WithName("test"),
+		},
+	}
+
+	g.Expect(m.autoCutover(vm)).To(gomega.BeFalse())
+
+	m.Plan.Spec.AutoCutover = &api.AutoCutover{Precopies: 1, MaxPrecopySeconds: 300}
+	g.Expect(m.autoCutover(vm)).To(gomega.BeTrue())
+
+	// Closed window.
+	now := time.Now().UTC()
+	m.Plan.Spec.AutoCutover.Windows = []api.MigrationWindow{{
+		Start: now.Add(time.Hour).Format("15:04"),
+		End:   now.Add(2 * time.Hour).Format("15:04"),
+	}}
+	g.Expect(m.autoCutover(vm)).To(gomega.BeFalse())
+
+	m.Plan.Spec.AutoCutover.Windows = nil
+	m.Plan.Spec.AutoCutover.MaxPrecopySeconds = 60
+	g.Expect(m.autoCutover(vm)).To(gomega.BeFalse())
+}
+
+func TestDeltaCopyBytes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	disks := []deltacopy.Disk{
+		{ID: "disk-1", Capacity: 1000},
+		{ID: "disk-2", Capacity: 2000},
+	}
+	extents := map[string][]deltacopy.Extent{
+		"disk-1": {{Offset: 0, Length: 10}, {Offset: 100, Length: 20}},
+	}
+	// The second disk has no extents and is copied whole.
+	g.Expect(deltaCopyBytes(disks, extents)).To(gomega.Equal(int64(2030)))
+	g.Expect(disks[1].Full).To(gomega.BeFalse())
+}
+
 func TestExecuteClearsSchedulerPendingCondition(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -411,6 +530,43 @@ func TestExecuteClearsSchedulerPendingCondition(t *testing.T) {
 	g.Expect(vm.HasCondition(api.ConditionPending)).To(gomega.BeFalse())
 }
 
+func TestRunFailureHook(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	m := &Migration{
+		Context: &plancontext.Context{
+			Log: logging.WithName("test"),
+		},
+	}
+	newVM := func() *plan.VMStatus {
+		return &plan.VMStatus{
+			VM:    plan.VM{Ref: ref.Ref{ID: "vm-1", Name: "test-vm"}},
+			Phase: api.PhaseCopyDisks,
+			Pipeline: []*plan.Step{
+				{Task: plan.Task{Name: api.PhaseFailureHook, Phase: api.StepPending}},
+			},
+		}
+	}
+
+	vm := newVM()
+	g.Expect(m.runFailureHook(vm)).To(gomega.BeTrue())
+	g.Expect(vm.Phase).To(gomega.Equal(api.PhaseFailureHook))
+	step, _ := vm.FindStep(api.PhaseFailureHook)
+	g.Expect(step.Phase).To(gomega.Equal(api.StepRunning))
+
+	step.MarkCompleted()
+	g.Expect(m.runFailureHook(vm)).To(gomega.BeFalse())
+
+	vm = newVM()
+	vm.Pipeline = nil
+	g.Expect(m.runFailureHook(vm)).To(gomega.BeFalse())
+	g.Expect(vm.Phase).To(gomega.Equal(api.PhaseCopyDisks))
+
+	vm = newVM()
+	vm.SetCondition(libcnd.Condition{Type: api.ConditionFailed, Status: libcnd.True})
+	g.Expect(m.runFailureHook(vm)).To(gomega.BeFalse())
+}
+
 func TestResolveCsiPVCs(t *testing.T) {
 	pvcWithSource := func(name, diskSource string) *core.PersistentVolumeClaim {
 		return &core.PersistentVolumeClaim{
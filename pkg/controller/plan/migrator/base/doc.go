@@ -40,6 +40,10 @@ var (
 	RunInspection                     libitr.Flag = 0x80
 	WindowsWaitForGuestReboot         libitr.Flag = 0x100
 	WaitForFinalSnapshotConsolidation libitr.Flag = 0x200
+	ChangeTracking                    libitr.Flag = 0x400
+	HasPrePowerOffHook                libitr.Flag = 0x800
+	HasPostTransferHook               libitr.Flag = 0x1000
+	HasPreCutoverHook                 libitr.Flag = 0x2000
 )
 
 // Steps.
@@ -118,6 +122,18 @@ func NextPhase(migrator Migrator, vm *plan.VMStatus) {
 	}
 }
 
+// CutoverPhase returns the first phase of the cutover of a warm migration,
+// which is the pre-cutover or pre-power-off hook when the VM has one.
+func CutoverPhase(vm *plan.VMStatus) string {
+	if _, found := vm.FindHook(api.PhasePreCutoverHook); found {
+		return api.PhasePreCutoverHook
+	}
+	if _, found := vm.FindHook(api.PhasePrePowerOffHook); found {
+		return api.PhasePrePowerOffHook
+	}
+	return api.PhaseStorePowerState
+}
+
 // next determines the next phase the VM should move to.
 func next(migrator Migrator, vm *plan.VMStatus) (next string) {
 	itinerary := migrator.Itinerary(vm.VM)
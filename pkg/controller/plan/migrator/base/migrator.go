@@ -54,7 +54,7 @@ func (r *BaseMigrator) Status(vm plan.VM) (status *plan.VMStatus) {
 }
 
 func (r *BaseMigrator) Reset(vm *plan.VMStatus, pipeline []*plan.Step) {
-	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed)
+	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed, api.ConditionRolledBack)
 	vm.MarkReset()
 	itr := r.Itinerary(vm.VM)
 	step, _ := itr.First()
@@ -65,6 +65,7 @@ func (r *BaseMigrator) Reset(vm *plan.VMStatus, pipeline []*plan.Step) {
 		return
 	}
 	vm.DisksCopied = false
+	vm.HookResults = nil
 	if r.Context.Plan.IsWarm() {
 		vm.Warm = &plan.Warm{}
 	}
@@ -188,6 +189,39 @@ func (r *BaseMigrator) Pipeline(vm plan.VM) (pipeline []*plan.Step, err error) {
 						Phase:       api.StepPending,
 					},
 				})
+		case api.PhasePrePowerOffHook:
+			pipeline = append(
+				pipeline,
+				&plan.Step{
+					Task: plan.Task{
+						Name:        api.PhasePrePowerOffHook,
+						Description: "Run hook before powering off the source VM.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		case api.PhasePostTransferHook:
+			pipeline = append(
+				pipeline,
+				&plan.Step{
+					Task: plan.Task{
+						Name:        api.PhasePostTransferHook,
+						Description: "Run hook after the disk transfer.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		case api.PhasePreCutoverHook:
+			pipeline = append(
+				pipeline,
+				&plan.Step{
+					Task: plan.Task{
+						Name:        api.PhasePreCutoverHook,
+						Description: "Run pre-cutover hook.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
 		case api.PhaseCreateVM:
 			pipeline = append(
 				pipeline,
@@ -246,6 +280,21 @@ func (r *BaseMigrator) Pipeline(vm plan.VM) (pipeline []*plan.Step, err error) {
 		return
 	}
 
+	// The failure hook is not part of the itinerary, the
+	// VM is moved to its phase only when the migration fails.
+	if _, found := vm.FindHook(api.PhaseFailureHook); found {
+		pipeline = append(
+			pipeline,
+			&plan.Step{
+				Task: plan.Task{
+					Name:        api.PhaseFailureHook,
+					Description: "Run hook after the migration failed.",
+					Progress:    libitr.Progress{Total: 1},
+					Phase:       api.StepPending,
+				},
+			})
+	}
+
 	r.Log.V(2).Info(
 		"Pipeline built.",
 		"vm",
@@ -304,7 +353,8 @@ func (r *BaseMigrator) Step(status *plan.VMStatus) (step string) {
 		step = VMCreation
 	case api.PhaseWaitForGuestReboots:
 		step = api.PhaseWaitForGuestReboots
-	case api.PhasePreHook, api.PhasePostHook:
+	case api.PhasePreHook, api.PhasePostHook, api.PhasePrePowerOffHook,
+		api.PhasePostTransferHook, api.PhasePreCutoverHook, api.PhaseFailureHook:
 		step = status.Phase
 	case api.PhaseStorePowerState, api.PhasePowerOffSource, api.PhaseWaitForPowerOff:
 		if r.Context.Plan.IsWarm() {
@@ -330,35 +380,38 @@ func (r *BaseMigrator) warmItinerary() *libitr.Itinerary {
 			{Name: api.PhasePreHook, All: HasPreHook},
 			{Name: api.PhaseCreateInitialSnapshot},
 			{Name: api.PhaseWaitForInitialSnapshot},
-			{Name: api.PhaseStoreInitialSnapshotDeltas, All: VSphere},
+			{Name: api.PhaseStoreInitialSnapshotDeltas, All: ChangeTracking},
 			{Name: api.PhasePreflightInspection, All: RunInspection},
 			{Name: api.PhaseCreateDataVolumes},
 			// Precopy loop start
 			{Name: api.PhaseCopyDisks},
 			{Name: api.PhaseCopyingPaused},
-			{Name: api.PhaseRemovePreviousSnapshot, All: VSphere},
-			{Name: api.PhaseWaitForPreviousSnapshotRemoval, All: VSphere},
+			{Name: api.PhaseRemovePreviousSnapshot, All: ChangeTracking},
+			{Name: api.PhaseWaitForPreviousSnapshotRemoval, All: ChangeTracking},
 			{Name: api.PhaseCreateSnapshot},
 			{Name: api.PhaseWaitForSnapshot},
-			{Name: api.PhaseStoreSnapshotDeltas, All: VSphere},
+			{Name: api.PhaseStoreSnapshotDeltas, All: ChangeTracking},
 			{Name: api.PhaseAddCheckpoint},
 			// Precopy loop end
+			{Name: api.PhasePreCutoverHook, All: HasPreCutoverHook},
+			{Name: api.PhasePrePowerOffHook, All: HasPrePowerOffHook},
 			{Name: api.PhaseStorePowerState},
 			{Name: api.PhasePowerOffSource},
 			{Name: api.PhaseWaitForPowerOff},
-			{Name: api.PhaseRemovePenultimateSnapshot, All: VSphere},
-			{Name: api.PhaseWaitForPenultimateSnapshotRemoval, All: VSphere},
+			{Name: api.PhaseRemovePenultimateSnapshot, All: ChangeTracking},
+			{Name: api.PhaseWaitForPenultimateSnapshotRemoval, All: ChangeTracking},
 			{Name: api.PhaseCreateFinalSnapshot},
 			{Name: api.PhaseWaitForFinalSnapshot},
 			{Name: api.PhaseAddFinalCheckpoint},
 			{Name: api.PhaseFinalize},
-			{Name: api.PhaseRemoveFinalSnapshot, All: VSphere},
+			{Name: api.PhaseRemoveFinalSnapshot, All: ChangeTracking},
+			{Name: api.PhasePostTransferHook, All: HasPostTransferHook},
 			{Name: api.PhaseCreateGuestConversionPod, All: RequiresConversion},
 			{Name: api.PhaseConvertGuest, All: RequiresConversion},
 			{Name: api.PhaseCreateVM},
 			{Name: api.PhaseWaitForGuestReboots, All: WindowsWaitForGuestReboot},
 			{Name: api.PhasePostHook, All: HasPostHook},
-			{Name: api.PhaseWaitForFinalSnapshotRemoval, All: VSphere | WaitForFinalSnapshotConsolidation},
+			{Name: api.PhaseWaitForFinalSnapshotRemoval, All: ChangeTracking | WaitForFinalSnapshotConsolidation},
 			{Name: api.PhaseCompleted},
 		},
 	}
@@ -370,6 +423,7 @@ func (r *BaseMigrator) coldItinerary() *libitr.Itinerary {
 		Pipeline: libitr.Pipeline{
 			{Name: api.PhaseStarted},
 			{Name: api.PhasePreHook, All: HasPreHook},
+			{Name: api.PhasePrePowerOffHook, All: HasPrePowerOffHook},
 			{Name: api.PhaseStorePowerState},
 			{Name: api.PhasePowerOffSource},
 			{Name: api.PhaseWaitForPowerOff},
@@ -380,6 +434,7 @@ func (r *BaseMigrator) coldItinerary() *libitr.Itinerary {
 			{Name: api.PhaseConvertGuest, All: RequiresConversion},
 			{Name: api.PhaseCopyDisksVirtV2V, All: RequiresConversion | VirtV2vDiskCopy},
 			{Name: api.PhaseConvertOpenstackSnapshot, All: OpenstackImageMigration},
+			{Name: api.PhasePostTransferHook, All: HasPostTransferHook},
 			{Name: api.PhaseCreateVM},
 			{Name: api.PhaseWaitForGuestReboots, All: WindowsWaitForGuestReboot},
 			{Name: api.PhasePostHook, All: HasPostHook},
@@ -394,6 +449,7 @@ func (r *BaseMigrator) onlyConversionItinerary() *libitr.Itinerary {
 		Pipeline: libitr.Pipeline{
 			{Name: api.PhaseStarted},
 			{Name: api.PhasePreHook, All: HasPreHook},
+			{Name: api.PhasePrePowerOffHook, All: HasPrePowerOffHook},
 			{Name: api.PhaseStorePowerState},
 			{Name: api.PhasePowerOffSource},
 			{Name: api.PhaseWaitForPowerOff},
@@ -462,8 +518,17 @@ func (r *BasePredicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
 		_, allowed = r.vm.FindHook(api.PhasePreHook)
 	case HasPostHook:
 		_, allowed = r.vm.FindHook(api.PhasePostHook)
+	case HasPrePowerOffHook:
+		_, allowed = r.vm.FindHook(api.PhasePrePowerOffHook)
+	case HasPostTransferHook:
+		_, allowed = r.vm.FindHook(api.PhasePostTransferHook)
+	case HasPreCutoverHook:
+		_, allowed = r.vm.FindHook(api.PhasePreCutoverHook)
 	case RequiresConversion:
 		allowed = r.context.Source.Provider.RequiresConversion() && !r.context.Plan.Spec.SkipGuestConversion
+		if allowed {
+			allowed, err = r.hookAllowsConversion()
+		}
 	case CDIDiskCopy:
 		var useV2vForTransfer bool
 		useV2vForTransfer, err = r.ensureUseV2vForTransfer()
@@ -482,6 +547,12 @@ func (r *BasePredicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
 		allowed = r.context.Plan.IsSourceProviderOpenstack()
 	case VSphere:
 		allowed = r.context.Plan.IsSourceProviderVSphere()
+	case ChangeTracking:
+		// Precopies are based on source snapshots that are removed once
+		// transferred (vSphere CBT, Hyper-V RCT, Nutanix recovery points).
+		allowed = r.context.Plan.IsSourceProviderVSphere() ||
+			r.context.Plan.IsSourceProviderHyperV() ||
+			r.context.Plan.IsSourceProviderNutanix()
 	case RunInspection:
 		allowed = r.context.Plan.ShouldRunPreflightInspection()
 	case WindowsWaitForGuestReboot:
@@ -492,6 +563,9 @@ func (r *BasePredicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
 		if target == "" {
 			target = r.context.Plan.Spec.TargetPowerState
 		}
+		if status := r.status(); status != nil {
+			target = status.ResolveTargetPowerState(r.context.Plan.Spec.TargetPowerState)
+		}
 		if target == plan.TargetPowerStateOff {
 			break
 		}
@@ -500,6 +574,9 @@ func (r *BasePredicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
 			break
 		}
 		allowed = r.context.Source.Provider.RequiresConversion() && !r.context.Plan.Spec.SkipGuestConversion
+		if allowed {
+			allowed, err = r.hookAllowsConversion()
+		}
 	case WaitForFinalSnapshotConsolidation:
 		allowed = settings.Settings.WaitForFinalSnapshotConsolidation
 	}
@@ -507,6 +584,33 @@ func (r *BasePredicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
 	return
 }
 
+// status returns the migration status of the VM, or nil
+// when the VM has not started migrating.
+func (r *BasePredicate) status() *plan.VMStatus {
+	status, found := r.context.Plan.Status.Migration.FindVM(r.vm.Ref)
+	if !found {
+		return nil
+	}
+	return status
+}
+
+// hookAllowsConversion returns false when a hook result skips the
+// guest conversion. The conversion cannot be skipped when virt-v2v
+// copies the disks.
+func (r *BasePredicate) hookAllowsConversion() (allowed bool, err error) {
+	allowed = true
+	status := r.status()
+	if status == nil || !status.HookSkipsGuestConversion() {
+		return
+	}
+	useV2vForTransfer, err := r.ensureUseV2vForTransfer()
+	if err != nil {
+		return
+	}
+	allowed = useV2vForTransfer
+	return
+}
+
 func (r *BasePredicate) Count() int {
-	return 0x200
+	return 0x400
 }
@@ -2,6 +2,7 @@ package base
 
 import (
 	"context"
+	"slices"
 	"sync/atomic"
 	"testing"
 
@@ -270,3 +271,183 @@ func TestReset_NormalWarm_SetsWarm(t *testing.T) {
 		t.Fatal("expected Warm to be set during normal warm reset")
 	}
 }
+
+func TestBasePredicate_ChangeTracking(t *testing.T) {
+	tests := []struct {
+		source   api.ProviderType
+		expected bool
+	}{
+		{api.VSphere, true},
+		{api.HyperV, true},
+		{api.OVirt, false},
+		{api.OpenStack, false},
+		{api.Nutanix, true},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.source), func(t *testing.T) {
+			source := tc.source
+			p := &api.Plan{}
+			p.Provider.Source = &api.Provider{Spec: api.ProviderSpec{Type: &source}}
+			pred := &BasePredicate{
+				vm:      &plan.VM{Ref: ref.Ref{ID: "vm-1"}},
+				context: &plancontext.Context{Plan: p},
+			}
+			allowed, err := pred.Evaluate(ChangeTracking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != tc.expected {
+				t.Fatalf("ChangeTracking allowed = %v for %s, want %v", allowed, tc.source, tc.expected)
+			}
+		})
+	}
+}
+
+func TestItinerary_Warm_HookPhases(t *testing.T) {
+	p := &api.Plan{Spec: api.PlanSpec{Warm: true}}
+	migrator := newBaseMigratorWithProvider(t, p, nil)
+
+	vm := plan.VM{
+		Ref: ref.Ref{ID: "vm-1"},
+		Hooks: []plan.HookRef{
+			{Step: api.PhasePreCutoverHook},
+			{Step: api.PhasePrePowerOffHook},
+			{Step: api.PhasePostTransferHook},
+		},
+	}
+	itr := migrator.Itinerary(vm)
+
+	transitions := [][2]string{
+		{api.PhaseAddCheckpoint, api.PhasePreCutoverHook},
+		{api.PhasePreCutoverHook, api.PhasePrePowerOffHook},
+		{api.PhasePrePowerOffHook, api.PhaseStorePowerState},
+		{api.PhaseRemoveFinalSnapshot, api.PhasePostTransferHook},
+		{api.PhasePostTransferHook, api.PhaseCreateGuestConversionPod},
+	}
+	for _, transition := range transitions {
+		next, _, err := itr.Next(transition[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Name != transition[1] {
+			t.Errorf("expected %q after %q, got %q", transition[1], transition[0], next.Name)
+		}
+	}
+}
+
+// The post-transfer hook runs once the disks are copied, which is done
+// by the conversion pod when virt-v2v copies the disks.
+func TestItinerary_Cold_PostTransferHookAfterCopy(t *testing.T) {
+	p := &api.Plan{}
+	migrator := newBaseMigratorWithProvider(t, p, nil)
+
+	vm := plan.VM{
+		Ref:   ref.Ref{ID: "vm-1"},
+		Hooks: []plan.HookRef{{Step: api.PhasePostTransferHook}},
+	}
+	itr := migrator.Itinerary(vm)
+
+	list, err := itr.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var phases []string
+	for _, step := range list {
+		phases = append(phases, step.Name)
+	}
+	hook := slices.Index(phases, api.PhasePostTransferHook)
+	if hook == -1 || hook+1 == len(phases) || phases[hook+1] != api.PhaseCreateVM {
+		t.Fatalf("expected %q before %q, got %v", api.PhasePostTransferHook, api.PhaseCreateVM, phases)
+	}
+	for _, phase := range []string{api.PhaseCopyDisks, api.PhaseConvertGuest, api.PhaseCopyDisksVirtV2V} {
+		if i := slices.Index(phases, phase); i > hook {
+			t.Errorf("expected %q before %q, got %v", phase, api.PhasePostTransferHook, phases)
+		}
+	}
+}
+
+func TestItinerary_Cold_SkipsHookPhasesWithoutHooks(t *testing.T) {
+	p := &api.Plan{}
+	migrator := newBaseMigratorWithProvider(t, p, nil)
+
+	vm := plan.VM{Ref: ref.Ref{ID: "vm-1"}}
+	itr := migrator.Itinerary(vm)
+
+	list, err := itr.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range list {
+		switch step.Name {
+		case api.PhasePrePowerOffHook, api.PhasePostTransferHook, api.PhasePreCutoverHook, api.PhaseFailureHook:
+			t.Errorf("cold itinerary without hooks should not contain %q", step.Name)
+		}
+	}
+}
+
+func TestCutoverPhase(t *testing.T) {
+	vm := &plan.VMStatus{}
+	if phase := CutoverPhase(vm); phase != api.PhaseStorePowerState {
+		t.Errorf("expected %q without hooks, got %q", api.PhaseStorePowerState, phase)
+	}
+	vm.Hooks = []plan.HookRef{{Step: api.PhasePrePowerOffHook}}
+	if phase := CutoverPhase(vm); phase != api.PhasePrePowerOffHook {
+		t.Errorf("expected %q, got %q", api.PhasePrePowerOffHook, phase)
+	}
+	vm.Hooks = append(vm.Hooks, plan.HookRef{Step: api.PhasePreCutoverHook})
+	if phase := CutoverPhase(vm); phase != api.PhasePreCutoverHook {
+		t.Errorf("expected %q, got %q", api.PhasePreCutoverHook, phase)
+	}
+}
+
+// A hook result skips the guest conversion only when the disks are copied by CDI.
+func TestBasePredicate_HookSkipsConversion(t *testing.T) {
+	tests := []struct {
+		name        string
+		netAppShift bool
+		skip        bool
+		expected    bool
+	}{
+		{name: "cdi copy, no hook result", netAppShift: true, expected: true},
+		{name: "cdi copy, skipped by hook", netAppShift: true, skip: true, expected: false},
+		{name: "virt-v2v copy, skipped by hook", skip: true, expected: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = storagev1.AddToScheme(scheme)
+			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			pred := newVsphereColdPredicate(t, cl, "sc1", tc.netAppShift)
+			pred.context.Source.Provider = pred.context.Plan.Provider.Source
+			pred.context.Plan.Status.Migration.VMs = []*plan.VMStatus{
+				{
+					VM:          *pred.vm,
+					HookResults: []plan.HookResult{{Step: api.PhasePreHook, SkipGuestConversion: tc.skip}},
+				},
+			}
+			allowed, err := pred.Evaluate(RequiresConversion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != tc.expected {
+				t.Fatalf("RequiresConversion allowed = %v, want %v", allowed, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReset_ClearsHookResults(t *testing.T) {
+	p := &api.Plan{}
+	migrator := newBaseMigratorWithProvider(t, p, nil)
+
+	vmStatus := &plan.VMStatus{
+		VM:          plan.VM{Ref: ref.Ref{ID: "vm-1"}},
+		HookResults: []plan.HookResult{{Step: api.PhasePreHook}},
+	}
+
+	migrator.Reset(vmStatus, []*plan.Step{})
+
+	if vmStatus.HookResults != nil {
+		t.Fatal("expected the hook results of the previous migration to be cleared")
+	}
+}
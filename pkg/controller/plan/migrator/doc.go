@@ -8,6 +8,7 @@ import (
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	"github.com/kubev2v/forklift/pkg/controller/plan/migrator/base"
 	"github.com/kubev2v/forklift/pkg/controller/plan/migrator/ocp"
+	"github.com/kubev2v/forklift/pkg/controller/plan/migrator/vsphere"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
 	ec2migrator "github.com/kubev2v/forklift/pkg/provider/ec2/controller/migrator"
 )
@@ -17,7 +18,17 @@ type Migrator = base.Migrator
 var log = logging.WithName("migrator")
 
 // New builds a new Migrator implementation from a plan context.
+// Plans with a vSphere destination use the vSphere migrator, otherwise
+// the migrator is selected by the source provider.
 func New(context *plancontext.Context) (migrator Migrator, err error) {
+	if context.Destination.Provider.Type() == api.VSphere {
+		migrator, err = vsphere.New(context)
+		if err != nil {
+			return
+		}
+		log.Info("Built migrator.", "plan", path.Join(context.Plan.Namespace, context.Plan.Name), "destination", api.VSphere)
+		return
+	}
 	switch context.Source.Provider.Type() {
 	case api.OpenShift:
 		migrator, err = ocp.New(context)
@@ -178,7 +178,7 @@ func (r *LiveMigrator) Status(vm planapi.VM) (status *planapi.VMStatus) {
 }
 
 func (r *LiveMigrator) Reset(vm *planapi.VMStatus, pipeline []*planapi.Step) {
-	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed)
+	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed, api.ConditionRolledBack)
 	vm.MarkReset()
 	itr := r.Itinerary(vm.VM)
 	step, _ := itr.First()
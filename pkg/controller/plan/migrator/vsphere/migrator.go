@@ -0,0 +1,388 @@
+package vsphere
+
+import (
+	"fmt"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/controller/plan/adapter"
+	adapterbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	"github.com/kubev2v/forklift/pkg/controller/plan/migrator/base"
+	"github.com/kubev2v/forklift/pkg/controller/plan/migrator/ocp"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
+	"github.com/kubev2v/forklift/pkg/lib/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Phases
+const (
+	Started          = api.PhaseStarted
+	PreHook          = api.PhasePreHook
+	StorePowerState  = api.PhaseStorePowerState
+	PowerOffSource   = api.PhasePowerOffSource
+	WaitForPowerOff  = api.PhaseWaitForPowerOff
+	ExportVM         = "ExportVM"
+	CreateUploadPod  = "CreateUploadPod"
+	UploadDisks      = "UploadDisks"
+	CreateVM         = api.PhaseCreateVM
+	PostHook         = api.PhasePostHook
+	Completed        = api.PhaseCompleted
+	uploadContainer  = "vmdk-upload"
+	uploadSpecPath   = "/etc/vmdk-upload"
+	uploadSpecVolume = "spec"
+)
+
+// Predicate flags.
+const (
+	FlagPreHook  libitr.Flag = 0x01
+	FlagPostHook libitr.Flag = 0x02
+)
+
+// Migrator for plans with a vSphere destination.
+// The source VM is exported by KubeVirt (VirtualMachineExport), its
+// disks are uploaded to the mapped datastores as VMDK disks by a
+// vmdk-upload pod running on the host cluster, and the VM is created
+// from its KubeVirt definition through the vSphere API.
+type Migrator struct {
+	*plancontext.Context
+	// Source cluster client.
+	sourceClient client.Client
+	// Source provider client (exports the VMs).
+	provider adapterbase.Client
+	// Source provider builder (disk transfer tasks).
+	builder adapterbase.Builder
+}
+
+// New builds a migrator for a plan with a vSphere destination.
+func New(context *plancontext.Context) (migrator base.Migrator, err error) {
+	m := &Migrator{Context: context}
+	err = m.Init()
+	if err != nil {
+		return
+	}
+	migrator = m
+	return
+}
+
+// Init the migrator.
+func (r *Migrator) Init() (err error) {
+	if r.Source.Provider.Type() != api.OpenShift {
+		err = liberr.New(
+			fmt.Sprintf(
+				"Provider type '%s' not supported as the source of a vSphere destination.",
+				r.Source.Provider.Type()))
+		return
+	}
+	r.sourceClient, err = ocp.K8sClient(r.Source.Provider)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	adp, err := adapter.New(r.Source.Provider)
+	if err != nil {
+		return
+	}
+	r.provider, err = adp.Client(r.Context)
+	if err != nil {
+		return
+	}
+	r.builder, err = adp.Builder(r.Context)
+	if err != nil {
+		return
+	}
+	return
+}
+
+func (r *Migrator) Logger() (logger logging.LevelLogger) {
+	return r.Log
+}
+
+// Begin the migration process. This is called once at the beginning
+// of a migration plan, before any of the VMs begin to migrate.
+func (r *Migrator) Begin() (err error) {
+	return
+}
+
+// Complete a VM migration. The upload pod and the secret holding the
+// credentials are removed.
+func (r *Migrator) Complete(vm *planapi.VMStatus) {
+	err := r.deleteUploadResources(vm)
+	if err != nil {
+		r.Log.Error(err, "Unable to clean up the upload pod.", "vm", vm.String())
+	}
+}
+
+func (r *Migrator) Status(vm planapi.VM) (status *planapi.VMStatus) {
+	if current, found := r.Context.Plan.Status.Migration.FindVM(vm.Ref); !found {
+		status = &planapi.VMStatus{VM: vm}
+	} else {
+		status = current
+	}
+	return
+}
+
+func (r *Migrator) Reset(vm *planapi.VMStatus, pipeline []*planapi.Step) {
+	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed, api.ConditionRolledBack)
+	vm.MarkReset()
+	itr := r.Itinerary(vm.VM)
+	step, _ := itr.First()
+	vm.Phase = step.Name
+	vm.Pipeline = pipeline
+	vm.Error = nil
+	vm.Warm = nil
+}
+
+func (r *Migrator) Itinerary(vm planapi.VM) (itinerary *libitr.Itinerary) {
+	itinerary = &libitr.Itinerary{
+		Name: "vsphere-destination",
+		Pipeline: libitr.Pipeline{
+			{Name: Started},
+			{Name: PreHook, All: FlagPreHook},
+			{Name: StorePowerState},
+			{Name: PowerOffSource},
+			{Name: WaitForPowerOff},
+			{Name: ExportVM},
+			{Name: CreateUploadPod},
+			{Name: UploadDisks},
+			{Name: CreateVM},
+			{Name: PostHook, All: FlagPostHook},
+			{Name: Completed},
+		},
+		Predicate: &Predicate{vm: &vm},
+	}
+	return
+}
+
+func (r *Migrator) Step(vm *planapi.VMStatus) (step string) {
+	switch vm.Phase {
+	case Started, StorePowerState, PowerOffSource, WaitForPowerOff:
+		step = base.Initialize
+	case PreHook, PostHook:
+		step = vm.Phase
+	case ExportVM, CreateUploadPod, UploadDisks:
+		step = base.DiskTransfer
+	case CreateVM:
+		step = base.VMCreation
+	default:
+		step = base.Unknown
+	}
+	return
+}
+
+func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error) {
+	itinerary := r.Itinerary(vm)
+	step, _ := itinerary.First()
+	for {
+		switch step.Name {
+		case Started:
+			pipeline = append(
+				pipeline,
+				&planapi.Step{
+					Task: planapi.Task{
+						Name:        base.Initialize,
+						Description: "Initialize migration.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		case PreHook:
+			pipeline = append(
+				pipeline,
+				&planapi.Step{
+					Task: planapi.Task{
+						Name:        PreHook,
+						Description: "Run pre-migration hook.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		case ExportVM:
+			var tasks []*planapi.Task
+			tasks, err = r.builder.Tasks(vm.Ref)
+			if err != nil {
+				return
+			}
+			total := int64(0)
+			for _, task := range tasks {
+				total += task.Progress.Total
+			}
+			pipeline = append(
+				pipeline,
+				&planapi.Step{
+					Task: planapi.Task{
+						Name:        base.DiskTransfer,
+						Description: "Upload disks to the vSphere datastores.",
+						Progress:    libitr.Progress{Total: total},
+						Annotations: map[string]string{
+							"unit": "MB",
+						},
+						Phase: api.StepPending,
+					},
+					Tasks: tasks,
+				})
+		case CreateVM:
+			pipeline = append(
+				pipeline,
+				&planapi.Step{
+					Task: planapi.Task{
+						Name:        base.VMCreation,
+						Description: "Create VM on vSphere.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		case PostHook:
+			pipeline = append(
+				pipeline,
+				&planapi.Step{
+					Task: planapi.Task{
+						Name:        PostHook,
+						Description: "Run post-migration hook.",
+						Progress:    libitr.Progress{Total: 1},
+						Phase:       api.StepPending,
+					},
+				})
+		}
+		next, done, _ := itinerary.Next(step.Name)
+		if !done {
+			step = next
+		} else {
+			break
+		}
+	}
+
+	r.Log.V(2).Info(
+		"Pipeline built.",
+		"vm",
+		vm.String())
+	return
+}
+
+// NextPhase transitions the VM to the next migration phase.
+func (r *Migrator) NextPhase(vm *planapi.VMStatus) {
+	base.NextPhase(r, vm)
+}
+
+func (r *Migrator) StepError(vm *planapi.VMStatus, err error) {
+	step, found := vm.FindStep(r.Step(vm))
+	if !found {
+		vm.AddError(fmt.Sprintf("Step '%s' not found", r.Step(vm)))
+		return
+	}
+	step.AddError(err.Error())
+}
+
+// ExecutePhase provides implementations of VM migration phases.
+// The hooks and the source power management are delegated to the
+// common pipeline.
+func (r *Migrator) ExecutePhase(vm *planapi.VMStatus) (ok bool, err error) {
+	ok = true
+	switch vm.Phase {
+	case Started:
+		err = r.begin(vm)
+		if err != nil {
+			r.StepError(vm, err)
+			err = nil
+			break
+		}
+		r.NextPhase(vm)
+	case PreHook, PostHook, StorePowerState, PowerOffSource, WaitForPowerOff, Completed:
+		ok = false
+	case ExportVM:
+		r.markStepRunning(vm)
+		var ready bool
+		ready, err = r.provider.PreTransferActions(vm.Ref)
+		if err != nil {
+			r.StepError(vm, err)
+			err = nil
+			break
+		}
+		if ready {
+			r.NextPhase(vm)
+		}
+	case CreateUploadPod:
+		err = r.ensureUploadPod(vm)
+		if err != nil {
+			r.StepError(vm, err)
+			err = nil
+			break
+		}
+		r.NextPhase(vm)
+	case UploadDisks:
+		var done bool
+		done, err = r.uploadDone(vm)
+		if err != nil {
+			r.StepError(vm, err)
+			err = nil
+			break
+		}
+		if done {
+			r.NextPhase(vm)
+		}
+	case CreateVM:
+		r.markStepRunning(vm)
+		err = r.createVM(vm)
+		if err != nil {
+			r.StepError(vm, err)
+			err = nil
+			break
+		}
+		r.NextPhase(vm)
+	default:
+		ok = false
+	}
+	return
+}
+
+// Mark the step of the current phase started.
+func (r *Migrator) markStepRunning(vm *planapi.VMStatus) {
+	step, found := vm.FindStep(r.Step(vm))
+	if !found {
+		return
+	}
+	if !step.MarkedStarted() {
+		step.MarkStarted()
+	}
+	step.Phase = api.StepRunning
+}
+
+// Start the migration of a VM. Upload resources left by an earlier
+// attempt are removed.
+func (r *Migrator) begin(vm *planapi.VMStatus) (err error) {
+	vm.MarkStarted()
+	r.markStepRunning(vm)
+	err = r.deleteUploadResources(vm)
+	if err != nil {
+		return
+	}
+	if vm.NewName == "" {
+		vm.NewName = vm.Name
+		if vm.TargetName != "" {
+			vm.NewName = vm.TargetName
+		}
+	}
+	return
+}
+
+// Predicate for the optional phases.
+type Predicate struct {
+	vm *planapi.VM
+}
+
+// Evaluate predicate flags.
+func (r *Predicate) Evaluate(flag libitr.Flag) (allowed bool, err error) {
+	switch flag {
+	case FlagPreHook:
+		_, allowed = r.vm.FindHook(PreHook)
+	case FlagPostHook:
+		_, allowed = r.vm.FindHook(PostHook)
+	}
+	return
+}
+
+// Count of predicate flags.
+func (r *Predicate) Count() int {
+	return 0x02
+}
@@ -0,0 +1,442 @@
+package vsphere
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	"github.com/kubev2v/forklift/pkg/controller/base"
+	convctx "github.com/kubev2v/forklift/pkg/controller/conversion/context"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/lib/vmdk"
+	"github.com/kubev2v/forklift/pkg/settings"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cnv "kubevirt.io/api/core/v1"
+	export "kubevirt.io/api/export/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Labels
+const (
+	// Application label of the upload pod and secret.
+	kApp = "forklift.app"
+)
+
+// QEMU user of the virt-v2v image.
+const qemuUser = int64(107)
+
+// Disk of the source VM.
+type sourceDisk struct {
+	// Name of the VM volume.
+	Volume string
+	// Name of the PVC, also the name of the exported volume.
+	Claim string
+	// Target datastore.
+	Datastore string
+	// Path of the VMDK descriptor in the datastore.
+	Path string
+}
+
+// Labels of the upload pod and secret of a VM.
+func (r *Migrator) uploadLabels(vm *planapi.VMStatus) (labels map[string]string) {
+	labels = r.Labeler.PlanVMLabels(vm.Ref)
+	labels[kApp] = uploadContainer
+	return
+}
+
+// Get the source VM.
+func (r *Migrator) sourceVM(vm *planapi.VMStatus) (source *cnv.VirtualMachine, err error) {
+	source = &cnv.VirtualMachine{}
+	err = r.sourceClient.Get(
+		context.TODO(),
+		client.ObjectKey{Namespace: vm.Namespace, Name: vm.Name},
+		source)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vm.String())
+	}
+	return
+}
+
+// Disks of the source VM and their location in vSphere. The datastore
+// of a disk is mapped from the storage class of its PVC.
+func (r *Migrator) disks(vm *planapi.VMStatus, source *cnv.VirtualMachine) (disks []sourceDisk, err error) {
+	for _, volume := range source.Spec.Template.Spec.Volumes {
+		var claim string
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			claim = volume.PersistentVolumeClaim.ClaimName
+		case volume.DataVolume != nil:
+			claim = volume.DataVolume.Name
+		default:
+			continue
+		}
+		pvc := &core.PersistentVolumeClaim{}
+		err = r.sourceClient.Get(
+			context.TODO(),
+			client.ObjectKey{Namespace: vm.Namespace, Name: claim},
+			pvc)
+		if err != nil {
+			err = liberr.Wrap(err, "pvc", claim)
+			return
+		}
+		storageClass := ""
+		if pvc.Spec.StorageClassName != nil {
+			storageClass = *pvc.Spec.StorageClassName
+		}
+		pair, found := r.Map.Storage.FindStorageByName(storageClass)
+		if !found || pair.Destination.Datastore == "" {
+			err = liberr.New(
+				"Storage class not mapped to a datastore.",
+				"pvc", claim,
+				"storageClass", storageClass)
+			return
+		}
+		disks = append(
+			disks,
+			sourceDisk{
+				Volume:    volume.Name,
+				Claim:     claim,
+				Datastore: pair.Destination.Datastore,
+				Path:      fmt.Sprintf("%s/%s_%d.vmdk", vm.NewName, vm.NewName, len(disks)),
+			})
+	}
+	return
+}
+
+// Find the cluster the VM is created in: the first cluster with
+// access to the datastore holding its first disk. VMs without disks
+// use the first datastore of the storage map.
+func (r *Migrator) placement(disks []sourceDisk) (cluster *model.Cluster, err error) {
+	var datastore string
+	if len(disks) > 0 {
+		datastore = disks[0].Datastore
+	} else {
+		for _, pair := range r.Map.Storage.Spec.Map {
+			if pair.Destination.Datastore != "" {
+				datastore = pair.Destination.Datastore
+				break
+			}
+		}
+	}
+	if datastore == "" {
+		err = liberr.New("No datastore mapped.")
+		return
+	}
+	dsRef := &ref.Ref{Name: datastore}
+	_, err = r.Destination.Inventory.Storage(dsRef)
+	if err != nil {
+		err = liberr.Wrap(err, "datastore", datastore)
+		return
+	}
+	clusters := []model.Cluster{}
+	err = r.Destination.Inventory.List(
+		&clusters,
+		web.Param{
+			Key:   web.DetailParam,
+			Value: "all",
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	for i := range clusters {
+		for _, ds := range clusters[i].Datastores {
+			if ds.ID == dsRef.ID {
+				cluster = &clusters[i]
+				return
+			}
+		}
+	}
+	err = liberr.New("No cluster found with access to the datastore.", "datastore", datastore)
+	return
+}
+
+// Inventory path of the datacenter of a cluster.
+func datacenterPath(clusterPath string) string {
+	if i := strings.Index(clusterPath, "/host/"); i > 0 {
+		return clusterPath[:i]
+	}
+	return path.Dir(path.Dir(clusterPath))
+}
+
+// Ensure the pod uploading the disks of a VM to the datastores. The
+// upload spec, including the credentials, is stored in a secret
+// mounted by the pod.
+func (r *Migrator) ensureUploadPod(vm *planapi.VMStatus) (err error) {
+	pod, err := r.uploadPod(vm)
+	if err != nil || pod != nil {
+		return
+	}
+	source, err := r.sourceVM(vm)
+	if err != nil {
+		return
+	}
+	disks, err := r.disks(vm, source)
+	if err != nil || len(disks) == 0 {
+		return
+	}
+	cluster, err := r.placement(disks)
+	if err != nil {
+		return
+	}
+	spec, err := r.uploadSpec(vm, disks)
+	if err != nil {
+		return
+	}
+	spec.Datacenter = datacenterPath(cluster.Path)
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	labels := r.uploadLabels(vm)
+	secret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: r.generatedName(vm),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Data: map[string][]byte{
+			"spec.json": specJSON,
+		},
+	}
+	err = r.Destination.Client.Create(context.TODO(), secret)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	pod = r.uploadPodSpec(vm, secret.Name, labels)
+	err = r.Destination.Client.Create(context.TODO(), pod)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.Log.Info(
+		"Created upload pod.",
+		"pod", path.Join(pod.Namespace, pod.Name),
+		"vm", vm.String())
+	return
+}
+
+// Build the upload spec from the VirtualMachineExport of a VM.
+func (r *Migrator) uploadSpec(vm *planapi.VMStatus, disks []sourceDisk) (spec *vmdk.Spec, err error) {
+	vmExport := &export.VirtualMachineExport{}
+	err = r.sourceClient.Get(
+		context.TODO(),
+		client.ObjectKey{Namespace: vm.Namespace, Name: vm.Name},
+		vmExport)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vm.String())
+		return
+	}
+	if vmExport.Status == nil ||
+		vmExport.Status.Links == nil ||
+		vmExport.Status.Links.External == nil ||
+		vmExport.Status.TokenSecretRef == nil {
+		err = liberr.New("VM export has no external link.", "vm", vm.String())
+		return
+	}
+	links := vmExport.Status.Links.External
+	token := &core.Secret{}
+	err = r.sourceClient.Get(
+		context.TODO(),
+		client.ObjectKey{Namespace: vm.Namespace, Name: *vmExport.Status.TokenSecretRef},
+		token)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vm.String())
+		return
+	}
+	provider := r.Destination.Provider
+	secret := r.Destination.Secret
+	spec = &vmdk.Spec{
+		URL:        provider.Spec.URL,
+		User:       string(secret.Data["user"]),
+		Password:   string(secret.Data["password"]),
+		Thumbprint: provider.Status.Fingerprint,
+		Insecure:   base.GetInsecureSkipVerifyFlag(secret),
+		Token:      string(token.Data["token"]),
+		CACert:     links.Cert,
+	}
+	for _, disk := range disks {
+		url := rawURL(links, disk.Claim)
+		if url == "" {
+			err = liberr.New("Raw image of the volume not exported.", "vm", vm.String(), "pvc", disk.Claim)
+			return
+		}
+		spec.Disks = append(
+			spec.Disks,
+			vmdk.Disk{
+				ID:        path.Join(vm.Namespace, disk.Claim),
+				URL:       url,
+				Datastore: disk.Datastore,
+				Path:      disk.Path,
+			})
+	}
+	return
+}
+
+// URL of the raw image of an exported volume.
+func rawURL(links *export.VirtualMachineExportLink, volume string) string {
+	for _, exported := range links.Volumes {
+		if exported.Name != volume {
+			continue
+		}
+		for _, format := range exported.Formats {
+			if format.Format == export.KubeVirtRaw {
+				return format.Url
+			}
+		}
+	}
+	return ""
+}
+
+// Build the upload pod.
+func (r *Migrator) uploadPodSpec(vm *planapi.VMStatus, secret string, labels map[string]string) *core.Pod {
+	cfg := convctx.PodConfigFromPlan(r.Plan)
+	nonRoot := true
+	allowPrivilegeEscalation := false
+	user := qemuUser
+	return &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: r.generatedName(vm),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Spec: core.PodSpec{
+			SecurityContext: &core.PodSecurityContext{
+				RunAsNonRoot:   &nonRoot,
+				SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+			},
+			RestartPolicy:      core.RestartPolicyNever,
+			ServiceAccountName: cmp.Or(r.Plan.Spec.ServiceAccount, settings.Settings.Migration.ServiceAccount),
+			Containers: []core.Container{
+				{
+					Name:    uploadContainer,
+					Image:   convctx.GetVirtV2vImage(&cfg),
+					Command: []string{"/usr/local/bin/vmdk-upload"},
+					Args:    []string{"-spec", path.Join(uploadSpecPath, "spec.json")},
+					VolumeMounts: []core.VolumeMount{
+						{
+							Name:      uploadSpecVolume,
+							MountPath: uploadSpecPath,
+							ReadOnly:  true,
+						},
+					},
+					SecurityContext: &core.SecurityContext{
+						AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+						RunAsUser:                &user,
+						Capabilities:             &core.Capabilities{Drop: []core.Capability{"ALL"}},
+					},
+				},
+			},
+			Volumes: []core.Volume{
+				{
+					Name: uploadSpecVolume,
+					VolumeSource: core.VolumeSource{
+						Secret: &core.SecretVolumeSource{
+							SecretName: secret,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Generated name of the upload resources.
+func (r *Migrator) generatedName(vm *planapi.VMStatus) string {
+	return strings.Join([]string{r.Plan.Name, vm.ID, uploadContainer}, "-") + "-"
+}
+
+// Get the upload pod of a VM, or nil when not found.
+func (r *Migrator) uploadPod(vm *planapi.VMStatus) (pod *core.Pod, err error) {
+	list := &core.PodList{}
+	err = r.Destination.Client.List(
+		context.TODO(),
+		list,
+		client.InNamespace(r.Plan.Spec.TargetNamespace),
+		client.MatchingLabels(r.uploadLabels(vm)))
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if len(list.Items) > 0 {
+		pod = &list.Items[0]
+	}
+	return
+}
+
+// Check whether the disks of a VM are uploaded. The transfer tasks
+// are completed when the upload pod succeeds. A failed upload is
+// reported with the termination message of the pod.
+func (r *Migrator) uploadDone(vm *planapi.VMStatus) (done bool, err error) {
+	pod, err := r.uploadPod(vm)
+	if err != nil {
+		return
+	}
+	if pod == nil {
+		// VMs without disks do not upload anything.
+		var source *cnv.VirtualMachine
+		source, err = r.sourceVM(vm)
+		if err != nil {
+			return
+		}
+		var disks []sourceDisk
+		disks, err = r.disks(vm, source)
+		if err != nil {
+			return
+		}
+		if len(disks) > 0 {
+			err = liberr.New("Upload pod not found.", "vm", vm.String())
+			return
+		}
+		done = true
+		return
+	}
+	switch pod.Status.Phase {
+	case core.PodSucceeded:
+		if step, found := vm.FindStep(r.Step(vm)); found {
+			for _, task := range step.Tasks {
+				task.Progress.Completed = task.Progress.Total
+				task.MarkCompleted()
+			}
+			step.Progress.Completed = step.Progress.Total
+		}
+		done = true
+	case core.PodFailed:
+		message := "Upload pod failed."
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.Message != "" {
+				message = status.State.Terminated.Message
+			}
+		}
+		err = liberr.New(message, "pod", path.Join(pod.Namespace, pod.Name))
+	}
+	return
+}
+
+// Delete the upload pods and secrets of a VM.
+func (r *Migrator) deleteUploadResources(vm *planapi.VMStatus) (err error) {
+	labels := r.uploadLabels(vm)
+	for _, object := range []client.Object{&core.Pod{}, &core.Secret{}} {
+		err = r.Destination.Client.DeleteAllOf(
+			context.TODO(),
+			object,
+			client.InNamespace(r.Plan.Spec.TargetNamespace),
+			client.MatchingLabels(labels),
+			client.PropagationPolicy(meta.DeletePropagationBackground))
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,420 @@
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	ocpadapter "github.com/kubev2v/forklift/pkg/controller/plan/adapter/ocp"
+	vsphereadapter "github.com/kubev2v/forklift/pkg/controller/plan/adapter/vsphere"
+	vspheremodel "github.com/kubev2v/forklift/pkg/controller/provider/model/vsphere"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
+	ocpclient "github.com/kubev2v/forklift/pkg/lib/client/openshift"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	core "k8s.io/api/core/v1"
+	cnv "kubevirt.io/api/core/v1"
+	kubevirtapi "kubevirt.io/api/instancetype"
+	instancetype "kubevirt.io/api/instancetype/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Annotation holding the guest OS of a KubeVirt VM.
+const AnnOS = "vm.kubevirt.io/os"
+
+// Unit number reserved for the SCSI controller.
+const scsiControllerUnit = 7
+
+// Hardware of the VM created on vSphere.
+type hardware struct {
+	CPUs           int32
+	CoresPerSocket int32
+	MemoryMB       int64
+	EFI            bool
+	SecureBoot     bool
+}
+
+// Network adapter of the VM created on vSphere.
+type nic struct {
+	Backing types.BaseVirtualDeviceBackingInfo
+	MAC     string
+}
+
+// Create the VM on vSphere with the uploaded disks, then power it on
+// when the source VM was running. A VM already created by an earlier
+// attempt is reused.
+func (r *Migrator) createVM(vm *planapi.VMStatus) (err error) {
+	source, err := r.sourceVM(vm)
+	if err != nil {
+		return
+	}
+	disks, err := r.disks(vm, source)
+	if err != nil {
+		return
+	}
+	cluster, err := r.placement(disks)
+	if err != nil {
+		return
+	}
+	hw, err := r.hardware(source)
+	if err != nil {
+		return
+	}
+	ctx := context.TODO()
+	vsphere, err := vsphereadapter.Connect(r.Destination.Provider, r.Destination.Secret)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = vsphere.Logout(ctx)
+	}()
+	finder := find.NewFinder(vsphere.Client)
+	datacenter, err := finder.Datacenter(ctx, datacenterPath(cluster.Path))
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	finder.SetDatacenter(datacenter)
+	folders, err := datacenter.Folders(ctx)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	target, err := finder.VirtualMachine(ctx, path.Join(folders.VmFolder.InventoryPath, vm.NewName))
+	if err != nil {
+		notFound := &find.NotFoundError{}
+		if !errors.As(err, &notFound) {
+			err = liberr.Wrap(err)
+			return
+		}
+		var computeResource *object.ClusterComputeResource
+		computeResource, err = finder.ClusterComputeResource(ctx, cluster.Path)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		var pool *object.ResourcePool
+		pool, err = computeResource.ResourcePool(ctx)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		var nics []nic
+		nics, err = r.nics(ctx, vsphere.Client, vm, source)
+		if err != nil {
+			return
+		}
+		datastore := ""
+		if len(disks) > 0 {
+			datastore = disks[0].Datastore
+		} else if ds, found := r.firstDatastore(); found {
+			datastore = ds
+		}
+		spec := configSpec(vm.NewName, datastore, guestID(source), hw, disks, nics)
+		var task *object.Task
+		task, err = folders.VmFolder.CreateVM(ctx, spec, pool, nil)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		var info *types.TaskInfo
+		info, err = task.WaitForResult(ctx, nil)
+		if err != nil {
+			err = liberr.Wrap(err, "vm", vm.String())
+			return
+		}
+		target = object.NewVirtualMachine(vsphere.Client, info.Result.(types.ManagedObjectReference))
+		r.Log.Info(
+			"Created VM on vSphere.",
+			"vm", vm.String(),
+			"target", target.Reference().Value)
+	}
+	if vm.RestorePowerState != planapi.VMPowerStateOn {
+		return
+	}
+	state, err := target.PowerState(ctx)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if state == types.VirtualMachinePowerStatePoweredOn {
+		return
+	}
+	task, err := target.PowerOn(ctx)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	err = task.Wait(ctx)
+	if err != nil {
+		err = liberr.Wrap(err, "vm", vm.String())
+	}
+	return
+}
+
+// First datastore of the storage map.
+func (r *Migrator) firstDatastore() (datastore string, found bool) {
+	for _, pair := range r.Map.Storage.Spec.Map {
+		if pair.Destination.Datastore != "" {
+			datastore = pair.Destination.Datastore
+			found = true
+			return
+		}
+	}
+	return
+}
+
+// Hardware of the source VM. The CPU and memory of VMs using an
+// instancetype are read from the instancetype.
+func (r *Migrator) hardware(source *cnv.VirtualMachine) (hw hardware, err error) {
+	hw = vmHardware(source)
+	if source.Spec.Instancetype == nil {
+		return
+	}
+	var spec instancetype.VirtualMachineInstancetypeSpec
+	key := client.ObjectKey{Name: source.Spec.Instancetype.Name}
+	if strings.EqualFold(source.Spec.Instancetype.Kind, kubevirtapi.SingularResourceName) ||
+		source.Spec.Instancetype.Kind == "VirtualMachineInstancetype" {
+		key.Namespace = source.Namespace
+		resource := &instancetype.VirtualMachineInstancetype{}
+		err = r.sourceClient.Get(context.TODO(), key, resource)
+		spec = resource.Spec
+	} else {
+		resource := &instancetype.VirtualMachineClusterInstancetype{}
+		err = r.sourceClient.Get(context.TODO(), key, resource)
+		spec = resource.Spec
+	}
+	if err != nil {
+		err = liberr.Wrap(err, "instancetype", key.String())
+		return
+	}
+	hw.CPUs = int32(spec.CPU.Guest)
+	hw.CoresPerSocket = 1
+	hw.MemoryMB = spec.Memory.Guest.Value() / 1024 / 1024
+	return
+}
+
+// Hardware defined in the template of a KubeVirt VM.
+func vmHardware(source *cnv.VirtualMachine) (hw hardware) {
+	hw = hardware{CPUs: 1, CoresPerSocket: 1}
+	domain := &source.Spec.Template.Spec.Domain
+	if cpu := domain.CPU; cpu != nil {
+		sockets := max(cpu.Sockets, 1)
+		cores := max(cpu.Cores, 1)
+		threads := max(cpu.Threads, 1)
+		hw.CPUs = int32(sockets * cores * threads)
+		hw.CoresPerSocket = int32(cores * threads)
+	}
+	switch {
+	case domain.Memory != nil && domain.Memory.Guest != nil:
+		hw.MemoryMB = domain.Memory.Guest.Value() / 1024 / 1024
+	default:
+		if memory, found := domain.Resources.Requests[core.ResourceMemory]; found {
+			hw.MemoryMB = memory.Value() / 1024 / 1024
+		} else if memory, found := domain.Resources.Limits[core.ResourceMemory]; found {
+			hw.MemoryMB = memory.Value() / 1024 / 1024
+		}
+	}
+	if domain.Firmware != nil && domain.Firmware.Bootloader != nil && domain.Firmware.Bootloader.EFI != nil {
+		hw.EFI = true
+		secureBoot := domain.Firmware.Bootloader.EFI.SecureBoot
+		hw.SecureBoot = secureBoot == nil || *secureBoot
+	}
+	return
+}
+
+// Guest OS identifier of the vSphere VM, derived from the OS annotation
+// or the preference of the KubeVirt VM.
+func guestID(source *cnv.VirtualMachine) string {
+	candidates := []string{
+		source.Annotations[AnnOS],
+		source.Spec.Template.ObjectMeta.Annotations[AnnOS],
+	}
+	if source.Spec.Preference != nil {
+		candidates = append(candidates, source.Spec.Preference.Name)
+	}
+	for _, candidate := range candidates {
+		os := strings.ToLower(candidate)
+		switch {
+		case os == "":
+			continue
+		case strings.HasPrefix(os, "win"):
+			if strings.Contains(os, "2k") || strings.Contains(os, "server") {
+				return string(types.VirtualMachineGuestOsIdentifierWindows9Server64Guest)
+			}
+			return string(types.VirtualMachineGuestOsIdentifierWindows9_64Guest)
+		case strings.HasPrefix(os, "rhel"):
+			switch {
+			case strings.HasPrefix(os, "rhel7"), strings.HasPrefix(os, "rhel.7"):
+				return string(types.VirtualMachineGuestOsIdentifierRhel7_64Guest)
+			case strings.HasPrefix(os, "rhel8"), strings.HasPrefix(os, "rhel.8"):
+				return string(types.VirtualMachineGuestOsIdentifierRhel8_64Guest)
+			default:
+				return string(types.VirtualMachineGuestOsIdentifierRhel9_64Guest)
+			}
+		case strings.HasPrefix(os, "centos"):
+			return string(types.VirtualMachineGuestOsIdentifierCentos64Guest)
+		case strings.HasPrefix(os, "fedora"):
+			return string(types.VirtualMachineGuestOsIdentifierFedora64Guest)
+		case strings.HasPrefix(os, "ubuntu"):
+			return string(types.VirtualMachineGuestOsIdentifierUbuntu64Guest)
+		}
+	}
+	return string(types.VirtualMachineGuestOsIdentifierOtherGuest64)
+}
+
+// Network adapters of the vSphere VM, backed by the networks mapped
+// from the networks of the KubeVirt VM.
+func (r *Migrator) nics(ctx context.Context, client *vim25.Client, vm *planapi.VMStatus, source *cnv.VirtualMachine) (nics []nic, err error) {
+	macs := make(map[string]string)
+	for _, iface := range source.Spec.Template.Spec.Domain.Devices.Interfaces {
+		macs[iface.Name] = iface.MacAddress
+	}
+	for _, network := range source.Spec.Template.Spec.Networks {
+		var pair api.NetworkPair
+		var found bool
+		switch {
+		case network.Pod != nil:
+			pair, found = r.Map.Network.FindNetworkByType(ocpadapter.Pod)
+		case network.Multus != nil:
+			name, namespace := ocpclient.GetNetworkNameAndNamespace(network.Multus.NetworkName, &vm.Ref)
+			pair, found = r.Map.Network.FindNetworkByNameAndNamespace(namespace, name)
+		default:
+			continue
+		}
+		if !found {
+			err = liberr.New("Network not mapped.", "vm", vm.String(), "network", network.Name)
+			return
+		}
+		if pair.Destination.Type == ocpadapter.Ignored {
+			continue
+		}
+		var resource interface{}
+		resource, err = r.Destination.Inventory.Network(&ref.Ref{Name: pair.Destination.Name})
+		if err != nil {
+			err = liberr.Wrap(err, "network", pair.Destination.Name)
+			return
+		}
+		var backing types.BaseVirtualDeviceBackingInfo
+		backing, err = networkBacking(ctx, client, resource.(*model.Network))
+		if err != nil {
+			return
+		}
+		nics = append(nics, nic{Backing: backing, MAC: macs[network.Name]})
+	}
+	return
+}
+
+// Backing of a network adapter connected to a vSphere network.
+func networkBacking(ctx context.Context, client *vim25.Client, network *model.Network) (backing types.BaseVirtualDeviceBackingInfo, err error) {
+	moRef := types.ManagedObjectReference{Value: network.ID}
+	switch network.Variant {
+	case vspheremodel.NetDvPortGroup:
+		moRef.Type = "DistributedVirtualPortgroup"
+		backing, err = object.NewDistributedVirtualPortgroup(client, moRef).EthernetCardBackingInfo(ctx)
+	case vspheremodel.OpaqueNetwork:
+		moRef.Type = "OpaqueNetwork"
+		backing, err = object.NewOpaqueNetwork(client, moRef).EthernetCardBackingInfo(ctx)
+	default:
+		moRef.Type = "Network"
+		backing, err = object.NewNetwork(client, moRef).EthernetCardBackingInfo(ctx)
+	}
+	if err != nil {
+		err = liberr.Wrap(err, "network", network.Name)
+	}
+	return
+}
+
+// Build the config spec of the vSphere VM. The disks are attached to a
+// paravirtual SCSI controller and the network adapters are vmxnet3.
+func configSpec(name, datastore, guest string, hw hardware, disks []sourceDisk, nics []nic) (spec types.VirtualMachineConfigSpec) {
+	spec = types.VirtualMachineConfigSpec{
+		Name:              name,
+		GuestId:           guest,
+		NumCPUs:           hw.CPUs,
+		NumCoresPerSocket: hw.CoresPerSocket,
+		MemoryMB:          hw.MemoryMB,
+		Firmware:          string(types.GuestOsDescriptorFirmwareTypeBios),
+		Files: &types.VirtualMachineFileInfo{
+			VmPathName: fmt.Sprintf("[%s]", datastore),
+		},
+	}
+	if hw.EFI {
+		spec.Firmware = string(types.GuestOsDescriptorFirmwareTypeEfi)
+		secureBoot := hw.SecureBoot
+		spec.BootOptions = &types.VirtualMachineBootOptions{
+			EfiSecureBootEnabled: &secureBoot,
+		}
+	}
+	controllerKey := int32(-1)
+	spec.DeviceChange = append(
+		spec.DeviceChange,
+		&types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device: &types.ParaVirtualSCSIController{
+				VirtualSCSIController: types.VirtualSCSIController{
+					VirtualController: types.VirtualController{
+						VirtualDevice: types.VirtualDevice{Key: controllerKey},
+						BusNumber:     0,
+					},
+					SharedBus: types.VirtualSCSISharingNoSharing,
+				},
+			},
+		})
+	unit := int32(0)
+	for i, disk := range disks {
+		if unit == scsiControllerUnit {
+			unit++
+		}
+		unitNumber := unit
+		spec.DeviceChange = append(
+			spec.DeviceChange,
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device: &types.VirtualDisk{
+					VirtualDevice: types.VirtualDevice{
+						Key:           int32(-100 - i),
+						ControllerKey: controllerKey,
+						UnitNumber:    &unitNumber,
+						Backing: &types.VirtualDiskFlatVer2BackingInfo{
+							VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+								FileName: fmt.Sprintf("[%s] %s", disk.Datastore, disk.Path),
+							},
+							DiskMode: string(types.VirtualDiskModePersistent),
+						},
+					},
+				},
+			})
+		unit++
+	}
+	for i, nic := range nics {
+		card := types.VirtualEthernetCard{
+			VirtualDevice: types.VirtualDevice{
+				Key:     int32(-200 - i),
+				Backing: nic.Backing,
+			},
+			AddressType: string(types.VirtualEthernetCardMacTypeGenerated),
+		}
+		if nic.MAC != "" {
+			card.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+			card.MacAddress = nic.MAC
+		}
+		spec.DeviceChange = append(
+			spec.DeviceChange,
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device: &types.VirtualVmxnet3{
+					VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: card},
+				},
+			})
+	}
+	return
+}
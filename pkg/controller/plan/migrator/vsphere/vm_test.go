@@ -0,0 +1,121 @@
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cnv "kubevirt.io/api/core/v1"
+)
+
+func TestVMHardware(t *testing.T) {
+	memory := resource.MustParse("4Gi")
+	secureBoot := false
+	source := &cnv.VirtualMachine{
+		Spec: cnv.VirtualMachineSpec{
+			Template: &cnv.VirtualMachineInstanceTemplateSpec{
+				Spec: cnv.VirtualMachineInstanceSpec{
+					Domain: cnv.DomainSpec{
+						CPU:    &cnv.CPU{Sockets: 2, Cores: 2},
+						Memory: &cnv.Memory{Guest: &memory},
+						Firmware: &cnv.Firmware{
+							Bootloader: &cnv.Bootloader{
+								EFI: &cnv.EFI{SecureBoot: &secureBoot},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	hw := vmHardware(source)
+	if hw.CPUs != 4 || hw.CoresPerSocket != 2 {
+		t.Errorf("CPUs = %d, cores per socket = %d", hw.CPUs, hw.CoresPerSocket)
+	}
+	if hw.MemoryMB != 4096 {
+		t.Errorf("MemoryMB = %d", hw.MemoryMB)
+	}
+	if !hw.EFI || hw.SecureBoot {
+		t.Errorf("EFI = %v, SecureBoot = %v", hw.EFI, hw.SecureBoot)
+	}
+}
+
+func TestGuestID(t *testing.T) {
+	cases := []struct {
+		os, preference, want string
+	}{
+		{"rhel9", "", string(types.VirtualMachineGuestOsIdentifierRhel9_64Guest)},
+		{"", "rhel.8", string(types.VirtualMachineGuestOsIdentifierRhel8_64Guest)},
+		{"windows2k19", "", string(types.VirtualMachineGuestOsIdentifierWindows9Server64Guest)},
+		{"", "windows.11", string(types.VirtualMachineGuestOsIdentifierWindows9_64Guest)},
+		{"", "", string(types.VirtualMachineGuestOsIdentifierOtherGuest64)},
+	}
+	for _, c := range cases {
+		source := &cnv.VirtualMachine{
+			ObjectMeta: meta.ObjectMeta{
+				Annotations: map[string]string{AnnOS: c.os},
+			},
+			Spec: cnv.VirtualMachineSpec{
+				Template: &cnv.VirtualMachineInstanceTemplateSpec{},
+			},
+		}
+		if c.preference != "" {
+			source.Spec.Preference = &cnv.PreferenceMatcher{Name: c.preference}
+		}
+		if got := guestID(source); got != c.want {
+			t.Errorf("guestID(%q, %q) = %s, want %s", c.os, c.preference, got, c.want)
+		}
+	}
+}
+
+func TestConfigSpec(t *testing.T) {
+	var disks []sourceDisk
+	for range 8 {
+		disks = append(disks, sourceDisk{Datastore: "ds1", Path: "vm/vm_0.vmdk"})
+	}
+	nics := []nic{
+		{Backing: &types.VirtualEthernetCardNetworkBackingInfo{}, MAC: "02:00:00:00:00:01"},
+		{Backing: &types.VirtualEthernetCardNetworkBackingInfo{}},
+	}
+	spec := configSpec("vm", "ds1", "rhel9_64Guest", hardware{CPUs: 2, CoresPerSocket: 1, MemoryMB: 2048, EFI: true}, disks, nics)
+	if spec.Files.VmPathName != "[ds1]" || spec.Firmware != "efi" || spec.BootOptions == nil {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+	if len(spec.DeviceChange) != 1+len(disks)+len(nics) {
+		t.Fatalf("device changes = %d", len(spec.DeviceChange))
+	}
+	for i := range disks {
+		disk := spec.DeviceChange[1+i].GetVirtualDeviceConfigSpec().Device.(*types.VirtualDisk)
+		if *disk.UnitNumber == scsiControllerUnit {
+			t.Errorf("disk %d uses the unit of the SCSI controller", i)
+		}
+		backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		if backing.FileName != "[ds1] vm/vm_0.vmdk" {
+			t.Errorf("disk %d file = %s", i, backing.FileName)
+		}
+	}
+	if unit := *spec.DeviceChange[len(disks)].GetVirtualDeviceConfigSpec().Device.(*types.VirtualDisk).UnitNumber; unit != 8 {
+		t.Errorf("last disk unit = %d, want 8", unit)
+	}
+	card := spec.DeviceChange[1+len(disks)].GetVirtualDeviceConfigSpec().Device.(*types.VirtualVmxnet3)
+	if card.AddressType != string(types.VirtualEthernetCardMacTypeManual) || card.MacAddress != "02:00:00:00:00:01" {
+		t.Errorf("NIC address = %s %s", card.AddressType, card.MacAddress)
+	}
+	card = spec.DeviceChange[2+len(disks)].GetVirtualDeviceConfigSpec().Device.(*types.VirtualVmxnet3)
+	if card.AddressType != string(types.VirtualEthernetCardMacTypeGenerated) {
+		t.Errorf("NIC address type = %s", card.AddressType)
+	}
+}
+
+func TestDatacenterPath(t *testing.T) {
+	cases := map[string]string{
+		"/DC/host/Cluster":            "/DC",
+		"/Folder/DC/host/Folder/Test": "/Folder/DC",
+	}
+	for clusterPath, want := range cases {
+		if got := datacenterPath(clusterPath); got != want {
+			t.Errorf("datacenterPath(%q) = %q, want %q", clusterPath, got, want)
+		}
+	}
+}
@@ -220,3 +220,48 @@ func RequestForMigration(ctx context.Context, a client.Object) (list []reconcile
 
 	return
 }
+
+type MigrationPolicyPredicate struct {
+	predicate.TypedFuncs[*api.MigrationPolicy]
+}
+
+func (r MigrationPolicyPredicate) Create(e event.TypedCreateEvent[*api.MigrationPolicy]) bool {
+	return true
+}
+
+func (r MigrationPolicyPredicate) Update(e event.TypedUpdateEvent[*api.MigrationPolicy]) bool {
+	changed := e.ObjectOld.Generation != e.ObjectNew.Generation
+	return changed
+}
+
+func (r MigrationPolicyPredicate) Delete(e event.TypedDeleteEvent[*api.MigrationPolicy]) bool {
+	return true
+}
+
+func (r MigrationPolicyPredicate) Generic(e event.TypedGenericEvent[*api.MigrationPolicy]) bool {
+	return false
+}
+
+// Plan requests for MigrationPolicy.
+// The plans in the policy namespace are validated again.
+func RequestForMigrationPolicy(ctx context.Context, reader client.Reader, a *api.MigrationPolicy) (list []reconcile.Request) {
+	plans := &api.PlanList{}
+	err := reader.List(ctx, plans, client.InNamespace(a.Namespace))
+	if err != nil {
+		log.Trace(err)
+		return
+	}
+	for i := range plans.Items {
+		plan := &plans.Items[i]
+		list = append(
+			list,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: plan.Namespace,
+					Name:      plan.Name,
+				},
+			})
+	}
+
+	return
+}
@@ -0,0 +1,181 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	core "k8s.io/api/core/v1"
+)
+
+// Rollback event reasons.
+const (
+	RollbackStarted   = "RollbackStarted"
+	RollbackSucceeded = "RollbackSucceeded"
+	RollbackFailed    = "RollbackFailed"
+	VMRolledBack      = "VMRolledBack"
+	VMRollbackFailed  = "VMRollbackFailed"
+)
+
+// Roll back the VMs migrated by the plan to the source.
+// Only the VMs that succeeded are rolled back. A VM that could not
+// be rolled back keeps its error and is rolled back again by the
+// next rollback.
+func (r *Migration) rollback() (reQ time.Duration, err error) {
+	snapshot := r.Plan.Status.Migration.ActiveSnapshot()
+	if !snapshot.HasCondition(api.ConditionExecuting) {
+		r.Plan.Status.Migration.MarkReset()
+		r.Plan.Status.Migration.MarkStarted()
+		snapshot.SetCondition(
+			libcnd.Condition{
+				Type:     api.ConditionExecuting,
+				Status:   True,
+				Category: api.CategoryAdvisory,
+				Message:  "The plan is ROLLING BACK.",
+				Durable:  true,
+			})
+		r.Log.Info("Rollback [STARTED]")
+		r.event(core.EventTypeNormal, RollbackStarted, "The plan rollback has STARTED.")
+	}
+	rollback := r.Migration.Spec.Rollback
+	for i := range rollback.VMs {
+		// resolve the VM ref in place
+		_, _ = r.Source.Inventory.VM(&rollback.VMs[i])
+	}
+	failed := 0
+	for _, vm := range r.Plan.Status.Migration.VMs {
+		if !rollback.Includes(vm.Ref) || !vm.HasCondition(api.ConditionSucceeded) {
+			continue
+		}
+		rErr := r.rollbackVM(vm, rollback.KeepTarget)
+		if rErr != nil {
+			r.Log.Error(rErr,
+				"Couldn't roll back the VM.",
+				"vm",
+				vm.String())
+			vm.AddError(rErr.Error())
+			failed++
+			r.event(
+				core.EventTypeWarning,
+				VMRollbackFailed,
+				fmt.Sprintf("Couldn't roll back VM [%s]: %s", strings.TrimSpace(vm.String()), rErr.Error()))
+			continue
+		}
+		r.event(
+			core.EventTypeNormal,
+			VMRolledBack,
+			fmt.Sprintf("VM [%s] rolled back to the source.", strings.TrimSpace(vm.String())))
+	}
+	rolledBack := []string{}
+	for _, vm := range r.Plan.Status.Migration.VMs {
+		if vm.HasCondition(api.ConditionRolledBack) {
+			rolledBack = append(rolledBack, vm.String())
+		}
+	}
+	if len(rolledBack) > 0 {
+		r.Plan.Status.SetCondition(
+			libcnd.Condition{
+				Type:     RolledBack,
+				Status:   True,
+				Category: api.CategoryAdvisory,
+				Message: fmt.Sprintf(
+					"%d VM(s) rolled back to the source.",
+					len(rolledBack)),
+				Items:   rolledBack,
+				Durable: true,
+			})
+	}
+	r.Plan.Status.Migration.MarkCompleted()
+	snapshot.DeleteCondition(api.ConditionExecuting)
+	if failed > 0 {
+		r.Log.Info("Rollback [FAILED]")
+		r.event(
+			core.EventTypeWarning,
+			RollbackFailed,
+			fmt.Sprintf("The plan rollback has FAILED for %d VM(s).", failed))
+		snapshot.SetCondition(
+			libcnd.Condition{
+				Type:     api.ConditionFailed,
+				Status:   True,
+				Category: api.CategoryAdvisory,
+				Message:  "The plan rollback has FAILED.",
+				Durable:  true,
+			})
+	} else {
+		r.Log.Info("Rollback [SUCCEEDED]")
+		r.event(core.EventTypeNormal, RollbackSucceeded, "The plan rollback has SUCCEEDED.")
+		snapshot.SetCondition(
+			libcnd.Condition{
+				Type:     api.ConditionSucceeded,
+				Status:   True,
+				Category: api.CategoryAdvisory,
+				Message:  "The plan rollback has SUCCEEDED.",
+				Durable:  true,
+			})
+	}
+	reQ = NoReQ
+	return
+}
+
+// Roll back a VM.
+// The target VM is stopped, or deleted along with its disks unless
+// kept, before the source VM is powered on so both never run at the
+// same time.
+func (r *Migration) rollbackVM(vm *plan.VMStatus, keepTarget bool) (err error) {
+	vm.Error = nil
+	if keepTarget {
+		r.Log.Info("Stopping target VM.", "vm", vm.String())
+		err = r.kubevirt.StopVM(vm)
+	} else {
+		r.Log.Info("Deleting target VM.", "vm", vm.String())
+		err = r.kubevirt.DeleteVM(vm)
+	}
+	if err != nil {
+		return
+	}
+	dontFailOnError := func(err error) bool {
+		if err != nil {
+			r.Log.Error(liberr.Wrap(err),
+				"Couldn't clean up after rolled back VM migration.",
+				"vm",
+				vm.String())
+		}
+		return false
+	}
+	_ = r.cleanup(vm, dontFailOnError, true)
+	if vm.RestorePowerState == plan.VMPowerStateOn {
+		r.Log.Info("Powering on source VM.", "vm", vm.String())
+		err = r.provider.PowerOn(vm.Ref)
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+	}
+	vm.Phase = api.PhaseRolledBack
+	vm.DeleteCondition(api.ConditionSucceeded)
+	vm.SetCondition(
+		libcnd.Condition{
+			Type:     api.ConditionRolledBack,
+			Status:   True,
+			Category: api.CategoryAdvisory,
+			Message:  "The VM has been rolled back to the source.",
+			Durable:  true,
+		})
+	r.Log.Info("VM rolled back.", "vm", vm.String())
+	return
+}
+
+// Record a rollback event on the plan and the migration.
+func (r *Migration) event(eventType, reason, message string) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Event(r.Plan, eventType, reason, message)
+	if r.Migration != nil {
+		r.recorder.Event(r.Migration, eventType, reason, message)
+	}
+}
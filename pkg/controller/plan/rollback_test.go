@@ -0,0 +1,227 @@
+package plan
+
+import (
+	"context"
+	"errors"
+
+	v1beta1 "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	"github.com/kubev2v/forklift/pkg/controller/plan/adapter"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web"
+	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	cnv "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Source provider client recording the VMs powered on.
+type rollbackSourceClient struct {
+	adapter.Client
+	powerOnErr error
+	poweredOn  []ref.Ref
+}
+
+func (r *rollbackSourceClient) PowerOn(vmRef ref.Ref) error {
+	if r.powerOnErr != nil {
+		return r.powerOnErr
+	}
+	r.poweredOn = append(r.poweredOn, vmRef)
+	return nil
+}
+
+// Inventory client not resolving the rolled back VMs.
+type rollbackInventory struct {
+	web.Client
+}
+
+func (r *rollbackInventory) VM(*ref.Ref) (interface{}, error) {
+	return nil, nil
+}
+
+func newRollbackMigration(keepTarget bool, objs ...runtime.Object) (*Migration, *rollbackSourceClient, *record.FakeRecorder) {
+	kubevirt := createKubeVirt(objs...)
+	kubevirt.Plan.Name = "test-plan"
+	kubevirt.Plan.Namespace = "test"
+	kubevirt.Plan.UID = "plan-uid"
+	kubevirt.Plan.Spec.TargetNamespace = "target-ns"
+	openshift := v1beta1.OpenShift
+	kubevirt.Plan.Referenced.Provider.Destination = &v1beta1.Provider{
+		Spec: v1beta1.ProviderSpec{Type: &openshift, URL: "https://remote.example.com"},
+	}
+	kubevirt.Plan.Status.Migration.History = []plan.Snapshot{{}}
+	kubevirt.Migration.Spec.Rollback = &v1beta1.Rollback{KeepTarget: keepTarget}
+	kubevirt.Source.Inventory = &rollbackInventory{}
+	source := &rollbackSourceClient{}
+	recorder := record.NewFakeRecorder(20)
+	m := &Migration{
+		Context:  kubevirt.Context,
+		kubevirt: *kubevirt,
+		provider: source,
+		recorder: recorder,
+	}
+	return m, source, recorder
+}
+
+func newRollbackTarget(id string) *cnv.VirtualMachine {
+	running := true
+	return &cnv.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "migrated-" + id,
+			Namespace: "target-ns",
+			Labels: map[string]string{
+				kPlan:          "plan-uid",
+				kPlanName:      "test-plan",
+				kPlanNamespace: "test",
+				kVM:            id,
+				kResource:      ResourceVMConfig,
+			},
+		},
+		Spec: cnv.VirtualMachineSpec{Running: &running},
+	}
+}
+
+func newRollbackVM(id string, powerState plan.VMPowerState) *plan.VMStatus {
+	vm := &plan.VMStatus{}
+	vm.ID = id
+	vm.RestorePowerState = powerState
+	vm.SetCondition(
+		libcnd.Condition{
+			Type:   v1beta1.ConditionSucceeded,
+			Status: libcnd.True,
+		})
+	return vm
+}
+
+func rollbackEvents(recorder *record.FakeRecorder) (events []string) {
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return
+		}
+	}
+}
+
+var _ = ginkgo.Describe("Rollback", func() {
+	ginkgo.Describe("rollbackVM", func() {
+		ginkgo.It("should stop the target VM when kept", func() {
+			target := newRollbackTarget("vm-1")
+			m, _, _ := newRollbackMigration(true, target)
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOff)
+
+			Expect(m.rollbackVM(vm, true)).To(Succeed())
+
+			stopped := &cnv.VirtualMachine{}
+			Expect(m.Destination.Client.Get(context.TODO(), client.ObjectKeyFromObject(target), stopped)).To(Succeed())
+			Expect(stopped.Spec.Running).To(BeNil())
+			Expect(*stopped.Spec.RunStrategy).To(Equal(cnv.RunStrategyHalted))
+			Expect(vm.Phase).To(Equal(v1beta1.PhaseRolledBack))
+			Expect(vm.HasCondition(v1beta1.ConditionRolledBack)).To(BeTrue())
+			Expect(vm.HasCondition(v1beta1.ConditionSucceeded)).To(BeFalse())
+		})
+
+		ginkgo.It("should delete the target VM when not kept", func() {
+			target := newRollbackTarget("vm-1")
+			m, _, _ := newRollbackMigration(false, target)
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOff)
+
+			Expect(m.rollbackVM(vm, false)).To(Succeed())
+
+			err := m.Destination.Client.Get(context.TODO(), client.ObjectKeyFromObject(target), &cnv.VirtualMachine{})
+			Expect(k8serr.IsNotFound(err)).To(BeTrue())
+			Expect(vm.HasCondition(v1beta1.ConditionRolledBack)).To(BeTrue())
+		})
+
+		ginkgo.It("should power on the source VM that was running", func() {
+			m, source, _ := newRollbackMigration(false, newRollbackTarget("vm-1"))
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOn)
+
+			Expect(m.rollbackVM(vm, false)).To(Succeed())
+
+			Expect(source.poweredOn).To(ConsistOf(ref.Ref{ID: "vm-1"}))
+		})
+
+		ginkgo.It("should not power on the source VM that was stopped", func() {
+			m, source, _ := newRollbackMigration(false, newRollbackTarget("vm-1"))
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOff)
+
+			Expect(m.rollbackVM(vm, false)).To(Succeed())
+
+			Expect(source.poweredOn).To(BeEmpty())
+		})
+
+		ginkgo.It("should not mark the VM rolled back when the power on fails", func() {
+			m, source, _ := newRollbackMigration(true, newRollbackTarget("vm-1"))
+			source.powerOnErr = errors.New("power on failed")
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOn)
+
+			Expect(m.rollbackVM(vm, true)).NotTo(Succeed())
+
+			Expect(vm.HasCondition(v1beta1.ConditionRolledBack)).To(BeFalse())
+			Expect(vm.HasCondition(v1beta1.ConditionSucceeded)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("rollback", func() {
+		ginkgo.It("should roll back the succeeded VMs and record events", func() {
+			m, _, recorder := newRollbackMigration(false, newRollbackTarget("vm-1"), newRollbackTarget("vm-2"))
+			succeeded := newRollbackVM("vm-1", plan.VMPowerStateOff)
+			failed := newRollbackVM("vm-2", plan.VMPowerStateOff)
+			failed.DeleteCondition(v1beta1.ConditionSucceeded)
+			failed.SetCondition(
+				libcnd.Condition{
+					Type:   v1beta1.ConditionFailed,
+					Status: libcnd.True,
+				})
+			m.Plan.Status.Migration.VMs = []*plan.VMStatus{succeeded, failed}
+
+			_, err := m.rollback()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(succeeded.HasCondition(v1beta1.ConditionRolledBack)).To(BeTrue())
+			Expect(failed.HasCondition(v1beta1.ConditionRolledBack)).To(BeFalse())
+			// The target VM of the VM not rolled back is kept.
+			Expect(m.Destination.Client.Get(
+				context.TODO(),
+				client.ObjectKeyFromObject(newRollbackTarget("vm-2")),
+				&cnv.VirtualMachine{})).To(Succeed())
+			snapshot := m.Plan.Status.Migration.ActiveSnapshot()
+			Expect(snapshot.HasCondition(v1beta1.ConditionSucceeded)).To(BeTrue())
+			// Recorded on both the plan and the migration.
+			Expect(rollbackEvents(recorder)).To(Equal([]string{
+				"Normal RollbackStarted The plan rollback has STARTED.",
+				"Normal RollbackStarted The plan rollback has STARTED.",
+				"Normal VMRolledBack VM [id:vm-1 name:''] rolled back to the source.",
+				"Normal VMRolledBack VM [id:vm-1 name:''] rolled back to the source.",
+				"Normal RollbackSucceeded The plan rollback has SUCCEEDED.",
+				"Normal RollbackSucceeded The plan rollback has SUCCEEDED.",
+			}))
+		})
+
+		ginkgo.It("should keep the error of a VM that could not be rolled back", func() {
+			m, source, recorder := newRollbackMigration(true, newRollbackTarget("vm-1"))
+			source.powerOnErr = errors.New("power on failed")
+			vm := newRollbackVM("vm-1", plan.VMPowerStateOn)
+			m.Plan.Status.Migration.VMs = []*plan.VMStatus{vm}
+
+			_, err := m.rollback()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vm.Error).NotTo(BeNil())
+			Expect(vm.Error.Reasons).To(ContainElement(ContainSubstring("power on failed")))
+			Expect(vm.HasCondition(v1beta1.ConditionSucceeded)).To(BeTrue())
+			snapshot := m.Plan.Status.Migration.ActiveSnapshot()
+			Expect(snapshot.HasCondition(v1beta1.ConditionFailed)).To(BeTrue())
+			events := rollbackEvents(recorder)
+			Expect(events).To(ContainElement("Warning VMRollbackFailed Couldn't roll back VM [id:vm-1 name:'']: power on failed"))
+			Expect(events).To(ContainElement("Warning RollbackFailed The plan rollback has FAILED for 1 VM(s)."))
+		})
+	})
+})
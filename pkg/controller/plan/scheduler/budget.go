@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Mutex shared by the schedulers of all providers to
+// ensure that concurrent reconciles don't exceed
+// the migration budgets.
+var budgetMutex sync.Mutex
+
+// Time a VM started by the scheduler is reserved while
+// waiting for the started VM to be seen in the plan status.
+const reservationTTL = 5 * time.Minute
+
+// VMs started by the budgeted schedulers by plan.
+// The plans are listed from the cache which lags behind the
+// status written by concurrent reconciles. A started VM is
+// counted as in flight until the cached plan shows it started.
+// Protected by the budgetMutex.
+var budgetReserved = reservations{}
+
+// VMs reserved by plan key and VM ID.
+type reservations map[string]map[string]time.Time
+
+// Scheduler limited by the migration budgets.
+// The provider scheduler is consulted only when every
+// budget that applies to the plan admits another VM.
+type Budgeted struct {
+	Scheduler
+	*plancontext.Context
+	// Budget that did not admit a VM on the last call to Next.
+	Exhausted *api.MigrationBudget
+}
+
+// Return the next VM to migrate.
+func (r *Budgeted) Next() (vm *plan.VMStatus, hasNext bool, err error) {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+	r.Exhausted = nil
+	budgetList := &api.MigrationBudgetList{}
+	err = r.List(context.TODO(), budgetList, client.InNamespace(r.Plan.Namespace))
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	var budgets []*api.MigrationBudget
+	for i := range budgetList.Items {
+		budget := &budgetList.Items[i]
+		if budget.Selects(r.Plan) {
+			budgets = append(budgets, budget)
+		}
+	}
+	if len(budgets) == 0 {
+		return r.Scheduler.Next()
+	}
+	planList := &api.PlanList{}
+	err = r.List(context.TODO(), planList, client.InNamespace(r.Plan.Namespace))
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	budgetReserved.release(planList.Items, time.Now())
+	plans := budgetReserved.apply(r.Plan, planList.Items)
+	for _, budget := range budgets {
+		if !Admit(budget, r.Plan, plans) {
+			r.Log.V(1).Info(
+				"Migration budget exhausted.",
+				"budget",
+				budget.Namespace+"/"+budget.Name,
+				"maxInFlight",
+				budget.Spec.MaxInFlight)
+			r.Exhausted = budget
+			return
+		}
+	}
+	vm, hasNext, err = r.Scheduler.Next()
+	if err == nil && hasNext {
+		budgetReserved.reserve(r.Plan, vm, time.Now())
+	}
+
+	return
+}
+
+// Reserve the VM started by the plan.
+func (r reservations) reserve(p *api.Plan, vm *plan.VMStatus, now time.Time) {
+	key := planKey(p)
+	if r[key] == nil {
+		r[key] = make(map[string]time.Time)
+	}
+	r[key][vm.ID] = now
+}
+
+// Release the reservations of the VMs shown started (or done) in
+// the listed plans, of the plans no longer executing and the
+// reservations older than the reservationTTL.
+func (r reservations) release(plans []api.Plan, now time.Time) {
+	listed := make(map[string]*api.Plan, len(plans))
+	for i := range plans {
+		listed[planKey(&plans[i])] = &plans[i]
+	}
+	for key, vms := range r {
+		p, found := listed[key]
+		if !found || !executing(p) {
+			delete(r, key)
+			continue
+		}
+		for id, reserved := range vms {
+			if now.Sub(reserved) > reservationTTL {
+				delete(vms, id)
+				continue
+			}
+			for _, vm := range p.Status.Migration.VMs {
+				if vm.ID == id && (vm.MarkedStarted() || vm.MarkedCompleted() || vm.HasCondition(api.ConditionCanceled)) {
+					delete(vms, id)
+					break
+				}
+			}
+		}
+		if len(vms) == 0 {
+			delete(r, key)
+		}
+	}
+}
+
+// Apply the reservations to the listed plans. The reserved VMs of
+// the other plans are counted as running. The statuses of the
+// current plan are modified in memory and not affected.
+func (r reservations) apply(current *api.Plan, plans []api.Plan) (applied []api.Plan) {
+	applied = plans
+	copied := false
+	for i := range plans {
+		key := planKey(&plans[i])
+		vms := r[key]
+		if len(vms) == 0 || key == planKey(current) {
+			continue
+		}
+		if !copied {
+			applied = make([]api.Plan, len(plans))
+			copy(applied, plans)
+			copied = true
+		}
+		p := plans[i].DeepCopy()
+		for _, vm := range p.Status.Migration.VMs {
+			if _, reserved := vms[vm.ID]; reserved {
+				vm.MarkStarted()
+			}
+		}
+		applied[i] = *p
+	}
+
+	return
+}
+
+// Determine whether the budget admits another VM of the plan.
+// The VMs in flight are counted across the executing plans within
+// the budget. The capacity is shared fairly by the plans with VMs
+// waiting: a plan may take a slot beyond its share (the budget
+// divided by the number of waiting plans) only when every other
+// waiting plan already holds its share.
+func Admit(budget *api.MigrationBudget, current *api.Plan, plans []api.Plan) bool {
+	inFlight := 0
+	waiting := make(map[string]int)
+	key := planKey
+	for i := range plans {
+		p := &plans[i]
+		// The statuses of the current plan are
+		// modified in memory.
+		if key(p) == key(current) {
+			p = current
+		} else if !budget.Selects(p) || !executing(p) {
+			continue
+		}
+		running, pending := count(p)
+		inFlight += running
+		if pending > 0 || p == current {
+			waiting[key(p)] = running
+		}
+	}
+	if _, found := waiting[key(current)]; !found {
+		running, _ := count(current)
+		inFlight += running
+		waiting[key(current)] = running
+	}
+	if inFlight >= budget.Spec.MaxInFlight {
+		return false
+	}
+	share := budget.Spec.MaxInFlight / len(waiting)
+	if share < 1 {
+		share = 1
+	}
+	if waiting[key(current)] < share {
+		return true
+	}
+	for k, running := range waiting {
+		if k != key(current) && running < share {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Key of the plan.
+func planKey(p *api.Plan) string {
+	return p.Namespace + "/" + p.Name
+}
+
+// Plan is being executed.
+func executing(p *api.Plan) bool {
+	if p.Spec.Archived {
+		return false
+	}
+	snapshot := p.Status.Migration.ActiveSnapshot()
+	return snapshot.HasCondition(api.ConditionExecuting)
+}
+
+// Count the VMs running and waiting to be started.
+func count(p *api.Plan) (running, pending int) {
+	for _, vm := range p.Status.Migration.VMs {
+		switch {
+		case vm.HasCondition(api.ConditionCanceled):
+		case vm.Running():
+			running++
+		case !vm.MarkedStarted() && !vm.MarkedCompleted():
+			pending++
+		}
+	}
+	return
+}
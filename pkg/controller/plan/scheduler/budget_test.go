@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	"github.com/kubev2v/forklift/pkg/lib/logging"
+	"github.com/onsi/gomega"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPlan(name, provider, target string, running, pending int) api.Plan {
+	p := api.Plan{
+		ObjectMeta: meta.ObjectMeta{Namespace: "test", Name: name},
+	}
+	p.Spec.Provider.Source = core.ObjectReference{Namespace: "test", Name: provider}
+	p.Spec.TargetNamespace = target
+	p.Status.Migration.History = []plan.Snapshot{{}}
+	p.Status.Migration.ActiveSnapshot().SetCondition(
+		libcnd.Condition{
+			Type:   api.ConditionExecuting,
+			Status: libcnd.True,
+		})
+	for i := 0; i < running; i++ {
+		vm := &plan.VMStatus{}
+		vm.ID = fmt.Sprintf("%s-running-%d", name, i)
+		vm.MarkStarted()
+		p.Status.Migration.VMs = append(p.Status.Migration.VMs, vm)
+	}
+	for i := 0; i < pending; i++ {
+		vm := &plan.VMStatus{}
+		vm.ID = fmt.Sprintf("%s-pending-%d", name, i)
+		p.Status.Migration.VMs = append(p.Status.Migration.VMs, vm)
+	}
+	return p
+}
+
+func newBudget(max int, provider, target string) *api.MigrationBudget {
+	budget := &api.MigrationBudget{
+		ObjectMeta: meta.ObjectMeta{Namespace: "test", Name: "budget"},
+	}
+	budget.Spec.MaxInFlight = max
+	budget.Spec.TargetNamespace = target
+	if provider != "" {
+		budget.Spec.Provider = &core.ObjectReference{Name: provider}
+	}
+	return budget
+}
+
+func TestBudgetSelects(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	p := newPlan("a", "vsphere", "team-a", 0, 1)
+	g.Expect(newBudget(1, "", "").Selects(&p)).To(gomega.BeTrue())
+	g.Expect(newBudget(1, "vsphere", "").Selects(&p)).To(gomega.BeTrue())
+	g.Expect(newBudget(1, "ovirt", "").Selects(&p)).To(gomega.BeFalse())
+	g.Expect(newBudget(1, "vsphere", "team-a").Selects(&p)).To(gomega.BeTrue())
+	g.Expect(newBudget(1, "vsphere", "team-b").Selects(&p)).To(gomega.BeFalse())
+	// Budget of another namespace.
+	other := newBudget(1, "", "")
+	other.Namespace = "other"
+	g.Expect(other.Selects(&p)).To(gomega.BeFalse())
+}
+
+func TestBudgetAdmit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	t.Run("within budget", func(_ *testing.T) {
+		a := newPlan("a", "vsphere", "team-a", 1, 3)
+		plans := []api.Plan{a}
+		g.Expect(Admit(newBudget(2, "", ""), &a, plans)).To(gomega.BeTrue())
+	})
+
+	t.Run("budget exhausted", func(_ *testing.T) {
+		a := newPlan("a", "vsphere", "team-a", 1, 3)
+		b := newPlan("b", "ovirt", "team-a", 3, 0)
+		plans := []api.Plan{a, b}
+		g.Expect(Admit(newBudget(4, "", "team-a"), &a, plans)).To(gomega.BeFalse())
+		// The plan of the other provider is not within the budget.
+		g.Expect(Admit(newBudget(4, "vsphere", ""), &a, plans)).To(gomega.BeTrue())
+	})
+
+	t.Run("fair share", func(_ *testing.T) {
+		a := newPlan("a", "vsphere", "team-a", 4, 5)
+		b := newPlan("b", "vsphere", "team-b", 1, 5)
+		plans := []api.Plan{a, b}
+		budget := newBudget(8, "vsphere", "")
+		// Plan a holds its share while b is waiting.
+		g.Expect(Admit(budget, &a, plans)).To(gomega.BeFalse())
+		g.Expect(Admit(budget, &b, plans)).To(gomega.BeTrue())
+	})
+
+	t.Run("share not used", func(_ *testing.T) {
+		a := newPlan("a", "vsphere", "team-a", 5, 5)
+		b := newPlan("b", "vsphere", "team-b", 1, 0)
+		plans := []api.Plan{a, b}
+		g.Expect(Admit(newBudget(8, "vsphere", ""), &a, plans)).To(gomega.BeTrue())
+	})
+
+	t.Run("plans not executing", func(_ *testing.T) {
+		a := newPlan("a", "vsphere", "team-a", 1, 5)
+		b := newPlan("b", "vsphere", "team-b", 4, 5)
+		b.Spec.Archived = true
+		plans := []api.Plan{a, b}
+		g.Expect(Admit(newBudget(2, "vsphere", ""), &a, plans)).To(gomega.BeTrue())
+	})
+}
+
+// Scheduler returning the pending VMs of the plan.
+type pendingScheduler struct {
+	plan *api.Plan
+}
+
+func (r *pendingScheduler) Next() (vm *plan.VMStatus, hasNext bool, err error) {
+	for _, vm = range r.plan.Status.Migration.VMs {
+		if !vm.MarkedStarted() {
+			hasNext = true
+			return
+		}
+	}
+	return
+}
+
+func TestBudgetReservations(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	now := time.Now()
+
+	a := newPlan("a", "vsphere", "team-a", 0, 3)
+	b := newPlan("b", "vsphere", "team-b", 0, 3)
+	reserved := reservations{}
+	reserved.reserve(&a, a.Status.Migration.VMs[0], now)
+	plans := []api.Plan{a, b}
+	applied := reserved.apply(&b, plans)
+	running, _ := count(&applied[0])
+	g.Expect(running).To(gomega.Equal(1))
+	// The listed plans are not modified.
+	running, _ = count(&plans[0])
+	g.Expect(running).To(gomega.Equal(0))
+	// The current plan is not affected.
+	applied = reserved.apply(&a, plans)
+	running, _ = count(&applied[0])
+	g.Expect(running).To(gomega.Equal(0))
+
+	// Kept until the VM is seen started.
+	reserved.release(plans, now)
+	g.Expect(reserved).To(gomega.HaveKey("test/a"))
+	started := *a.DeepCopy()
+	started.Status.Migration.VMs[0].MarkStarted()
+	reserved.release([]api.Plan{started, b}, now)
+	g.Expect(reserved).To(gomega.BeEmpty())
+
+	// Expired.
+	reserved.reserve(&a, a.Status.Migration.VMs[0], now)
+	reserved.release(plans, now.Add(reservationTTL+time.Second))
+	g.Expect(reserved).To(gomega.BeEmpty())
+
+	// Plan no longer executing.
+	reserved.reserve(&a, a.Status.Migration.VMs[0], now)
+	reserved.release([]api.Plan{b}, now)
+	g.Expect(reserved).To(gomega.BeEmpty())
+}
+
+func TestBudgetedConcurrentPlans(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	t.Cleanup(func() { budgetReserved = reservations{} })
+
+	scheme := runtime.NewScheme()
+	g.Expect(api.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+	a := newPlan("a", "vsphere", "team-a", 0, 5)
+	b := newPlan("b", "vsphere", "team-b", 0, 5)
+	// The cached plans never show the started VMs.
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(a.DeepCopy(), b.DeepCopy(), newBudget(1, "", "")).
+		Build()
+
+	started := make(chan string, 10)
+	wg := sync.WaitGroup{}
+	for _, p := range []api.Plan{a, b} {
+		current := p.DeepCopy()
+		scheduler := &Budgeted{
+			Scheduler: &pendingScheduler{plan: current},
+			Context: &plancontext.Context{
+				Client: cl,
+				Plan:   current,
+				Log:    logging.WithName("budget-test"),
+			},
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				vm, hasNext, err := scheduler.Next()
+				if err != nil {
+					t.Errorf("next: %v", err)
+					return
+				}
+				if hasNext {
+					vm.MarkStarted()
+					started <- vm.ID
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(started)
+
+	g.Expect(started).To(gomega.HaveLen(1))
+}
@@ -24,12 +24,16 @@ type Scheduler interface {
 }
 
 // Scheduler factory.
+// The provider schedulers are limited by the migration budgets.
 func New(ctx *plancontext.Context) (scheduler Scheduler, err error) {
 	switch ctx.Source.Provider.Type() {
 	case api.VSphere:
 		scheduler = &vsphere.Scheduler{
-			Context:     ctx,
-			MaxInFlight: settings.Settings.MaxInFlight,
+			Context:                      ctx,
+			MaxInFlight:                  settings.Settings.MaxInFlight,
+			MaxDatastoreDisksInFlight:    settings.Settings.MaxDatastoreDisksInFlight,
+			MaxStorageClassDisksInFlight: settings.Settings.MaxStorageClassDisksInFlight,
+			MaxBytesInFlight:             settings.Settings.MaxBytesInFlight,
 		}
 	case api.OVirt:
 		scheduler = &ovirt.Scheduler{
@@ -68,6 +72,11 @@ func New(ctx *plancontext.Context) (scheduler Scheduler, err error) {
 		}
 	default:
 		err = liberr.New("provider not supported.")
+		return
+	}
+	scheduler = &Budgeted{
+		Scheduler: scheduler,
+		Context:   ctx,
 	}
 
 	return
@@ -3,6 +3,8 @@ package vsphere
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strconv"
 	"sync"
 
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
@@ -11,6 +13,9 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/settings"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Phases.
@@ -20,6 +25,8 @@ const (
 	ConvertGuest             = "ConvertGuest"
 	CreateVM                 = "CreateVM"
 	PostHook                 = "PostHook"
+	PostTransferHook         = "PostTransferHook"
+	FailureHook              = "FailureHook"
 	Completed                = "Completed"
 	Canceled                 = "Canceled"
 )
@@ -32,6 +39,10 @@ const (
 	NotFound        = "NotFound"
 )
 
+// Regex which matches the snapshot identifier suffix of a
+// vSphere disk backing file.
+var backingFilePattern = regexp.MustCompile(`-\d\d\d\d\d\d.vmdk`)
+
 // Package level mutex to ensure that
 // multiple concurrent reconciles don't
 // attempt to schedule VMs into the same
@@ -44,9 +55,23 @@ type Scheduler struct {
 	// Maximum number of disks per host that can be
 	// migrated at once.
 	MaxInFlight int
+	// Maximum number of disks per source datastore (by ID
+	// or name) that can be migrated at once.
+	MaxDatastoreDisksInFlight settings.Limits
+	// Maximum number of disks per target storage class
+	// that can be migrated at once.
+	MaxStorageClassDisksInFlight settings.Limits
+	// Maximum number of bytes of disk that can be
+	// migrated at once. 0 is unlimited.
+	MaxBytesInFlight int64
 	// Mapping of hosts by ID to the number of disks
 	// on each host that are currently being migrated.
 	inFlight map[string]int
+	// Disks, by datastore and storage class, and bytes
+	// that are currently being migrated.
+	loadInFlight load
+	// Mapping of datastore IDs to names.
+	datastoreNames map[string]string
 	// Mapping of hosts by ID to lists of VMs
 	// that are waiting to be migrated.
 	pending map[string][]*pendingVM
@@ -58,6 +83,39 @@ type Scheduler struct {
 type pendingVM struct {
 	status *plan.VMStatus
 	cost   int
+	// Disks left to migrate.
+	load load
+	// User-assigned priority.
+	priority int32
+	// Position on the plan.
+	index int
+}
+
+// Load of the disks left to migrate.
+type load struct {
+	// Number of disks by source datastore ID.
+	datastores map[string]int
+	// Number of disks by target storage class.
+	storageClasses map[string]int
+	// Total capacity of the disks.
+	bytes int64
+}
+
+// Add a load.
+func (r *load) add(l load) {
+	if r.datastores == nil {
+		r.datastores = make(map[string]int)
+	}
+	if r.storageClasses == nil {
+		r.storageClasses = make(map[string]int)
+	}
+	for ds, n := range l.datastores {
+		r.datastores[ds] += n
+	}
+	for sc, n := range l.storageClasses {
+		r.storageClasses[sc] += n
+	}
+	r.bytes += l.bytes
 }
 
 // Return the next VM to migrate.
@@ -68,11 +126,10 @@ func (r *Scheduler) Next() (vm *plan.VMStatus, hasNext bool, err error) {
 	if err != nil {
 		return
 	}
-	for _, vms := range r.schedulable() {
-		if len(vms) > 0 {
-			vm = vms[0].status
-			hasNext = true
-		}
+	next := r.next(r.schedulable(), r.Plan.Spec.MigrationOrder)
+	if next != nil {
+		vm = next.status
+		hasNext = true
 	}
 
 	if hasNext {
@@ -105,6 +162,12 @@ func (r *Scheduler) buildSchedule() (err error) {
 		"Schedule built.",
 		"inflight",
 		r.inFlight,
+		"datastores",
+		r.loadInFlight.datastores,
+		"storageClasses",
+		r.loadInFlight.storageClasses,
+		"bytes",
+		r.loadInFlight.bytes,
 		"pending",
 		r.pending,
 	)
@@ -116,6 +179,8 @@ func (r *Scheduler) buildSchedule() (err error) {
 // are currently in flight for each host.
 func (r *Scheduler) buildInFlight() (err error) {
 	r.inFlight = make(map[string]int)
+	r.loadInFlight = load{}
+	r.datastoreNames = make(map[string]string)
 
 	// Since we modify the plan VMStatuses in memory,
 	// we need to use the plan from the context rather
@@ -131,6 +196,7 @@ func (r *Scheduler) buildInFlight() (err error) {
 		}
 		if vmStatus.Running() {
 			r.inFlight[vm.Host] += r.cost(vm, vmStatus)
+			r.loadInFlight.add(r.load(vm, vmStatus, r.Map.Storage))
 		}
 	}
 
@@ -161,6 +227,12 @@ func (r *Scheduler) buildInFlight() (err error) {
 			continue
 		}
 
+		var storageMap *api.StorageMap
+		storageMap, err = r.storageMap(&p)
+		if err != nil {
+			return
+		}
+
 		for _, vmStatus := range p.Status.Migration.VMs {
 			if !vmStatus.Running() {
 				continue
@@ -177,6 +249,34 @@ func (r *Scheduler) buildInFlight() (err error) {
 				return err
 			}
 			r.inFlight[vm.Host] += r.cost(vm, vmStatus)
+			r.loadInFlight.add(r.load(vm, vmStatus, storageMap))
+		}
+	}
+
+	return
+}
+
+// Get the storage map of another plan.
+// Only needed when the storage classes are limited.
+func (r *Scheduler) storageMap(p *api.Plan) (storageMap *api.StorageMap, err error) {
+	if r.MaxStorageClassDisksInFlight.Unlimited() {
+		return
+	}
+	ref := p.Spec.Map.Storage
+	storageMap = &api.StorageMap{}
+	err = r.Get(
+		context.TODO(),
+		k8sclient.ObjectKey{
+			Namespace: ref.Namespace,
+			Name:      ref.Name,
+		},
+		storageMap)
+	if err != nil {
+		storageMap = nil
+		if k8serr.IsNotFound(err) {
+			err = nil
+		} else {
+			err = liberr.Wrap(err)
 		}
 	}
 
@@ -198,7 +298,7 @@ func (r *Scheduler) buildPending() (err error) {
 		return
 	}
 
-	for _, vmStatus := range r.Plan.Status.Migration.VMs {
+	for i, vmStatus := range r.Plan.Status.Migration.VMs {
 		if vmStatus.HasCondition(Canceled) {
 			continue
 		}
@@ -214,13 +314,24 @@ func (r *Scheduler) buildPending() (err error) {
 			continue
 		}
 		r.pending[vm.Host] = append(r.pending[vm.Host], &pendingVM{
-			status: vmStatus,
-			cost:   r.cost(vm, vmStatus),
+			status:   vmStatus,
+			cost:     r.cost(vm, vmStatus),
+			load:     r.load(vm, vmStatus, r.Map.Storage),
+			priority: r.priority(vmStatus),
+			index:    i,
 		})
 	}
 	return
 }
 
+// priority returns the user-assigned priority of the VM.
+func (r *Scheduler) priority(vmStatus *plan.VMStatus) int32 {
+	if specVM, found := r.Plan.Spec.FindVM(vmStatus.Ref); found {
+		return specVM.Priority
+	}
+	return vmStatus.Priority
+}
+
 // hasActiveSharedDiskCreators reports whether any VM that creates
 // shareable PVCs (shared disk + migrateSharedDisks=true) has not
 // yet completed.
@@ -253,7 +364,7 @@ func (r *Scheduler) cost(vm *model.VM, vmStatus *plan.VMStatus) int {
 	useV2vForTransfer, _ := r.Plan.ShouldUseV2vForTransfer(vmStatus.Ref)
 	if useV2vForTransfer {
 		switch vmStatus.Phase {
-		case CreateVM, PostHook, Completed:
+		case CreateVM, PostHook, Completed, FailureHook:
 			// In these phases we already have the disk transferred and are left only to create the VM
 			// By setting the cost to 0 other VMs can start migrating
 			return 0
@@ -262,18 +373,26 @@ func (r *Scheduler) cost(vm *model.VM, vmStatus *plan.VMStatus) int {
 		}
 	} else if r.Plan.IsUsingOffloadPlugin() {
 		switch vmStatus.Phase {
-		case CreateVM, PostHook, Completed, ConvertGuest, CreateGuestConversionPod:
+		case CreateVM, PostHook, Completed, FailureHook, PostTransferHook, ConvertGuest, CreateGuestConversionPod:
 			// In these phases we already have the disk transferred and are left only to create the VM
 			// By setting the cost to 0 other VMs can start migrating
 			return 0
+		case CopyingPaused:
+			// Warm migrations release the host slot between the precopies.
+			return 0
 		default:
+			if r.offloadCopied(vmStatus) {
+				// The initial copy was offloaded, the following precopies and the
+				// final delta transfer the changed blocks with CDI.
+				return len(vm.Disks) - r.finishedDisks(vmStatus)
+			}
 			// Once all disks have been copied by the offload plugin, release the host slot
 			// so that other VMs can start migrating without waiting for conversion to begin.
 			return 1
 		}
 	} else {
 		switch vmStatus.Phase {
-		case CreateVM, PostHook, Completed, CopyingPaused, ConvertGuest, CreateGuestConversionPod:
+		case CreateVM, PostHook, Completed, FailureHook, PostTransferHook, CopyingPaused, ConvertGuest, CreateGuestConversionPod:
 			// The warm/remote migrations this is done on already transferred disks,
 			// and we can start other VM migrations at these point.
 			// By setting the cost to 0 other VMs can start migrating
@@ -285,6 +404,29 @@ func (r *Scheduler) cost(vm *model.VM, vmStatus *plan.VMStatus) int {
 	}
 }
 
+// offloadCopied returns whether the initial copy of a warm migration,
+// performed by the offload plugin, has completed. Every disk transfer
+// task has either completed the initial copy or been reset to transfer
+// the changed blocks of a following precopy.
+func (r *Scheduler) offloadCopied(vmStatus *plan.VMStatus) bool {
+	if vmStatus.Warm == nil {
+		return false
+	}
+	step, found := vmStatus.FindStep(DiskTransfer)
+	if !found || len(step.Tasks) == 0 {
+		return false
+	}
+	for _, task := range step.Tasks {
+		if precopy, _ := strconv.Atoi(task.Annotations["Precopy"]); precopy > 1 {
+			continue
+		}
+		if task.Phase != Completed {
+			return false
+		}
+	}
+	return true
+}
+
 // finishedDisks returns a number of the disks that have completed the disk transfer
 // This can reduce the migration time as VMs with one large disks and many small disks won't halt the scheduler
 func (r *Scheduler) finishedDisks(vmStatus *plan.VMStatus) int {
@@ -302,8 +444,83 @@ func (r *Scheduler) finishedDisks(vmStatus *plan.VMStatus) int {
 	return resp
 }
 
+// load returns the disks left to migrate by source datastore and
+// target storage class, and their total capacity. Disks are released
+// once their transfer has completed, or all at once when the cost of
+// the VM drops to 0.
+func (r *Scheduler) load(vm *model.VM, vmStatus *plan.VMStatus, storageMap *api.StorageMap) (l load) {
+	l.datastores = make(map[string]int)
+	l.storageClasses = make(map[string]int)
+	if r.cost(vm, vmStatus) == 0 {
+		return
+	}
+	finished := r.finishedTasks(vmStatus)
+	for _, disk := range vm.Disks {
+		if finished[disk.File] || finished[r.baseFile(disk.File)] {
+			continue
+		}
+		ds := disk.Datastore.ID
+		l.datastores[ds]++
+		if _, found := r.datastoreNames[ds]; !found {
+			r.datastoreNames[ds] = r.datastoreName(ds)
+		}
+		if storageMap != nil {
+			if pair, found := storageMap.FindStorage(ds); found && pair.Destination.StorageClass != "" {
+				l.storageClasses[pair.Destination.StorageClass]++
+			}
+		}
+		l.bytes += disk.Capacity
+	}
+
+	return
+}
+
+// datastoreName returns the name of the datastore.
+// Only needed when the datastores are limited by name.
+func (r *Scheduler) datastoreName(id string) (name string) {
+	if len(r.MaxDatastoreDisksInFlight.Keyed) == 0 {
+		return
+	}
+	ds := &model.Datastore{}
+	err := r.Source.Inventory.Get(ds, id)
+	if err != nil {
+		r.Log.V(1).Info(
+			"Datastore not found.",
+			"id",
+			id,
+			"error",
+			err.Error())
+		return
+	}
+	name = ds.Name
+	return
+}
+
+// finishedTasks returns the names of the disk transfer tasks that have completed.
+func (r *Scheduler) finishedTasks(vmStatus *plan.VMStatus) (finished map[string]bool) {
+	finished = make(map[string]bool)
+	for _, step := range vmStatus.Pipeline {
+		switch step.Name {
+		case DiskTransfer, DiskTransferV2v, DiskAllocation:
+			for _, task := range step.Tasks {
+				if task.Phase == Completed {
+					finished[task.Name] = true
+				}
+			}
+		}
+	}
+	return
+}
+
+// baseFile returns the disk backing file without the snapshot
+// suffix, as used to name the tasks of warm migrations.
+func (r *Scheduler) baseFile(file string) string {
+	return backingFilePattern.ReplaceAllString(file, ".vmdk")
+}
+
 // Return a map of all the VMs that could be scheduled
-// based on the available host capacities.
+// based on the available host, datastore, storage class
+// and byte capacities.
 func (r *Scheduler) schedulable() (schedulable map[string][]*pendingVM) {
 	schedulable = make(map[string][]*pendingVM)
 	for host, vms := range r.pending {
@@ -311,6 +528,9 @@ func (r *Scheduler) schedulable() (schedulable map[string][]*pendingVM) {
 			continue
 		}
 		for i := range vms {
+			if !r.fits(vms[i].load) {
+				continue
+			}
 			if vms[i].cost+r.inFlight[host] <= r.MaxInFlight {
 				schedulable[host] = append(schedulable[host], vms[i])
 			}
@@ -324,3 +544,62 @@ func (r *Scheduler) schedulable() (schedulable map[string][]*pendingVM) {
 
 	return
 }
+
+// Determine whether the load fits within the datastore,
+// storage class and byte limits.
+func (r *Scheduler) fits(l load) bool {
+	for ds, n := range l.datastores {
+		limit := r.MaxDatastoreDisksInFlight.Limit(ds, r.datastoreNames[ds])
+		if !fits(int64(n), int64(r.loadInFlight.datastores[ds]), int64(limit)) {
+			return false
+		}
+	}
+	for sc, n := range l.storageClasses {
+		limit := r.MaxStorageClassDisksInFlight.Limit(sc)
+		if !fits(int64(n), int64(r.loadInFlight.storageClasses[sc]), int64(limit)) {
+			return false
+		}
+	}
+	return fits(l.bytes, r.loadInFlight.bytes, r.MaxBytesInFlight)
+}
+
+// Determine whether the amount fits within the limit given the
+// amount in flight. A limit of 0 is unlimited. In case the amount
+// is larger than the limit, it fits only when nothing is in flight
+// so that the VM is eventually migrated.
+func fits(amount, inFlight, limit int64) bool {
+	if limit <= 0 || amount == 0 || inFlight == 0 {
+		return true
+	}
+	return inFlight+amount <= limit
+}
+
+// Return the schedulable VM to start first: the VM with the highest
+// priority, then the smallest or largest VM according to the order,
+// then the first VM listed on the plan.
+func (r *Scheduler) next(schedulable map[string][]*pendingVM, order api.MigrationOrder) (next *pendingVM) {
+	for _, vms := range schedulable {
+		for _, vm := range vms {
+			if next == nil || before(vm, next, order) {
+				next = vm
+			}
+		}
+	}
+	return
+}
+
+// Determine whether VM a should be started before VM b.
+func before(a, b *pendingVM, order api.MigrationOrder) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if a.load.bytes != b.load.bytes {
+		switch order {
+		case api.MigrationOrderSmallestFirst:
+			return a.load.bytes < b.load.bytes
+		case api.MigrationOrderLargestFirst:
+			return a.load.bytes > b.load.bytes
+		}
+	}
+	return a.index < b.index
+}
@@ -3,7 +3,12 @@ package vsphere
 import (
 	"testing"
 
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
+	vspheremodel "github.com/kubev2v/forklift/pkg/controller/provider/model/vsphere"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
+	"github.com/kubev2v/forklift/pkg/settings"
 	"github.com/onsi/gomega"
 )
 
@@ -104,6 +109,94 @@ func TestScheduler(t *testing.T) {
 	g.Expect(scheduler.schedulable()).To(gomega.Equal(expectedSchedule))
 }
 
+func TestSchedulerStorageLimits(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	slow := pendingVM{
+		cost: 1,
+		load: load{
+			datastores:     map[string]int{"ds-1": 2},
+			storageClasses: map[string]int{"ceph": 2},
+			bytes:          20,
+		},
+	}
+	fast := pendingVM{
+		cost: 1,
+		load: load{
+			datastores:     map[string]int{"ds-2": 1},
+			storageClasses: map[string]int{"ceph": 1},
+			bytes:          10,
+		},
+	}
+	newScheduler := func() *Scheduler {
+		return &Scheduler{
+			MaxInFlight: 10,
+			inFlight:    map[string]int{"host": 2},
+			loadInFlight: load{
+				datastores:     map[string]int{"ds-1": 2},
+				storageClasses: map[string]int{"ceph": 2},
+				bytes:          20,
+			},
+			datastoreNames: map[string]string{"ds-1": "slow", "ds-2": "fast"},
+			pending:        map[string][]*pendingVM{"host": {&slow, &fast}},
+		}
+	}
+
+	t.Run("unlimited", func(_ *testing.T) {
+		scheduler := newScheduler()
+		g.Expect(scheduler.schedulable()["host"]).To(gomega.HaveLen(2))
+	})
+
+	t.Run("datastore limited by name", func(_ *testing.T) {
+		scheduler := newScheduler()
+		scheduler.MaxDatastoreDisksInFlight = settings.Limits{Keyed: map[string]int{"slow": 3}}
+		g.Expect(scheduler.schedulable()["host"]).To(gomega.Equal([]*pendingVM{&fast}))
+	})
+
+	t.Run("storage class limited", func(_ *testing.T) {
+		scheduler := newScheduler()
+		scheduler.MaxStorageClassDisksInFlight = settings.Limits{Default: 3}
+		g.Expect(scheduler.schedulable()["host"]).To(gomega.Equal([]*pendingVM{&fast}))
+	})
+
+	t.Run("bytes limited", func(_ *testing.T) {
+		scheduler := newScheduler()
+		scheduler.MaxBytesInFlight = 30
+		g.Expect(scheduler.schedulable()["host"]).To(gomega.Equal([]*pendingVM{&fast}))
+	})
+
+	t.Run("oversize VM scheduled when idle", func(_ *testing.T) {
+		scheduler := newScheduler()
+		scheduler.loadInFlight = load{}
+		scheduler.MaxBytesInFlight = 5
+		scheduler.MaxDatastoreDisksInFlight = settings.Limits{Default: 1}
+		g.Expect(scheduler.schedulable()["host"]).To(gomega.HaveLen(2))
+	})
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	small := &pendingVM{index: 2, load: load{bytes: 10}}
+	large := &pendingVM{index: 1, load: load{bytes: 30}}
+	medium := &pendingVM{index: 0, load: load{bytes: 20}}
+	schedulable := map[string][]*pendingVM{
+		"hostA": {small, large},
+		"hostB": {medium},
+	}
+	scheduler := Scheduler{}
+
+	g.Expect(scheduler.next(schedulable, "")).To(gomega.BeIdenticalTo(medium))
+	g.Expect(scheduler.next(schedulable, api.MigrationOrderSmallestFirst)).To(gomega.BeIdenticalTo(small))
+	g.Expect(scheduler.next(schedulable, api.MigrationOrderLargestFirst)).To(gomega.BeIdenticalTo(large))
+
+	urgent := &pendingVM{index: 3, priority: 1, load: load{bytes: 20}}
+	schedulable["hostB"] = append(schedulable["hostB"], urgent)
+	g.Expect(scheduler.next(schedulable, api.MigrationOrderSmallestFirst)).To(gomega.BeIdenticalTo(urgent))
+
+	g.Expect(scheduler.next(map[string][]*pendingVM{}, "")).To(gomega.BeNil())
+}
+
 func newVMStatus(id string) *plan.VMStatus {
 	s := &plan.VMStatus{}
 	s.ID = id
@@ -211,3 +304,62 @@ func TestSharedDiskPriority(t *testing.T) {
 		g.Expect(pending["h1"][0].status.ID).To(gomega.Equal("vm-2998"))
 	})
 }
+
+func TestOffloadCost(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	p := &api.Plan{}
+	p.Spec.Warm = true
+	p.Referenced.Map.Storage = &api.StorageMap{
+		Spec: api.StorageMapSpec{
+			Map: []api.StoragePair{
+				{
+					OffloadPlugin: &api.OffloadPlugin{
+						VSphereXcopyPluginConfig: &api.VSphereXcopyPluginConfig{
+							SecretRef:            "secret",
+							StorageVendorProduct: api.StorageVendorProductOntap,
+						},
+					},
+				},
+			},
+		},
+	}
+	scheduler := Scheduler{Context: &plancontext.Context{Plan: p}}
+	vm := &model.VM{}
+	vm.Disks = make([]vspheremodel.Disk, 3)
+
+	status := newVMStatus("vm")
+	status.Warm = &plan.Warm{Precopies: []plan.Precopy{{Snapshot: "snapshot-1"}}}
+	step := &plan.Step{Task: plan.Task{Name: DiskTransfer}}
+	for i := 0; i < 3; i++ {
+		step.Tasks = append(step.Tasks, &plan.Task{
+			Phase:       api.StepRunning,
+			Annotations: map[string]string{"Precopy": "1"},
+		})
+	}
+	status.Pipeline = []*plan.Step{step}
+
+	// The initial copy is offloaded.
+	status.Phase = api.PhaseCopyDisks
+	g.Expect(scheduler.cost(vm, status)).To(gomega.Equal(1))
+
+	// Some disks are copied.
+	step.Tasks[0].Phase = Completed
+	g.Expect(scheduler.cost(vm, status)).To(gomega.Equal(1))
+
+	// The host is released between the precopies.
+	status.Phase = CopyingPaused
+	g.Expect(scheduler.cost(vm, status)).To(gomega.Equal(0))
+
+	// The incremental precopies are transferred by CDI.
+	status.Warm.Precopies = append(status.Warm.Precopies, plan.Precopy{Snapshot: "snapshot-2"})
+	for _, task := range step.Tasks {
+		task.Phase = api.StepRunning
+		task.Annotations["Precopy"] = "2"
+	}
+	status.Phase = api.PhaseCopyDisks
+	g.Expect(scheduler.cost(vm, status)).To(gomega.Equal(3))
+
+	status.Phase = CreateVM
+	g.Expect(scheduler.cost(vm, status)).To(gomega.Equal(0))
+}
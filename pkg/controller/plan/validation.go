@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/controller/provider/web"
 	"github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
 	"github.com/kubev2v/forklift/pkg/controller/validation"
+	"github.com/kubev2v/forklift/pkg/controller/validation/policy"
 	libaap "github.com/kubev2v/forklift/pkg/lib/aap"
 	ocp "github.com/kubev2v/forklift/pkg/lib/client/openshift"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
@@ -77,6 +79,9 @@ const (
 	HookNotValid                    = "HookNotValid"
 	HookNotReady                    = "HookNotReady"
 	HookStepNotValid                = "HookStepNotValid"
+	MigrationPolicyViolated         = "MigrationPolicyViolated"
+	MigrationPolicyWarning          = "MigrationPolicyWarning"
+	MigrationPolicyNotValid         = "MigrationPolicyNotValid"
 	Executing                       = "Executing"
 	Succeeded                       = "Succeeded"
 	Failed                          = "Failed"
@@ -117,6 +122,13 @@ const (
 	// datastore lacks NAS export details in inventory (required for MTV annotations).
 	NetAppShiftDatastoreNASMissing = "NetAppShiftDatastoreNASMissing"
 	ConversionResumable            = "ConversionResumable"
+	AutoCutoverNotValid            = "AutoCutoverNotValid"
+	DryRunRendered                 = "DryRunRendered"
+	DryRunFailed                   = "DryRunFailed"
+	RolledBack                     = "RolledBack"
+
+	// CsiVolumeImportWarmNotSupported (Critical) blocks warm migration when the storage map uses CSI volume import.
+	CsiVolumeImportWarmNotSupported = "CsiVolumeImportWarmNotSupported"
 )
 
 // Categories
@@ -150,6 +162,8 @@ const (
 	MissingGuestInfo            = "MissingGuestInformation"
 	MissingChangedBlockTracking = "MissingChangedBlockTracking"
 	ConsolidationNeeded         = "ConsolidationNeeded"
+	Violated                    = "Violated"
+	OutsideWindow               = "OutsideWindow"
 )
 
 // Statuses
@@ -225,6 +239,9 @@ func (r *Reconciler) validate(plan *api.Plan) error {
 		return err
 	}
 
+	r.validateAutoCutover(plan)
+	r.validateWarmOffload(plan)
+
 	if err = r.validateVM(plan, ctx); err != nil {
 		return err
 	}
@@ -241,6 +258,10 @@ func (r *Reconciler) validate(plan *api.Plan) error {
 		return err
 	}
 
+	if err = r.validateMigrationPolicies(plan); err != nil {
+		return err
+	}
+
 	if err = r.validateVirtV2vImage(plan); err != nil {
 		return err
 	}
@@ -463,11 +484,78 @@ func (r *Reconciler) validateWarmMigration(ctx *plancontext.Context) (err error)
 	return
 }
 
+// Validate the storage offload of warm migrations. The initial copy
+// is offloaded by the vSphere XCOPY populator and the precopies transfer
+// the changed blocks with CDI, which requires a VDDK image. The CSI
+// volume import clones the volumes once and only supports cold migrations.
+func (r *Reconciler) validateWarmOffload(plan *api.Plan) {
+	if !plan.IsWarm() || plan.Referenced.Map.Storage == nil {
+		return
+	}
+	source := plan.Referenced.Provider.Source
+	if source != nil && source.Type() == api.VSphere && plan.IsUsingOffloadPlugin() &&
+		settings.GetVDDKImage(source.Spec.Settings) == "" {
+		plan.Status.SetCondition(libcnd.Condition{
+			Type:     VDDKInitImageUnavailable,
+			Status:   True,
+			Reason:   NotSet,
+			Category: api.CategoryCritical,
+			Message:  "VDDK image is required for the precopies of warm migration with storage copy offload, only the initial copy is offloaded. Set it on the source provider or configure a global VDDK image.",
+		})
+	}
+	items := []string{}
+	for _, pair := range plan.Referenced.Map.Storage.Spec.Map {
+		if pair.OffloadPlugin != nil && pair.OffloadPlugin.CsiVolumeImport != nil {
+			items = append(items, pair.Source.String())
+		}
+	}
+	if len(items) > 0 {
+		plan.Status.SetCondition(libcnd.Condition{
+			Type:     CsiVolumeImportWarmNotSupported,
+			Status:   True,
+			Category: api.CategoryCritical,
+			Reason:   NotSupported,
+			Message:  "CSI volume import is not supported for warm migration. Use a cold migration plan, or offload the copy with `vsphereXcopyConfig`.",
+			Items:    items,
+		})
+	}
+}
+
+// Validate the automatic cutover policy.
+func (r *Reconciler) validateAutoCutover(plan *api.Plan) {
+	policy := plan.Spec.AutoCutover
+	if policy == nil {
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		plan.Status.SetCondition(libcnd.Condition{
+			Type:     AutoCutoverNotValid,
+			Status:   True,
+			Category: api.CategoryCritical,
+			Reason:   NotValid,
+			Message:  fmt.Sprintf("Automatic cutover policy not valid: %s.", err.Error()),
+		})
+	}
+}
+
 func (r *Reconciler) validateMigrationType(ctx *plancontext.Context) (err error) {
 	provider := ctx.Plan.Referenced.Provider.Source
 	if provider == nil {
 		return nil
 	}
+	// Only cold migrations to a vSphere destination are supported.
+	destination := ctx.Plan.Referenced.Provider.Destination
+	if destination != nil && destination.Type() == api.VSphere &&
+		(ctx.Plan.IsWarm() || ctx.Plan.Spec.Type == api.MigrationLive) {
+		ctx.Plan.Status.SetCondition(libcnd.Condition{
+			Type:     MigrationTypeNotValid,
+			Status:   True,
+			Category: Critical,
+			Reason:   NotSupported,
+			Message:  fmt.Sprintf("`%s` migration to a vSphere destination is not supported.", ctx.Plan.Spec.Type),
+		})
+		return
+	}
 	pAdapter, err := adapter.New(provider)
 	if err != nil {
 		return err
@@ -1663,8 +1751,35 @@ func (r *Reconciler) planHasLocalHookPods(plan *api.Plan) bool {
 
 // planHookValidSteps is the set of pipeline steps that may reference a Hook.
 var planHookValidSteps = map[string]struct{}{
-	api.PhasePreHook:  {},
-	api.PhasePostHook: {},
+	api.PhasePreHook:          {},
+	api.PhasePostHook:         {},
+	api.PhasePrePowerOffHook:  {},
+	api.PhasePostTransferHook: {},
+	api.PhasePreCutoverHook:   {},
+	api.PhaseFailureHook:      {},
+}
+
+// Determine whether the migrator of the plan runs the hook step.
+// The EC2, live and vSphere destination migrators run only the
+// pre, post and failure hooks.
+func planHookStepSupported(plan *api.Plan, step string) bool {
+	switch step {
+	case api.PhasePrePowerOffHook, api.PhasePostTransferHook, api.PhasePreCutoverHook:
+	default:
+		return true
+	}
+	if plan.Spec.Type == api.MigrationLive {
+		return false
+	}
+	source := plan.Referenced.Provider.Source
+	if source != nil && source.Type() == api.EC2 {
+		return false
+	}
+	destination := plan.Referenced.Provider.Destination
+	if destination != nil && destination.Type() == api.VSphere {
+		return false
+	}
+	return true
 }
 
 const (
@@ -1735,6 +1850,11 @@ func (r *Reconciler) validateHooks(plan *api.Plan) (err error) {
 		for _, ref := range vm.Hooks {
 			if _, ok := planHookValidSteps[ref.Step]; !ok {
 				stepNotValid.Items = append(stepNotValid.Items, planHookStepDescription(vm, ref.Step))
+			} else if ref.Step == api.PhasePreCutoverHook && !plan.IsWarm() {
+				// Only warm migrations have a cutover.
+				stepNotValid.Items = append(stepNotValid.Items, planHookStepDescription(vm, ref.Step))
+			} else if !planHookStepSupported(plan, ref.Step) {
+				stepNotValid.Items = append(stepNotValid.Items, planHookStepDescription(vm, ref.Step))
 			}
 			if !libref.RefSet(&ref.Hook) {
 				notSet.Items = append(notSet.Items, fmt.Sprintf(planHookVMOnlyFmt, vm.String()))
@@ -1792,6 +1912,165 @@ func (r *Reconciler) validateHooks(plan *api.Plan) (err error) {
 	return
 }
 
+// Migration policy rule expressions.
+var migrationPolicyRules = &policy.Rules{}
+
+// Validate the VMs against the migration policies in the plan namespace.
+func (r *Reconciler) validateMigrationPolicies(plan *api.Plan) (err error) {
+	if plan.Status.HasCondition(Executing) {
+		return
+	}
+	provider := plan.Referenced.Provider.Source
+	if provider == nil {
+		return
+	}
+	list := &api.MigrationPolicyList{}
+	err = r.List(
+		context.TODO(),
+		list,
+		client.InNamespace(plan.Namespace))
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if len(list.Items) == 0 {
+		return
+	}
+	violated := libcnd.Condition{
+		Type:     MigrationPolicyViolated,
+		Status:   True,
+		Reason:   Violated,
+		Category: api.CategoryCritical,
+		Message:  "VM violates a critical migration policy rule.",
+		Items:    []string{},
+	}
+	warned := libcnd.Condition{
+		Type:     MigrationPolicyWarning,
+		Status:   True,
+		Reason:   Violated,
+		Category: api.CategoryWarn,
+		Message:  "VM violates a migration policy rule.",
+		Items:    []string{},
+	}
+	notValid := libcnd.Condition{
+		Type:     MigrationPolicyNotValid,
+		Status:   True,
+		Reason:   NotValid,
+		Category: api.CategoryCritical,
+		Message:  "Migration policy rule cannot be evaluated.",
+		Items:    []string{},
+	}
+	inventory, err := web.NewClient(provider)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	// The rules of all the VMs are evaluated within the plan timeout
+	// so that costly rules can't hold the reconcile. The VMs left are
+	// reported as not evaluated.
+	ctx, cancel := context.WithTimeout(context.TODO(), policy.PlanTimeout)
+	defer cancel()
+	for i := range plan.Spec.VMs {
+		vm := &plan.Spec.VMs[i]
+		if vm.Ref.NotSet() {
+			continue
+		}
+		if status, found := plan.Status.Migration.FindVM(vm.Ref); found {
+			if status.HasCondition(api.ConditionSucceeded) {
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			notValid.Items = append(
+				notValid.Items,
+				fmt.Sprintf("%s (migration policies not evaluated: time limit of %s exceeded)", vm.Ref.String(), policy.PlanTimeout))
+			continue
+		}
+		v, pErr := inventory.VM(&vm.Ref)
+		if pErr != nil {
+			if errors.As(pErr, &web.NotFoundError{}) || errors.As(pErr, &web.RefNotUniqueError{}) {
+				// Reported by the VM validation.
+				continue
+			}
+			err = liberr.Wrap(pErr)
+			return
+		}
+		input := map[string]interface{}{
+			"vm":     v,
+			"planVM": vm,
+			"plan": map[string]interface{}{
+				"name":      plan.Name,
+				"namespace": plan.Namespace,
+				"spec":      plan.Spec,
+			},
+		}
+		evalMigrationPolicies(
+			ctx,
+			list.Items,
+			provider.Type(),
+			vm.Ref.String(),
+			input,
+			&violated,
+			&warned,
+			&notValid)
+	}
+	for _, cnd := range []libcnd.Condition{violated, warned, notValid} {
+		if len(cnd.Items) > 0 {
+			plan.Status.SetCondition(cnd)
+		}
+	}
+
+	return
+}
+
+// Evaluate the migration policy rules for a VM and add the
+// violations to the conditions. A rule that cannot be evaluated,
+// or is not evaluated before the context is done, is reported
+// as not valid and blocks the plan.
+func evalMigrationPolicies(
+	ctx context.Context,
+	policies []api.MigrationPolicy,
+	providerType api.ProviderType,
+	vm string,
+	input interface{},
+	violated, warned, notValid *libcnd.Condition) {
+	for i := range policies {
+		mp := &policies[i]
+		for j := range mp.Spec.Rules {
+			rule := &mp.Spec.Rules[j]
+			if rule.ProviderType != nil && *rule.ProviderType != providerType {
+				continue
+			}
+			ruleRef := path.Join(mp.Name, rule.Name)
+			if ctx.Err() != nil {
+				notValid.Items = append(
+					notValid.Items,
+					fmt.Sprintf("%s (%s: not evaluated, time limit of %s exceeded)", vm, ruleRef, policy.PlanTimeout))
+				continue
+			}
+			found, err := migrationPolicyRules.Violated(ctx, mp, rule.Expression, input)
+			if err != nil {
+				notValid.Items = append(
+					notValid.Items,
+					fmt.Sprintf("%s (%s: %s)", vm, ruleRef, err.Error()))
+				continue
+			}
+			if !found {
+				continue
+			}
+			item := fmt.Sprintf("%s (%s)", vm, ruleRef)
+			if rule.Message != "" {
+				item = fmt.Sprintf("%s (%s: %s)", vm, ruleRef, rule.Message)
+			}
+			if rule.Critical() {
+				violated.Items = append(violated.Items, item)
+			} else {
+				warned.Items = append(warned.Items, item)
+			}
+		}
+	}
+}
+
 func (r *Reconciler) validateVddkImage(plan *api.Plan) (err error) {
 	source := plan.Referenced.Provider.Source
 	if source == nil {
@@ -1815,7 +2094,8 @@ func (r *Reconciler) validateVddkImage(plan *api.Plan) (err error) {
 		}
 		err = r.validateVddkImageJob(job, plan)
 	}
-	if plan.IsWarm() && vddkImage == "" {
+	// Warm migrations with storage copy offload are validated with the offload.
+	if plan.IsWarm() && vddkImage == "" && !plan.IsUsingOffloadPlugin() {
 		plan.Status.SetCondition(libcnd.Condition{
 			Type:     VDDKInitImageUnavailable,
 			Status:   True,
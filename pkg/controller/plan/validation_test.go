@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"strconv"
 
 	k8snet "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
@@ -553,6 +554,59 @@ var _ = ginkgo.Describe("Plan Validations", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 			gomega.Expect(plan.Status.HasCondition(HookServiceAccountNotValid)).To(gomega.BeFalse())
 		})
+
+		ginkgo.It("should accept the additional hook steps", func() {
+			hook := newHook("", nil)
+			plan := newPlanWithHook()
+			plan.Spec.Warm = true
+			for _, step := range []string{api.PhasePrePowerOffHook, api.PhasePostTransferHook, api.PhasePreCutoverHook, api.PhaseFailureHook} {
+				plan.Spec.VMs[0].Hooks = append(plan.Spec.VMs[0].Hooks, apisplan.HookRef{
+					Step: step,
+					Hook: core.ObjectReference{Name: hookName, Namespace: planNS},
+				})
+			}
+			r := createFakeReconciler(hook)
+
+			err := r.validateHooks(plan)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(plan.Status.HasCondition(HookStepNotValid)).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should set condition for a pre-cutover hook in a cold plan", func() {
+			hook := newHook("", nil)
+			plan := newPlanWithHook()
+			plan.Spec.VMs[0].Hooks[0].Step = api.PhasePreCutoverHook
+			r := createFakeReconciler(hook)
+
+			err := r.validateHooks(plan)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(plan.Status.HasCondition(HookStepNotValid)).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should set condition for a post-transfer hook in an EC2 plan", func() {
+			hook := newHook("", nil)
+			plan := newPlanWithHook()
+			plan.Spec.VMs[0].Hooks[0].Step = api.PhasePostTransferHook
+			ec2 := api.EC2
+			plan.Referenced.Provider.Source = &api.Provider{Spec: api.ProviderSpec{Type: &ec2}}
+			r := createFakeReconciler(hook)
+
+			err := r.validateHooks(plan)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(plan.Status.HasCondition(HookStepNotValid)).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should set condition for a pre-power-off hook in a live plan", func() {
+			hook := newHook("", nil)
+			plan := newPlanWithHook()
+			plan.Spec.Type = api.MigrationLive
+			plan.Spec.VMs[0].Hooks[0].Step = api.PhasePrePowerOffHook
+			r := createFakeReconciler(hook)
+
+			err := r.validateHooks(plan)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(plan.Status.HasCondition(HookStepNotValid)).To(gomega.BeTrue())
+		})
 	})
 
 	ginkgo.Describe("validateConversionTempStorage", func() {
@@ -1637,3 +1691,189 @@ var _ = ginkgo.Describe("checkConversionResumable", func() {
 		gomega.Expect(cnd.Message).NotTo(gomega.ContainSubstring("succeeded-vm"))
 	})
 })
+
+var _ = ginkgo.Describe("evalMigrationPolicies", func() {
+	newConditions := func() (violated, warned, notValid *libcnd.Condition) {
+		violated = &libcnd.Condition{Type: MigrationPolicyViolated, Items: []string{}}
+		warned = &libcnd.Condition{Type: MigrationPolicyWarning, Items: []string{}}
+		notValid = &libcnd.Condition{Type: MigrationPolicyNotValid, Items: []string{}}
+		return
+	}
+	input := map[string]interface{}{
+		"vm": map[string]interface{}{
+			"cpuCount": 96,
+			"guestId":  "windows7Server64Guest",
+		},
+	}
+	policies := []api.MigrationPolicy{
+		{
+			ObjectMeta: meta.ObjectMeta{Name: "platform"},
+			Spec: api.MigrationPolicySpec{
+				Rules: []api.MigrationPolicyRule{
+					{
+						Name:       "max-cpu",
+						Expression: "input.vm.cpuCount > 64",
+						Message:    "Too many vCPUs.",
+					},
+					{
+						Name:       "windows-2008",
+						Expression: `startswith(input.vm.guestId, "windows7Server")`,
+						Severity:   api.PolicySeverityWarning,
+					},
+					{
+						Name:       "small",
+						Expression: "input.vm.cpuCount < 2",
+					},
+					{
+						Name:         "ovirt-only",
+						Expression:   "input.vm.cpuCount > 1",
+						ProviderType: ptr.To(api.OVirt),
+					},
+				},
+			},
+		},
+	}
+
+	ginkgo.It("should report the violations by severity", func() {
+		violated, warned, notValid := newConditions()
+		evalMigrationPolicies(context.TODO(), policies, api.VSphere, "vm-1", input, violated, warned, notValid)
+		gomega.Expect(violated.Items).To(gomega.Equal([]string{"vm-1 (platform/max-cpu: Too many vCPUs.)"}))
+		gomega.Expect(warned.Items).To(gomega.Equal([]string{"vm-1 (platform/windows-2008)"}))
+		gomega.Expect(notValid.Items).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should report rules that cannot be evaluated", func() {
+		violated, warned, notValid := newConditions()
+		broken := []api.MigrationPolicy{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "broken"},
+				Spec: api.MigrationPolicySpec{
+					Rules: []api.MigrationPolicyRule{
+						{Name: "parse", Expression: "input.vm.cpuCount >"},
+					},
+				},
+			},
+		}
+		evalMigrationPolicies(context.TODO(), broken, api.VSphere, "vm-1", input, violated, warned, notValid)
+		gomega.Expect(notValid.Items).To(gomega.HaveLen(1))
+		gomega.Expect(violated.Items).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should report the rules not evaluated within the plan timeout", func() {
+		violated, warned, notValid := newConditions()
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		evalMigrationPolicies(ctx, policies, api.VSphere, "vm-1", input, violated, warned, notValid)
+		gomega.Expect(notValid.Items).To(gomega.HaveLen(3))
+		gomega.Expect(violated.Items).To(gomega.BeEmpty())
+		gomega.Expect(warned.Items).To(gomega.BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("validateWarmOffload", func() {
+	var reconciler *Reconciler
+
+	ginkgo.BeforeEach(func() {
+		reconciler = &Reconciler{
+			base.Reconciler{},
+			nil,
+		}
+	})
+
+	planWithOffload := func(warm bool, plugin api.OffloadPlugin) *api.Plan {
+		p := &api.Plan{}
+		p.Spec.Warm = warm
+		p.Referenced.Map.Storage = &api.StorageMap{
+			Spec: api.StorageMapSpec{
+				Map: []api.StoragePair{
+					{
+						Source:        ref.Ref{ID: "datastore-1"},
+						OffloadPlugin: &plugin,
+					},
+				},
+			},
+		}
+		return p
+	}
+	xcopy := api.OffloadPlugin{
+		VSphereXcopyPluginConfig: &api.VSphereXcopyPluginConfig{
+			SecretRef:            "secret",
+			StorageVendorProduct: api.StorageVendorProductOntap,
+		},
+	}
+	csiImport := api.OffloadPlugin{
+		CsiVolumeImport: &api.CsiVolumeImport{
+			SecretRef:            "secret",
+			StorageVendorProduct: api.StorageVendorProductOntap,
+		},
+	}
+
+	ginkgo.It("should allow warm migration with XCOPY offload", func() {
+		p := planWithOffload(true, xcopy)
+		reconciler.validateWarmOffload(p)
+		gomega.Expect(p.Status.HasCondition(CsiVolumeImportWarmNotSupported)).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should block warm migration with CSI volume import", func() {
+		p := planWithOffload(true, csiImport)
+		reconciler.validateWarmOffload(p)
+		gomega.Expect(p.Status.HasCondition(CsiVolumeImportWarmNotSupported)).To(gomega.BeTrue())
+		gomega.Expect(p.Status.FindCondition(CsiVolumeImportWarmNotSupported).Items).To(gomega.HaveLen(1))
+	})
+
+	ginkgo.It("should allow cold migration with CSI volume import", func() {
+		p := planWithOffload(false, csiImport)
+		reconciler.validateWarmOffload(p)
+		gomega.Expect(p.Status.HasCondition(CsiVolumeImportWarmNotSupported)).To(gomega.BeFalse())
+	})
+
+	ginkgo.Describe("VDDK image", func() {
+		var savedVddkImage string
+
+		withSource := func(p *api.Plan, vddkImage string) *api.Plan {
+			vsphere := api.VSphere
+			p.Referenced.Provider.Source = &api.Provider{
+				ObjectMeta: meta.ObjectMeta{Name: "vsphere-src"},
+				Spec: api.ProviderSpec{
+					Type:     &vsphere,
+					Settings: map[string]string{api.VDDK: vddkImage},
+				},
+			}
+			return p
+		}
+
+		ginkgo.BeforeEach(func() {
+			savedVddkImage = Settings.Migration.VddkImage
+			Settings.Migration.VddkImage = ""
+		})
+
+		ginkgo.AfterEach(func() {
+			Settings.Migration.VddkImage = savedVddkImage
+		})
+
+		ginkgo.It("should require VDDK image for the precopies of warm XCOPY offload", func() {
+			p := withSource(planWithOffload(true, xcopy), "")
+			reconciler.validateWarmOffload(p)
+			gomega.Expect(p.Status.HasCondition(VDDKInitImageUnavailable)).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should accept the VDDK image of the source provider", func() {
+			p := withSource(planWithOffload(true, xcopy), "quay.io/vddk:latest")
+			reconciler.validateWarmOffload(p)
+			gomega.Expect(p.Status.HasCondition(VDDKInitImageUnavailable)).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should accept the global VDDK image", func() {
+			Settings.Migration.VddkImage = "quay.io/vddk:global"
+			p := withSource(planWithOffload(true, xcopy), "")
+			reconciler.validateWarmOffload(p)
+			gomega.Expect(p.Status.HasCondition(VDDKInitImageUnavailable)).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should not require VDDK image for cold XCOPY offload", func() {
+			p := withSource(planWithOffload(false, xcopy), "")
+			reconciler.validateWarmOffload(p)
+			gomega.Expect(p.Status.HasCondition(VDDKInitImageUnavailable)).To(gomega.BeFalse())
+		})
+	})
+})
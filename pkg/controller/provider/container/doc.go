@@ -42,3 +42,15 @@ func Build(
 
 	return nil
 }
+
+// Determine if the collector for the provider reconciles
+// an inventory kept across restarts rather than loading
+// it into an empty DB.
+func Resumable(provider *api.Provider) bool {
+	switch provider.Type() {
+	case api.VSphere:
+		return true
+	}
+
+	return false
+}
@@ -57,6 +57,16 @@ func (m *mockDriver) GetComputerInfo() (*driver.ComputerInfoData, error) {
 func (m *mockDriver) GetClusterVMGroups() ([]driver.ClusterGroupData, error) {
 	return m.clusterVMs, nil
 }
+func (m *mockDriver) CreateRecoveryCheckpoint(string, string) (string, error) { return "", nil }
+func (m *mockDriver) GetCheckpoint(string, string, string) (*driver.CheckpointData, error) {
+	return nil, nil //nolint:nilnil
+}
+func (m *mockDriver) ConvertToReferencePoint(string, string) error  { return nil }
+func (m *mockDriver) RemoveCheckpoint(string, string, string) error { return nil }
+func (m *mockDriver) GetVirtualDiskChanges(string, string, int64, string) ([]driver.DiskChange, error) {
+	return nil, nil
+}
+func (m *mockDriver) RemoveReferencePoints([]string, string) error { return nil }
 func (m *mockDriver) RunOnNode(command, computerName string) (string, error) {
 	if m.runOnNodeFn != nil {
 		return m.runOnNodeFn(command, computerName)
@@ -1,16 +1,22 @@
 package nutanix
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	libpath "path"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	"github.com/kubev2v/forklift/pkg/controller/base"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libweb "github.com/kubev2v/forklift/pkg/lib/inventory/web"
@@ -63,6 +69,33 @@ type Client struct {
 	prismResolved bool
 }
 
+// New client for the provider.
+func NewClient(provider *api.Provider, secret *core.Secret) (r *Client) {
+	log := logging.WithName("client|nutanix").WithValues(
+		"provider",
+		libpath.Join(
+			provider.GetNamespace(),
+			provider.GetName()))
+
+	var err error
+	clientTimeout := DefaultClientTimeout
+	if timeout, ok := provider.Spec.Settings["nutanixClientTimeout"]; ok {
+		if clientTimeout, err = time.ParseDuration(timeout); err != nil {
+			log.Error(err, "Couldn't parse timeout, falling back to default")
+			clientTimeout = DefaultClientTimeout
+		}
+	}
+	r = &Client{
+		url:           provider.Spec.URL,
+		secret:        secret,
+		settings:      provider.Spec.Settings,
+		log:           log,
+		clientTimeout: clientTimeout,
+	}
+
+	return
+}
+
 // Connect and authenticate with Nutanix Prism
 func (r *Client) connect() (status int, err error) {
 	var TLSClientConfig *tls.Config
@@ -188,6 +221,64 @@ func (r *Client) post(url string, body interface{}, object interface{}) (status
 	return r.client.Post(url, body, object)
 }
 
+// Send a request to a v4 endpoint. Unlike get() and post(), the reply is
+// decoded for any 2xx status since v4 actions are accepted (202) and run as
+// tasks. Mutating requests carry the NTNX-Request-Id idempotency header and
+// the entity tag (If-Match) read with the entity, when given.
+func (r *Client) send(method, url, etag string, body interface{}, object interface{}) (status int, reply http.Header, err error) {
+	status, err = r.connect()
+	if err != nil {
+		return
+	}
+
+	header := r.createAuthHeader()
+	if method != http.MethodGet {
+		header.Set("NTNX-Request-Id", uuid.New().String())
+	}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		var b []byte
+		b, err = json.Marshal(body)
+		if err != nil {
+			return
+		}
+		reader = bytes.NewReader(b)
+	}
+	request, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		err = liberr.Wrap(err, "url", url)
+		return
+	}
+	request.Header = header
+	client := http.Client{Transport: r.client.Transport}
+	response, err := client.Do(request)
+	if err != nil {
+		err = liberr.Wrap(err, "method", method, "url", url)
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		err = liberr.Wrap(err, "url", url)
+		return
+	}
+	status = response.StatusCode
+	reply = response.Header
+	if status/100 == 2 && object != nil && len(content) > 0 {
+		err = json.Unmarshal(content, object)
+		if err != nil {
+			err = liberr.Wrap(err, "url", url)
+		}
+	}
+
+	return
+}
+
 // listAllV3 pages through a v3 list endpoint and unmarshals entities directly
 // into typed structs, following the response's total_matches across pages.
 func listAllV3[T any](r *Client, resourceKind string, filter map[string]interface{}, pageSize int) ([]T, error) {
@@ -62,28 +62,8 @@ func New(db libmodel.DB, provider *api.Provider, secret *core.Secret) (r *Collec
 		libpath.Join(
 			provider.GetNamespace(),
 			provider.GetName()))
-	clientLog := logging.WithName("client|nutanix").WithValues(
-		"provider",
-		libpath.Join(
-			provider.GetNamespace(),
-			provider.GetName()))
-
-	var err error
-	clientTimeout := DefaultClientTimeout
-	if timeout, ok := provider.Spec.Settings["nutanixClientTimeout"]; ok {
-		if clientTimeout, err = time.ParseDuration(timeout); err != nil {
-			log.Error(err, "Couldn't parse timeout, falling back to default")
-			clientTimeout = DefaultClientTimeout
-		}
-	}
 	r = &Collector{
-		client: &Client{
-			url:           provider.Spec.URL,
-			secret:        secret,
-			settings:      provider.Spec.Settings,
-			log:           clientLog,
-			clientTimeout: clientTimeout,
-		},
+		client:   NewClient(provider, secret),
 		provider: provider,
 		db:       db,
 		log:      log,
@@ -1,6 +1,10 @@
 package nutanix
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 )
 
@@ -47,3 +51,115 @@ func (r *Client) listImagesCentral() ([]imageEntity, error) {
 
 	return images, nil
 }
+
+// Image states.
+const (
+	ImageComplete = "COMPLETE"
+	ImageError    = "ERROR"
+)
+
+// DiskImage is a disk image created from a VM disk.
+type DiskImage struct {
+	UUID      string
+	State     string
+	SizeBytes int64
+	// Messages reported for an image in the ERROR state.
+	Messages []string
+}
+
+// v3 image, limited to the fields used to track an image created
+// from a VM disk.
+type imageV3Raw struct {
+	Metadata metadata `json:"metadata"`
+	Status   struct {
+		State       string `json:"state"`
+		MessageList []struct {
+			Message string `json:"message"`
+			Reason  string `json:"reason"`
+		} `json:"message_list"`
+		Resources struct {
+			SizeBytes int64 `json:"size_bytes"`
+		} `json:"resources"`
+	} `json:"status"`
+}
+
+// Create a disk image from a VM disk. The image UUID is chosen by the
+// caller so the image can be found again without recording the task
+// creating it. An image that already exists has been created by an
+// earlier request.
+func (r *Client) CreateDiskImage(imageUUID, name, vmDiskUUID string) (err error) {
+	body := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"kind": "image",
+			"uuid": imageUUID,
+		},
+		"spec": map[string]interface{}{
+			"name":        name,
+			"description": "Created by Forklift to migrate the VM disk " + vmDiskUUID,
+			"resources": map[string]interface{}{
+				"image_type": "DISK_IMAGE",
+				"data_source_reference": map[string]interface{}{
+					"kind": "vm_disk",
+					"uuid": vmDiskUUID,
+				},
+			},
+		},
+	}
+	url := fmt.Sprintf("%s%s", r.url, imagesV3Path)
+	status, _, err := r.send(http.MethodPost, url, "", body, nil)
+	if err != nil {
+		return
+	}
+	switch status {
+	case http.StatusOK, http.StatusAccepted, http.StatusConflict:
+	default:
+		err = liberr.New(fmt.Sprintf("unexpected status creating image: %d", status), "name", name, "disk", vmDiskUUID)
+	}
+	return
+}
+
+// Get a disk image. Returns nil when not found.
+func (r *Client) GetDiskImage(imageUUID string) (image *DiskImage, err error) {
+	url := fmt.Sprintf("%s%s/%s", r.url, imagesV3Path, imageUUID)
+	var result imageV3Raw
+	status, _, err := r.send(http.MethodGet, url, "", nil, &result)
+	if err != nil {
+		return
+	}
+	switch status {
+	case http.StatusOK:
+		image = &DiskImage{
+			UUID:      imageUUID,
+			State:     result.Status.State,
+			SizeBytes: result.Status.Resources.SizeBytes,
+		}
+		for _, m := range result.Status.MessageList {
+			image.Messages = append(image.Messages, strings.TrimSpace(m.Reason+" "+m.Message))
+		}
+	case http.StatusNotFound:
+	default:
+		err = liberr.New(fmt.Sprintf("unexpected status getting image: %d", status), "image", imageUUID)
+	}
+	return
+}
+
+// Delete a disk image. An image that is not found has already been
+// deleted.
+func (r *Client) DeleteImage(imageUUID string) (err error) {
+	url := fmt.Sprintf("%s%s/%s", r.url, imagesV3Path, imageUUID)
+	status, _, err := r.send(http.MethodDelete, url, "", nil, nil)
+	if err != nil {
+		return
+	}
+	switch status {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent, http.StatusNotFound:
+	default:
+		err = liberr.New(fmt.Sprintf("unexpected status deleting image: %d", status), "image", imageUUID)
+	}
+	return
+}
+
+// URL the content of an image is downloaded from, given the Prism URL.
+func ImageFileURL(url, imageUUID string) string {
+	return fmt.Sprintf("%s%s/%s/file", strings.TrimRight(url, "/"), imagesV3Path, imageUUID)
+}
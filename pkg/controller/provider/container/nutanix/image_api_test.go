@@ -0,0 +1,108 @@
+package nutanix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiskImageLifecycle(t *testing.T) {
+	state := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+		case r.Method == http.MethodPost && r.URL.Path == imagesV3Path:
+			var body struct {
+				Metadata struct {
+					UUID string `json:"uuid"`
+				} `json:"metadata"`
+				Spec struct {
+					Resources struct {
+						DataSourceReference struct {
+							Kind string `json:"kind"`
+							UUID string `json:"uuid"`
+						} `json:"data_source_reference"`
+					} `json:"resources"`
+				} `json:"spec"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			source := body.Spec.Resources.DataSourceReference
+			if body.Metadata.UUID != "image-1" || source.Kind != "vm_disk" || source.UUID != "disk-1" {
+				t.Errorf("unexpected body: %+v", body)
+			}
+			state = "PENDING"
+			w.WriteHeader(http.StatusAccepted)
+		case r.URL.Path == imagesV3Path+"/image-1":
+			if state == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == http.MethodDelete {
+				state = ""
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			_, _ = w.Write([]byte(`{"metadata":{"uuid":"image-1"},"status":{"state":"` + state + `","resources":{"size_bytes":1024}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := createTestClient(server.URL)
+	image, err := client.GetDiskImage("image-1")
+	if err != nil || image != nil {
+		t.Fatalf("expected no image, got %v %v", image, err)
+	}
+	if err = client.CreateDiskImage("image-1", "forklift-image", "disk-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	image, err = client.GetDiskImage("image-1")
+	if err != nil || image == nil || image.State != "PENDING" {
+		t.Fatalf("expected a pending image, got %+v %v", image, err)
+	}
+	state = ImageComplete
+	image, _ = client.GetDiskImage("image-1")
+	if image.State != ImageComplete || image.SizeBytes != 1024 {
+		t.Fatalf("expected a complete image, got %+v", image)
+	}
+	if err = client.DeleteImage("image-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = client.DeleteImage("image-1"); err != nil {
+		t.Fatalf("deleting a missing image: %v", err)
+	}
+}
+
+func TestDiskImage_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+			return
+		case imagesV3Path + "/image-1":
+			_, _ = w.Write([]byte(`{"status":{"state":"ERROR","message_list":[{"reason":"INTERNAL_ERROR","message":"disk busy"}]}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	image, err := createTestClient(server.URL).GetDiskImage("image-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if image.State != ImageError || len(image.Messages) != 1 || !strings.Contains(image.Messages[0], "disk busy") {
+		t.Fatalf("unexpected image: %+v", image)
+	}
+}
+
+func TestImageFileURL(t *testing.T) {
+	got := ImageFileURL("https://prism:9440/", "image-1")
+	if got != "https://prism:9440/api/nutanix/v3/images/image-1/file" {
+		t.Errorf("ImageFileURL() = %s", got)
+	}
+}
@@ -17,6 +17,14 @@ const (
 	// isn't reliably populated when queried through Prism Central; images
 	// registered with PC's image service only show up here.
 	imagesV4Path = "/api/vmm/v4.0/content/images"
+	// imagesV3Path is the v3 images endpoint, served by both Prism modes.
+	// Images created from VM disks are tracked through their v3 state.
+	imagesV3Path = "/api/nutanix/v3/images"
+	// Prism Central only: recovery points (dataprotection), the tasks
+	// tracking asynchronous v4 operations (prism) and AHV VMs (vmm).
+	recoveryPointsV4Path = "/api/dataprotection/v4.0/config/recovery-points"
+	tasksV4Path          = "/api/prism/v4.0/config/tasks"
+	vmsV4Path            = "/api/vmm/v4.0/ahv/config/vms"
 )
 
 // PrismMode identifies whether the provider URL targets Prism Central or Element.
@@ -0,0 +1,299 @@
+package nutanix
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+)
+
+// Recovery point status.
+const (
+	RecoveryPointComplete = "COMPLETE"
+)
+
+// Changed region types. Zeroed regions read back as zeroes.
+const (
+	RegionRegular = "REGULAR"
+	RegionZeroed  = "ZEROED"
+)
+
+// Task status.
+const (
+	taskSucceeded = "SUCCEEDED"
+	taskFailed    = "FAILED"
+	taskCanceled  = "CANCELED"
+)
+
+// Relation of the recovery point affected by a task.
+const recoveryPointRel = "dataprotection:config:recovery-point"
+
+// RecoveryPoint is a crash consistent recovery point of one or more VMs.
+type RecoveryPoint struct {
+	ExtID            string            `json:"extId"`
+	Name             string            `json:"name"`
+	Status           string            `json:"status"`
+	VMRecoveryPoints []VMRecoveryPoint `json:"vmRecoveryPoints"`
+}
+
+// VMRecoveryPoint is the part of a recovery point capturing a VM.
+type VMRecoveryPoint struct {
+	ExtID   string `json:"extId"`
+	VMExtID string `json:"vmExtId"`
+}
+
+// Find the recovery point of a VM.
+func (r *RecoveryPoint) FindVM(vmExtID string) (ref VMRecoveryPointRef, found bool) {
+	for _, vm := range r.VMRecoveryPoints {
+		if vm.VMExtID == vmExtID {
+			ref = VMRecoveryPointRef{
+				RecoveryPoint:   r.ExtID,
+				VMRecoveryPoint: vm.ExtID,
+			}
+			found = true
+			return
+		}
+	}
+	return
+}
+
+// VMRecoveryPointRef identifies the recovery point of a VM.
+type VMRecoveryPointRef struct {
+	RecoveryPoint   string
+	VMRecoveryPoint string
+}
+
+// String representation: <recovery point>/<VM recovery point>.
+func (r VMRecoveryPointRef) String() string {
+	return r.RecoveryPoint + "/" + r.VMRecoveryPoint
+}
+
+// Parse the string representation of a VM recovery point reference.
+func ParseVMRecoveryPointRef(s string) (ref VMRecoveryPointRef, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		err = liberr.New("invalid VM recovery point reference", "ref", s)
+		return
+	}
+	ref = VMRecoveryPointRef{
+		RecoveryPoint:   parts[0],
+		VMRecoveryPoint: parts[1],
+	}
+	return
+}
+
+// ChangedRegion is a byte range of a disk that differs from the
+// reference recovery point.
+type ChangedRegion struct {
+	Offset     int64  `json:"offset"`
+	Length     int64  `json:"length"`
+	RegionType string `json:"regionType"`
+}
+
+// v4 task.
+type taskV4Raw struct {
+	ExtID            string `json:"extId"`
+	Status           string `json:"status"`
+	EntitiesAffected []struct {
+		ExtID string `json:"extId"`
+		Rel   string `json:"rel"`
+	} `json:"entitiesAffected"`
+	ErrorMessages []struct {
+		Message string `json:"message"`
+	} `json:"errorMessages"`
+}
+
+// v4 reference to the task running an asynchronous operation.
+type taskReferenceV4Raw struct {
+	Data struct {
+		ExtID string `json:"extId"`
+	} `json:"data"`
+}
+
+// Recovery points are only served by Prism Central.
+func (r *Client) ensureCentral() error {
+	if _, err := r.connect(); err != nil {
+		return err
+	}
+	if r.prism.Mode != PrismCentral {
+		return liberr.New("recovery points require Prism Central", "mode", r.prism.Mode)
+	}
+	return nil
+}
+
+// Create a crash consistent recovery point of a VM. Returns the
+// task creating the recovery point.
+func (r *Client) CreateRecoveryPoint(name, vmExtID string) (task string, err error) {
+	if err = r.ensureCentral(); err != nil {
+		return
+	}
+	body := map[string]interface{}{
+		"name":              name,
+		"recoveryPointType": "CRASH_CONSISTENT",
+		"vmRecoveryPoints": []map[string]interface{}{
+			{"vmExtId": vmExtID},
+		},
+	}
+	url := fmt.Sprintf("%s%s", r.url, recoveryPointsV4Path)
+	var result taskReferenceV4Raw
+	status, _, err := r.send(http.MethodPost, url, "", body, &result)
+	if err != nil {
+		return
+	}
+	if status != http.StatusAccepted && status != http.StatusOK {
+		err = liberr.New(fmt.Sprintf("unexpected status creating recovery point: %d", status), "name", name)
+		return
+	}
+	task = result.Data.ExtID
+	return
+}
+
+// Get the recovery point created by a task. Reports done when the task has
+// succeeded; a failed or canceled task is an error.
+func (r *Client) RecoveryPointTask(task string) (recoveryPoint string, done bool, err error) {
+	if err = r.ensureCentral(); err != nil {
+		return
+	}
+	url := fmt.Sprintf("%s%s/%s", r.url, tasksV4Path, task)
+	var result struct {
+		Data taskV4Raw `json:"data"`
+	}
+	status, _, err := r.send(http.MethodGet, url, "", nil, &result)
+	if err != nil {
+		return
+	}
+	if status != http.StatusOK {
+		err = liberr.New(fmt.Sprintf("unexpected status getting task: %d", status), "task", task)
+		return
+	}
+	switch result.Data.Status {
+	case taskSucceeded:
+		for _, entity := range result.Data.EntitiesAffected {
+			if entity.Rel == recoveryPointRel {
+				recoveryPoint = entity.ExtID
+				done = true
+				return
+			}
+		}
+		err = liberr.New("task did not create a recovery point", "task", task)
+	case taskFailed, taskCanceled:
+		messages := []string{}
+		for _, m := range result.Data.ErrorMessages {
+			messages = append(messages, m.Message)
+		}
+		err = liberr.New(
+			"recovery point task did not succeed",
+			"task", task,
+			"status", result.Data.Status,
+			"errors", strings.Join(messages, "; "))
+	}
+	return
+}
+
+// Get a recovery point. Returns nil when not found.
+func (r *Client) GetRecoveryPoint(extID string) (recoveryPoint *RecoveryPoint, err error) {
+	recoveryPoint, _, err = r.getRecoveryPoint(extID)
+	return
+}
+
+// Get a recovery point and its entity tag.
+func (r *Client) getRecoveryPoint(extID string) (recoveryPoint *RecoveryPoint, etag string, err error) {
+	if err = r.ensureCentral(); err != nil {
+		return
+	}
+	url := fmt.Sprintf("%s%s/%s", r.url, recoveryPointsV4Path, extID)
+	var result struct {
+		Data RecoveryPoint `json:"data"`
+	}
+	status, reply, err := r.send(http.MethodGet, url, "", nil, &result)
+	if err != nil {
+		return
+	}
+	switch status {
+	case http.StatusOK:
+		recoveryPoint = &result.Data
+		etag = reply.Get("ETag")
+	case http.StatusNotFound:
+	default:
+		err = liberr.New(fmt.Sprintf("unexpected status getting recovery point: %d", status), "recoveryPoint", extID)
+	}
+	return
+}
+
+// Delete a recovery point. A recovery point that is not found has
+// already been deleted. Deletion is asynchronous; poll GetRecoveryPoint
+// to learn when it is gone.
+func (r *Client) DeleteRecoveryPoint(extID string) (err error) {
+	recoveryPoint, etag, err := r.getRecoveryPoint(extID)
+	if err != nil || recoveryPoint == nil {
+		return
+	}
+	url := fmt.Sprintf("%s%s/%s", r.url, recoveryPointsV4Path, extID)
+	status, _, err := r.send(http.MethodDelete, url, etag, nil, nil)
+	if err != nil {
+		return
+	}
+	switch status {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent, http.StatusNotFound:
+	default:
+		err = liberr.New(fmt.Sprintf("unexpected status deleting recovery point: %d", status), "recoveryPoint", extID)
+	}
+	return
+}
+
+// Get the regions of a disk that changed between the reference recovery
+// point and the recovery point. The regions are requested a page at a
+// time, following the next offset of the reply.
+func (r *Client) ChangedRegions(vm VMRecoveryPointRef, diskExtID string, reference VMRecoveryPointRef) (regions []ChangedRegion, err error) {
+	if err = r.ensureCentral(); err != nil {
+		return
+	}
+	url := fmt.Sprintf(
+		"%s%s/%s/vm-recovery-points/%s/disks/%s/$actions/compute-changed-regions",
+		r.url, recoveryPointsV4Path, vm.RecoveryPoint, vm.VMRecoveryPoint, diskExtID)
+	offset := int64(0)
+	for {
+		body := map[string]interface{}{
+			"offset": offset,
+			"referenceDiskRecoveryPoint": map[string]interface{}{
+				"recoveryPointExtId":   reference.RecoveryPoint,
+				"vmRecoveryPointExtId": reference.VMRecoveryPoint,
+				"diskExtId":            diskExtID,
+			},
+		}
+		var result struct {
+			Data struct {
+				Regions    []ChangedRegion `json:"regions"`
+				NextOffset *int64          `json:"nextOffset"`
+			} `json:"data"`
+		}
+		var status int
+		status, _, err = r.send(http.MethodPost, url, "", body, &result)
+		if err != nil {
+			return
+		}
+		if status != http.StatusOK {
+			err = liberr.New(
+				fmt.Sprintf("unexpected status computing changed regions: %d", status),
+				"recoveryPoint", vm.String(),
+				"disk", diskExtID)
+			return
+		}
+		regions = append(regions, result.Data.Regions...)
+		next := result.Data.NextOffset
+		if next == nil || *next <= offset {
+			break
+		}
+		offset = *next
+	}
+	return
+}
+
+// URL the data of a disk in a recovery point is read from with HTTP
+// range requests, given the Prism Central URL.
+func DiskDataURL(url string, vm VMRecoveryPointRef, diskExtID string) string {
+	return fmt.Sprintf(
+		"%s%s/%s/vm-recovery-points/%s/disks/%s/data",
+		strings.TrimRight(url, "/"), recoveryPointsV4Path, vm.RecoveryPoint, vm.VMRecoveryPoint, diskExtID)
+}
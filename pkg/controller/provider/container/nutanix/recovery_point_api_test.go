@@ -0,0 +1,258 @@
+package nutanix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+)
+
+func createCentralTestClient(url string) *Client {
+	return createTestClientWithSettings(url, map[string]string{
+		api.NutanixPrismType: api.NutanixPrismCentral,
+	})
+}
+
+func TestRecoveryPointLifecycle(t *testing.T) {
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+		case r.Method == http.MethodPost && r.URL.Path == recoveryPointsV4Path:
+			if r.Header.Get("NTNX-Request-Id") == "" {
+				t.Error("expected NTNX-Request-Id header")
+			}
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "rp-1" {
+				t.Errorf("unexpected name: %v", body["name"])
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"data":{"extId":"task-1"}}`))
+		case r.URL.Path == tasksV4Path+"/task-1":
+			_, _ = w.Write([]byte(`{"data":{"extId":"task-1","status":"SUCCEEDED","entitiesAffected":[
+				{"extId":"vm-1","rel":"vmm:ahv:config:vm"},
+				{"extId":"rp-uuid","rel":"dataprotection:config:recovery-point"}]}}`))
+		case r.URL.Path == recoveryPointsV4Path+"/rp-uuid":
+			if deleted {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == http.MethodDelete {
+				if r.Header.Get("If-Match") != "etag-1" {
+					t.Errorf("unexpected If-Match: %q", r.Header.Get("If-Match"))
+				}
+				deleted = true
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"data":{"extId":"task-2"}}`))
+				return
+			}
+			w.Header().Set("ETag", "etag-1")
+			_, _ = w.Write([]byte(`{"data":{"extId":"rp-uuid","name":"rp-1","status":"COMPLETE",
+				"vmRecoveryPoints":[{"extId":"vmrp-1","vmExtId":"vm-1"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := createCentralTestClient(server.URL)
+	task, err := client.CreateRecoveryPoint("rp-1", "vm-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task != "task-1" {
+		t.Fatalf("expected task-1, got %s", task)
+	}
+	rpID, done, err := client.RecoveryPointTask(task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done || rpID != "rp-uuid" {
+		t.Fatalf("expected done with rp-uuid, got %v %s", done, rpID)
+	}
+	rp, err := client.GetRecoveryPoint(rpID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rp == nil || rp.Status != RecoveryPointComplete {
+		t.Fatalf("expected complete recovery point, got %+v", rp)
+	}
+	ref, found := rp.FindVM("vm-1")
+	if !found || ref.String() != "rp-uuid/vmrp-1" {
+		t.Fatalf("unexpected VM recovery point: %v %s", found, ref)
+	}
+	if err = client.DeleteRecoveryPoint(rpID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rp, err = client.GetRecoveryPoint(rpID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rp != nil {
+		t.Fatal("expected recovery point to be deleted")
+	}
+	// Deleting again is a no-op.
+	if err = client.DeleteRecoveryPoint(rpID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecoveryPointTask_Failed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+		case tasksV4Path + "/task-1":
+			_, _ = w.Write([]byte(`{"data":{"status":"FAILED","errorMessages":[{"message":"quota exceeded"}]}}`))
+		case tasksV4Path + "/task-2":
+			_, _ = w.Write([]byte(`{"data":{"status":"RUNNING"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := createCentralTestClient(server.URL)
+	_, done, err := client.RecoveryPointTask("task-1")
+	if err == nil || !strings.Contains(err.Error(), "did not succeed") {
+		t.Fatalf("expected task failure, got %v", err)
+	}
+	if done {
+		t.Fatal("failed task must not be done")
+	}
+	_, done, err = client.RecoveryPointTask("task-2")
+	if err != nil || done {
+		t.Fatalf("expected running task, got %v %v", done, err)
+	}
+}
+
+func TestChangedRegions_Pages(t *testing.T) {
+	var offsets []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+		case recoveryPointsV4Path + "/rp-2/vm-recovery-points/vmrp-2/disks/disk-1/$actions/compute-changed-regions":
+			var body struct {
+				Offset    float64           `json:"offset"`
+				Reference map[string]string `json:"referenceDiskRecoveryPoint"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Reference["recoveryPointExtId"] != "rp-1" || body.Reference["vmRecoveryPointExtId"] != "vmrp-1" {
+				t.Errorf("unexpected reference: %v", body.Reference)
+			}
+			offsets = append(offsets, body.Offset)
+			if body.Offset == 0 {
+				_, _ = w.Write([]byte(`{"data":{"regions":[{"offset":0,"length":4096,"regionType":"REGULAR"}],"nextOffset":1048576}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"regions":[{"offset":1048576,"length":8192,"regionType":"ZEROED"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := createCentralTestClient(server.URL)
+	regions, err := client.ChangedRegions(
+		VMRecoveryPointRef{RecoveryPoint: "rp-2", VMRecoveryPoint: "vmrp-2"},
+		"disk-1",
+		VMRecoveryPointRef{RecoveryPoint: "rp-1", VMRecoveryPoint: "vmrp-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(offsets) != 2 || offsets[1] != 1048576 {
+		t.Fatalf("unexpected request offsets: %v", offsets)
+	}
+	expected := []ChangedRegion{
+		{Offset: 0, Length: 4096, RegionType: RegionRegular},
+		{Offset: 1048576, Length: 8192, RegionType: RegionZeroed},
+	}
+	if len(regions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, regions)
+	}
+	for i := range expected {
+		if regions[i] != expected[i] {
+			t.Errorf("regions[%d] = %+v, want %+v", i, regions[i], expected[i])
+		}
+	}
+}
+
+func TestRecoveryPoints_RequireCentral(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/nutanix/v3/clusters/list" {
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+			return
+		}
+		t.Errorf("unexpected request: %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := createTestClient(server.URL)
+	if _, err := client.CreateRecoveryPoint("rp-1", "vm-1"); err == nil {
+		t.Fatal("expected an error on Prism Element")
+	}
+}
+
+func TestVMRecoveryPointRef(t *testing.T) {
+	ref, err := ParseVMRecoveryPointRef("rp-1/vmrp-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.RecoveryPoint != "rp-1" || ref.VMRecoveryPoint != "vmrp-1" {
+		t.Fatalf("unexpected ref: %+v", ref)
+	}
+	for _, s := range []string{"", "rp-1", "rp-1/", "/vmrp-1", "a/b/c"} {
+		if _, err = ParseVMRecoveryPointRef(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+	url := DiskDataURL("https://pc:9440/", ref, "disk-1")
+	expected := "https://pc:9440" + recoveryPointsV4Path + "/rp-1/vm-recovery-points/vmrp-1/disks/disk-1/data"
+	if url != expected {
+		t.Fatalf("DiskDataURL() = %s, want %s", url, expected)
+	}
+}
+
+func TestPowerOffVM(t *testing.T) {
+	var action string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/nutanix/v3/clusters/list":
+			_, _ = w.Write([]byte(`{"entities":[]}`))
+		case r.Method == http.MethodGet && r.URL.Path == vmsV4Path+"/vm-1":
+			w.Header().Set("ETag", "etag-vm")
+			_, _ = w.Write([]byte(`{"data":{"extId":"vm-1","powerState":"ON"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == vmsV4Path+"/vm-1/$actions/power-off":
+			if r.Header.Get("If-Match") != "etag-vm" {
+				t.Errorf("unexpected If-Match: %q", r.Header.Get("If-Match"))
+			}
+			action = "power-off"
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := createCentralTestClient(server.URL)
+	state, err := client.VMPowerState("vm-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != PowerStateOn {
+		t.Fatalf("expected ON, got %s", state)
+	}
+	if err = client.PowerOffVM("vm-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action != "power-off" {
+		t.Fatal("expected power-off action")
+	}
+}
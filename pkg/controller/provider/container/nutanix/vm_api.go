@@ -0,0 +1,79 @@
+package nutanix
+
+import (
+	"fmt"
+	"net/http"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+)
+
+// VM power states.
+const (
+	PowerStateOn  = "ON"
+	PowerStateOff = "OFF"
+)
+
+// v4 AHV VM, limited to the fields used by power management.
+type vmV4Raw struct {
+	ExtID      string `json:"extId"`
+	PowerState string `json:"powerState"`
+}
+
+// Get the power state of a VM.
+func (r *Client) VMPowerState(vmExtID string) (state string, err error) {
+	vm, _, err := r.getVM(vmExtID)
+	if err != nil {
+		return
+	}
+	state = vm.PowerState
+	return
+}
+
+// Power on a VM.
+func (r *Client) PowerOnVM(vmExtID string) error {
+	return r.vmAction(vmExtID, "power-on")
+}
+
+// Power off a VM.
+func (r *Client) PowerOffVM(vmExtID string) error {
+	return r.vmAction(vmExtID, "power-off")
+}
+
+// Get a VM and its entity tag.
+func (r *Client) getVM(vmExtID string) (vm *vmV4Raw, etag string, err error) {
+	if err = r.ensureCentral(); err != nil {
+		return
+	}
+	url := fmt.Sprintf("%s%s/%s", r.url, vmsV4Path, vmExtID)
+	var result struct {
+		Data vmV4Raw `json:"data"`
+	}
+	status, reply, err := r.send(http.MethodGet, url, "", nil, &result)
+	if err != nil {
+		return
+	}
+	if status != http.StatusOK {
+		err = liberr.New(fmt.Sprintf("unexpected status getting VM: %d", status), "vm", vmExtID)
+		return
+	}
+	vm = &result.Data
+	etag = reply.Get("ETag")
+	return
+}
+
+// Run a VM action. The action runs as a task.
+func (r *Client) vmAction(vmExtID, action string) (err error) {
+	_, etag, err := r.getVM(vmExtID)
+	if err != nil {
+		return
+	}
+	url := fmt.Sprintf("%s%s/%s/$actions/%s", r.url, vmsV4Path, vmExtID, action)
+	status, _, err := r.send(http.MethodPost, url, etag, nil, nil)
+	if err != nil {
+		return
+	}
+	if status != http.StatusAccepted && status != http.StatusOK {
+		err = liberr.New(fmt.Sprintf("unexpected status running VM action: %d", status), "vm", vmExtID, "action", action)
+	}
+	return
+}
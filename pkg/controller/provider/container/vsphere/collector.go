@@ -301,6 +301,8 @@ type Collector struct {
 	cancel func()
 	// has parity.
 	parity bool
+	// Reconcile the inventory found in the DB.
+	resync *Resync
 	// Cached missing privileges from the last check.
 	missingPrivs   *privilegeCheckResult
 	missingPrivsMu *sync.RWMutex
@@ -443,6 +445,8 @@ func (r *Collector) Shutdown() {
 //  1. connect.
 //  2. apply updates.
 //
+// When the DB contains an inventory previously collected,
+// parity is reported while it is reconciled.
 // Blocks waiting on updates until canceled.
 func (r *Collector) getUpdates(ctx context.Context) error {
 	_, err := r.connect(ctx)
@@ -504,6 +508,7 @@ func (r *Collector) getUpdates(ctx context.Context) error {
 	watchList := []*libmodel.Watch{}
 	defer func() {
 		r.parity = false
+		r.resync = nil
 		for _, w := range watchList {
 			w.End()
 		}
@@ -514,6 +519,15 @@ func (r *Collector) getUpdates(ctx context.Context) error {
 			}
 		}
 	}()
+	r.resync, err = NewResync(r.db)
+	if err != nil {
+		return err
+	}
+	if r.resync != nil {
+		r.parity = true
+		r.log.Info("Inventory found, reconciling.")
+		watchList = r.watch()
+	}
 	for {
 		response, err := methods.WaitForUpdatesEx(ctx, r.client, &req)
 		if err != nil {
@@ -534,6 +548,7 @@ func (r *Collector) getUpdates(ctx context.Context) error {
 			continue
 		}
 		req.Version = updateSet.Version
+		complete := updateSet.Truncated == nil || !*updateSet.Truncated
 		tx, err = r.db.Begin()
 		if err != nil {
 			return err
@@ -547,6 +562,18 @@ func (r *Collector) getUpdates(ctx context.Context) error {
 				break
 			}
 		}
+		if err == nil && complete && r.resync != nil {
+			err = r.resync.Purge(tx)
+			if err != nil {
+				r.log.Error(err, "purge failed.")
+			}
+		}
+		if err == nil && complete && !r.parity {
+			err = checkpoint(tx)
+			if err != nil {
+				r.log.Error(err, "checkpoint failed.")
+			}
+		}
 		if err == nil {
 			err = tx.Commit()
 			if err != nil {
@@ -557,7 +584,14 @@ func (r *Collector) getUpdates(ctx context.Context) error {
 				r.log.Error(endErr, "tx rollback failed.")
 			}
 		}
-		if err == nil && (updateSet.Truncated == nil || !*updateSet.Truncated) {
+		if err == nil && complete {
+			if r.resync != nil {
+				r.resync = nil
+				r.log.Info(
+					"Inventory reconciled.",
+					"duration",
+					time.Since(mark))
+			}
 			if !r.parity {
 				r.parity = true
 				r.log.Info(
@@ -1276,6 +1310,9 @@ func (r Collector) applyEnter(tx *libmodel.Tx, u types.ObjectUpdate) error {
 	if !selected {
 		return nil
 	}
+	if r.resync != nil {
+		return r.resync.Enter(tx, adapter, u)
+	}
 	adapter.Apply(u)
 	m := adapter.Model()
 	err := tx.Insert(m)
@@ -477,6 +477,110 @@ var _ = Describe("apply with tags", func() {
 	})
 })
 
+var _ = Describe("resync", func() {
+	var (
+		collector *Collector
+		db        libmodel.DB
+		tmpDir    string
+		ctx       context.Context
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "vsphere-resync-test")
+		Expect(err).NotTo(HaveOccurred())
+		db = libmodel.New(filepath.Join(tmpDir, "test.db"), model.All()...)
+		err = db.Open(true)
+		Expect(err).NotTo(HaveOccurred())
+		collector = &Collector{
+			log: logging.WithName("test"),
+			db:  db,
+		}
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		if db != nil {
+			_ = db.Close(true)
+		}
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	It("should not resync without a checkpoint", func() {
+		resync, err := NewResync(db)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resync).To(BeNil())
+	})
+
+	It("should resync once the checkpoint is recorded", func() {
+		tx, err := db.Begin()
+		Expect(err).NotTo(HaveOccurred())
+		err = checkpoint(tx)
+		Expect(err).NotTo(HaveOccurred())
+		err = tx.Commit()
+		Expect(err).NotTo(HaveOccurred())
+		resync, err := NewResync(db)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resync).NotTo(BeNil())
+	})
+
+	It("should update only changed models and purge objects not listed", func() {
+		err := db.Insert(&model.VM{Base: model.Base{ID: "vm-kept"}})
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Insert(&model.VM{Base: model.Base{ID: "vm-changed"}})
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Insert(&model.VM{Base: model.Base{ID: "vm-gone"}})
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Insert(&libmodel.Checkpoint{Name: CheckpointName})
+		Expect(err).NotTo(HaveOccurred())
+		kept := &model.VM{Base: model.Base{ID: "vm-kept"}}
+		err = db.Get(kept)
+		Expect(err).NotTo(HaveOccurred())
+
+		collector.resync, err = NewResync(db)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(collector.resync).NotTo(BeNil())
+		updates := []types.ObjectUpdate{
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  types.ManagedObjectReference{Type: VirtualMachine, Value: "vm-kept"},
+			},
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  types.ManagedObjectReference{Type: VirtualMachine, Value: "vm-changed"},
+				ChangeSet: []types.PropertyChange{
+					{Name: fName, Op: types.PropertyChangeOpAssign, Val: "renamed"},
+				},
+			},
+			{
+				Kind: types.ObjectUpdateKindEnter,
+				Obj:  types.ManagedObjectReference{Type: VirtualMachine, Value: "vm-new"},
+			},
+		}
+		tx, err := db.Begin()
+		Expect(err).NotTo(HaveOccurred())
+		err = collector.apply(ctx, tx, updates)
+		Expect(err).NotTo(HaveOccurred())
+		err = collector.resync.Purge(tx)
+		Expect(err).NotTo(HaveOccurred())
+		err = tx.Commit()
+		Expect(err).NotTo(HaveOccurred())
+
+		vm := &model.VM{Base: model.Base{ID: "vm-kept"}}
+		err = db.Get(vm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.Revision).To(Equal(kept.Revision))
+		vm = &model.VM{Base: model.Base{ID: "vm-changed"}}
+		err = db.Get(vm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.Name).To(Equal("renamed"))
+		err = db.Get(&model.VM{Base: model.Base{ID: "vm-new"}})
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Get(&model.VM{Base: model.Base{ID: "vm-gone"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("HasParity", func() {
 	It("should return false initially", func() {
 		collector := &Collector{}
@@ -477,6 +477,7 @@ func (v *HostAdapter) Apply(u types.ObjectUpdate) {
 				v.model.AdvancedOptions = v.Ref(p.Val)
 			case fHostBusAdapter:
 				if array, cast := p.Val.(types.ArrayOfHostHostBusAdapter); cast {
+					v.model.HbaDiskInfo = nil
 					for _, hba := range array.HostHostBusAdapter {
 						hbaDiskInfo := model.HbaDiskInfo{}
 						protocolType := model.ProtocolUnknown
@@ -508,6 +509,7 @@ func (v *HostAdapter) Apply(u types.ObjectUpdate) {
 				}
 			case fScsiTopology:
 				if array, cast := p.Val.(types.ArrayOfHostScsiTopologyInterface); cast {
+					v.model.HostScsiTopology = nil
 					for _, scsiTopologyInterface := range array.HostScsiTopologyInterface {
 						hostScsiTopology := model.HostScsiTopology{}
 						hostScsiTopology.HbaKey = scsiTopologyInterface.Adapter
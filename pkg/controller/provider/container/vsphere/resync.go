@@ -0,0 +1,142 @@
+package vsphere
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	model "github.com/kubev2v/forklift/pkg/controller/provider/model/vsphere"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Checkpoint name.
+const CheckpointName = "vsphere"
+
+// Reconcile the inventory found in the DB.
+// The version of the property collector is scoped to the
+// collector created for the session and cannot be resumed by
+// a new session. Instead, the initial update set (which lists all
+// objects) is compared with the stored models. Only models that
+// changed are updated and models for objects no longer listed
+// are deleted once the update set is complete.
+type Resync struct {
+	// Keys of the listed objects.
+	listed map[string]bool
+}
+
+// Build a resync when the DB contains an inventory
+// previously collected. The DB contains an inventory when
+// the checkpoint has been recorded.
+func NewResync(db libmodel.DB) (resync *Resync, err error) {
+	checkpoint := &libmodel.Checkpoint{Name: CheckpointName}
+	err = db.Get(checkpoint)
+	if err != nil {
+		if errors.Is(err, libmodel.NotFound) {
+			err = nil
+		} else {
+			err = liberr.Wrap(err)
+		}
+		return
+	}
+	resync = &Resync{
+		listed: make(map[string]bool),
+	}
+
+	return
+}
+
+// Object listed.
+// The model is inserted when not found and updated only
+// when the update changed it.
+func (r *Resync) Enter(tx *libmodel.Tx, adapter Adapter, u types.ObjectUpdate) (err error) {
+	m := adapter.Model()
+	r.listed[r.key(m)] = true
+	err = tx.Get(m)
+	if err != nil {
+		if errors.Is(err, libmodel.NotFound) {
+			adapter.Apply(u)
+			err = tx.Insert(m)
+		}
+		if err != nil {
+			err = liberr.Wrap(err)
+		}
+		return
+	}
+	stored, err := json.Marshal(m)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	adapter.Apply(u)
+	applied, err := json.Marshal(m)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if string(stored) == string(applied) {
+		return
+	}
+	err = tx.Update(m)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+
+	return
+}
+
+// Delete models for objects not listed.
+func (r *Resync) Purge(tx *libmodel.Tx) (err error) {
+	lists := []interface{}{
+		&[]model.Folder{},
+		&[]model.Datacenter{},
+		&[]model.Cluster{},
+		&[]model.Host{},
+		&[]model.Network{},
+		&[]model.Datastore{},
+		&[]model.VM{},
+	}
+	for _, list := range lists {
+		err = tx.List(list, libmodel.ListOptions{})
+		if err != nil {
+			err = liberr.Wrap(err)
+			return
+		}
+		lv := reflect.ValueOf(list).Elem()
+		for i := 0; i < lv.Len(); i++ {
+			m := lv.Index(i).Addr().Interface().(libmodel.Model)
+			if r.listed[r.key(m)] {
+				continue
+			}
+			err = tx.Delete(m)
+			if err != nil {
+				err = liberr.Wrap(err)
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Model key.
+func (r *Resync) key(m libmodel.Model) string {
+	return fmt.Sprintf("%T/%s", m, m.Pk())
+}
+
+// Record the checkpoint.
+// Recorded once the initial update set loaded into an empty DB
+// is complete. A reconciled DB already has the checkpoint.
+func checkpoint(tx *libmodel.Tx) (err error) {
+	err = tx.Insert(
+		&libmodel.Checkpoint{
+			Name: CheckpointName,
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+
+	return
+}
@@ -95,6 +95,11 @@ func Add(mgr manager.Manager) error {
 
 	web.Start()
 
+	if Settings.PolicyAgent.Embedded {
+		policy.Agent.Evaluator = &policy.Evaluator{
+			Client: mgr.GetAPIReader(),
+		}
+	}
 	policy.Agent.Start()
 
 	cnt, err := controller.New(
@@ -364,7 +369,11 @@ func (r *Reconciler) updateContainer(provider *api.Provider) (err error) {
 
 	log.Info("Update container.")
 	db := r.getDB(provider)
-	err = db.Open(true)
+	// The DB kept across restarts is reused only on the first
+	// load and only by collectors able to reconcile it.
+	_, loaded := r.container.Get(provider)
+	keep := Settings.Inventory.Persistent && !loaded && container.Resumable(provider)
+	err = db.Open(!keep)
 	if err != nil {
 		return
 	}
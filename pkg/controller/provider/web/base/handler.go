@@ -10,6 +10,7 @@ import (
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	"github.com/kubev2v/forklift/pkg/controller/provider/model/base"
 	libcontainer "github.com/kubev2v/forklift/pkg/lib/inventory/container"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
 	libweb "github.com/kubev2v/forklift/pkg/lib/inventory/web"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -22,6 +23,7 @@ const (
 	DetailParam   = "detail"
 	NsParam       = "namespace"
 	NameParam     = "name"
+	QueryParam    = "query"
 )
 
 // Reply Header.
@@ -61,6 +63,8 @@ type Handler struct {
 	Collector libcontainer.Collector
 	// Resources detail level.
 	Detail int
+	// Predicate parsed from the `query` parameter.
+	Query libmodel.Predicate
 }
 
 // Prepare to handle the request.
@@ -77,11 +81,15 @@ func (h *Handler) Prepare(ctx *gin.Context) (int, error) {
 	if status != http.StatusOK {
 		return status, nil
 	}
+	status, err := h.setQuery(ctx)
+	if status != http.StatusOK {
+		return status, err
+	}
 	status = h.setProvider(ctx)
 	if status != http.StatusOK {
 		return status, nil
 	}
-	status, err := h.permit(ctx)
+	status, err = h.permit(ctx)
 	if status != http.StatusOK {
 		return status, err
 	}
@@ -142,6 +150,35 @@ func (h *Handler) setDetail(ctx *gin.Context) (status int) {
 	return
 }
 
+// Set the query predicate.
+// The query is parsed here and built against the listed
+// model by the collection handlers.
+func (h *Handler) setQuery(ctx *gin.Context) (status int, err error) {
+	status = http.StatusOK
+	q := ctx.Request.URL.Query()
+	query := q.Get(QueryParam)
+	if len(strings.TrimSpace(query)) == 0 {
+		return
+	}
+	h.Query, err = libmodel.ParseQuery(query)
+	if err != nil {
+		status = http.StatusBadRequest
+	}
+
+	return
+}
+
+// Combine the list predicate with the query predicate.
+func (h *Handler) WithQuery(p libmodel.Predicate) libmodel.Predicate {
+	if h.Query == nil {
+		return p
+	}
+	if p == nil {
+		return h.Query
+	}
+	return libmodel.And(p, h.Query)
+}
+
 func (h *Handler) Token(ctx *gin.Context) string {
 	return DefaultAuth.Token(ctx)
 }
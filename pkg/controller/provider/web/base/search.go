@@ -0,0 +1,117 @@
+package base
+
+import (
+	"strings"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
+)
+
+// Search fields.
+const (
+	SearchName = "name"
+	SearchUUID = "uuid"
+	SearchIP   = "ip"
+	SearchMAC  = "mac"
+)
+
+// Search the VMs in the provider inventory.
+// Implemented by each provider type.
+type Searcher func(provider *api.Provider, db libmodel.DB, term SearchTerm) ([]SearchHit, error)
+
+// Search term.
+// The name is matched by case-insensitive substring.
+// The UUID, IP and MAC addresses are matched exactly, ignoring
+// the case and the MAC address separators.
+type SearchTerm struct {
+	// Lower-case text.
+	text string
+	// Text without MAC address separators.
+	mac string
+}
+
+// Build a search term.
+func NewSearchTerm(text string) (t SearchTerm) {
+	t.text = strings.ToLower(strings.TrimSpace(text))
+	t.mac = t.stripMAC(t.text)
+	return
+}
+
+// Empty term.
+func (t SearchTerm) Empty() bool {
+	return t.text == ""
+}
+
+// Match the candidate.
+// Returns the field matched.
+func (t SearchTerm) Match(c SearchCandidate) (field string, matched bool) {
+	if t.Empty() {
+		return
+	}
+	if strings.Contains(strings.ToLower(c.Name), t.text) {
+		return SearchName, true
+	}
+	for _, uuid := range c.UUIDs {
+		if uuid != "" && strings.ToLower(uuid) == t.text {
+			return SearchUUID, true
+		}
+	}
+	for _, ip := range c.IPs {
+		if ip != "" && strings.ToLower(ip) == t.text {
+			return SearchIP, true
+		}
+	}
+	for _, mac := range c.MACs {
+		if mac != "" && t.stripMAC(strings.ToLower(mac)) == t.mac {
+			return SearchMAC, true
+		}
+	}
+
+	return
+}
+
+// Remove the MAC address separators.
+func (t SearchTerm) stripMAC(s string) string {
+	return strings.NewReplacer(":", "", "-", "", ".", "").Replace(s)
+}
+
+// Search candidate.
+// The identifiers and addresses of a VM.
+type SearchCandidate struct {
+	Name  string
+	UUIDs []string
+	IPs   []string
+	MACs  []string
+}
+
+// Search hit.
+type SearchHit struct {
+	// Provider.
+	Provider SearchProvider `json:"provider"`
+	// Resource kind.
+	Kind string `json:"kind"`
+	// Resource ID.
+	ID string `json:"id"`
+	// Resource name.
+	Name string `json:"name"`
+	// Field matched.
+	Matched string `json:"matched"`
+	// Link to the resource.
+	SelfLink string `json:"selfLink"`
+}
+
+// Provider of a search hit.
+type SearchProvider struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+	Type      string `json:"type"`
+}
+
+// Set the provider.
+func (r *SearchProvider) With(p *api.Provider) {
+	r.Namespace = p.Namespace
+	r.Name = p.Name
+	r.UID = string(p.UID)
+	r.Type = p.Type().String()
+}
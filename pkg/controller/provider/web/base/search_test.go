@@ -0,0 +1,35 @@
+package base
+
+import (
+	"testing"
+)
+
+func TestSearchTermMatch(t *testing.T) {
+	candidate := SearchCandidate{
+		Name:  "payroll-db-01",
+		UUIDs: []string{"4216B2A3-0E6F-4B1C-9C5A-7F1E2D3C4B5A"},
+		IPs:   []string{"10.1.2.3"},
+		MACs:  []string{"00:50:56:AB:CD:EF"},
+	}
+	tests := []struct {
+		term    string
+		field   string
+		matched bool
+	}{
+		{term: "payroll-db", field: SearchName, matched: true},
+		{term: "PAYROLL", field: SearchName, matched: true},
+		{term: "4216b2a3-0e6f-4b1c-9c5a-7f1e2d3c4b5a", field: SearchUUID, matched: true},
+		{term: "10.1.2.3", field: SearchIP, matched: true},
+		{term: "10.1.2", matched: false},
+		{term: "00-50-56-ab-cd-ef", field: SearchMAC, matched: true},
+		{term: "0050.56ab.cdef", field: SearchMAC, matched: true},
+		{term: "web", matched: false},
+		{term: "  ", matched: false},
+	}
+	for _, tt := range tests {
+		field, matched := NewSearchTerm(tt.term).Match(candidate)
+		if matched != tt.matched || field != tt.field {
+			t.Errorf("term %q: got (%q, %v), want (%q, %v)", tt.term, field, matched, tt.field, tt.matched)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package base
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
 )
 
 func SetForkliftError(ctx *gin.Context, err error) {
@@ -10,3 +13,14 @@ func SetForkliftError(ctx *gin.Context, err error) {
 		_ = ctx.Error(err)
 	}
 }
+
+// Set the status of a failed list.
+// A query not valid for the listed collection is a bad request.
+func SetListError(ctx *gin.Context, err error) {
+	if libmodel.QueryNotValid(err) {
+		ctx.Status(http.StatusBadRequest)
+		SetForkliftError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusInternalServerError)
+}
@@ -26,6 +26,11 @@ func All(container *container.Container, k8s client.Client) (all []libweb.Reques
 			},
 		},
 		&aap.Handler{Client: k8s},
+		&SearchHandler{
+			Handler: base.Handler{
+				Container: container,
+			},
+		},
 	}
 	all = append(
 		all,
@@ -54,8 +54,9 @@ func (h *Handler) ListOptions(ctx *gin.Context) libmodel.ListOptions {
 		detail = model.MaxDetail
 	}
 	return libmodel.ListOptions{
-		Detail: detail,
-		Page:   &h.Page,
+		Predicate: h.WithQuery(nil),
+		Detail:    detail,
+		Page:      &h.Page,
 	}
 }
 
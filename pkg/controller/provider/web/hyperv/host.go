@@ -45,7 +45,7 @@ func (h HostHandler) List(ctx *gin.Context) {
 	err := db.List(&list, h.ListOptions(ctx))
 	if err != nil {
 		log.Trace(err, "url", ctx.Request.URL)
-		ctx.Status(http.StatusInternalServerError)
+		base.SetListError(ctx, err)
 		return
 	}
 	content := []interface{}{}
@@ -48,7 +48,7 @@ func (h NetworkHandler) List(ctx *gin.Context) {
 	err := db.List(&list, h.ListOptions(ctx))
 	if err != nil {
 		log.Trace(err, "url", ctx.Request.URL)
-		ctx.Status(http.StatusInternalServerError)
+		base.SetListError(ctx, err)
 		return
 	}
 	err = h.filter(ctx, &list)
@@ -50,7 +50,7 @@ func (h ImageHandler) List(ctx *gin.Context) {
 				err,
 				"url",
 				ctx.Request.URL)
-			ctx.Status(http.StatusInternalServerError)
+			base.SetListError(ctx, err)
 		}
 	}()
 	db := h.Collector.DB()
@@ -0,0 +1,49 @@
+package nutanix
+
+import (
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/model/nutanix"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
+)
+
+// Search the VMs.
+// See: base.Searcher.
+func Search(provider *api.Provider, db libmodel.DB, term base.SearchTerm) (hits []base.SearchHit, err error) {
+	list := []model.VM{}
+	err = db.List(&list, libmodel.ListOptions{Detail: model.MaxDetail})
+	if err != nil {
+		return
+	}
+	for i := range list {
+		m := &list[i]
+		c := base.SearchCandidate{
+			Name:  m.Name,
+			UUIDs: []string{m.ID, m.UUID},
+		}
+		for _, nic := range m.NICs {
+			c.MACs = append(c.MACs, nic.MACAddress)
+			c.IPs = append(c.IPs, nic.IPAddresses...)
+		}
+		field, matched := term.Match(c)
+		if !matched {
+			continue
+		}
+		hits = append(
+			hits,
+			base.SearchHit{
+				Kind:    "VM",
+				ID:      m.ID,
+				Name:    m.Name,
+				Matched: field,
+				SelfLink: base.Link(
+					VMRoot,
+					base.Params{
+						base.ProviderParam: string(provider.UID),
+						VMParam:            m.ID,
+					}),
+			})
+	}
+
+	return
+}
@@ -0,0 +1,71 @@
+package openstack
+
+import (
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	model "github.com/kubev2v/forklift/pkg/controller/provider/model/openstack"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
+)
+
+// Server address keys.
+const (
+	AddrKey    = "addr"
+	MacAddrKey = "OS-EXT-IPS-MAC:mac_addr"
+)
+
+// Search the VMs.
+// See: base.Searcher.
+func Search(provider *api.Provider, db libmodel.DB, term base.SearchTerm) (hits []base.SearchHit, err error) {
+	list := []model.VM{}
+	err = db.List(&list, libmodel.ListOptions{Detail: model.MaxDetail})
+	if err != nil {
+		return
+	}
+	for i := range list {
+		m := &list[i]
+		c := base.SearchCandidate{
+			Name:  m.Name,
+			UUIDs: []string{m.ID},
+			IPs:   []string{m.AccessIPv4, m.AccessIPv6},
+		}
+		// Addresses: network name => list of addresses.
+		for _, addresses := range m.Addresses {
+			list, cast := addresses.([]interface{})
+			if !cast {
+				continue
+			}
+			for _, address := range list {
+				address, cast := address.(map[string]interface{})
+				if !cast {
+					continue
+				}
+				if ip, cast := address[AddrKey].(string); cast {
+					c.IPs = append(c.IPs, ip)
+				}
+				if mac, cast := address[MacAddrKey].(string); cast {
+					c.MACs = append(c.MACs, mac)
+				}
+			}
+		}
+		field, matched := term.Match(c)
+		if !matched {
+			continue
+		}
+		hits = append(
+			hits,
+			base.SearchHit{
+				Kind:    "VM",
+				ID:      m.ID,
+				Name:    m.Name,
+				Matched: field,
+				SelfLink: base.Link(
+					VMRoot,
+					base.Params{
+						base.ProviderParam: string(provider.UID),
+						VMParam:            m.ID,
+					}),
+			})
+	}
+
+	return
+}
@@ -59,7 +59,7 @@ func (h WorkloadHandler) Get(ctx *gin.Context) {
 				err,
 				"url",
 				ctx.Request.URL)
-			ctx.Status(http.StatusInternalServerError)
+			base.SetListError(ctx, err)
 		}
 	}()
 	if err != nil {
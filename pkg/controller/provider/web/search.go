@@ -0,0 +1,150 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/hyperv"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/nutanix"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/openstack"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/ova"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/ovirt"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/vsphere"
+	ec2web "github.com/kubev2v/forklift/pkg/provider/ec2/inventory/web"
+)
+
+// Routes.
+const (
+	SearchRoot = "/search"
+	TermParam  = "term"
+	TypeParam  = "type"
+)
+
+// Searchers by provider type.
+var Searchers = map[api.ProviderType]base.Searcher{
+	api.VSphere:   vsphere.Search,
+	api.OVirt:     ovirt.Search,
+	api.OpenStack: openstack.Search,
+	api.Ova:       ova.Search,
+	api.EC2:       ec2web.Search,
+	api.HyperV:    hyperv.Search,
+	api.Nutanix:   nutanix.Search,
+}
+
+// Search handler.
+// Search the VMs of every ready provider by name, UUID,
+// IP address or MAC address.
+type SearchHandler struct {
+	base.Handler
+}
+
+// Add routes to the `gin` router.
+func (h *SearchHandler) AddRoutes(e *gin.Engine) {
+	e.GET(SearchRoot, h.List)
+	e.GET(SearchRoot+"/", h.List)
+}
+
+// List search hits.
+// Parameters:
+//
+//	term: the name, UUID, IP address or MAC address (required).
+//	namespace: limit to providers in the namespace.
+//	type: limit to providers of the type.
+func (h SearchHandler) List(ctx *gin.Context) {
+	status, err := h.Prepare(ctx)
+	if status != http.StatusOK {
+		ctx.Status(status)
+		base.SetForkliftError(ctx, err)
+		return
+	}
+	q := ctx.Request.URL.Query()
+	term := base.NewSearchTerm(q.Get(TermParam))
+	if term.Empty() {
+		ctx.Status(http.StatusBadRequest)
+		return
+	}
+	hits, err := h.search(q.Get(base.NsParam), q.Get(TypeParam), term)
+	if err != nil {
+		log.Trace(
+			err,
+			"url",
+			ctx.Request.URL)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	content := []interface{}{}
+	for _, hit := range hits {
+		content = append(content, hit)
+	}
+
+	h.Page.Slice(&content)
+
+	ctx.JSON(http.StatusOK, content)
+}
+
+// Get a specific REST resource.
+func (h SearchHandler) Get(ctx *gin.Context) {
+}
+
+// Search the providers concurrently.
+// Providers without parity are skipped.
+func (h *SearchHandler) search(ns, kind string, term base.SearchTerm) (hits []base.SearchHit, err error) {
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, collector := range h.Container.List() {
+		p, cast := collector.Owner().(*api.Provider)
+		if !cast {
+			continue
+		}
+		if ns != "" && ns != p.Namespace {
+			continue
+		}
+		if kind != "" && kind != p.Type().String() {
+			continue
+		}
+		searcher, found := Searchers[p.Type()]
+		if !found || !collector.HasParity() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, sErr := searcher(p, collector.DB(), term)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if sErr != nil {
+				err = sErr
+				return
+			}
+			for i := range found {
+				found[i].Provider.With(p)
+			}
+			hits = append(hits, found...)
+		}()
+	}
+	wg.Wait()
+	if err != nil {
+		return
+	}
+	sort.Slice(
+		hits,
+		func(i, j int) bool {
+			a, b := hits[i], hits[j]
+			if a.Provider.Namespace != b.Provider.Namespace {
+				return a.Provider.Namespace < b.Provider.Namespace
+			}
+			if a.Provider.Name != b.Provider.Name {
+				return a.Provider.Name < b.Provider.Name
+			}
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID < b.ID
+		})
+
+	return
+}
@@ -44,7 +44,7 @@ func (h Handler) ListOptions(ctx *gin.Context) libmodel.ListOptions {
 		detail = model.MaxDetail
 	}
 	return libmodel.ListOptions{
-		Predicate: h.Predicate(ctx),
+		Predicate: h.WithQuery(h.Predicate(ctx)),
 		Detail:    detail,
 		Page:      &h.Page,
 	}
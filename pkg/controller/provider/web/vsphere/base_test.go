@@ -1,9 +1,15 @@
 package vsphere
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	model "github.com/kubev2v/forklift/pkg/controller/provider/model/vsphere"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
 	. "github.com/onsi/gomega"
 )
 
@@ -250,3 +256,113 @@ func TestVMWithCopiesToolsFields(t *testing.T) {
 			r.ToolsStatus, r.ToolsRunningStatus, r.ToolsVersionStatus)
 	}
 }
+
+func TestListQuery(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	db := libmodel.New("/tmp/test-vsphere-query.db", &model.VM{})
+	err := db.Open(true)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = db.Close(true)
+	}()
+	vms := []*model.VM{
+		{
+			Base:       model.Base{ID: "vm-1", Name: "db-01"},
+			PowerState: "poweredOn",
+			CpuCount:   16,
+			Tags:       []model.Tag{{ID: "t-1", Name: "prod"}},
+		},
+		{
+			Base:       model.Base{ID: "vm-2", Name: "db-02"},
+			PowerState: "poweredOff",
+			CpuCount:   16,
+			Tags:       []model.Tag{{ID: "t-1", Name: "prod"}},
+		},
+		{
+			Base:       model.Base{ID: "vm-3", Name: "web-01"},
+			PowerState: "poweredOn",
+			CpuCount:   16,
+			Tags:       []model.Tag{{ID: "t-2", Name: "dev"}},
+		},
+		{
+			Base:       model.Base{ID: "vm-4", Name: "web-02"},
+			PowerState: "poweredOn",
+			CpuCount:   4,
+			Tags:       []model.Tag{{ID: "t-1", Name: "prod"}},
+		},
+	}
+	for _, vm := range vms {
+		g.Expect(db.Insert(vm)).To(Succeed())
+	}
+
+	h := Handler{}
+	h.Query, err = libmodel.ParseQuery(`powerState=poweredOn and cpuCount>8 and tags contains "prod"`)
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?name=db-01", nil)
+	g.Expect(h.Paged.Prepare(ctx)).To(Equal(http.StatusOK))
+	list := []model.VM{}
+	err = db.List(&list, h.ListOptions(ctx))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(list).To(HaveLen(1))
+	g.Expect(list[0].ID).To(Equal("vm-1"))
+
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	h.Query, err = libmodel.ParseQuery(`not tags contains prod or cpuCount <= 4`)
+	g.Expect(err).ToNot(HaveOccurred())
+	list = []model.VM{}
+	err = db.List(&list, h.ListOptions(ctx))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(list).To(HaveLen(2))
+
+	h.Query, err = libmodel.ParseQuery(`folderName=x`)
+	g.Expect(err).ToNot(HaveOccurred())
+	err = db.List(&[]model.VM{}, h.ListOptions(ctx))
+	g.Expect(libmodel.QueryNotValid(err)).To(BeTrue())
+}
+
+func TestSearch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	db := libmodel.New("/tmp/test-vsphere-search.db", &model.VM{})
+	err := db.Open(true)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = db.Close(true)
+	}()
+	vms := []*model.VM{
+		{
+			Base: model.Base{ID: "vm-1", Name: "payroll-db"},
+			UUID: "4216b2a3-0e6f-4b1c-9c5a-7f1e2d3c4b5a",
+		},
+		{
+			Base:          model.Base{ID: "vm-2", Name: "web-01"},
+			NICs:          []model.NIC{{MAC: "00:50:56:ab:cd:ef"}},
+			GuestNetworks: []model.GuestNetwork{{MAC: "00:50:56:ab:cd:ef", IP: "10.1.2.3"}},
+		},
+	}
+	for _, vm := range vms {
+		g.Expect(db.Insert(vm)).To(Succeed())
+	}
+	provider := &api.Provider{}
+	provider.UID = "p-1"
+
+	hits, err := Search(provider, db, base.NewSearchTerm("payroll"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hits).To(HaveLen(1))
+	g.Expect(hits[0].ID).To(Equal("vm-1"))
+	g.Expect(hits[0].Matched).To(Equal(base.SearchName))
+	g.Expect(hits[0].SelfLink).To(Equal("providers/vsphere/p-1/vms/vm-1"))
+
+	hits, err = Search(provider, db, base.NewSearchTerm("10.1.2.3"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hits).To(HaveLen(1))
+	g.Expect(hits[0].ID).To(Equal("vm-2"))
+	g.Expect(hits[0].Matched).To(Equal(base.SearchIP))
+
+	hits, err = Search(provider, db, base.NewSearchTerm("00-50-56-AB-CD-EF"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(hits).To(HaveLen(1))
+	g.Expect(hits[0].Matched).To(Equal(base.SearchMAC))
+}
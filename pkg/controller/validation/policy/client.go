@@ -42,6 +42,9 @@ func (r *ValidationError) Error() string {
 // Client.
 type Client struct {
 	LibClient
+	// Embedded evaluator used instead of the
+	// policy agent when enabled.
+	Evaluator *Evaluator
 }
 
 // Enabled.
@@ -49,6 +52,11 @@ func (r *Client) Enabled() bool {
 	return Settings.PolicyAgent.Enabled()
 }
 
+// The policies are evaluated in-process.
+func (r *Client) embedded() bool {
+	return Settings.PolicyAgent.Embedded && r.Evaluator != nil
+}
+
 // Policy version.
 func (r *Client) Version(path string) (version int, err error) {
 	if !r.Enabled() {
@@ -59,9 +67,22 @@ func (r *Client) Version(path string) (version int, err error) {
 			Version int `json:"rules_version"`
 		} `json:"result"`
 	}{}
-	err = r.get(path, out)
-	if err != nil {
-		return
+	if r.embedded() {
+		// Pick up changes to the extra policies.
+		err = r.Evaluator.Load(context.TODO())
+		if err != nil {
+			return
+		}
+		err = r.Evaluator.Eval(context.TODO(), path, nil, &out.Result)
+		if err != nil {
+			return
+		}
+		out.Result.Version = r.Evaluator.Version(out.Result.Version)
+	} else {
+		err = r.get(path, out)
+		if err != nil {
+			return
+		}
 	}
 
 	version = out.Result.Version
@@ -95,7 +116,14 @@ func (r *Client) Validate(
 			Errors   []string        `json:"errors"`
 		}
 	}{}
-	err = r.post(path, in, out)
+	if r.embedded() {
+		err = r.Evaluator.Eval(context.TODO(), path, workload, &out.Result)
+		if err == nil {
+			out.Result.Version = r.Evaluator.Version(out.Result.Version)
+		}
+	} else {
+		err = r.post(path, in, out)
+	}
 	if err != nil {
 		err = liberr.Wrap(err)
 		return
@@ -174,7 +202,7 @@ func (r *Client) post(path string, in interface{}, out interface{}) (err error)
 
 // Build and set the transport as needed.
 func (c *Client) buildTransport() (err error) {
-	if c.Transport != nil || !Settings.PolicyAgent.Enabled() {
+	if c.Transport != nil || Settings.PolicyAgent.URL == "" {
 		return
 	}
 	transport := &http.Transport{
@@ -0,0 +1,286 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/settings"
+	"github.com/kubev2v/forklift/validation"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	core "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMaps labeled with PolicyLabel in the controller namespace
+// hold extra policies evaluated with the built-in policies.
+// Each key ending with .rego is a policy module.
+const (
+	PolicyLabel = "forklift.konveyor.io/validation-policy"
+)
+
+// Root of the built-in policies.
+const policyRoot = "policies"
+
+// Data API prefix of the policy agent endpoints.
+const dataPrefix = "/v1/data/"
+
+// Embedded policy evaluator.
+// Evaluates the built-in policies, and the extra policies
+// stored in ConfigMaps, in-process without a policy agent.
+type Evaluator struct {
+	// Client used to list the ConfigMaps of extra policies.
+	// Only the built-in policies are evaluated when not set.
+	Client k8sclient.Reader
+	// Built-in policies.
+	Policies fs.FS
+	// Protect the compiled policies.
+	mutex sync.RWMutex
+	// Compiled policies.
+	compiler *ast.Compiler
+	// Digest of the loaded extra policies.
+	digest uint32
+	// Prepared queries by path.
+	queries map[string]rego.PreparedEvalQuery
+}
+
+// Load (or reload) the policies. The policies are compiled again
+// only when the extra policies have changed. Extra policies that
+// cannot be compiled are reported and ignored.
+func (r *Evaluator) Load(ctx context.Context) (err error) {
+	builtin, err := r.builtin()
+	if err != nil {
+		return
+	}
+	extra, err := r.extra(ctx)
+	if err != nil {
+		return
+	}
+	digest := r.hash(extra)
+	r.mutex.RLock()
+	loaded := r.compiler != nil && r.digest == digest
+	r.mutex.RUnlock()
+	if loaded {
+		return
+	}
+	modules := make(map[string]string, len(builtin)+len(extra))
+	for name, module := range builtin {
+		modules[name] = module
+	}
+	for name, module := range extra {
+		modules[name] = module
+	}
+	compiler, err := ast.CompileModules(modules)
+	if err != nil && len(extra) > 0 {
+		log.Error(
+			err,
+			"Extra validation policies ignored.")
+		compiler, err = ast.CompileModules(builtin)
+	}
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.mutex.Lock()
+	r.compiler = compiler
+	r.digest = digest
+	r.queries = make(map[string]rego.PreparedEvalQuery)
+	r.mutex.Unlock()
+	log.Info(
+		"Validation policies loaded.",
+		"builtin",
+		len(builtin),
+		"extra",
+		len(extra))
+
+	return
+}
+
+// Evaluate the document at the policy agent data path
+// (for example: /v1/data/io/konveyor/forklift/vmware/validate)
+// and decode it into out.
+func (r *Evaluator) Eval(ctx context.Context, path string, input interface{}, out interface{}) (err error) {
+	query, err := r.query(ctx, path)
+	if err != nil {
+		return
+	}
+	options := []rego.EvalOption{}
+	if input != nil {
+		var doc interface{}
+		doc, err = document(input)
+		if err != nil {
+			return
+		}
+		options = append(options, rego.EvalInput(doc))
+	}
+	rs, err := query.Eval(ctx, options...)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		err = liberr.New("policy not found.", "path", path)
+		return
+	}
+	b, err := json.Marshal(rs[0].Expressions[0].Value)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	err = json.Unmarshal(b, out)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+
+	return
+}
+
+// Policy version reported for the rules version of the built-in
+// policies. The version changes with the extra policies so that
+// the VMs are validated again when the extra policies change.
+// The rules version is kept in the high bits and the full digest
+// of the extra policies in the low bits so that different rule
+// sets never share a version.
+func (r *Evaluator) Version(rules int) int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if r.digest == 0 {
+		return rules
+	}
+	return rules<<32 | int(r.digest)
+}
+
+// Prepared query for the path.
+func (r *Evaluator) query(ctx context.Context, path string) (query rego.PreparedEvalQuery, err error) {
+	r.mutex.RLock()
+	compiler := r.compiler
+	query, found := r.queries[path]
+	r.mutex.RUnlock()
+	if found {
+		return
+	}
+	if compiler == nil {
+		err = r.Load(ctx)
+		if err != nil {
+			return
+		}
+		r.mutex.RLock()
+		compiler = r.compiler
+		r.mutex.RUnlock()
+	}
+	ref := "data." + strings.ReplaceAll(strings.Trim(strings.TrimPrefix(path, dataPrefix), "/"), "/", ".")
+	query, err = rego.New(
+		rego.Query(ref),
+		rego.Compiler(compiler)).PrepareForEval(ctx)
+	if err != nil {
+		err = liberr.Wrap(err, "path", path)
+		return
+	}
+	r.mutex.Lock()
+	if r.compiler == compiler {
+		r.queries[path] = query
+	}
+	r.mutex.Unlock()
+
+	return
+}
+
+// Built-in policy modules.
+func (r *Evaluator) builtin() (modules map[string]string, err error) {
+	policies := r.Policies
+	if policies == nil {
+		policies = validation.Policies
+	}
+	modules = make(map[string]string)
+	err = fs.WalkDir(
+		policies,
+		policyRoot,
+		func(name string, entry fs.DirEntry, wErr error) (err error) {
+			if wErr != nil || entry.IsDir() {
+				err = wErr
+				return
+			}
+			if path.Ext(name) != ".rego" || strings.HasSuffix(name, "_test.rego") {
+				return
+			}
+			b, err := fs.ReadFile(policies, name)
+			if err != nil {
+				return
+			}
+			modules[name] = string(b)
+			return
+		})
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+
+	return
+}
+
+// Extra policy modules stored in ConfigMaps.
+func (r *Evaluator) extra(ctx context.Context) (modules map[string]string, err error) {
+	modules = make(map[string]string)
+	if r.Client == nil {
+		return
+	}
+	list := &core.ConfigMapList{}
+	err = r.Client.List(
+		ctx,
+		list,
+		k8sclient.InNamespace(settings.ControllerNamespace()),
+		k8sclient.MatchingLabels{PolicyLabel: "true"})
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	for _, cm := range list.Items {
+		for key, module := range cm.Data {
+			if path.Ext(key) != ".rego" {
+				continue
+			}
+			modules[path.Join(cm.Name, key)] = module
+		}
+	}
+
+	return
+}
+
+// Digest of the extra policies, zero when there are none.
+func (r *Evaluator) hash(modules map[string]string) uint32 {
+	if len(modules) == 0 {
+		return 0
+	}
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	h := fnv.New32a()
+	for _, name := range names {
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte(modules[name]))
+	}
+	return h.Sum32()
+}
+
+// Input document matching the JSON input posted to the policy agent.
+func document(input interface{}) (doc interface{}, err error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	err = json.Unmarshal(b, &doc)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+
+	return
+}
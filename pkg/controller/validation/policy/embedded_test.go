@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/kubev2v/forklift/pkg/controller/provider/model/vsphere"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testVersionPath  = "/v1/data/io/konveyor/forklift/vmware/rules_version"
+	testValidatePath = "/v1/data/io/konveyor/forklift/vmware/validate"
+)
+
+const testExtraPolicy = `package io.konveyor.forklift.vmware
+
+import rego.v1
+
+concerns contains flag if {
+	input.name == "db-01"
+	flag := {
+		"id": "custom.db",
+		"category": "Warning",
+		"label": "Database VM",
+		"assessment": "Schedule the migration with the database team.",
+	}
+}
+`
+
+type testValidation struct {
+	Version  int             `json:"rules_version"`
+	Concerns []model.Concern `json:"concerns"`
+	Errors   []string        `json:"errors"`
+}
+
+func hasConcern(concerns []model.Concern, id string) bool {
+	for _, concern := range concerns {
+		if concern.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEvaluatorBuiltin(t *testing.T) {
+	evaluator := &Evaluator{}
+	ctx := context.Background()
+
+	version := &testValidation{}
+	if err := evaluator.Eval(ctx, testVersionPath, nil, version); err != nil {
+		t.Fatalf("rules version: %v", err)
+	}
+	if version.Version == 0 {
+		t.Fatal("expected the rules version of the built-in policies")
+	}
+
+	result := &testValidation{}
+	input := map[string]interface{}{"name": "Invalid_Name"}
+	if err := evaluator.Eval(ctx, testValidatePath, input, result); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if result.Version != version.Version {
+		t.Fatalf("rules version = %d, want %d", result.Version, version.Version)
+	}
+	if !hasConcern(result.Concerns, "vmware.vm.name.invalid") {
+		t.Fatalf("expected the invalid name concern, got %+v", result.Concerns)
+	}
+
+	result = &testValidation{}
+	if err := evaluator.Eval(ctx, testValidatePath, map[string]interface{}{}, result); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(result.Errors) == 0 {
+		t.Fatal("expected an error for an input without a VM name")
+	}
+}
+
+func TestEvaluatorExtraPolicies(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "forklift")
+	scheme := runtime.NewScheme()
+	if err := core.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme core: %v", err)
+	}
+	newConfigMap := func(module string) *core.ConfigMap {
+		return &core.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      "custom-policies",
+				Namespace: "forklift",
+				Labels:    map[string]string{PolicyLabel: "true"},
+			},
+			Data: map[string]string{
+				"db.rego":   module,
+				"README.md": "Not a policy.",
+			},
+		}
+	}
+	ctx := context.Background()
+
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newConfigMap(testExtraPolicy)).Build()
+	evaluator := &Evaluator{Client: cl}
+	if err := evaluator.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	result := &testValidation{}
+	if err := evaluator.Eval(ctx, testValidatePath, map[string]interface{}{"name": "db-01"}, result); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !hasConcern(result.Concerns, "custom.db") {
+		t.Fatalf("expected the concern of the extra policy, got %+v", result.Concerns)
+	}
+	version := evaluator.Version(result.Version)
+	if version == result.Version {
+		t.Fatal("expected the extra policies to change the policy version")
+	}
+	if evaluator.Version(result.Version+1) == version {
+		t.Fatal("expected the rules version to change the policy version")
+	}
+
+	// A broken extra policy is ignored.
+	cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(newConfigMap("package broken {")).Build()
+	evaluator = &Evaluator{Client: cl}
+	if err := evaluator.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	result = &testValidation{}
+	if err := evaluator.Eval(ctx, testValidatePath, map[string]interface{}{"name": "db-01"}, result); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if hasConcern(result.Concerns, "custom.db") {
+		t.Fatal("unexpected concern of the broken extra policy")
+	}
+	if evaluator.Version(result.Version) == version {
+		t.Fatal("expected changed extra policies to change the policy version")
+	}
+}
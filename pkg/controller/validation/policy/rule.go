@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Default time allowed to evaluate a rule expression.
+const RuleTimeout = 5 * time.Second
+
+// Time allowed to evaluate the rules of all the VMs of a plan.
+const PlanTimeout = 30 * time.Second
+
+// Time the prepared queries of a policy are kept when not used.
+const QueryTTL = time.Hour
+
+// Capabilities of the rule expressions.
+// The expressions are written by namespace users and evaluated by
+// the controller, so builtins that reach the network, expose the
+// controller runtime or are not deterministic are not available.
+var ruleCapabilities = func() *ast.Capabilities {
+	capabilities := ast.CapabilitiesForThisVersion()
+	builtins := make([]*ast.Builtin, 0, len(capabilities.Builtins))
+	for _, builtin := range capabilities.Builtins {
+		if builtin.Nondeterministic || strings.HasPrefix(builtin.Name, "net.") {
+			continue
+		}
+		switch builtin.Name {
+		case "http.send", "opa.runtime", "time.now_ns":
+			continue
+		}
+		builtins = append(builtins, builtin)
+	}
+	capabilities.Builtins = builtins
+	capabilities.AllowNet = []string{}
+	return capabilities
+}()
+
+// Rule expressions evaluator.
+// Evaluates the rego queries of user-defined rules
+// (for example: input.vm.cpuCount > 64) in-process.
+// The prepared queries are cached by policy generation and
+// evicted once the policy is updated or no longer used.
+type Rules struct {
+	// Time allowed to evaluate an expression.
+	// RuleTimeout when not set.
+	Timeout time.Duration
+	// Protect the prepared queries.
+	mutex sync.Mutex
+	// Prepared queries by policy UID.
+	policies map[types.UID]*policyQueries
+}
+
+// Prepared queries of a policy generation.
+type policyQueries struct {
+	// Generation of the policy.
+	generation int64
+	// Last time a query was used.
+	used time.Time
+	// Prepared queries by expression.
+	queries map[string]rego.PreparedEvalQuery
+}
+
+// Returns true when the expression of a rule of the policy
+// is satisfied by the input. An expression that is undefined
+// or false for the input is not violated.
+func (r *Rules) Violated(ctx context.Context, policy meta.Object, expression string, input interface{}) (violated bool, err error) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = RuleTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	query, err := r.query(ctx, policy, expression)
+	if err != nil {
+		return
+	}
+	doc, err := document(input)
+	if err != nil {
+		return
+	}
+	rs, err := query.Eval(ctx, rego.EvalInput(doc))
+	if err != nil {
+		err = liberr.Wrap(err, "expression", expression)
+		return
+	}
+	for _, result := range rs {
+		if r.satisfied(result) {
+			violated = true
+			break
+		}
+	}
+
+	return
+}
+
+// Returns true when none of the expressions is false.
+// A reference to a false value (input.vm.isTemplate)
+// is defined but not satisfied.
+func (r *Rules) satisfied(result rego.Result) bool {
+	for _, expression := range result.Expressions {
+		if b, isBool := expression.Value.(bool); isBool && !b {
+			return false
+		}
+	}
+	return true
+}
+
+// Prepared query for the expression of the policy.
+func (r *Rules) query(ctx context.Context, policy meta.Object, expression string) (query rego.PreparedEvalQuery, err error) {
+	now := time.Now()
+	r.mutex.Lock()
+	cached, found := r.policies[policy.GetUID()]
+	if found && cached.generation == policy.GetGeneration() {
+		cached.used = now
+		query, found = cached.queries[expression]
+	} else {
+		found = false
+	}
+	r.mutex.Unlock()
+	if found {
+		return
+	}
+	query, err = rego.New(
+		rego.Query(expression),
+		rego.Capabilities(ruleCapabilities),
+		rego.StrictBuiltinErrors(true)).PrepareForEval(ctx)
+	if err != nil {
+		err = liberr.Wrap(err, "expression", expression)
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.policies == nil {
+		r.policies = make(map[types.UID]*policyQueries)
+	}
+	cached, found = r.policies[policy.GetUID()]
+	if !found || cached.generation != policy.GetGeneration() {
+		cached = &policyQueries{
+			generation: policy.GetGeneration(),
+			queries:    make(map[string]rego.PreparedEvalQuery),
+		}
+		r.policies[policy.GetUID()] = cached
+	}
+	cached.used = now
+	cached.queries[expression] = query
+	r.evict(now)
+
+	return
+}
+
+// Evict the queries of the policies not used within the QueryTTL,
+// including the policies that have been deleted.
+// The mutex must be held.
+func (r *Rules) evict(now time.Time) {
+	for uid, cached := range r.policies {
+		if now.Sub(cached.used) > QueryTTL {
+			delete(r.policies, uid)
+		}
+	}
+}
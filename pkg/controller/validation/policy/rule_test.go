@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Policy owning the rules.
+var testPolicy = &meta.ObjectMeta{UID: "policy-uid", Generation: 1}
+
+func TestRulesViolated(t *testing.T) {
+	rules := &Rules{}
+	ctx := context.Background()
+	input := map[string]interface{}{
+		"vm": map[string]interface{}{
+			"cpuCount":   96,
+			"isTemplate": false,
+			"guestId":    "windows7Server64Guest",
+			"tags": []interface{}{
+				map[string]interface{}{"name": "prod"},
+			},
+		},
+		"planVM": map[string]interface{}{
+			"hooks": []interface{}{
+				map[string]interface{}{"step": "PostHook"},
+			},
+		},
+	}
+	cases := []struct {
+		expression string
+		violated   bool
+	}{
+		{`input.vm.cpuCount > 64`, true},
+		{`input.vm.cpuCount > 128`, false},
+		{`input.vm.isTemplate`, false},
+		{`input.vm.missing`, false},
+		{`contains(input.vm.guestId, "windows7Server")`, true},
+		{`input.vm.cpuCount > 64; startswith(input.vm.guestId, "rhel")`, false},
+		{`input.vm.tags[_].name == "prod"; count([h | h := input.planVM.hooks[_]; h.step == "PreHook"]) == 0`, true},
+		{`input.vm.tags[_].name == "prod"; count([h | h := input.planVM.hooks[_]; h.step == "PostHook"]) == 0`, false},
+	}
+	for _, c := range cases {
+		violated, err := rules.Violated(ctx, testPolicy, c.expression, input)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expression, err)
+		}
+		if violated != c.violated {
+			t.Fatalf("%s: expected violated=%v", c.expression, c.violated)
+		}
+	}
+}
+
+func TestRulesNotValid(t *testing.T) {
+	rules := &Rules{}
+	_, err := rules.Violated(context.Background(), testPolicy, `input.vm.cpuCount >`, nil)
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
+
+func TestRulesRestrictedBuiltins(t *testing.T) {
+	rules := &Rules{}
+	expressions := []string{
+		`http.send({"method": "get", "url": "http://169.254.169.254/"}).status_code == 200`,
+		`opa.runtime().env.HOME != ""`,
+		`count(net.lookup_ip_addr("example.com")) > 0`,
+		`net.cidr_contains("10.0.0.0/8", "10.0.0.1")`,
+		`time.now_ns() > 0`,
+	}
+	for _, expression := range expressions {
+		_, err := rules.Violated(context.Background(), testPolicy, expression, nil)
+		if err == nil {
+			t.Fatalf("%s: expected the builtin to be rejected", expression)
+		}
+	}
+}
+
+func TestRulesTimeout(t *testing.T) {
+	rules := &Rules{Timeout: 10 * time.Millisecond}
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	input := map[string]interface{}{"items": items}
+	_, err := rules.Violated(
+		context.Background(),
+		testPolicy,
+		`count([x | input.items[x]; input.items[_]; input.items[_]]) > 0`,
+		input)
+	if err == nil {
+		t.Fatal("expected the evaluation to time out")
+	}
+}
+
+func TestRulesQueryCache(t *testing.T) {
+	rules := &Rules{}
+	ctx := context.Background()
+	policy := &meta.ObjectMeta{UID: "cached", Generation: 1}
+	other := &meta.ObjectMeta{UID: "unused", Generation: 1}
+	for _, p := range []*meta.ObjectMeta{policy, other} {
+		if _, err := rules.Violated(ctx, p, `input.vm.cpuCount > 64`, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rules.policies) != 2 {
+		t.Fatalf("expected the queries of 2 policies, got %d", len(rules.policies))
+	}
+
+	// The queries of the previous generation are evicted.
+	policy.Generation = 2
+	if _, err := rules.Violated(ctx, policy, `input.vm.cpuCount > 128`, nil); err != nil {
+		t.Fatal(err)
+	}
+	cached := rules.policies[policy.UID]
+	if cached.generation != 2 || len(cached.queries) != 1 {
+		t.Fatalf("expected the query of generation 2 only, got %d queries of generation %d",
+			len(cached.queries), cached.generation)
+	}
+
+	// The queries of the policies not used are evicted.
+	rules.policies[other.UID].used = time.Now().Add(-2 * QueryTTL)
+	if _, err := rules.Violated(ctx, policy, `input.vm.cpuCount > 256`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := rules.policies[other.UID]; found {
+		t.Fatal("expected the queries of the unused policy to be evicted")
+	}
+}
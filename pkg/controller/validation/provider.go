@@ -174,9 +174,10 @@ func (r *ProviderPair) validateDestination(pair provider.Pair) (result libcnd.Co
 		result.SetCondition(newCnd)
 	}
 
-	// A non-openshift destination is not supported.
+	// A non-openshift destination is only supported for
+	// migrations from openshift to vSphere.
 	r.Referenced.Destination = pv.Referenced
-	if r.Referenced.Destination != nil && r.Referenced.Destination.Type() != api.OpenShift {
+	if r.Referenced.Destination != nil && !r.supportedDestination() {
 		result.SetCondition(libcnd.Condition{
 			Type:     DestinationProviderNotValid,
 			Status:   True,
@@ -188,3 +189,16 @@ func (r *ProviderPair) validateDestination(pair provider.Pair) (result libcnd.Co
 	}
 	return
 }
+
+// Determine whether the destination provider type is supported
+// for the source provider.
+func (r *ProviderPair) supportedDestination() bool {
+	switch r.Referenced.Destination.Type() {
+	case api.OpenShift:
+		return true
+	case api.VSphere:
+		return r.Referenced.Source != nil && r.Referenced.Source.Type() == api.OpenShift
+	default:
+		return false
+	}
+}
@@ -83,6 +83,21 @@ const (
 
 	qemuGroup = 107
 
+	// External storage offload drivers are installed by an init
+	// container into a volume shared with the populator container.
+	driverContainerName = "install-driver"
+	driverVolumeName    = "offload-driver"
+	driverDir           = "/opt/offload-driver"
+	// ExternalDriverPath is where the populator finds an external
+	// storage offload driver.
+	ExternalDriverPath = driverDir + "/driver"
+
+	// The NFS datastore of the source disk is mounted read-only in the
+	// populator pod when the populator copies from the export.
+	sourceDatastoreVolumeName = "source-datastore"
+	// SourceDatastorePath is where the populator finds the NFS datastore.
+	SourceDatastorePath = "/mnt/source-datastore"
+
 	labelSourceHost = api.LabelSourceHost
 )
 
@@ -104,6 +119,11 @@ var (
 			resource:           "openstackvolumepopulators",
 			regexKey:           "openstack_volume_populator",
 		},
+		api.Ec2VolumePopulatorKind: {
+			storageResourceKey: "snapshot_id",
+			resource:           api.Ec2VolumePopulatorResource,
+			regexKey:           "ec2_volume_populator",
+		},
 		api.VSphereXcopyVolumePopulatorKind: {
 			storageResourceKey: "source_vmdk",
 			resource:           api.VSphereXcopyVolumePopulatorResource,
@@ -705,6 +725,18 @@ func (c *controller) syncPvc(ctx context.Context, key, pvcNamespace, pvcName str
 				}
 			}
 
+			if c.gk.Kind == api.VSphereXcopyVolumePopulatorKind {
+				driverImage, _, _ := unstructured.NestedString(crInstance.Object, "spec", "driverImage")
+				if driverImage != "" {
+					addExternalDriver(&pod.Spec, driverImage)
+				}
+				nfsServer, _, _ := unstructured.NestedString(crInstance.Object, "spec", "nfsServer")
+				nfsPath, _, _ := unstructured.NestedString(crInstance.Object, "spec", "nfsPath")
+				if nfsServer != "" && nfsPath != "" {
+					addSourceDatastore(&pod.Spec, nfsServer, nfsPath)
+				}
+			}
+
 			if waitForFirstConsumer {
 				pod.Spec.NodeName = nodeName
 			}
@@ -1197,6 +1229,53 @@ func makePopulatePodSpec(pvcPrimeName, secretName string) corev1.PodSpec {
 	}
 }
 
+// addExternalDriver adds an init container running the driver image to
+// install the external storage offload driver at ExternalDriverPath.
+// The entrypoint of the driver image is the driver itself, invoked with
+// the 'install' method and the destination path.
+func addExternalDriver(spec *corev1.PodSpec, image string) {
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: driverVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	mount := corev1.VolumeMount{
+		Name:      driverVolumeName,
+		MountPath: driverDir,
+	}
+	con := &spec.Containers[0]
+	con.VolumeMounts = append(con.VolumeMounts, mount)
+	spec.InitContainers = append(spec.InitContainers, corev1.Container{
+		Name:            driverContainerName,
+		Image:           image,
+		Args:            []string{"install", ExternalDriverPath},
+		VolumeMounts:    []corev1.VolumeMount{mount},
+		SecurityContext: con.SecurityContext.DeepCopy(),
+	})
+}
+
+// addSourceDatastore mounts the NFS export of the source datastore
+// read-only at SourceDatastorePath.
+func addSourceDatastore(spec *corev1.PodSpec, server, path string) {
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: sourceDatastoreVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			NFS: &corev1.NFSVolumeSource{
+				Server:   server,
+				Path:     path,
+				ReadOnly: true,
+			},
+		},
+	})
+	con := &spec.Containers[0]
+	con.VolumeMounts = append(con.VolumeMounts, corev1.VolumeMount{
+		Name:      sourceDatastoreVolumeName,
+		MountPath: SourceDatastorePath,
+		ReadOnly:  true,
+	})
+}
+
 func (c *controller) checkIntreeStorageClass(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass) error {
 	if !strings.HasPrefix(sc.Provisioner, "kubernetes.io/") {
 		// This is not an in-tree StorageClass
@@ -165,18 +165,25 @@ func isThrottled(recorder *record.FakeRecorder) bool {
 	}
 }
 
-// createdPodLabels returns the labels of the pod created via the fake kubeClient, or nil if none was created.
-func createdPodLabels(fakeClient *fake.Clientset) map[string]string {
+// createdPod returns the pod created via the fake kubeClient, or nil if none was created.
+func createdPod(fakeClient *fake.Clientset) *corev1.Pod {
 	for _, action := range fakeClient.Actions() {
 		if action.GetVerb() == "create" && action.GetResource().Resource == "pods" {
 			obj := action.(interface{ GetObject() runtime.Object }).GetObject()
-			pod := obj.(*corev1.Pod)
-			return pod.Labels
+			return obj.(*corev1.Pod)
 		}
 	}
 	return nil
 }
 
+// createdPodLabels returns the labels of the pod created via the fake kubeClient, or nil if none was created.
+func createdPodLabels(fakeClient *fake.Clientset) map[string]string {
+	if pod := createdPod(fakeClient); pod != nil {
+		return pod.Labels
+	}
+	return nil
+}
+
 // ── Test cases ──────────────────────────────────────────────────────────────
 
 // 1.1 No dedicated hosts — throttle fires when maxInFlight reached on source host.
@@ -327,3 +334,97 @@ func TestThrottle_Mixed_WithoutMigrationHost_ThrottlesOnSourceHost(t *testing.T)
 		t.Error("expected throttle on sourceHost when no migrationHost configured")
 	}
 }
+
+// External driver — the driver image is installed by an init container into a shared volume.
+func TestExternalDriver_InitContainer(t *testing.T) {
+	pvc := makePVC("pvc-1", "test-ns", "cr-1", "test-sc")
+	cr := makeCR("cr-1", "test-ns", "source-host", "")
+	_ = unstructured.SetNestedField(cr.Object, "quay.io/vendor/driver:1.0", "spec", "driverImage")
+
+	c, fakeClient, _ := buildController(t, 10, nil, []*corev1.PersistentVolumeClaim{pvc}, []*unstructured.Unstructured{cr})
+
+	err := c.syncPvc(context.Background(), "test-ns/pvc-1", "test-ns", "pvc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := createdPod(fakeClient)
+	if pod == nil {
+		t.Fatal("expected pod to be created")
+	}
+	if len(pod.Spec.InitContainers) != 1 {
+		t.Fatalf("expected 1 init container, got %d", len(pod.Spec.InitContainers))
+	}
+	init := pod.Spec.InitContainers[0]
+	if init.Image != "quay.io/vendor/driver:1.0" {
+		t.Errorf("init container image = %q", init.Image)
+	}
+	if len(init.Args) != 2 || init.Args[0] != "install" || init.Args[1] != ExternalDriverPath {
+		t.Errorf("init container args = %v", init.Args)
+	}
+	mounted := false
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		if mount.Name == driverVolumeName && mount.MountPath == driverDir {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("driver volume not mounted in the populator container: %v", pod.Spec.Containers[0].VolumeMounts)
+	}
+}
+
+// Built-in drivers do not add an init container.
+func TestExternalDriver_NotSet(t *testing.T) {
+	pvc := makePVC("pvc-1", "test-ns", "cr-1", "test-sc")
+	cr := makeCR("cr-1", "test-ns", "source-host", "")
+
+	c, fakeClient, _ := buildController(t, 10, nil, []*corev1.PersistentVolumeClaim{pvc}, []*unstructured.Unstructured{cr})
+
+	err := c.syncPvc(context.Background(), "test-ns/pvc-1", "test-ns", "pvc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := createdPod(fakeClient)
+	if pod == nil {
+		t.Fatal("expected pod to be created")
+	}
+	if len(pod.Spec.InitContainers) != 0 {
+		t.Errorf("expected no init container, got %v", pod.Spec.InitContainers)
+	}
+}
+
+// NFS datastore — the export of the source datastore is mounted read-only.
+func TestSourceDatastore_NFSMount(t *testing.T) {
+	pvc := makePVC("pvc-1", "test-ns", "cr-1", "test-sc")
+	cr := makeCR("cr-1", "test-ns", "source-host", "")
+	_ = unstructured.SetNestedField(cr.Object, "nfs.example.com", "spec", "nfsServer")
+	_ = unstructured.SetNestedField(cr.Object, "/vol/datastore1", "spec", "nfsPath")
+
+	c, fakeClient, _ := buildController(t, 10, nil, []*corev1.PersistentVolumeClaim{pvc}, []*unstructured.Unstructured{cr})
+
+	err := c.syncPvc(context.Background(), "test-ns/pvc-1", "test-ns", "pvc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := createdPod(fakeClient)
+	if pod == nil {
+		t.Fatal("expected pod to be created")
+	}
+	var nfs *corev1.NFSVolumeSource
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == sourceDatastoreVolumeName {
+			nfs = volume.NFS
+		}
+	}
+	if nfs == nil || nfs.Server != "nfs.example.com" || nfs.Path != "/vol/datastore1" || !nfs.ReadOnly {
+		t.Fatalf("unexpected source datastore volume: %+v", nfs)
+	}
+	mounted := false
+	for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+		if mount.Name == sourceDatastoreVolumeName && mount.MountPath == SourceDatastorePath && mount.ReadOnly {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("source datastore not mounted read-only in the populator container: %v", pod.Spec.Containers[0].VolumeMounts)
+	}
+}
@@ -0,0 +1,316 @@
+// Package dryrun provides a k8s client that captures the objects written
+// instead of sending them to the cluster.
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Build a dry-run client reading from the cluster through the client.
+func New(c client.Client) *Client {
+	return &Client{
+		Client:  c,
+		objects: make(map[string]*entry),
+	}
+}
+
+// Captured object.
+type entry struct {
+	// Object as written.
+	object client.Object
+	// Written through the client rather than added as existing.
+	rendered bool
+}
+
+// Dry-run client.
+// Objects created, updated or patched are kept in memory and never sent
+// to the cluster. Reads return the captured objects over the objects
+// of the cluster, so code creating an object and reading it back works
+// as it does against the cluster.
+type Client struct {
+	client.Client
+	mutex sync.Mutex
+	// Captured objects by key.
+	objects map[string]*entry
+	// Keys in the order the objects were first written.
+	order []string
+}
+
+// Add an existing object.
+// The object is read back like the captured ones but not rendered.
+func (r *Client) Add(obj client.Object) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, false)
+	return
+}
+
+// Objects written, in the order they were first written.
+func (r *Client) Objects() (objects []client.Object) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, key := range r.order {
+		e, found := r.objects[key]
+		if !found || !e.rendered {
+			continue
+		}
+		objects = append(objects, e.object.DeepCopyObject().(client.Object))
+	}
+	return
+}
+
+// Get an object.
+func (r *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) (err error) {
+	gvk, err := r.GroupVersionKindFor(obj)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.mutex.Lock()
+	e, found := r.objects[r.key(gvk, key.Namespace, key.Name)]
+	r.mutex.Unlock()
+	if found {
+		err = r.copy(e.object, obj)
+		return
+	}
+	err = r.Client.Get(ctx, key, obj, opts...)
+	return
+}
+
+// List objects.
+// The captured objects matching the options are merged into the list.
+func (r *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (err error) {
+	err = r.Client.List(ctx, list, opts...)
+	if err != nil {
+		return
+	}
+	options := &client.ListOptions{}
+	options.ApplyOptions(opts)
+	gvk, err := r.GroupVersionKindFor(list)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	merged := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		object, cast := item.(client.Object)
+		if !cast {
+			merged = append(merged, item)
+			continue
+		}
+		if _, found := r.objects[r.key(gvk, object.GetNamespace(), object.GetName())]; !found {
+			merged = append(merged, item)
+		}
+	}
+	for _, key := range r.order {
+		e, found := r.objects[key]
+		if !found || !strings.HasPrefix(key, gvk.String()+"|") {
+			continue
+		}
+		if options.Namespace != "" && e.object.GetNamespace() != options.Namespace {
+			continue
+		}
+		if options.LabelSelector != nil && !options.LabelSelector.Matches(labels.Set(e.object.GetLabels())) {
+			continue
+		}
+		merged = append(merged, e.object.DeepCopyObject())
+	}
+	err = apimeta.SetList(list, merged)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+	return
+}
+
+// Create an object.
+// The name is generated and the UID assigned as the API server does.
+func (r *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) (err error) {
+	gvk, err := r.GroupVersionKindFor(obj)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		obj.SetName(obj.GetGenerateName() + rand.String(5))
+	}
+	r.mutex.Lock()
+	_, found := r.objects[r.key(gvk, obj.GetNamespace(), obj.GetName())]
+	r.mutex.Unlock()
+	if !found {
+		existing := obj.DeepCopyObject().(client.Object)
+		gErr := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+		found = gErr == nil
+	}
+	if found {
+		gr := schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}
+		err = k8serr.NewAlreadyExists(gr, obj.GetName())
+		return
+	}
+	obj.SetUID(uuid.NewUUID())
+	obj.SetCreationTimestamp(meta.Now())
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, true)
+	return
+}
+
+// Update an object.
+func (r *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, true)
+	return
+}
+
+// Patch an object.
+// The object is captured as patched by the caller.
+func (r *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, true)
+	return
+}
+
+// Delete an object.
+// Only the captured object is deleted.
+func (r *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) (err error) {
+	gvk, err := r.GroupVersionKindFor(obj)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key := r.key(gvk, obj.GetNamespace(), obj.GetName())
+	if _, found := r.objects[key]; !found {
+		return
+	}
+	delete(r.objects, key)
+	for i := range r.order {
+		if r.order[i] == key {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return
+}
+
+// Delete objects. Nothing is deleted.
+func (r *Client) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return nil
+}
+
+// Status writer.
+func (r *Client) Status() client.SubResourceWriter {
+	return &subResource{Client: r, name: "status"}
+}
+
+// Subresource client.
+func (r *Client) SubResource(name string) client.SubResourceClient {
+	return &subResource{Client: r, name: name}
+}
+
+// Store a copy of the object.
+// An object already captured keeps whether it is rendered.
+// The mutex must be held.
+func (r *Client) store(obj client.Object, rendered bool) (key string, err error) {
+	gvk, err := r.GroupVersionKindFor(obj)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	object := obj.DeepCopyObject().(client.Object)
+	object.GetObjectKind().SetGroupVersionKind(gvk)
+	object.SetResourceVersion("")
+	object.SetManagedFields(nil)
+	key = r.key(gvk, obj.GetNamespace(), obj.GetName())
+	e, found := r.objects[key]
+	if found {
+		e.object = object
+		return
+	}
+	r.objects[key] = &entry{object: object, rendered: rendered}
+	r.order = append(r.order, key)
+	return
+}
+
+// Object key.
+func (r *Client) key(gvk schema.GroupVersionKind, namespace, name string) string {
+	return gvk.String() + "|" + namespace + "/" + name
+}
+
+// Copy an object into another of the same kind.
+func (r *Client) copy(in, out client.Object) (err error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	err = json.Unmarshal(b, out)
+	if err != nil {
+		err = liberr.Wrap(err)
+	}
+	return
+}
+
+// Subresource client.
+// Reads are sent to the cluster, status writes update the
+// captured object and other writes are dropped.
+type subResource struct {
+	*Client
+	name string
+}
+
+// Get the subresource.
+func (r *subResource) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	return r.Client.Client.SubResource(r.name).Get(ctx, obj, subResource, opts...)
+}
+
+// Create the subresource. Nothing is created.
+func (r *subResource) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	return nil
+}
+
+// Update the subresource.
+func (r *subResource) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) (err error) {
+	if r.name != "status" {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, true)
+	return
+}
+
+// Patch the subresource.
+func (r *subResource) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) (err error) {
+	if r.name != "status" {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.store(obj, true)
+	return
+}
@@ -0,0 +1,123 @@
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newClient(t *testing.T, objs ...runtime.Object) (*Client, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := core.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cluster := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build()
+	return New(cluster), cluster
+}
+
+func TestCreateIsNotSent(t *testing.T) {
+	r, cluster := newClient(t)
+	cm := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns", GenerateName: "cm-"},
+		Data:       map[string]string{"key": "value"},
+	}
+	if err := r.Create(context.TODO(), cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Name == "" || cm.UID == "" {
+		t.Errorf("name and UID not set: %q %q", cm.Name, cm.UID)
+	}
+	if err := cluster.Get(context.TODO(), client.ObjectKeyFromObject(cm), &core.ConfigMap{}); !k8serr.IsNotFound(err) {
+		t.Errorf("created on the cluster: %v", err)
+	}
+	read := &core.ConfigMap{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(cm), read); err != nil {
+		t.Fatal(err)
+	}
+	if read.Data["key"] != "value" {
+		t.Errorf("read back %v", read.Data)
+	}
+	if err := r.Create(context.TODO(), cm); !k8serr.IsAlreadyExists(err) {
+		t.Errorf("created twice: %v", err)
+	}
+	objects := r.Objects()
+	if len(objects) != 1 || objects[0].GetObjectKind().GroupVersionKind().Kind != "ConfigMap" {
+		t.Errorf("objects = %v", objects)
+	}
+}
+
+func TestListMergesCaptured(t *testing.T) {
+	labels := map[string]string{"vmID": "vm-1"}
+	existing := &core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "existing", Labels: labels}}
+	r, _ := newClient(t, existing)
+	captured := []client.Object{
+		&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "created", Labels: labels}},
+		&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "other", Name: "namespace", Labels: labels}},
+		&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "unlabeled"}},
+	}
+	for _, object := range captured {
+		if err := r.Create(context.TODO(), object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pvc := &core.PersistentVolumeClaim{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "pvc", Labels: labels}}
+	if err := r.Add(pvc); err != nil {
+		t.Fatal(err)
+	}
+	list := &core.ConfigMapList{}
+	err := r.List(context.TODO(), list, client.InNamespace("ns"), client.MatchingLabels(labels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	if len(names) != 2 || names[0] != "existing" || names[1] != "created" {
+		t.Errorf("listed %v", names)
+	}
+	// Added objects are read but not rendered.
+	if objects := r.Objects(); len(objects) != len(captured) {
+		t.Errorf("rendered %d objects, want %d", len(objects), len(captured))
+	}
+}
+
+func TestPatchExistingIsCaptured(t *testing.T) {
+	existing := &core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "existing"}}
+	r, cluster := newClient(t, existing)
+	cm := &core.ConfigMap{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(existing), cm); err != nil {
+		t.Fatal(err)
+	}
+	original := cm.DeepCopy()
+	cm.Data = map[string]string{"key": "value"}
+	if err := r.Patch(context.TODO(), cm, client.MergeFrom(original)); err != nil {
+		t.Fatal(err)
+	}
+	onCluster := &core.ConfigMap{}
+	if err := cluster.Get(context.TODO(), client.ObjectKeyFromObject(existing), onCluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(onCluster.Data) != 0 {
+		t.Errorf("patched on the cluster: %v", onCluster.Data)
+	}
+	if objects := r.Objects(); len(objects) != 1 {
+		t.Errorf("rendered %d objects, want 1", len(objects))
+	}
+	if err := r.Delete(context.TODO(), cm); err != nil {
+		t.Fatal(err)
+	}
+	if objects := r.Objects(); len(objects) != 0 {
+		t.Errorf("rendered %d objects after delete, want 0", len(objects))
+	}
+	if err := cluster.Get(context.TODO(), client.ObjectKeyFromObject(existing), onCluster); err != nil {
+		t.Errorf("deleted on the cluster: %v", err)
+	}
+}
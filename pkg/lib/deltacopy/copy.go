@@ -0,0 +1,53 @@
+package deltacopy
+
+import (
+	"bytes"
+	"io"
+)
+
+// ChunkSize is the largest read issued to the source.
+const ChunkSize = 4 * 1024 * 1024
+
+// Copier copies extents from a source to a target.
+type Copier struct {
+	// Skip writing chunks that contain only zeroes. Only safe
+	// when the target is known to read back zeroes, such as a
+	// freshly created sparse file.
+	Sparse bool
+	// Called after each chunk with the number of bytes read.
+	Progress func(n int64)
+}
+
+// Copy the extents from src to the same offsets in dst and
+// return the number of bytes read.
+func (r *Copier) Copy(src io.ReaderAt, dst io.WriterAt, extents []Extent) (copied int64, err error) {
+	buf := make([]byte, ChunkSize)
+	zero := make([]byte, ChunkSize)
+	for _, extent := range extents {
+		offset := extent.Offset
+		for offset < extent.End() {
+			n := extent.End() - offset
+			if n > ChunkSize {
+				n = ChunkSize
+			}
+			chunk := buf[:n]
+			_, err = src.ReadAt(chunk, offset)
+			if err != nil && err != io.EOF {
+				return
+			}
+			err = nil
+			if !r.Sparse || !bytes.Equal(chunk, zero[:n]) {
+				_, err = dst.WriteAt(chunk, offset)
+				if err != nil {
+					return
+				}
+			}
+			offset += n
+			copied += n
+			if r.Progress != nil {
+				r.Progress(n)
+			}
+		}
+	}
+	return
+}
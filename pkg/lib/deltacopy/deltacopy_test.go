@@ -0,0 +1,241 @@
+package deltacopy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestCoalesce(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	extents := []Extent{
+		{Offset: 100, Length: 10},
+		{Offset: 0, Length: 10},
+		{Offset: 5, Length: 10},
+		{Offset: 50, Length: 0},
+		{Offset: 20, Length: 10},
+	}
+	g.Expect(Coalesce(extents, 0)).To(gomega.Equal([]Extent{
+		{Offset: 0, Length: 15},
+		{Offset: 20, Length: 10},
+		{Offset: 100, Length: 10},
+	}))
+	g.Expect(Coalesce(extents, 5)).To(gomega.Equal([]Extent{
+		{Offset: 0, Length: 30},
+		{Offset: 100, Length: 10},
+	}))
+	g.Expect(Total(Coalesce(extents, 0))).To(gomega.Equal(int64(35)))
+}
+
+func TestReduce(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	extents := []Extent{
+		{Offset: 0, Length: 10},
+		{Offset: 12, Length: 10},
+		{Offset: 100, Length: 10},
+		{Offset: 115, Length: 10},
+		{Offset: 1000, Length: 10},
+	}
+	g.Expect(Reduce(extents, 10)).To(gomega.HaveLen(5))
+	g.Expect(Reduce(extents, 0)).To(gomega.HaveLen(5))
+	g.Expect(Reduce(extents, 3)).To(gomega.Equal([]Extent{
+		{Offset: 0, Length: 22},
+		{Offset: 100, Length: 25},
+		{Offset: 1000, Length: 10},
+	}))
+	g.Expect(Reduce(extents, 1)).To(gomega.Equal([]Extent{
+		{Offset: 0, Length: 1010},
+	}))
+}
+
+func TestCopy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	size := ChunkSize*2 + 512
+	src := make([]byte, size)
+	for i := range src {
+		src[i] = byte(i%251 + 1)
+	}
+	path := filepath.Join(t.TempDir(), "disk.img")
+	target, err := os.Create(path)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	defer target.Close()
+	g.Expect(target.Truncate(int64(size))).To(gomega.Succeed())
+
+	var progress int64
+	copier := Copier{Progress: func(n int64) { progress += n }}
+	extents := []Extent{
+		{Offset: 10, Length: 100},
+		{Offset: ChunkSize - 10, Length: ChunkSize + 20},
+	}
+	copied, err := copier.Copy(bytes.NewReader(src), target, extents)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(copied).To(gomega.Equal(Total(extents)))
+	g.Expect(progress).To(gomega.Equal(copied))
+
+	got, err := os.ReadFile(path)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	want := make([]byte, size)
+	for _, e := range extents {
+		copy(want[e.Offset:e.End()], src[e.Offset:e.End()])
+	}
+	g.Expect(got).To(gomega.Equal(want))
+}
+
+func TestCopySparse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	src := make([]byte, ChunkSize*2)
+	src[ChunkSize+1] = 1
+	target := &recorder{}
+	copier := Copier{Sparse: true}
+	copied, err := copier.Copy(bytes.NewReader(src), target, []Extent{{Offset: 0, Length: int64(len(src))}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(copied).To(gomega.Equal(int64(len(src))))
+	g.Expect(target.offsets).To(gomega.Equal([]int64{ChunkSize}))
+}
+
+func TestHTTP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	image := make([]byte, 8192)
+	for i := range image {
+		image[i] = byte(i % 253)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeContent(w, r, "disk", time.Time{}, bytes.NewReader(image))
+	}))
+	defer server.Close()
+
+	source := &HTTP{
+		URL:    server.URL,
+		Client: server.Client(),
+		Header: http.Header{"Authorization": []string{"Basic secret"}},
+	}
+	p := make([]byte, 1000)
+	n, err := source.ReadAt(p, 4000)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(n).To(gomega.Equal(1000))
+	g.Expect(p).To(gomega.Equal(image[4000:5000]))
+
+	n, err = source.ReadAt(p, 7692)
+	g.Expect(err).To(gomega.Equal(io.EOF))
+	g.Expect(n).To(gomega.Equal(500))
+	g.Expect(p[:n]).To(gomega.Equal(image[7692:]))
+
+	_, err = source.ReadAt(p, 9000)
+	g.Expect(err).To(gomega.Equal(io.EOF))
+
+	source.Header = nil
+	_, err = source.ReadAt(p, 0)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(source.Close()).To(gomega.Succeed())
+}
+
+func TestNBD(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	image := make([]byte, 8192)
+	for i := range image {
+		image[i] = byte(i % 253)
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	go serve(server, image)
+
+	nbd, err := NewNBD(client)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(nbd.Size()).To(gomega.Equal(int64(len(image))))
+
+	p := make([]byte, 1000)
+	n, err := nbd.ReadAt(p, 4000)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(n).To(gomega.Equal(1000))
+	g.Expect(p).To(gomega.Equal(image[4000:5000]))
+
+	n, err = nbd.ReadAt(p, 7692)
+	g.Expect(err).To(gomega.Equal(io.EOF))
+	g.Expect(n).To(gomega.Equal(500))
+	g.Expect(p[:n]).To(gomega.Equal(image[7692:]))
+
+	g.Expect(nbd.Close()).To(gomega.Succeed())
+}
+
+// Records the offsets written.
+type recorder struct {
+	offsets []int64
+}
+
+func (r *recorder) WriteAt(p []byte, offset int64) (int, error) {
+	r.offsets = append(r.offsets, offset)
+	return len(p), nil
+}
+
+// Minimal NBD server exporting an image.
+func serve(conn net.Conn, image []byte) {
+	defer conn.Close()
+	be := binary.BigEndian
+	_ = binary.Write(conn, be, uint64(nbdMagic))
+	_ = binary.Write(conn, be, uint64(nbdOptMagic))
+	_ = binary.Write(conn, be, uint16(nbdFlagFixed|nbdFlagNoZeroes))
+	var flags uint32
+	_ = binary.Read(conn, be, &flags)
+	var option struct {
+		Magic  uint64
+		Option uint32
+		Length uint32
+	}
+	_ = binary.Read(conn, be, &option)
+	_, _ = io.CopyN(io.Discard, conn, int64(option.Length))
+	info := make([]byte, 12)
+	be.PutUint16(info, nbdInfoExport)
+	be.PutUint64(info[2:], uint64(len(image)))
+	for _, reply := range []struct {
+		Type uint32
+		Data []byte
+	}{
+		{Type: nbdRepInfo, Data: info},
+		{Type: nbdRepAck},
+	} {
+		_ = binary.Write(conn, be, uint64(nbdRepMagic))
+		_ = binary.Write(conn, be, option.Option)
+		_ = binary.Write(conn, be, reply.Type)
+		_ = binary.Write(conn, be, uint32(len(reply.Data)))
+		if len(reply.Data) > 0 {
+			_, _ = conn.Write(reply.Data)
+		}
+	}
+	for {
+		var request struct {
+			Magic   uint32
+			Flags   uint16
+			Command uint16
+			Handle  uint64
+			Offset  uint64
+			Length  uint32
+		}
+		err := binary.Read(conn, be, &request)
+		if err != nil || request.Command == nbdCmdDisc {
+			return
+		}
+		_ = binary.Write(conn, be, uint32(nbdReplyMagic))
+		_ = binary.Write(conn, be, uint32(0))
+		_ = binary.Write(conn, be, request.Handle)
+		_, _ = conn.Write(image[request.Offset : request.Offset+uint64(request.Length)])
+	}
+}
@@ -0,0 +1,60 @@
+package deltacopy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTP is a raw disk image read with HTTP range requests.
+type HTTP struct {
+	// URL of the image.
+	URL string
+	// Client issuing the requests.
+	Client *http.Client
+	// Headers sent with each request, such as the authorization.
+	Header http.Header
+}
+
+// ReadAt reads len(p) bytes at offset. Reading past the end of the
+// image returns the bytes read and io.EOF.
+func (r *HTTP) ReadAt(p []byte, offset int64) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+	request, err := http.NewRequest(http.MethodGet, r.URL, nil)
+	if err != nil {
+		return
+	}
+	for name, values := range r.Header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+int64(len(p))-1))
+	response, err := r.Client.Do(request)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		err = io.EOF
+		return
+	default:
+		err = fmt.Errorf("reading '%s' at offset %d: unexpected status %d", r.URL, offset, response.StatusCode)
+		return
+	}
+	n, err = io.ReadFull(response.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return
+}
+
+// Close idle connections.
+func (r *HTTP) Close() error {
+	r.Client.CloseIdleConnections()
+	return nil
+}
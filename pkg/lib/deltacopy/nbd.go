@@ -0,0 +1,188 @@
+package deltacopy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// NBD protocol constants.
+const (
+	nbdMagic          = 0x4e42444d41474943 // NBDMAGIC
+	nbdOptMagic       = 0x49484156454f5054 // IHAVEOPT
+	nbdRepMagic       = 0x0003e889045565a9
+	nbdRequestMagic   = 0x25609513
+	nbdReplyMagic     = 0x67446698
+	nbdFlagFixed      = 1 << 0
+	nbdFlagNoZeroes   = 1 << 1
+	nbdOptGo          = 7
+	nbdRepAck         = 1
+	nbdRepInfo        = 3
+	nbdRepErrorBit    = 1 << 31
+	nbdInfoExport     = 0
+	nbdCmdRead        = 0
+	nbdCmdDisc        = 2
+	nbdMaxReplyLength = 64 * 1024
+)
+
+// NBD is a minimal read-only client for the fixed newstyle
+// NBD protocol, sufficient to read images exported by qemu-nbd.
+type NBD struct {
+	conn   net.Conn
+	size   int64
+	handle uint64
+	mutex  sync.Mutex
+}
+
+// NewNBD negotiates the default export on an established connection.
+func NewNBD(conn net.Conn) (client *NBD, err error) {
+	client = &NBD{conn: conn}
+	err = client.handshake()
+	if err != nil {
+		client = nil
+	}
+	return
+}
+
+// Size of the export in bytes.
+func (r *NBD) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(p) bytes from the export at offset.
+func (r *NBD) ReadAt(p []byte, offset int64) (n int, err error) {
+	if offset >= r.size {
+		err = io.EOF
+		return
+	}
+	length := len(p)
+	if offset+int64(length) > r.size {
+		length = int(r.size - offset)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handle++
+	err = r.request(nbdCmdRead, offset, uint32(length))
+	if err != nil {
+		return
+	}
+	var reply struct {
+		Magic  uint32
+		Error  uint32
+		Handle uint64
+	}
+	err = binary.Read(r.conn, binary.BigEndian, &reply)
+	if err != nil {
+		return
+	}
+	if reply.Magic != nbdReplyMagic || reply.Handle != r.handle {
+		err = errors.New("nbd: unexpected reply")
+		return
+	}
+	if reply.Error != 0 {
+		err = fmt.Errorf("nbd: read at %d failed with error %d", offset, reply.Error)
+		return
+	}
+	n, err = io.ReadFull(r.conn, p[:length])
+	if err == nil && length < len(p) {
+		err = io.EOF
+	}
+	return
+}
+
+// Close disconnects from the server.
+func (r *NBD) Close() (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_ = r.request(nbdCmdDisc, 0, 0)
+	err = r.conn.Close()
+	return
+}
+
+// Fixed newstyle handshake using NBD_OPT_GO on the default export.
+func (r *NBD) handshake() (err error) {
+	var greeting struct {
+		Magic    uint64
+		OptMagic uint64
+		Flags    uint16
+	}
+	err = binary.Read(r.conn, binary.BigEndian, &greeting)
+	if err != nil {
+		return
+	}
+	if greeting.Magic != nbdMagic || greeting.OptMagic != nbdOptMagic {
+		err = errors.New("nbd: server does not support newstyle negotiation")
+		return
+	}
+	if greeting.Flags&nbdFlagFixed == 0 {
+		err = errors.New("nbd: server does not support fixed newstyle negotiation")
+		return
+	}
+	flags := uint32(nbdFlagFixed)
+	if greeting.Flags&nbdFlagNoZeroes != 0 {
+		flags |= nbdFlagNoZeroes
+	}
+	// Empty export name and no information requests.
+	data := make([]byte, 6)
+	err = r.write(flags, uint64(nbdOptMagic), uint32(nbdOptGo), uint32(len(data)), data)
+	if err != nil {
+		return
+	}
+	for {
+		var reply struct {
+			Magic  uint64
+			Option uint32
+			Type   uint32
+			Length uint32
+		}
+		err = binary.Read(r.conn, binary.BigEndian, &reply)
+		if err != nil {
+			return
+		}
+		if reply.Magic != nbdRepMagic || reply.Length > nbdMaxReplyLength {
+			err = errors.New("nbd: unexpected option reply")
+			return
+		}
+		payload := make([]byte, reply.Length)
+		_, err = io.ReadFull(r.conn, payload)
+		if err != nil {
+			return
+		}
+		switch {
+		case reply.Type&nbdRepErrorBit != 0:
+			err = fmt.Errorf("nbd: export negotiation failed with reply %#x: %s", reply.Type, payload)
+			return
+		case reply.Type == nbdRepInfo:
+			if len(payload) >= 12 && binary.BigEndian.Uint16(payload) == nbdInfoExport {
+				r.size = int64(binary.BigEndian.Uint64(payload[2:]))
+			}
+		case reply.Type == nbdRepAck:
+			return
+		}
+	}
+}
+
+// Send a transmission request.
+func (r *NBD) request(command uint16, offset int64, length uint32) error {
+	return r.write(
+		uint32(nbdRequestMagic),
+		uint16(0),
+		command,
+		r.handle,
+		uint64(offset),
+		length)
+}
+
+// Write big endian fields.
+func (r *NBD) write(fields ...any) (err error) {
+	for _, field := range fields {
+		err = binary.Write(r.conn, binary.BigEndian, field)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package deltacopy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Source is a readable disk image.
+type Source interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Time allowed for qemu-nbd to create its socket.
+var NbdStartTimeout = 30 * time.Second
+
+// Open a source disk image. Raw images are read directly, other
+// formats are exported read-only through qemu-nbd.
+func Open(path string, format string) (source Source, err error) {
+	if format == "" || format == FormatRaw {
+		source, err = os.Open(path)
+		return
+	}
+	dir, err := os.MkdirTemp("", "delta-copy-")
+	if err != nil {
+		return
+	}
+	socket := filepath.Join(dir, "nbd.sock")
+	cmd := exec.Command(
+		"qemu-nbd",
+		"--read-only",
+		"--shared=1",
+		"--format="+format,
+		"--socket="+socket,
+		path)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Start()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return
+	}
+	qemu := &qemuNbd{cmd: cmd, dir: dir}
+	deadline := time.Now().Add(NbdStartTimeout)
+	for {
+		var conn net.Conn
+		conn, err = net.Dial("unix", socket)
+		if err == nil {
+			qemu.NBD, err = NewNBD(conn)
+			if err != nil {
+				_ = conn.Close()
+				_ = qemu.Close()
+				return
+			}
+			source = qemu
+			return
+		}
+		if time.Now().After(deadline) {
+			_ = qemu.Close()
+			err = fmt.Errorf("qemu-nbd did not export '%s': %w", path, err)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Image exported by a qemu-nbd process.
+type qemuNbd struct {
+	*NBD
+	cmd *exec.Cmd
+	dir string
+}
+
+// Close the connection and stop qemu-nbd.
+func (r *qemuNbd) Close() (err error) {
+	if r.NBD != nil {
+		err = r.NBD.Close()
+	}
+	_ = r.cmd.Process.Kill()
+	_ = r.cmd.Wait()
+	_ = os.RemoveAll(r.dir)
+	return
+}
@@ -0,0 +1,137 @@
+// Package deltacopy copies disk images, or only the changed extents of
+// disk images, into block devices or raw files. It is used by the
+// delta-copy pod to transfer warm migration precopies for providers
+// that are not handled by CDI.
+package deltacopy
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+// Source disk image formats.
+const (
+	FormatRaw = "raw"
+)
+
+// Spec describes the disks transferred by a delta copy pod.
+type Spec struct {
+	Disks []Disk `json:"disks"`
+}
+
+// ReadsURLs reports whether any disk is read from a URL.
+func (r *Spec) ReadsURLs() bool {
+	for _, disk := range r.Disks {
+		if disk.URL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Disk to be transferred.
+type Disk struct {
+	// Disk identifier.
+	ID string `json:"id"`
+	// Path to the source disk image.
+	Source string `json:"source,omitempty"`
+	// URL the disk is read from with HTTP range requests, in place of Source.
+	URL string `json:"url,omitempty"`
+	// EBS snapshot the disk is read from with the EBS direct APIs, in place of Source.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Earlier EBS snapshot of the same volume already copied to the target.
+	// Only the blocks changed since are copied.
+	BaseSnapshot string `json:"baseSnapshot,omitempty"`
+	// Format of the source disk image, as named by qemu (raw, vhdx, vpc, qcow2, ...).
+	Format string `json:"format,omitempty"`
+	// Path to the target block device or raw file.
+	Target string `json:"target,omitempty"`
+	// Virtual size of the disk in bytes.
+	Capacity int64 `json:"capacity"`
+	// Copy the whole disk rather than the extents.
+	Full bool `json:"full,omitempty"`
+	// Changed extents to be copied.
+	Extents []Extent `json:"extents,omitempty"`
+}
+
+// Bytes returns the number of bytes the disk transfer reads.
+func (r *Disk) Bytes() int64 {
+	if r.Full {
+		return r.Capacity
+	}
+	return Total(r.Extents)
+}
+
+// Extent is a byte range of a virtual disk.
+type Extent struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// End returns the offset following the extent.
+func (r Extent) End() int64 {
+	return r.Offset + r.Length
+}
+
+// Total returns the number of bytes covered by the extents.
+func Total(extents []Extent) (total int64) {
+	for _, e := range extents {
+		total += e.Length
+	}
+	return
+}
+
+// Coalesce sorts the extents and merges the ones that overlap or are
+// separated by no more than gap bytes. Empty extents are dropped.
+func Coalesce(extents []Extent, gap int64) (merged []Extent) {
+	sorted := make([]Extent, 0, len(extents))
+	for _, e := range extents {
+		if e.Length > 0 {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	for _, e := range sorted {
+		n := len(merged)
+		if n > 0 && e.Offset <= merged[n-1].End()+gap {
+			if e.End() > merged[n-1].End() {
+				merged[n-1].Length = e.End() - merged[n-1].Offset
+			}
+			continue
+		}
+		merged = append(merged, e)
+	}
+	return
+}
+
+// Reduce coalesces the extents and then merges the closest neighbours
+// until no more than max extents remain. Merging copies some unchanged
+// bytes but keeps the spec small enough to be passed in a ConfigMap.
+func Reduce(extents []Extent, max int) []Extent {
+	merged := Coalesce(extents, 0)
+	if max < 1 || len(merged) <= max {
+		return merged
+	}
+	gaps := make([]int64, 0, len(merged)-1)
+	for i := 1; i < len(merged); i++ {
+		gaps = append(gaps, merged[i].Offset-merged[i-1].End())
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i] < gaps[j]
+	})
+	return Coalesce(merged, gaps[len(merged)-max-1])
+}
+
+// ReadSpec reads a JSON encoded spec from a file.
+func ReadSpec(path string) (spec *Spec, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	spec = &Spec{}
+	err = json.Unmarshal(b, spec)
+	return
+}
@@ -45,6 +45,16 @@ type HyperVDriver interface {
 	GetClusterVMGroups() ([]ClusterGroupData, error)
 	GetComputerInfo() (*ComputerInfoData, error)
 
+	// Warm migration based on Resilient Change Tracking (RCT).
+	// The computerName routes the command to a cluster node, empty runs locally.
+	CreateRecoveryCheckpoint(vmName, computerName string) (string, error)
+	// GetCheckpoint returns nil when the checkpoint does not exist.
+	GetCheckpoint(vmName, checkpointID, computerName string) (*CheckpointData, error)
+	ConvertToReferencePoint(checkpointID, computerName string) error
+	RemoveCheckpoint(vmName, checkpointID, computerName string) error
+	GetVirtualDiskChanges(diskPath, rctID string, capacity int64, computerName string) ([]DiskChange, error)
+	RemoveReferencePoints(rctIDs []string, computerName string) error
+
 	// Raw command execution
 	ExecuteCommand(command string) (string, error)
 	// RunOnNode wraps a command to execute on a specific cluster node via
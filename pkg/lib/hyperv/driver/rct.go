@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ps "github.com/kubev2v/forklift/pkg/lib/hyperv/powershell"
+)
+
+// Checkpoint and change tracking commands can take a while on busy hosts.
+const checkpointCommandTimeout = 10 * time.Minute
+
+type CheckpointData struct {
+	Id    string               `json:"Id"`
+	Name  string               `json:"Name"`
+	Disks []CheckpointDiskData `json:"Disks"`
+}
+
+type CheckpointDiskData struct {
+	Path  string `json:"Path"`
+	RctId string `json:"RctId"`
+}
+
+// DiskChange is a changed byte range of a virtual disk.
+type DiskChange struct {
+	Offset int64
+	Length int64
+}
+
+func (d *WinRMDriver) CreateRecoveryCheckpoint(vmName, computerName string) (string, error) {
+	cmd := ps.BuildCommand(ps.CreateRecoveryCheckpoint, vmName, vmName)
+	stdout, err := d.runOnNodeWithTimeout(cmd, computerName, checkpointCommandTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to create checkpoint for VM %s: %w", vmName, err)
+	}
+	if stdout == "" {
+		return "", fmt.Errorf("no checkpoint returned for VM %s", vmName)
+	}
+	return stdout, nil
+}
+
+func (d *WinRMDriver) GetCheckpoint(vmName, checkpointID, computerName string) (*CheckpointData, error) {
+	cmd := ps.BuildCommand(ps.GetCheckpoint, vmName, checkpointID)
+	stdout, err := d.RunOnNode(cmd, computerName)
+	if err != nil {
+		return nil, err
+	}
+	if stdout == "" {
+		return nil, nil
+	}
+	checkpoint := &CheckpointData{}
+	if err := json.Unmarshal([]byte(stdout), checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint JSON: %w", err)
+	}
+	return checkpoint, nil
+}
+
+func (d *WinRMDriver) ConvertToReferencePoint(checkpointID, computerName string) error {
+	cmd := ps.BuildCommand(ps.ConvertToReferencePoint, checkpointID)
+	_, err := d.runOnNodeWithTimeout(cmd, computerName, checkpointCommandTimeout)
+	return err
+}
+
+func (d *WinRMDriver) RemoveCheckpoint(vmName, checkpointID, computerName string) error {
+	cmd := ps.BuildCommand(ps.RemoveCheckpoint, vmName, checkpointID)
+	_, err := d.runOnNodeWithTimeout(cmd, computerName, checkpointCommandTimeout)
+	return err
+}
+
+func (d *WinRMDriver) GetVirtualDiskChanges(diskPath, rctID string, capacity int64, computerName string) ([]DiskChange, error) {
+	cmd := ps.BuildCommand(ps.GetVirtualDiskChanges, strconv.FormatInt(capacity, 10), diskPath, rctID)
+	stdout, err := d.runOnNodeWithTimeout(cmd, computerName, checkpointCommandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes of disk %s: %w", diskPath, err)
+	}
+	var ranges struct {
+		Offsets json.RawMessage `json:"Offsets"`
+		Lengths json.RawMessage `json:"Lengths"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &ranges); err != nil {
+		return nil, fmt.Errorf("failed to parse disk changes JSON: %w", err)
+	}
+	offsets, err := unmarshalInt64s(ranges.Offsets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disk change offsets: %w", err)
+	}
+	lengths, err := unmarshalInt64s(ranges.Lengths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disk change lengths: %w", err)
+	}
+	if len(offsets) != len(lengths) {
+		return nil, fmt.Errorf("disk %s returned %d offsets and %d lengths", diskPath, len(offsets), len(lengths))
+	}
+	changes := make([]DiskChange, 0, len(offsets))
+	for i := range offsets {
+		changes = append(changes, DiskChange{Offset: offsets[i], Length: lengths[i]})
+	}
+	return changes, nil
+}
+
+func (d *WinRMDriver) RemoveReferencePoints(rctIDs []string, computerName string) error {
+	if len(rctIDs) == 0 {
+		return nil
+	}
+	cmd := ps.BuildCommand(ps.RemoveReferencePoints, strings.Join(rctIDs, ","))
+	_, err := d.runOnNodeWithTimeout(cmd, computerName, checkpointCommandTimeout)
+	return err
+}
+
+// runOnNodeWithTimeout is RunOnNode for long running commands.
+func (d *WinRMDriver) runOnNodeWithTimeout(command, computerName string, timeout time.Duration) (string, error) {
+	cmd := ps.RunOnNode(command, computerName, d.password, d.username)
+	return d.ExecuteCommandWithTimeout(cmd, timeout)
+}
+
+// unmarshalInt64s unmarshals a JSON array, a bare number or null
+// (PowerShell collapses single element arrays) into a slice.
+func unmarshalInt64s(data json.RawMessage) ([]int64, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	return UnmarshalArrayOrSingle[int64](data)
+}
@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_unmarshalInt64s(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []int64
+		wantErr bool
+	}{
+		{
+			name: "missing",
+			data: "",
+			want: nil,
+		},
+		{
+			name: "null",
+			data: "null",
+			want: nil,
+		},
+		{
+			name: "empty array",
+			data: "[]",
+			want: nil,
+		},
+		{
+			name: "single value",
+			data: "4096",
+			want: []int64{4096},
+		},
+		{
+			name: "array",
+			data: "[0,1048576,68719476736]",
+			want: []int64{0, 1048576, 68719476736},
+		},
+		{
+			name:    "invalid",
+			data:    `"abc"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unmarshalInt64s(json.RawMessage(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unmarshalInt64s() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unmarshalInt64s() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -204,3 +204,76 @@ const (
 	// BatchGetVMGuest collects guest OS and guest network config for running VMs.
 	BatchGetVMGuest = `$r=@{};foreach($vm in(Get-VM|?{$_.State-eq'Running'})){$n=$vm.Name;$e=@{};$ci=Get-CimInstance -Namespace root\virtualization\v2 -ClassName Msvm_ComputerSystem -Filter "ElementName='$n'" -EA 0;if($ci){$kv=Get-CimAssociatedInstance -InputObject $ci -ResultClassName Msvm_KvpExchangeComponent -EA 0;if($kv-and$kv.GuestIntrinsicExchangeItems){$os='';$om='';foreach($i in $kv.GuestIntrinsicExchangeItems){$x=[xml]$i;$pn=$x.INSTANCE.PROPERTY|?{$_.NAME-eq'Name'}|Select -Exp VALUE;$pv=$x.INSTANCE.PROPERTY|?{$_.NAME-eq'Data'}|Select -Exp VALUE;if($pn-eq'OSName'){$os=$pv}elseif($pn-eq'OSMajorVersion'){$om=$pv}};if($os-and$om-and$os-notmatch'\d'){$os="$os $om"};$e['GuestOS']=$os};$vs=Get-CimAssociatedInstance -InputObject $ci -ResultClassName Msvm_VirtualSystemSettingData|?{$_.VirtualSystemType-eq'Microsoft:Hyper-V:System:Realized'};if($vs){$ps=Get-CimAssociatedInstance -InputObject $vs -ResultClassName Msvm_SyntheticEthernetPortSettingData;$nc=@();foreach($p in $ps){$gc=Get-CimAssociatedInstance -InputObject $p -ResultClassName Msvm_GuestNetworkAdapterConfiguration;if($gc){$nc+=[PSCustomObject]@{MAC=$p.Address;IPs=$gc.IPAddresses;Subnets=$gc.Subnets;DHCP=$gc.DHCPEnabled;GW=$gc.DefaultGateways;DNS=$gc.DNSServers}}};if($nc.Count-gt 0){$e['GuestNetworks']=$nc}}};if($e.Count-gt 0){$r[$n]=$e}};$r|ConvertTo-Json -Depth 4 -Compress`
 )
+
+// Warm migration scripts based on Resilient Change Tracking (RCT).
+// Checkpoints are crash-consistent recovery checkpoints created through the
+// Msvm_VirtualSystemSnapshotService. A checkpoint is converted to a reference
+// point once it has been copied so the RCT IDs of its disks remain usable as
+// the baseline of the next copy without keeping a differencing disk around.
+const (
+	// waitForJob waits for the WMI job started by the method call stored in $r
+	// and throws when the method or the job fails.
+	waitForJob = `if($r.ReturnValue -eq 4096){$j=[wmi]$r.Job;while($j.JobState -eq 3 -or $j.JobState -eq 4){Start-Sleep -Milliseconds 500;$j=[wmi]$r.Job};if($j.JobState -ne 7){throw "Job failed: $($j.ErrorDescription)"}}elseif($r.ReturnValue -ne 0){throw "Method failed with return value $($r.ReturnValue)"}`
+
+	// CreateRecoveryCheckpoint creates a crash-consistent recovery checkpoint
+	// and returns the ID of the newest recovery checkpoint of the VM.
+	// Parameters: vmName, vmName
+	CreateRecoveryCheckpoint = `$ns='root\virtualization\v2'
+$vm=Get-WmiObject -Namespace $ns -Class Msvm_ComputerSystem -Filter "ElementName='%s'"
+if(-not $vm){throw 'VM not found'}
+$svc=Get-WmiObject -Namespace $ns -Class Msvm_VirtualSystemSnapshotService
+$sd=([wmiclass]'\\.\root\virtualization\v2:Msvm_VirtualSystemSnapshotSettingData').CreateInstance()
+$sd.ConsistencyLevel=2
+$sd.IgnoreNonSnapshottableDisks=$true
+$r=$svc.CreateSnapshot($vm,$sd.GetText(2),32768)
+` + waitForJob + `
+$s=Get-VMSnapshot -VMName '%s' -SnapshotType Recovery|Sort-Object CreationTime|Select-Object -Last 1
+if(-not $s){throw 'Recovery checkpoint not found'}
+$s.Id.ToString()`
+
+	// GetCheckpoint returns a recovery checkpoint with the path and RCT ID of
+	// each of its virtual disks. Returns nothing when the checkpoint does not exist.
+	// Parameters: vmName, checkpointId
+	GetCheckpoint = `$s=Get-VMSnapshot -VMName '%s' -SnapshotType Recovery -ErrorAction SilentlyContinue|Where-Object{$_.Id -eq '%s'}
+if(-not $s){return}
+$d=@($s.HardDrives|ForEach-Object{$v=Get-VHD -Path $_.Path -ErrorAction SilentlyContinue;$id='';if($v -and $v.RctId){$id=$v.RctId};[PSCustomObject]@{Path=$_.Path;RctId=$id}})
+[PSCustomObject]@{Id=$s.Id.ToString();Name=$s.Name;Disks=$d}|ConvertTo-Json -Depth 3 -Compress`
+
+	// ConvertToReferencePoint starts the conversion of a checkpoint into a
+	// reference point. The conversion merges the checkpoint differencing disks
+	// and runs asynchronously; the checkpoint disappears once it is done.
+	// Parameters: checkpointId
+	ConvertToReferencePoint = `$ns='root\virtualization\v2'
+$s=Get-WmiObject -Namespace $ns -Class Msvm_VirtualSystemSettingData -Filter "InstanceID='Microsoft:%s'"
+if(-not $s){throw 'Checkpoint not found'}
+$svc=Get-WmiObject -Namespace $ns -Class Msvm_VirtualSystemSnapshotService
+$r=$svc.ConvertToReferencePoint($s)
+if($r.ReturnValue -ne 0 -and $r.ReturnValue -ne 4096){throw "ConvertToReferencePoint failed with return value $($r.ReturnValue)"}`
+
+	// RemoveCheckpoint deletes a recovery checkpoint, merging its differencing disks.
+	// Parameters: vmName, checkpointId
+	RemoveCheckpoint = `Get-VMSnapshot -VMName '%s' -SnapshotType Recovery -ErrorAction SilentlyContinue|Where-Object{$_.Id -eq '%s'}|Remove-VMSnapshot -Confirm:$false`
+
+	// GetVirtualDiskChanges returns the byte ranges of a virtual disk changed
+	// since the RCT ID as JSON with Offsets and Lengths arrays. The query is
+	// repeated until the whole disk has been processed.
+	// Parameters: capacity, windowsPath, rctId
+	GetVirtualDiskChanges = `$ns='root\virtualization\v2'
+$svc=Get-WmiObject -Namespace $ns -Class Msvm_ImageManagementService
+$size=[uint64]'%s';$off=[uint64]0;$o=@();$l=@()
+while($off -lt $size){$p=$svc.GetMethodParameters('GetVirtualDiskChanges');$p.Path='%s';$p.LimitId='%s';$p.ByteOffset=$off;$p.ByteLength=$size-$off
+$r=$svc.InvokeMethod('GetVirtualDiskChanges',$p,$null)
+if($r.ReturnValue -ne 0){throw "GetVirtualDiskChanges failed with return value $($r.ReturnValue)"}
+if($r.ByteOffsets){$o+=$r.ByteOffsets;$l+=$r.ByteLengths}
+if($r.ProcessedByteLength -eq 0){break};$off+=$r.ProcessedByteLength}
+@{Offsets=$o;Lengths=$l}|ConvertTo-Json -Compress`
+
+	// RemoveReferencePoints destroys the reference points tracking any of the
+	// RCT IDs.
+	// Parameters: comma-separated rctIds
+	RemoveReferencePoints = `$ns='root\virtualization\v2'
+$ids='%s' -split ','
+$svc=Get-WmiObject -Namespace $ns -Class Msvm_VirtualSystemReferencePointService
+foreach($p in @(Get-WmiObject -Namespace $ns -Class Msvm_VirtualSystemReferencePoint)){if(@($p.ResilientChangeTrackingIdentifiers|Where-Object{$ids -contains $_}).Count -gt 0){$r=$svc.DestroyReferencePoint($p)
+` + waitForJob + `}}`
+)
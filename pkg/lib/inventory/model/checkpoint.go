@@ -0,0 +1,13 @@
+package model
+
+// Checkpoint model.
+// Records that a collector loaded a complete inventory so
+// it may be reconciled when the DB is kept across restarts.
+type Checkpoint struct {
+	// Collector name.
+	Name string `sql:"pk"`
+}
+
+func (m *Checkpoint) Pk() string {
+	return m.Name
+}
@@ -2,7 +2,10 @@ package model
 
 import (
 	"database/sql"
+	"fmt"
+	"hash/fnv"
 	"os"
+	"slices"
 	"time"
 
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
@@ -60,10 +63,21 @@ type Client struct {
 
 // Create the database.
 // Build the schema to support the specified models.
+// When not deleted, the existing DB file is kept only when it
+// was built with the same schema. Otherwise, it is rebuilt.
 // See: Pool.Open().
 func (r *Client) Open(delete bool) (err error) {
+	ddls, err := r.schema()
+	if err != nil {
+		panic(err)
+	}
+	version := r.version(ddls)
+	if !delete && !r.matched(version) {
+		delete = true
+		r.log.V(3).Info("DB schema not matched.")
+	}
 	if delete {
-		_ = os.Remove(r.path)
+		r.remove()
 		r.log.V(3).Info("DB file deleted.")
 	}
 	err = r.pool.Open(1, 10, r.path, &r.journal)
@@ -74,10 +88,10 @@ func (r *Client) Open(delete bool) (err error) {
 	defer func() {
 		if err != nil {
 			_ = r.pool.Close()
-			_ = os.Remove(r.path)
+			r.remove()
 		}
 	}()
-	err = r.build()
+	err = r.build(ddls, version)
 	if err != nil {
 		panic(err)
 	}
@@ -103,7 +117,7 @@ func (r *Client) Close(delete bool) (err error) {
 			"Error closing the session pool.")
 	}
 	if delete {
-		_ = os.Remove(r.path)
+		r.remove()
 		r.log.V(3).Info("DB file deleted.")
 	}
 
@@ -347,16 +361,20 @@ func (r *Client) EndWatch(watch *Watch) {
 }
 
 // Build the data model.
-func (r *Client) build() (err error) {
-	r.models = append(r.models, &Label{})
+// Returns the DDL for the schema.
+func (r *Client) schema() (ddls []string, err error) {
+	r.models = append(r.models, &Label{}, &Checkpoint{})
 	r.dm, err = NewModel(r.models)
 	if err != nil {
-		return err
-	}
-	ddls, err := r.dm.DDL()
-	if err != nil {
-		return err
+		return
 	}
+	ddls, err = r.dm.DDL()
+	return
+}
+
+// Build the schema.
+// The schema version is recorded in the DB.
+func (r *Client) build(ddls []string, version int32) (err error) {
 	session := r.pool.Writer()
 	defer session.Return()
 	for _, ddl := range ddls {
@@ -374,10 +392,63 @@ func (r *Client) build() (err error) {
 				ddl)
 		}
 	}
+	_, err = session.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	if err != nil {
+		return liberr.Wrap(err)
+	}
 
 	return nil
 }
 
+// Schema version.
+// Digest of the DDL; never zero which is the
+// version of a DB built before versions were recorded.
+// The DDL is sorted since the generation order of the
+// tables is not stable.
+func (r *Client) version(ddls []string) (version int32) {
+	sorted := slices.Clone(ddls)
+	slices.Sort(sorted)
+	h := fnv.New32a()
+	for _, ddl := range sorted {
+		_, _ = h.Write([]byte(ddl))
+	}
+	version = int32(h.Sum32() & 0x7fffffff)
+	if version == 0 {
+		version = 1
+	}
+	return
+}
+
+// Determine if the existing DB file was built
+// with the schema version.
+func (r *Client) matched(version int32) (matched bool) {
+	if _, err := os.Stat(r.path); err != nil {
+		return
+	}
+	db, err := sql.Open("sqlite", r.path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	found := int32(0)
+	err = db.QueryRow("PRAGMA user_version").Scan(&found)
+	if err != nil {
+		r.log.V(3).Error(err, "read schema version failed.")
+		return
+	}
+	matched = found == version
+	return
+}
+
+// Remove the DB files.
+func (r *Client) remove() {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		_ = os.Remove(r.path + suffix)
+	}
+}
+
 // Database transaction.
 type Tx struct {
 	// DB session.
@@ -1180,6 +1180,43 @@ func TestCloseDB(t *testing.T) {
 	g.Expect(handler.done).To(gomega.BeTrue())
 }
 
+func TestPersistent(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	path := "/tmp/test-persistent.db"
+	// Built.
+	DB := New(path, &PlainObject{})
+	err := DB.Open(true)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	err = DB.Insert(&PlainObject{ID: 1, Name: "Elmer"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	err = DB.Insert(&Checkpoint{Name: "test"})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	_ = DB.Close(false)
+	// Kept when the schema matched.
+	DB = New(path, &PlainObject{})
+	err = DB.Open(false)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	plain := &PlainObject{ID: 1}
+	err = DB.Get(plain)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(plain.Name).To(gomega.Equal("Elmer"))
+	checkpoint := &Checkpoint{Name: "test"}
+	err = DB.Get(checkpoint)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	_ = DB.Close(false)
+	// Rebuilt when the schema changed.
+	DB = New(path, &PlainObject{}, &TestObject{})
+	err = DB.Open(false)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	err = DB.Get(&PlainObject{ID: 1})
+	g.Expect(errors.Is(err, NotFound)).To(gomega.BeTrue())
+	_ = DB.Close(true)
+	// Version independent of the DDL order.
+	client := &Client{}
+	g.Expect(client.version([]string{"CREATE A", "CREATE B"})).To(
+		gomega.Equal(client.version([]string{"CREATE B", "CREATE A"})))
+}
+
 func TestMutatingWatch(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 	DB := New("/tmp/test-mutating-watch.db", &TestObject{})
@@ -1539,3 +1576,83 @@ func fieldNames(fields []*Field) (names []string) {
 
 	return
 }
+
+func TestQuery(t *testing.T) {
+	var err error
+	g := gomega.NewGomegaWithT(t)
+	DB := New(
+		"/tmp/test-query.db",
+		&TestObject{})
+	err = DB.Open(true)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	N := 10
+	for i := 0; i < N; i++ {
+		name := "Elmer"
+		if i%2 == 0 {
+			name = "Bugs_Bunny"
+		}
+		object := &TestObject{
+			ID:     i,
+			Name:   name,
+			Age:    18 + i,
+			Bool:   i < 3,
+			Object: TestEncoded{Name: fmt.Sprintf("json-%d", i)},
+			Slice:  []string{"hello", fmt.Sprintf("world-%d", i)},
+		}
+		err = DB.Insert(object)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+	}
+	list := func(query string) (ids []int, err error) {
+		p, err := ParseQuery(query)
+		if err != nil {
+			return
+		}
+		list := []TestObject{}
+		err = DB.List(&list, ListOptions{Predicate: p, Sort: []int{2}})
+		for _, m := range list {
+			ids = append(ids, m.ID)
+		}
+		return
+	}
+	// Comparisons.
+	ids, err := list("name=Elmer and id>=7")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.Equal([]int{7, 9}))
+	ids, err = list("age <= 19 or (bool == true and not id = 2)")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.Equal([]int{0, 1}))
+	ids, err = list("NAME != 'Elmer' AND id < 3")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.Equal([]int{0, 2}))
+	// Contains.
+	ids, err = list(`slice contains "world-3" or object contains json-4`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.Equal([]int{3, 4}))
+	ids, err = list("name contains s_b and id > 6")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.Equal([]int{8}))
+	ids, err = list(`name contains "%"`)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ids).To(gomega.BeEmpty())
+	// Not valid.
+	for _, query := range []string{
+		"name=",
+		"name Elmer",
+		"(id > 1",
+		"id > 1 name",
+		"name = 'Elmer",
+		"id ! 1",
+	} {
+		_, err = ParseQuery(query)
+		g.Expect(QueryNotValid(err)).To(gomega.BeTrue(), query)
+	}
+	for _, query := range []string{
+		"unknown=1",
+		"name > 1",
+		"id = one",
+		"bool = maybe",
+	} {
+		_, err = list(query)
+		g.Expect(QueryNotValid(err)).To(gomega.BeTrue(), query)
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 	"strings"
 	"text/template"
@@ -69,6 +70,36 @@ func Lt(field string, value interface{}) *LtPredicate {
 	}
 }
 
+// New Gte (>=) predicate.
+func Gte(field string, value interface{}) *GtePredicate {
+	return &GtePredicate{
+		SimplePredicate{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
+// New Lte (<=) predicate.
+func Lte(field string, value interface{}) *LtePredicate {
+	return &LtePredicate{
+		SimplePredicate{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
+// New Contains predicate.
+func Contains(field string, value interface{}) *ContainsPredicate {
+	return &ContainsPredicate{
+		SimplePredicate{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
 // AND predicate.
 func And(predicates ...Predicate) *AndPredicate {
 	return &AndPredicate{
@@ -87,6 +118,13 @@ func Or(predicates ...Predicate) *OrPredicate {
 	}
 }
 
+// NOT predicate.
+func Not(predicate Predicate) *NotPredicate {
+	return &NotPredicate{
+		Predicate: predicate,
+	}
+}
+
 // Label predicate.
 func Match(labels Labels) *LabelPredicate {
 	return &LabelPredicate{
@@ -164,6 +202,27 @@ func (p *SimplePredicate) build(operator string, options *FilterOptions) error {
 	return nil
 }
 
+// Build a comparison valid only for integer fields.
+func (p *SimplePredicate) numeric(operator string, options *FilterOptions) error {
+	f, found := p.match(options.fields)
+	if !found {
+		return liberr.Wrap(PredicateRefErr)
+	}
+	switch f.Value.Kind() {
+	case reflect.String,
+		reflect.Bool:
+		return PredicateTypeErr
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		return p.build(operator, options)
+	default:
+		return FieldTypeErr
+	}
+}
+
 // Equals (=) predicate.
 type EqPredicate struct {
 	SimplePredicate
@@ -230,23 +289,7 @@ type GtPredicate struct {
 
 // Build.
 func (p *GtPredicate) Build(options *FilterOptions) error {
-	f, found := p.match(options.fields)
-	if !found {
-		return liberr.Wrap(PredicateRefErr)
-	}
-	switch f.Value.Kind() {
-	case reflect.String,
-		reflect.Bool:
-		return PredicateTypeErr
-	case reflect.Int,
-		reflect.Int8,
-		reflect.Int16,
-		reflect.Int32,
-		reflect.Int64:
-		return p.build(">", options)
-	default:
-		return FieldTypeErr
-	}
+	return p.numeric(">", options)
 }
 
 // Render the expression.
@@ -261,30 +304,124 @@ type LtPredicate struct {
 
 // Build.
 func (p *LtPredicate) Build(options *FilterOptions) error {
+	return p.numeric("<", options)
+}
+
+// Render the expression.
+func (p *LtPredicate) Expr() string {
+	return p.expr
+}
+
+// Greater than or equal (>=) predicate.
+type GtePredicate struct {
+	SimplePredicate
+}
+
+// Build.
+func (p *GtePredicate) Build(options *FilterOptions) error {
+	return p.numeric(">=", options)
+}
+
+// Render the expression.
+func (p *GtePredicate) Expr() string {
+	return p.expr
+}
+
+// Less than or equal (<=) predicate.
+type LtePredicate struct {
+	SimplePredicate
+}
+
+// Build.
+func (p *LtePredicate) Build(options *FilterOptions) error {
+	return p.numeric("<=", options)
+}
+
+// Render the expression.
+func (p *LtePredicate) Expr() string {
+	return p.expr
+}
+
+// Contains predicate.
+// String fields contain the value as a substring (case-insensitive).
+// Encoded (json) fields contain an element equal to the value, or
+// an object element with a `name` or `id` equal to the value.
+type ContainsPredicate struct {
+	SimplePredicate
+}
+
+// Build.
+func (p *ContainsPredicate) Build(options *FilterOptions) error {
 	f, found := p.match(options.fields)
 	if !found {
 		return liberr.Wrap(PredicateRefErr)
 	}
-	switch f.Value.Kind() {
+	pv := reflect.ValueOf(p.Value)
+	switch pv.Kind() {
 	case reflect.String,
-		reflect.Bool:
-		return PredicateTypeErr
-	case reflect.Int,
+		reflect.Bool,
+		reflect.Int,
 		reflect.Int8,
 		reflect.Int16,
 		reflect.Int32,
 		reflect.Int64:
-		return p.build("<", options)
 	default:
-		return FieldTypeErr
+		return liberr.Wrap(PredicateValueErr)
+	}
+	switch f.Value.Kind() {
+	case reflect.String:
+		pattern := fmt.Sprint(p.Value)
+		pattern = likeEscaper.Replace(pattern)
+		param := options.Param(f.Name, "%"+pattern+"%")
+		p.expr = f.Name + " LIKE " + param + ` ESCAPE '\'`
+	case reflect.Slice,
+		reflect.Map,
+		reflect.Struct:
+		param := options.Param(f.Name, p.Value)
+		p.expr = strings.Join(
+			[]string{
+				"EXISTS (SELECT 1 FROM json_each(" + f.Name + ") WHERE",
+				"CASE json_each.type WHEN 'object' THEN",
+				"json_extract(json_each.value, '$.name') = " + param,
+				"OR json_extract(json_each.value, '$.id') = " + param,
+				"ELSE json_each.value = " + param,
+				"END)",
+			},
+			" ")
+	default:
+		return PredicateTypeErr
 	}
+
+	return nil
 }
 
 // Render the expression.
-func (p *LtPredicate) Expr() string {
+func (p *ContainsPredicate) Expr() string {
 	return p.expr
 }
 
+// Escape LIKE wildcards.
+var likeEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"%", `\%`,
+	"_", `\_`)
+
+// NOT predicate.
+type NotPredicate struct {
+	// Negated predicate.
+	Predicate Predicate
+}
+
+// Build.
+func (p *NotPredicate) Build(options *FilterOptions) error {
+	return p.Predicate.Build(options)
+}
+
+// Render the expression.
+func (p *NotPredicate) Expr() string {
+	return "NOT (" + p.Predicate.Expr() + ")"
+}
+
 // Compound predicate.
 type CompoundPredicate struct {
 	// List of predicates.
@@ -315,7 +452,7 @@ func (p *AndPredicate) Expr() string {
 		predicates = append(predicates, p.Expr())
 	}
 
-	expr := strings.Join(predicates, " AND ")
+	expr := "(" + strings.Join(predicates, " AND ") + ")"
 
 	return expr
 }
@@ -344,7 +481,7 @@ func (p *OrPredicate) Expr() string {
 		predicates = append(predicates, p.Expr())
 	}
 
-	expr := strings.Join(predicates, " OR ")
+	expr := "(" + strings.Join(predicates, " OR ") + ")"
 
 	return expr
 }
@@ -0,0 +1,329 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+)
+
+// Errors.
+var (
+	// Query syntax error.
+	QueryErr = errors.New("query not valid")
+)
+
+// Parse a query into a predicate.
+// Grammar:
+//
+//	query      = or
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | comparison
+//	comparison = field operator value
+//	operator   = "=" | "==" | "!=" | ">" | ">=" | "<" | "<=" | "contains"
+//	value      = quoted string | number | true | false | word
+//
+// Keywords are case-insensitive. Field names are matched with
+// the model fields case-insensitively when the predicate is built.
+// Example:
+//
+//	powerState=poweredOn and cpuCount>8 and tags contains "prod"
+func ParseQuery(query string) (p Predicate, err error) {
+	parser := &queryParser{}
+	err = parser.scan(query)
+	if err != nil {
+		return
+	}
+	p, err = parser.or()
+	if err != nil {
+		return
+	}
+	if !parser.done() {
+		err = parser.error("unexpected token")
+	}
+
+	return
+}
+
+// Determine if the error is caused by a query not valid
+// for the listed model: syntax, unknown field, wrong value
+// or operator not valid for the type of the field.
+func QueryNotValid(err error) bool {
+	return errors.Is(err, QueryErr) ||
+		errors.Is(err, PredicateRefErr) ||
+		errors.Is(err, PredicateTypeErr) ||
+		errors.Is(err, PredicateValueErr) ||
+		errors.Is(err, FieldTypeErr) ||
+		errors.Is(err, strconv.ErrSyntax) ||
+		errors.Is(err, strconv.ErrRange)
+}
+
+// Query token kinds.
+const (
+	tkWord = iota
+	tkString
+	tkOperator
+	tkOpen
+	tkClose
+)
+
+// Query token.
+type queryToken struct {
+	kind  int
+	text  string
+	index int
+}
+
+// Query parser.
+type queryParser struct {
+	tokens []queryToken
+	next   int
+}
+
+// Split the query into tokens.
+func (r *queryParser) scan(query string) (err error) {
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			r.tokens = append(r.tokens, queryToken{kind: tkOpen, text: "(", index: i})
+			i++
+		case c == ')':
+			r.tokens = append(r.tokens, queryToken{kind: tkClose, text: ")", index: i})
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			text := strings.Builder{}
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					text.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == c {
+					closed = true
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				err = liberr.Wrap(
+					fmt.Errorf("%w: unterminated string at %d", QueryErr, start))
+				return
+			}
+			r.tokens = append(r.tokens, queryToken{kind: tkString, text: text.String(), index: start})
+		case strings.ContainsRune("=!<>", c):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				err = liberr.Wrap(
+					fmt.Errorf("%w: unexpected '!' at %d", QueryErr, start))
+				return
+			}
+			r.tokens = append(r.tokens, queryToken{kind: tkOperator, text: op, index: start})
+		default:
+			start := i
+			for i < len(runes) && r.wordRune(runes[i]) {
+				i++
+			}
+			if i == start {
+				err = liberr.Wrap(
+					fmt.Errorf("%w: unexpected '%c' at %d", QueryErr, c, start))
+				return
+			}
+			r.tokens = append(r.tokens, queryToken{kind: tkWord, text: string(runes[start:i]), index: start})
+		}
+	}
+
+	return
+}
+
+// Runes of unquoted words.
+func (r *queryParser) wordRune(c rune) bool {
+	return unicode.IsLetter(c) ||
+		unicode.IsDigit(c) ||
+		strings.ContainsRune("_.-:/", c)
+}
+
+// All the tokens consumed.
+func (r *queryParser) done() bool {
+	return r.next >= len(r.tokens)
+}
+
+// Current token.
+func (r *queryParser) peek() (token queryToken, found bool) {
+	if r.done() {
+		return
+	}
+	token = r.tokens[r.next]
+	found = true
+	return
+}
+
+// Current token is the keyword.
+func (r *queryParser) keyword(word string) bool {
+	token, found := r.peek()
+	return found && token.kind == tkWord && strings.EqualFold(token.text, word)
+}
+
+// Syntax error at the current token.
+func (r *queryParser) error(reason string) error {
+	token, found := r.peek()
+	if !found {
+		return liberr.Wrap(
+			fmt.Errorf("%w: %s at end of query", QueryErr, reason))
+	}
+	return liberr.Wrap(
+		fmt.Errorf("%w: %s '%s' at %d", QueryErr, reason, token.text, token.index))
+}
+
+// or = and { "or" and }
+func (r *queryParser) or() (p Predicate, err error) {
+	p, err = r.and()
+	if err != nil {
+		return
+	}
+	predicates := []Predicate{p}
+	for r.keyword("or") {
+		r.next++
+		p, err = r.and()
+		if err != nil {
+			return
+		}
+		predicates = append(predicates, p)
+	}
+	if len(predicates) > 1 {
+		p = Or(predicates...)
+	}
+
+	return
+}
+
+// and = unary { "and" unary }
+func (r *queryParser) and() (p Predicate, err error) {
+	p, err = r.unary()
+	if err != nil {
+		return
+	}
+	predicates := []Predicate{p}
+	for r.keyword("and") {
+		r.next++
+		p, err = r.unary()
+		if err != nil {
+			return
+		}
+		predicates = append(predicates, p)
+	}
+	if len(predicates) > 1 {
+		p = And(predicates...)
+	}
+
+	return
+}
+
+// unary = "not" unary | "(" or ")" | comparison
+func (r *queryParser) unary() (p Predicate, err error) {
+	if r.keyword("not") {
+		r.next++
+		p, err = r.unary()
+		if err != nil {
+			return
+		}
+		p = Not(p)
+		return
+	}
+	token, found := r.peek()
+	if found && token.kind == tkOpen {
+		r.next++
+		p, err = r.or()
+		if err != nil {
+			return
+		}
+		token, found = r.peek()
+		if !found || token.kind != tkClose {
+			err = r.error("expected ')'")
+			return
+		}
+		r.next++
+		return
+	}
+
+	return r.comparison()
+}
+
+// comparison = field operator value
+func (r *queryParser) comparison() (p Predicate, err error) {
+	field, found := r.peek()
+	if !found || field.kind != tkWord {
+		err = r.error("expected field")
+		return
+	}
+	r.next++
+	operator, found := r.peek()
+	if !found || !(operator.kind == tkOperator || r.keyword("contains")) {
+		err = r.error("expected operator")
+		return
+	}
+	r.next++
+	token, found := r.peek()
+	if !found || (token.kind != tkWord && token.kind != tkString) {
+		err = r.error("expected value")
+		return
+	}
+	r.next++
+	value := r.value(token)
+	switch strings.ToLower(operator.text) {
+	case "=", "==":
+		p = Eq(field.text, value)
+	case "!=":
+		p = Neq(field.text, value)
+	case ">":
+		p = Gt(field.text, value)
+	case ">=":
+		p = Gte(field.text, value)
+	case "<":
+		p = Lt(field.text, value)
+	case "<=":
+		p = Lte(field.text, value)
+	case "contains":
+		p = Contains(field.text, value)
+	default:
+		r.next -= 2
+		err = r.error("unknown operator")
+	}
+
+	return
+}
+
+// Value of the token.
+// Unquoted integers and booleans are typed.
+func (r *queryParser) value(token queryToken) interface{} {
+	if token.kind == tkString {
+		return token.text
+	}
+	if n, err := strconv.ParseInt(token.text, 10, 64); err == nil {
+		return n
+	}
+	switch strings.ToLower(token.text) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return token.text
+}
@@ -0,0 +1,99 @@
+// Package vmdk uploads raw disk images to vSphere datastores as VMDK
+// disks. It is used by the vmdk-upload pod to transfer the disks of
+// VMs migrated from OpenShift Virtualization to vSphere.
+package vmdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Sector size of a VMDK extent.
+const SectorSize = 512
+
+// Spec describes the disks uploaded by a vmdk-upload pod.
+type Spec struct {
+	// vSphere SDK URL.
+	URL string `json:"url"`
+	// vSphere user.
+	User string `json:"user"`
+	// vSphere password.
+	Password string `json:"password"`
+	// SHA-1 fingerprint of the vSphere certificate.
+	Thumbprint string `json:"thumbprint,omitempty"`
+	// Skip the verification of the vSphere certificate.
+	Insecure bool `json:"insecure,omitempty"`
+	// Datacenter of the datastores.
+	Datacenter string `json:"datacenter"`
+	// Token of the VirtualMachineExport serving the disks.
+	Token string `json:"token"`
+	// CA certificate of the VirtualMachineExport serving the disks.
+	CACert string `json:"caCert,omitempty"`
+	// Disks.
+	Disks []Disk `json:"disks"`
+}
+
+// Disk to be uploaded.
+type Disk struct {
+	// Disk identifier, the name of the exported volume.
+	ID string `json:"id"`
+	// URL of the raw disk image.
+	URL string `json:"url"`
+	// Name of the target datastore.
+	Datastore string `json:"datastore"`
+	// Path of the VMDK descriptor in the datastore.
+	Path string `json:"path"`
+}
+
+// ReadSpec reads the spec from a file.
+func ReadSpec(path string) (spec *Spec, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	spec = &Spec{}
+	err = json.Unmarshal(b, spec)
+	return
+}
+
+// FlatPath returns the path of the flat extent of a VMDK descriptor.
+func FlatPath(path string) string {
+	return strings.TrimSuffix(path, ".vmdk") + "-flat.vmdk"
+}
+
+// Capacity rounds the size of a disk image up to whole sectors.
+func Capacity(size int64) int64 {
+	return (size + SectorSize - 1) / SectorSize * SectorSize
+}
+
+// Descriptor returns the descriptor of a preallocated VMDK disk
+// backed by a single flat extent holding the raw image. The flat
+// extent is referenced relative to the descriptor.
+func Descriptor(extent string, capacity int64) string {
+	sectors := capacity / SectorSize
+	cylinders := sectors / (255 * 63)
+	if cylinders > 65535 {
+		cylinders = 65535
+	}
+	return fmt.Sprintf(`# Disk DescriptorFile
+version=1
+encoding="UTF-8"
+CID=fffffffe
+parentCID=ffffffff
+createType="vmfs"
+
+# Extent description
+RW %d VMFS "%s"
+
+# The Disk Data Base
+#DDB
+
+ddb.adapterType = "lsilogic"
+ddb.geometry.cylinders = "%d"
+ddb.geometry.heads = "255"
+ddb.geometry.sectors = "63"
+ddb.virtualHWVersion = "14"
+`, sectors, extent, cylinders)
+}
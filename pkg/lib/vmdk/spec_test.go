@@ -0,0 +1,46 @@
+package vmdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapacity(t *testing.T) {
+	cases := []struct {
+		size, want int64
+	}{
+		{0, 0},
+		{1, 512},
+		{512, 512},
+		{513, 1024},
+		{10 << 30, 10 << 30},
+	}
+	for _, c := range cases {
+		if got := Capacity(c.size); got != c.want {
+			t.Errorf("Capacity(%d) = %d, want %d", c.size, got, c.want)
+		}
+	}
+}
+
+func TestFlatPath(t *testing.T) {
+	if got := FlatPath("vm/vm_0.vmdk"); got != "vm/vm_0-flat.vmdk" {
+		t.Errorf("FlatPath = %q", got)
+	}
+}
+
+func TestDescriptor(t *testing.T) {
+	descriptor := Descriptor("vm_0-flat.vmdk", 10<<30)
+	for _, want := range []string{
+		`createType="vmfs"`,
+		`RW 20971520 VMFS "vm_0-flat.vmdk"`,
+		`ddb.geometry.cylinders = "1305"`,
+	} {
+		if !strings.Contains(descriptor, want) {
+			t.Errorf("descriptor misses %q:\n%s", want, descriptor)
+		}
+	}
+	descriptor = Descriptor("big-flat.vmdk", 2<<40)
+	if !strings.Contains(descriptor, `ddb.geometry.cylinders = "65535"`) {
+		t.Errorf("cylinders not capped:\n%s", descriptor)
+	}
+}
@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"fmt"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
+	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/controller/mapping"
+	ec2client "github.com/kubev2v/forklift/pkg/provider/ec2/inventory/client"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildVolumePopulator creates an Ec2VolumePopulator CR spec for an EBS snapshot.
+// The populator copies the snapshot through the EBS direct APIs into the PVC
+// referencing it, which lets the target cluster run outside of AWS.
+func (r *Builder) BuildVolumePopulator(vmRef ref.Ref, volumeInfo *VolumeInfo, secretName string) *api.Ec2VolumePopulator {
+	labels := r.Labeler.MigrationVMLabels(vmRef)
+	labels["forklift.konveyor.io/volume-id"] = volumeInfo.OriginalVolumeID
+
+	populator := &api.Ec2VolumePopulator{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", volumeInfo.SnapshotID),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+			Annotations: map[string]string{
+				"forklift.konveyor.io/original-volume-id": volumeInfo.OriginalVolumeID,
+				"forklift.konveyor.io/snapshot-id":        volumeInfo.SnapshotID,
+			},
+		},
+		Spec: api.Ec2VolumePopulatorSpec{
+			Region:          r.getRegion(),
+			SnapshotID:      volumeInfo.SnapshotID,
+			SecretName:      secretName,
+			TransferNetwork: r.Plan.Spec.TransferNetwork,
+		},
+	}
+
+	r.log.Info("Built Ec2VolumePopulator spec",
+		"vm", vmRef.Name,
+		"snapshotID", volumeInfo.SnapshotID,
+		"originalVolumeID", volumeInfo.OriginalVolumeID)
+
+	return populator
+}
+
+// BuildPopulatorPVC creates a PVC spec populated from an Ec2VolumePopulator CR.
+// The volume and access modes are taken from the storage mapping, defaulting
+// to a ReadWriteOnce block volume.
+func (r *Builder) BuildPopulatorPVC(vmRef ref.Ref, volumeInfo *VolumeInfo, populatorName string, index int) (*core.PersistentVolumeClaim, error) {
+	r.log.Info("Building populator PVC for EBS snapshot",
+		"vm", vmRef.Name,
+		"snapshotID", volumeInfo.SnapshotID,
+		"originalVolumeID", volumeInfo.OriginalVolumeID,
+		"populator", populatorName,
+		"index", index)
+
+	var storageClass string
+	volumeMode := core.PersistentVolumeBlock
+	accessMode := core.ReadWriteOnce
+	if pair := mapping.FindStoragePair(r.Map.Storage, volumeInfo.VolumeType); pair != nil {
+		storageClass = pair.Destination.StorageClass
+		if pair.Destination.VolumeMode != "" {
+			volumeMode = pair.Destination.VolumeMode
+		}
+		if pair.Destination.AccessMode != "" {
+			accessMode = pair.Destination.AccessMode
+		}
+	}
+
+	volumeSizeBytes := volumeInfo.SizeGiB * 1024 * 1024 * 1024
+	pvcSize := r.calculatePVCSize(volumeSizeBytes, &volumeMode)
+
+	pvcLabels := r.Labeler.MigrationVMLabels(vmRef)
+	pvcLabels["forklift.konveyor.io/volume-id"] = volumeInfo.OriginalVolumeID
+
+	pvc := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: r.Plan.Spec.TargetNamespace,
+			Labels:    pvcLabels,
+			Annotations: map[string]string{
+				planbase.AnnDiskSource:                    volumeInfo.OriginalVolumeID,
+				"forklift.konveyor.io/original-volume-id": volumeInfo.OriginalVolumeID,
+				"forklift.konveyor.io/snapshot-id":        volumeInfo.SnapshotID,
+				"forklift.konveyor.io/disk-index":         fmt.Sprintf("%d", index),
+			},
+		},
+		Spec: core.PersistentVolumeClaimSpec{
+			AccessModes: []core.PersistentVolumeAccessMode{
+				accessMode,
+			},
+			VolumeMode: &volumeMode,
+			Resources: core.VolumeResourceRequirements{
+				Requests: core.ResourceList{
+					core.ResourceStorage: *pvcSize,
+				},
+			},
+			DataSourceRef: &core.TypedObjectReference{
+				APIGroup: &api.SchemeGroupVersion.Group,
+				Kind:     api.Ec2VolumePopulatorKind,
+				Name:     populatorName,
+			},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+
+	if err := r.setPVCNameFromTemplate(&pvc.ObjectMeta, vmRef, volumeInfo, index); err != nil {
+		return nil, err
+	}
+
+	r.log.Info("Built populator PVC spec",
+		"vm", vmRef.Name,
+		"pvcName", pvc.Name,
+		"pvcGenerateName", pvc.GenerateName,
+		"storageClass", storageClass,
+		"volumeMode", volumeMode,
+		"pvcSize", pvcSize.String())
+
+	return pvc, nil
+}
+
+// getRegion returns the AWS region of the source provider from its secret.
+func (r *Builder) getRegion() string {
+	if r.Source.Secret == nil {
+		return ""
+	}
+	return string(r.Source.Secret.Data[ec2client.Region])
+}
@@ -413,11 +413,16 @@ func (r *Builder) mapNodeSelector(awsInstance *model.InstanceDetails, object *cn
 
 // getTargetAZ retrieves the target availability zone from provider settings.
 // This is the AZ where EBS volumes are created and where VMs should be scheduled.
+// No zone applies when the disks are copied through the EBS direct APIs.
 func (r *Builder) getTargetAZ() (string, error) {
 	if r.Source.Provider == nil {
 		return "", fmt.Errorf("source provider is nil")
 	}
 
+	if r.Source.Provider.IsEC2EBSDirect() {
+		return "", fmt.Errorf("target-az does not apply to the %s transfer mode", api.EC2TransferModeEBSDirect)
+	}
+
 	if r.Source.Provider.Spec.Settings == nil {
 		return "", fmt.Errorf("provider spec.settings is not configured")
 	}
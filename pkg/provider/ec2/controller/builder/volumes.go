@@ -9,6 +9,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	planbase "github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
 	utils "github.com/kubev2v/forklift/pkg/controller/plan/util"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	liberr "github.com/kubev2v/forklift/pkg/lib/error"
 	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	"github.com/kubev2v/forklift/pkg/provider/ec2/controller/inventory"
@@ -118,6 +119,16 @@ func (r *Builder) SupportsVolumePopulators() bool {
 	return false
 }
 
+// SupportsDeltaCopy returns false as EC2 does not use the delta copy pod.
+func (r *Builder) SupportsDeltaCopy() bool {
+	return false
+}
+
+// DeltaCopyDisks is a no-op for EC2.
+func (r *Builder) DeltaCopyDisks(vmRef ref.Ref) (disks []deltacopy.Disk, err error) {
+	return
+}
+
 func (r *Builder) PopulatorOffloadInfo(_ *core.PersistentVolumeClaim) (map[string]string, error) {
 	return map[string]string{}, nil
 }
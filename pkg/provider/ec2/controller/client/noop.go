@@ -5,6 +5,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	"github.com/kubev2v/forklift/pkg/controller/plan/adapter/base"
 	"github.com/kubev2v/forklift/pkg/controller/plan/util"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
 	cdi "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
@@ -28,5 +29,10 @@ func (r *Client) GetSnapshotDeltas(vmRef ref.Ref, snapshot string, hostsFunc uti
 	return make(map[string]string), nil
 }
 
+// GetChangedExtents is a no-op for EC2 - precopies are not transferred by the delta copy pod.
+func (r *Client) GetChangedExtents(vmRef ref.Ref, snapshot string, baseline map[string]string, hostsFunc util.HostsFunc) (extents map[string][]deltacopy.Extent, err error) {
+	return
+}
+
 // Compile-time interface check. Ensures Client implements required base.Client interface.
 var _ base.Client = &Client{}
@@ -257,22 +257,81 @@ func (r *Client) GetSnapshotsForVM(vmRef ref.Ref) (map[string]string, error) {
 	return snapshotMap, nil
 }
 
-// GetSnapshotIDsForVM returns a comma-separated string of snapshot IDs for the VM.
-// Convenience method for use with CheckSnapshotReady which expects this format.
+// GetSnapshotIDsForVM returns a comma-separated string of all the snapshot IDs tagged
+// with the VM ID. A warm migration creates several snapshots of each volume, so the
+// IDs are not deduplicated by volume and all of them are removed on cleanup.
 func (r *Client) GetSnapshotIDsForVM(vmRef ref.Ref) (string, error) {
-	snapshotMap, err := r.GetSnapshotsForVM(vmRef)
+	client, err := r.getSourceClient()
 	if err != nil {
 		return "", err
 	}
 
-	snapshotIDs := make([]string, 0, len(snapshotMap))
-	for _, snapshotID := range snapshotMap {
-		snapshotIDs = append(snapshotIDs, snapshotID)
+	ctx := context.Background()
+	input := &ec2.DescribeSnapshotsInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("tag:forklift.konveyor.io/vmID"),
+				Values: []string{vmRef.ID},
+			},
+		},
+	}
+
+	result, err := client.DescribeSnapshots(ctx, input)
+	if err != nil {
+		log.Error(err, "Failed to query snapshots for VM", "vm", vmRef.Name, "id", vmRef.ID)
+		return "", liberr.Wrap(err)
+	}
+
+	snapshotIDs := make([]string, 0, len(result.Snapshots))
+	for _, snapshot := range result.Snapshots {
+		if snapshotID := aws.ToString(snapshot.SnapshotId); snapshotID != "" {
+			snapshotIDs = append(snapshotIDs, snapshotID)
+		}
 	}
 
 	return strings.Join(snapshotIDs, ","), nil
 }
 
+// GetSnapshotVolumes returns the source volume of each of the comma-separated snapshots.
+// Returns a map of volumeID -> snapshotID, used to record the snapshots of a precopy.
+func (r *Client) GetSnapshotVolumes(vmRef ref.Ref, snapshot string) (map[string]string, error) {
+	client, err := r.getSourceClient()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotIDs := splitSnapshotIDs(snapshot)
+	if len(snapshotIDs) == 0 {
+		return nil, fmt.Errorf("no snapshot IDs found")
+	}
+
+	ctx := context.Background()
+	input := &ec2.DescribeSnapshotsInput{
+		SnapshotIds: snapshotIDs,
+	}
+
+	result, err := client.DescribeSnapshots(ctx, input)
+	if err != nil {
+		log.Error(err, "Failed to describe snapshots", "vm", vmRef.Name)
+		return nil, liberr.Wrap(err)
+	}
+
+	snapshotMap := make(map[string]string)
+	for _, snapshot := range result.Snapshots {
+		volumeID := aws.ToString(snapshot.VolumeId)
+		snapshotID := aws.ToString(snapshot.SnapshotId)
+		if volumeID != "" && snapshotID != "" {
+			snapshotMap[volumeID] = snapshotID
+		}
+	}
+
+	if len(snapshotMap) != len(snapshotIDs) {
+		return nil, fmt.Errorf("found the volumes of %d of %d snapshots", len(snapshotMap), len(snapshotIDs))
+	}
+
+	return snapshotMap, nil
+}
+
 // GetCreatedVolumesForVM queries AWS for EBS volumes created during migration for this VM.
 // Returns a map of originalVolumeID -> newVolumeID by reading tags from each volume.
 func (r *Client) GetCreatedVolumesForVM(vmRef ref.Ref) (map[string]string, error) {
@@ -0,0 +1,269 @@
+package ensurer
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	convctx "github.com/kubev2v/forklift/pkg/controller/conversion/context"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/settings"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Labels of the delta copy pods and their spec config maps.
+const (
+	// DeltaCopyLabel marks the resources of the pods copying the changed blocks of a warm migration.
+	DeltaCopyLabel = "forklift.konveyor.io/ec2-delta-copy"
+	// PrecopyLabel holds the number of the precopy transferred by a delta copy pod.
+	PrecopyLabel = "forklift.konveyor.io/precopy"
+)
+
+// Delta copy pod settings.
+const (
+	// Mount path of the transfer spec.
+	deltaCopySpecPath = "/etc/delta-copy"
+	// User of the virt-v2v image.
+	qemuUser = int64(107)
+)
+
+// EnsureDeltaCopyPod creates the pod copying the blocks changed between the
+// snapshots of a precopy into the populated PVCs. The disks are keyed by the
+// original volume ID, which labels the PVCs. The pod reads the snapshots
+// through the EBS direct APIs with the credentials of the populator secret.
+func (r *Ensurer) EnsureDeltaCopyPod(ctx context.Context, vm *planapi.VMStatus, precopy int, disks []deltacopy.Disk, secretName string) error {
+	existing, err := r.GetDeltaCopyPod(ctx, vm, precopy)
+	if err != nil || existing != nil {
+		return err
+	}
+	cfg := convctx.PodConfigFromPlan(r.Plan)
+	image := convctx.GetVirtV2vImage(&cfg)
+	if image == "" {
+		return liberr.New("virt-v2v image is not set; cannot create delta copy pod")
+	}
+
+	pvcList := &core.PersistentVolumeClaimList{}
+	err = r.Client.List(ctx, pvcList, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(r.Labeler.MigrationVMLabels(vm.Ref)),
+	})
+	if err != nil {
+		r.log.Error(err, "Failed to list PVCs", "vm", vm.Name)
+		return liberr.Wrap(err)
+	}
+	pvcsByVolume := make(map[string]*core.PersistentVolumeClaim)
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if volumeID, ok := pvc.Labels["forklift.konveyor.io/volume-id"]; ok {
+			pvcsByVolume[volumeID] = pvc
+		}
+	}
+
+	var volumes []core.Volume
+	var mounts []core.VolumeMount
+	var devices []core.VolumeDevice
+	spec := deltacopy.Spec{}
+	for i, disk := range disks {
+		pvc, found := pvcsByVolume[disk.ID]
+		if !found {
+			return liberr.New("PVC not found for volume.", "vm", vm.Name, "volume", disk.ID)
+		}
+		volumes = append(volumes, core.Volume{
+			Name: pvc.Name,
+			VolumeSource: core.VolumeSource{
+				PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvc.Name,
+				},
+			},
+		})
+		if pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == core.PersistentVolumeBlock {
+			disk.Target = fmt.Sprintf("/dev/block%d", i)
+			devices = append(devices, core.VolumeDevice{
+				Name:       pvc.Name,
+				DevicePath: disk.Target,
+			})
+		} else {
+			mountPath := fmt.Sprintf("/mnt/disks/disk%d", i)
+			disk.Target = path.Join(mountPath, "disk.img")
+			mounts = append(mounts, core.VolumeMount{
+				Name:      pvc.Name,
+				MountPath: mountPath,
+			})
+		}
+		spec.Disks = append(spec.Disks, disk)
+	}
+
+	labels := r.deltaCopyLabels(vm)
+	labels[PrecopyLabel] = strconv.Itoa(precopy)
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	configMap := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%s-delta-copy-", r.Plan.Name, vm.ID),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Data: map[string]string{
+			"spec.json": string(specJSON),
+		},
+	}
+	err = controllerutil.SetOwnerReference(r.Plan, configMap, r.Client.Scheme())
+	if err != nil {
+		r.log.Error(err, "Failed to set owner reference on delta copy config map", "vm", vm.Name)
+	}
+	err = r.Client.Create(ctx, configMap)
+	if err != nil {
+		r.log.Error(err, "Failed to create delta copy config map", "vm", vm.Name)
+		return liberr.Wrap(err)
+	}
+	volumes = append(volumes, core.Volume{
+		Name: "spec",
+		VolumeSource: core.VolumeSource{
+			ConfigMap: &core.ConfigMapVolumeSource{
+				LocalObjectReference: core.LocalObjectReference{
+					Name: configMap.Name,
+				},
+			},
+		},
+	})
+	mounts = append(mounts, core.VolumeMount{
+		Name:      "spec",
+		MountPath: deltaCopySpecPath,
+		ReadOnly:  true,
+	})
+
+	nonRoot := true
+	allowPrivilegeEscalation := false
+	user := qemuUser
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%s-delta-copy-", r.Plan.Name, vm.ID),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Spec: core.PodSpec{
+			SecurityContext: &core.PodSecurityContext{
+				RunAsNonRoot:   &nonRoot,
+				FSGroup:        &user,
+				SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+			},
+			RestartPolicy:      core.RestartPolicyOnFailure,
+			ServiceAccountName: cmp.Or(r.Plan.Spec.ServiceAccount, settings.Settings.Migration.ServiceAccount),
+			Containers: []core.Container{
+				{
+					Name:    "delta-copy",
+					Image:   image,
+					Command: []string{"/usr/local/bin/delta-copy"},
+					Args:    []string{"-spec", path.Join(deltaCopySpecPath, "spec.json")},
+					EnvFrom: []core.EnvFromSource{
+						{
+							SecretRef: &core.SecretEnvSource{
+								LocalObjectReference: core.LocalObjectReference{Name: secretName},
+							},
+						},
+					},
+					VolumeMounts:  mounts,
+					VolumeDevices: devices,
+					SecurityContext: &core.SecurityContext{
+						AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+						RunAsUser:                &user,
+						Capabilities:             &core.Capabilities{Drop: []core.Capability{"ALL"}},
+					},
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+	err = controllerutil.SetOwnerReference(r.Plan, pod, r.Client.Scheme())
+	if err != nil {
+		r.log.Error(err, "Failed to set owner reference on delta copy pod", "vm", vm.Name)
+	}
+	err = r.Client.Create(ctx, pod)
+	if err != nil {
+		r.log.Error(err, "Failed to create delta copy pod", "vm", vm.Name)
+		return liberr.Wrap(err)
+	}
+
+	r.log.Info("Created delta copy pod",
+		"vm", vm.Name,
+		"pod", path.Join(pod.Namespace, pod.Name),
+		"precopy", precopy,
+		"diskCount", len(spec.Disks))
+
+	return nil
+}
+
+// GetDeltaCopyPod returns the delta copy pod of a precopy, or nil when not found.
+func (r *Ensurer) GetDeltaCopyPod(ctx context.Context, vm *planapi.VMStatus, precopy int) (*core.Pod, error) {
+	labels := r.deltaCopyLabels(vm)
+	labels[PrecopyLabel] = strconv.Itoa(precopy)
+	list := &core.PodList{}
+	err := r.Client.List(ctx, list, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(labels),
+	})
+	if err != nil {
+		r.log.Error(err, "Failed to list delta copy pods", "vm", vm.Name)
+		return nil, liberr.Wrap(err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	return &list.Items[0], nil
+}
+
+// DeleteDeltaCopyPods deletes the delta copy pods of the VM and their spec config maps.
+func (r *Ensurer) DeleteDeltaCopyPods(ctx context.Context, vm *planapi.VMStatus) error {
+	selector := &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(r.deltaCopyLabels(vm)),
+	}
+	pods := &core.PodList{}
+	err := r.Client.List(ctx, pods, selector)
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	for i := range pods.Items {
+		err = r.Client.Delete(ctx, &pods.Items[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return liberr.Wrap(err, "pod", pods.Items[i].Name)
+		}
+	}
+	configMaps := &core.ConfigMapList{}
+	err = r.Client.List(ctx, configMaps, selector)
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	for i := range configMaps.Items {
+		err = r.Client.Delete(ctx, &configMaps.Items[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return liberr.Wrap(err, "configMap", configMaps.Items[i].Name)
+		}
+	}
+
+	r.log.V(1).Info("Removed delta copy pods",
+		"vm", vm.Name,
+		"podCount", len(pods.Items),
+		"configMapCount", len(configMaps.Items))
+
+	return nil
+}
+
+// deltaCopyLabels returns the labels of the delta copy resources of the VM.
+func (r *Ensurer) deltaCopyLabels(vm *planapi.VMStatus) map[string]string {
+	labels := r.Labeler.MigrationVMLabels(vm.Ref)
+	labels[DeltaCopyLabel] = "true"
+	return labels
+}
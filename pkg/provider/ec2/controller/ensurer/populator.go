@@ -0,0 +1,228 @@
+package ensurer
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	ec2client "github.com/kubev2v/forklift/pkg/provider/ec2/inventory/client"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PopulatorSecretLabel marks the secret holding the AWS credentials of the populator pods.
+const PopulatorSecretLabel = "forklift.konveyor.io/ec2-populator"
+
+// EnsurePopulatorSecret copies the source AWS credentials into the target namespace
+// for the Ec2VolumePopulator pods, which read them from their environment.
+// Only the region and the access keys are copied. Returns the secret name.
+func (r *Ensurer) EnsurePopulatorSecret(ctx context.Context, vm *planapi.VMStatus) (string, error) {
+	labels := r.Labeler.MigrationVMLabels(vm.Ref)
+	labels[PopulatorSecretLabel] = "true"
+
+	existingList := &core.SecretList{}
+	err := r.Client.List(ctx, existingList, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(labels),
+	})
+	if err != nil {
+		r.log.Error(err, "Failed to list existing populator secrets", "vm", vm.Name)
+		return "", liberr.Wrap(err)
+	}
+	if len(existingList.Items) > 0 {
+		return existingList.Items[0].Name, nil
+	}
+
+	region, accessKeyID, secretAccessKey, err := ec2client.ExtractCredentials(r.Source.Secret)
+	if err != nil {
+		return "", liberr.Wrap(err)
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return "", liberr.New(
+			fmt.Sprintf("the %s transfer mode requires the provider secret to include '%s' and '%s'",
+				api.EC2TransferModeEBSDirect, ec2client.AccessKeyID, ec2client.SecretAccessKey),
+			"vm", vm.Name)
+	}
+
+	secret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-ec2-", r.Plan.Name),
+			Namespace:    r.Plan.Spec.TargetNamespace,
+			Labels:       labels,
+		},
+		Type: core.SecretTypeOpaque,
+		Data: map[string][]byte{
+			ec2client.Region:          []byte(region),
+			ec2client.AccessKeyID:     []byte(accessKeyID),
+			ec2client.SecretAccessKey: []byte(secretAccessKey),
+		},
+	}
+	err = controllerutil.SetOwnerReference(r.Plan, secret, r.Client.Scheme())
+	if err != nil {
+		r.log.Error(err, "Failed to set owner reference on populator secret", "vm", vm.Name)
+	}
+	err = r.Client.Create(ctx, secret)
+	if err != nil {
+		r.log.Error(err, "Failed to create populator secret", "vm", vm.Name)
+		return "", liberr.Wrap(err)
+	}
+
+	r.log.Info("Created populator secret",
+		"vm", vm.Name,
+		"secret", secret.Name,
+		"namespace", secret.Namespace)
+
+	return secret.Name, nil
+}
+
+// EnsureVolumePopulators creates the Ec2VolumePopulator CRs of the VM volumes.
+// Returns a map of original volume ID -> populator CR name.
+func (r *Ensurer) EnsureVolumePopulators(ctx context.Context, vm *planapi.VMStatus, populators []*api.Ec2VolumePopulator) (map[string]string, error) {
+	populatorNames := make(map[string]string)
+
+	existing, err := r.GetVolumePopulators(ctx, vm)
+	if err != nil {
+		return nil, err
+	}
+	existingByVolume := make(map[string]*api.Ec2VolumePopulator)
+	for i := range existing {
+		populator := &existing[i]
+		if volumeID, ok := populator.Labels["forklift.konveyor.io/volume-id"]; ok {
+			existingByVolume[volumeID] = populator
+		}
+	}
+
+	for _, populator := range populators {
+		originalVolumeID := populator.Labels["forklift.konveyor.io/volume-id"]
+
+		if existingPopulator, exists := existingByVolume[originalVolumeID]; exists {
+			populatorNames[originalVolumeID] = existingPopulator.Name
+			r.log.V(1).Info("Volume populator already exists",
+				"vm", vm.Name,
+				"originalVolumeID", originalVolumeID,
+				"populator", existingPopulator.Name)
+			continue
+		}
+
+		err = controllerutil.SetOwnerReference(r.Plan, populator, r.Client.Scheme())
+		if err != nil {
+			r.log.Error(err, "Failed to set owner reference on volume populator", "vm", vm.Name)
+		}
+
+		err = r.Client.Create(ctx, populator)
+		if err != nil {
+			r.log.Error(err, "Failed to create volume populator",
+				"vm", vm.Name,
+				"originalVolumeID", originalVolumeID)
+			return nil, liberr.Wrap(err)
+		}
+
+		populatorNames[originalVolumeID] = populator.Name
+
+		r.log.Info("Created volume populator",
+			"vm", vm.Name,
+			"populator", populator.Name,
+			"snapshotID", populator.Spec.SnapshotID,
+			"originalVolumeID", originalVolumeID)
+	}
+
+	return populatorNames, nil
+}
+
+// GetVolumePopulators lists the Ec2VolumePopulator CRs of the VM.
+func (r *Ensurer) GetVolumePopulators(ctx context.Context, vm *planapi.VMStatus) ([]api.Ec2VolumePopulator, error) {
+	list := &api.Ec2VolumePopulatorList{}
+	err := r.Client.List(ctx, list, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(r.Labeler.MigrationVMLabels(vm.Ref)),
+	})
+	if err != nil {
+		r.log.Error(err, "Failed to list volume populators", "vm", vm.Name)
+		return nil, liberr.Wrap(err)
+	}
+	return list.Items, nil
+}
+
+// DeleteVolumePopulators deletes the Ec2VolumePopulator CRs and the populator
+// secret of the VM once its disks have been populated.
+func (r *Ensurer) DeleteVolumePopulators(ctx context.Context, vm *planapi.VMStatus) error {
+	populators, err := r.GetVolumePopulators(ctx, vm)
+	if err != nil {
+		return err
+	}
+	for i := range populators {
+		err = r.Client.Delete(ctx, &populators[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return liberr.Wrap(err, "populator", populators[i].Name)
+		}
+	}
+
+	labels := r.Labeler.MigrationVMLabels(vm.Ref)
+	labels[PopulatorSecretLabel] = "true"
+	secrets := &core.SecretList{}
+	err = r.Client.List(ctx, secrets, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(labels),
+	})
+	if err != nil {
+		return liberr.Wrap(err)
+	}
+	for i := range secrets.Items {
+		err = r.Client.Delete(ctx, &secrets.Items[i])
+		if err != nil && !errors.IsNotFound(err) {
+			return liberr.Wrap(err, "secret", secrets.Items[i].Name)
+		}
+	}
+
+	r.log.Info("Removed volume populators",
+		"vm", vm.Name,
+		"populatorCount", len(populators),
+		"secretCount", len(secrets.Items))
+
+	return nil
+}
+
+// CheckPopulatorPVCsBound checks if all PVCs populated from Ec2VolumePopulator CRs
+// are bound. The prime PVCs of the populator controller share the VM labels and
+// are skipped.
+func (r *Ensurer) CheckPopulatorPVCsBound(ctx context.Context, vm *planapi.VMStatus) (allBound bool, err error) {
+	pvcList := &core.PersistentVolumeClaimList{}
+	err = r.Client.List(ctx, pvcList, &client.ListOptions{
+		Namespace:     r.Plan.Spec.TargetNamespace,
+		LabelSelector: k8slabels.SelectorFromSet(r.Labeler.MigrationVMLabels(vm.Ref)),
+	})
+	if err != nil {
+		r.log.Error(err, "Failed to list populator PVCs", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	count := 0
+	allBound = true
+	for _, pvc := range pvcList.Items {
+		dataSource := pvc.Spec.DataSourceRef
+		if dataSource == nil || dataSource.Kind != api.Ec2VolumePopulatorKind {
+			continue
+		}
+		count++
+		if pvc.Status.Phase != core.ClaimBound {
+			r.log.Info("Populator PVC not yet bound",
+				"vm", vm.Name,
+				"pvc", pvc.Name,
+				"phase", pvc.Status.Phase)
+			allBound = false
+		}
+	}
+
+	if count == 0 {
+		r.log.Info("No populator PVCs found for VM", "vm", vm.Name)
+		return false, nil
+	}
+
+	return allBound, nil
+}
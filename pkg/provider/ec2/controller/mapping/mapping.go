@@ -9,17 +9,28 @@ import (
 // FindStorageClass looks up target storage class for EBS volume type (gp2, gp3, io1, etc).
 // Returns storage class name from StorageMap or empty string if no mapping found.
 func FindStorageClass(storageMap *api.StorageMap, volumeType string) string {
+	if pair := FindStoragePair(storageMap, volumeType); pair != nil {
+		return pair.Destination.StorageClass
+	}
+
+	return ""
+}
+
+// FindStoragePair finds the storage mapping for a given EBS volume type.
+// Returns the matching StoragePair or nil if no mapping found.
+func FindStoragePair(storageMap *api.StorageMap, volumeType string) *api.StoragePair {
 	if storageMap == nil {
-		return ""
+		return nil
 	}
 
-	for _, mapping := range storageMap.Spec.Map {
-		if mapping.Source.Name == volumeType {
-			return mapping.Destination.StorageClass
+	for i := range storageMap.Spec.Map {
+		candidate := &storageMap.Spec.Map[i]
+		if candidate.Source.Name == volumeType {
+			return candidate
 		}
 	}
 
-	return ""
+	return nil
 }
 
 // HasStorageMapping checks if a storage mapping exists for the given volume type.
@@ -74,6 +74,55 @@ var _ = Describe("EC2 Controller Mapping", func() {
 		})
 	})
 
+	Describe("FindStoragePair", func() {
+		It("should find storage pair for volume type", func() {
+			storageMap := &api.StorageMap{
+				Spec: api.StorageMapSpec{
+					Map: []api.StoragePair{
+						{
+							Source:      ref.Ref{Name: "gp2"},
+							Destination: api.DestinationStorage{StorageClass: "standard"},
+						},
+						{
+							Source: ref.Ref{Name: "gp3"},
+							Destination: api.DestinationStorage{
+								StorageClass: "nfs",
+								VolumeMode:   "Filesystem",
+								AccessMode:   "ReadWriteMany",
+							},
+						},
+					},
+				},
+			}
+
+			result := FindStoragePair(storageMap, "gp3")
+
+			Expect(result).NotTo(BeNil())
+			Expect(result.Destination.StorageClass).To(Equal("nfs"))
+			Expect(string(result.Destination.VolumeMode)).To(Equal("Filesystem"))
+			Expect(string(result.Destination.AccessMode)).To(Equal("ReadWriteMany"))
+		})
+
+		It("should return nil when volume type not found", func() {
+			storageMap := &api.StorageMap{
+				Spec: api.StorageMapSpec{
+					Map: []api.StoragePair{
+						{
+							Source:      ref.Ref{Name: "gp2"},
+							Destination: api.DestinationStorage{StorageClass: "standard"},
+						},
+					},
+				},
+			}
+
+			Expect(FindStoragePair(storageMap, "io1")).To(BeNil())
+		})
+
+		It("should return nil when storageMap is nil", func() {
+			Expect(FindStoragePair(nil, "gp2")).To(BeNil())
+		})
+	})
+
 	Describe("HasStorageMapping", func() {
 		var storageMap *api.StorageMap
 
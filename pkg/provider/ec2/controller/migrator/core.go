@@ -17,6 +17,7 @@ import (
 
 // Migrator orchestrates EC2 to KubeVirt VM migrations through workflow phases.
 // Flow: Initialize→PreHook→PowerOff→CreateSnapshots→WaitSnapshots→CreateDataVolumes→Finalize→CreateVM→RemoveSnapshots→PostHook→Complete
+// Warm flow (ebsDirect only): Initialize→PreHook→InitialSnapshot→PopulateVolumes→[Snapshot→CopyChangedBlocks]*→PowerOff→FinalSnapshot→CopyFinalChangedBlocks→Finalize→CreateVM→RemoveSnapshots→PostHook→Complete
 type Migrator struct {
 	*plancontext.Context                     // Plan context with provider config, mappings, client
 	log                  logging.LevelLogger // Structured logger
@@ -80,15 +81,25 @@ func New(ctx *plancontext.Context) (migbase.Migrator, error) {
 	return migrator, nil
 }
 
-// Type returns the supported migration type.
+// Type returns the migration type of the plan.
 func (r *Migrator) Type() api.MigrationType {
+	if r.Context.Plan.IsWarm() {
+		return api.MigrationWarm
+	}
 	return api.MigrationCold
 }
 
 // Supported returns whether the plan's migration type is supported.
+// Warm migrations need the EBS direct APIs to list the changed blocks.
 func (r *Migrator) Supported() bool {
-	return r.Context.Plan.Spec.Type == api.MigrationCold ||
-		r.Context.Plan.Spec.Type == ""
+	switch r.Context.Plan.Spec.Type {
+	case "", api.MigrationCold:
+		return true
+	case api.MigrationWarm:
+		return r.Source.Provider.IsEC2EBSDirect()
+	default:
+		return false
+	}
 }
 
 // DestinationClient returns the destination client (not used for EC2).
@@ -116,6 +116,68 @@ func (r *Migrator) ExecutePhase(vm *planapi.VMStatus) (ok bool, err error) {
 		if ready {
 			r.NextPhase(vm)
 		}
+	case PhaseCreatePopulatorVolumes:
+		if step, found := vm.FindStep(DiskTransfer); found {
+			if !step.MarkedStarted() {
+				step.MarkStarted()
+			}
+			step.Phase = api.StepRunning
+		}
+		ok = true
+		var ready bool
+		ready, err = r.createPopulatorVolumes(vm)
+		if err != nil {
+			break
+		}
+		if ready {
+			r.NextPhase(vm)
+		}
+	case PhaseWaitForPopulatorVolumes:
+		ok = true
+		var ready bool
+		ready, err = r.waitForPopulatorVolumes(vm)
+		if err != nil {
+			break
+		}
+		if ready {
+			if r.Plan.IsWarm() {
+				r.completePrecopy(vm)
+			}
+			r.NextPhase(vm)
+		}
+	case api.PhaseCreateInitialSnapshot, api.PhaseCreateSnapshot, api.PhaseCreateFinalSnapshot:
+		ok = true
+		err = r.createPrecopySnapshot(vm)
+		if err == nil {
+			r.NextPhase(vm)
+		}
+	case api.PhaseWaitForInitialSnapshot, api.PhaseWaitForSnapshot, api.PhaseWaitForFinalSnapshot:
+		ok = true
+		var ready bool
+		ready, err = r.waitForPrecopySnapshot(vm)
+		if err != nil {
+			break
+		}
+		if ready {
+			r.NextPhase(vm)
+		}
+	case api.PhaseCopyingPaused:
+		ok = true
+		r.copyingPaused(vm)
+	case PhaseCopyChangedBlocks, PhaseCopyFinalChangedBlocks:
+		ok = true
+		var done bool
+		done, err = r.copyChangedBlocks(vm)
+		if err != nil {
+			break
+		}
+		if done {
+			if vm.Phase == PhaseCopyChangedBlocks {
+				vm.Phase = api.PhaseCopyingPaused
+			} else {
+				r.NextPhase(vm)
+			}
+		}
 	case api.PhaseStorePowerState:
 		ok = false
 	case api.PhaseCreateGuestConversionPod:
@@ -180,13 +242,22 @@ func (r *Migrator) Step(status *planapi.VMStatus) (step string) {
 	case api.PhasePreHook:
 		step = api.PhasePreHook
 	case api.PhaseStorePowerState, api.PhasePowerOffSource, api.PhaseWaitForPowerOff:
-		step = PrepareSource
-	case PhaseCreateSnapshots, PhaseWaitForSnapshots:
+		if r.Plan.IsWarm() {
+			step = Cutover
+		} else {
+			step = PrepareSource
+		}
+	case PhaseCreateSnapshots, PhaseWaitForSnapshots,
+		api.PhaseCreateInitialSnapshot, api.PhaseWaitForInitialSnapshot:
 		step = CreateSnapshots
 	case PhaseShareSnapshots:
 		step = ShareSnapshots
-	case PhaseCreateVolumes, PhaseWaitForVolumes, PhaseCreatePVsAndPVCs:
+	case PhaseCreateVolumes, PhaseWaitForVolumes, PhaseCreatePVsAndPVCs,
+		PhaseCreatePopulatorVolumes, PhaseWaitForPopulatorVolumes,
+		api.PhaseCopyingPaused, api.PhaseCreateSnapshot, api.PhaseWaitForSnapshot, PhaseCopyChangedBlocks:
 		step = DiskTransfer
+	case api.PhaseCreateFinalSnapshot, api.PhaseWaitForFinalSnapshot, PhaseCopyFinalChangedBlocks:
+		step = Cutover
 	case api.PhaseCreateGuestConversionPod, api.PhaseConvertGuest:
 		step = ImageConversion
 	case api.PhaseFinalize, api.PhaseCreateVM:
@@ -13,16 +13,22 @@ const (
 	PostHookFlag     = 1 << 1 // Include post-hook phase
 	ConversionFlag   = 1 << 2 // Include guest conversion phases
 	CrossAccountFlag = 1 << 3 // Include cross-account snapshot sharing phase
+	EBSVolumeFlag    = 1 << 4 // Include EBS volume creation phases
+	EBSDirectFlag    = 1 << 5 // Include EBS direct populator phases
 )
 
 // Itinerary builds the EC2 migration workflow sequence defining phase order.
 func (r *Migrator) Itinerary(vm planapi.VM) *libitr.Itinerary {
 	r.vm = &vm
+	if r.Plan.IsWarm() {
+		return r.warmItinerary(vm)
+	}
 	return r.coldItinerary(vm)
 }
 
 // coldItinerary is the full EC2 cold migration workflow.
 // Includes: Initialize→PreHook→PowerOff→CreateSnapshots→WaitSnapshots→[ShareSnapshots]→CreateVolumes→WaitForVolumes→CreatePVsAndPVCs→CreateGuestConversionPod→ConvertGuest→Finalize→CreateVM→RemoveSnapshots→PostHook→Completed.
+// In the EBS direct transfer mode, CreatePopulatorVolumes→WaitForPopulatorVolumes replace the volume phases.
 func (r *Migrator) coldItinerary(vm planapi.VM) *libitr.Itinerary {
 	return &libitr.Itinerary{
 		Name: "EC2 Cold Migration",
@@ -35,9 +41,11 @@ func (r *Migrator) coldItinerary(vm planapi.VM) *libitr.Itinerary {
 			{Name: PhaseCreateSnapshots},
 			{Name: PhaseWaitForSnapshots},
 			{Name: PhaseShareSnapshots, All: CrossAccountFlag},
-			{Name: PhaseCreateVolumes},
-			{Name: PhaseWaitForVolumes},
-			{Name: PhaseCreatePVsAndPVCs},
+			{Name: PhaseCreateVolumes, All: EBSVolumeFlag},
+			{Name: PhaseWaitForVolumes, All: EBSVolumeFlag},
+			{Name: PhaseCreatePVsAndPVCs, All: EBSVolumeFlag},
+			{Name: PhaseCreatePopulatorVolumes, All: EBSDirectFlag},
+			{Name: PhaseWaitForPopulatorVolumes, All: EBSDirectFlag},
 			{Name: api.PhaseCreateGuestConversionPod, All: ConversionFlag},
 			{Name: api.PhaseConvertGuest, All: ConversionFlag},
 			{Name: api.PhaseFinalize},
@@ -83,8 +91,12 @@ func (p *EC2Predicate) Evaluate(flag libitr.Flag) (bool, error) {
 		return p.context.Source.Provider.RequiresConversion() && !p.context.Plan.Spec.SkipGuestConversion, nil
 	}
 
-	// Cross-account snapshot sharing: include if cross-account mode is enabled
+	// Cross-account snapshot sharing: include if cross-account mode is enabled.
+	// The EBS direct APIs read the snapshots in the source account.
 	if flag&CrossAccountFlag != 0 {
+		if p.context.Source.Provider.IsEC2EBSDirect() {
+			return false, nil
+		}
 		if p.migrator != nil {
 			ec2Client := p.migrator.getEC2Client()
 			return ec2Client.IsCrossAccount(), nil
@@ -92,9 +104,19 @@ func (p *EC2Predicate) Evaluate(flag libitr.Flag) (bool, error) {
 		return false, nil
 	}
 
+	// EBS volume phases: include unless the disks are copied through the EBS direct APIs
+	if flag&EBSVolumeFlag != 0 {
+		return !p.context.Source.Provider.IsEC2EBSDirect(), nil
+	}
+
+	// EBS direct phases: include if the disks are copied through the EBS direct APIs
+	if flag&EBSDirectFlag != 0 {
+		return p.context.Source.Provider.IsEC2EBSDirect(), nil
+	}
+
 	return true, nil
 }
 
 func (p *EC2Predicate) Count() int {
-	return 4 // PreHook, PostHook, Conversion, CrossAccount
+	return 6 // PreHook, PostHook, Conversion, CrossAccount, EBSVolume, EBSDirect
 }
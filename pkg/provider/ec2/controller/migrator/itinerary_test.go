@@ -6,6 +6,7 @@ import (
 	"github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/ref"
 	plancontext "github.com/kubev2v/forklift/pkg/controller/plan/context"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
+	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
 	"github.com/kubev2v/forklift/pkg/lib/logging"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -64,6 +65,21 @@ var _ = Describe("EC2 Migrator Reset", func() {
 		Expect(vm.Phase).To(Equal(api.PhaseStarted))
 		Expect(vm.DisksCopied).To(BeTrue(), "EC2 Reset does not clear DisksCopied")
 	})
+
+	It("should restart the precopy history of warm migrations", func() {
+		m := newMigrator()
+		m.Plan.Spec.Warm = true
+		vm := &planapi.VMStatus{
+			VM:   planapi.VM{Ref: ref.Ref{ID: "i-321"}},
+			Warm: &planapi.Warm{Successes: 2, Precopies: []planapi.Precopy{{Snapshot: "snap-1"}}},
+		}
+		vm.Phase = PhaseCopyChangedBlocks
+
+		m.Reset(vm, nil)
+
+		Expect(vm.Phase).To(Equal(api.PhaseStarted))
+		Expect(vm.Warm).To(Equal(&planapi.Warm{}))
+	})
 })
 
 var _ = Describe("EC2 Itinerary", func() {
@@ -109,4 +125,78 @@ var _ = Describe("EC2 Itinerary", func() {
 			Expect(phaseNames).To(HaveKey(api.PhaseCompleted))
 		})
 	})
+	Describe("Warm migration", func() {
+		newWarmMigrator := func() *Migrator {
+			m := newMigrator()
+			m.Plan.Spec.Warm = true
+			m.Source.Provider.Spec.Settings = map[string]string{
+				api.EC2TransferMode: api.EC2TransferModeEBSDirect,
+			}
+			return m
+		}
+
+		It("should return warm itinerary", func() {
+			m := newWarmMigrator()
+			vm := planapi.VM{Ref: ref.Ref{ID: "i-123"}}
+			itr := m.Itinerary(vm)
+			Expect(itr.Name).To(Equal("EC2 Warm Migration"))
+			Expect(m.Type()).To(Equal(api.MigrationWarm))
+		})
+
+		It("should only be supported in the EBS direct transfer mode", func() {
+			m := newWarmMigrator()
+			m.Plan.Spec.Type = api.MigrationWarm
+			Expect(m.Supported()).To(BeTrue())
+
+			m.Source.Provider.Spec.Settings = nil
+			Expect(m.Supported()).To(BeFalse())
+		})
+
+		It("should initialize the warm status", func() {
+			m := newWarmMigrator()
+			status := m.Status(planapi.VM{Ref: ref.Ref{ID: "i-123"}})
+			Expect(status.Warm).NotTo(BeNil())
+		})
+
+		It("should map the precopy phases to steps", func() {
+			m := newWarmMigrator()
+			step := func(phase string) string {
+				return m.Step(&planapi.VMStatus{Phase: phase})
+			}
+			Expect(step(api.PhaseCreateInitialSnapshot)).To(Equal(CreateSnapshots))
+			Expect(step(api.PhaseWaitForInitialSnapshot)).To(Equal(CreateSnapshots))
+			Expect(step(api.PhaseCopyingPaused)).To(Equal(DiskTransfer))
+			Expect(step(api.PhaseCreateSnapshot)).To(Equal(DiskTransfer))
+			Expect(step(PhaseCopyChangedBlocks)).To(Equal(DiskTransfer))
+			Expect(step(api.PhasePowerOffSource)).To(Equal(Cutover))
+			Expect(step(api.PhaseWaitForFinalSnapshot)).To(Equal(Cutover))
+			Expect(step(PhaseCopyFinalChangedBlocks)).To(Equal(Cutover))
+		})
+	})
+
+	Describe("Transfer mode predicates", func() {
+		evaluate := func(m *Migrator, flag libitr.Flag) bool {
+			vm := planapi.VM{Ref: ref.Ref{ID: "i-123"}}
+			predicate := &EC2Predicate{vm: &vm, context: m.Context}
+			included, err := predicate.Evaluate(flag)
+			Expect(err).NotTo(HaveOccurred())
+			return included
+		}
+
+		It("should include the EBS volume phases by default", func() {
+			m := newMigrator()
+			Expect(evaluate(m, EBSVolumeFlag)).To(BeTrue())
+			Expect(evaluate(m, EBSDirectFlag)).To(BeFalse())
+		})
+
+		It("should include the populator phases in the EBS direct transfer mode", func() {
+			m := newMigrator()
+			m.Source.Provider.Spec.Settings = map[string]string{
+				api.EC2TransferMode: api.EC2TransferModeEBSDirect,
+			}
+			Expect(evaluate(m, EBSVolumeFlag)).To(BeFalse())
+			Expect(evaluate(m, EBSDirectFlag)).To(BeTrue())
+			Expect(evaluate(m, CrossAccountFlag)).To(BeFalse())
+		})
+	})
 })
@@ -28,21 +28,28 @@ func (r *Migrator) Complete(vm *planapi.VMStatus) {
 // Status creates a new VMStatus object for tracking migration progress.
 // Returns a VMStatus initialized with the VM definition, ready to be populated with pipeline and progress.
 func (r *Migrator) Status(vm planapi.VM) *planapi.VMStatus {
-	return &planapi.VMStatus{
+	status := &planapi.VMStatus{
 		VM: vm,
 	}
+	if r.Plan.IsWarm() {
+		status.Warm = &planapi.Warm{}
+	}
+	return status
 }
 
 // Reset re-initializes a VM's migration status for retry after failure or cancellation.
-// EC2 has a single cold itinerary (always starts with PhaseStarted), never sets
-// DisksCopied or Warm, and does not support resume-conversion — so this is a
-// minimal reset without the warm/resume logic in BaseMigrator.Reset.
+// Both EC2 itineraries start with PhaseStarted, never set DisksCopied, and do not
+// support resume-conversion — so this is a minimal reset that only restarts the
+// precopy history of warm migrations.
 func (r *Migrator) Reset(vm *planapi.VMStatus, pipeline []*planapi.Step) {
-	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed)
+	vm.DeleteCondition(api.ConditionCanceled, api.ConditionFailed, api.ConditionRolledBack)
 	vm.MarkReset()
 	vm.Pipeline = pipeline
 	vm.Phase = api.PhaseStarted
 	vm.Error = nil
+	if r.Plan.IsWarm() {
+		vm.Warm = &planapi.Warm{}
+	}
 
 	r.log.V(1).Info("VM status reset", "vm", vm.Name)
 }
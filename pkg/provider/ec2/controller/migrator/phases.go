@@ -56,6 +56,39 @@ const (
 	// This phase advances to PhaseFinalize when all PVCs are bound.
 	PhaseCreatePVsAndPVCs = "CreatePVsAndPVCs"
 
+	// PhaseCreatePopulatorVolumes controls the populator PVC creation phase.
+	// This phase is only executed in the EBS direct transfer mode.
+	// During this phase, the migrator:
+	//   - Copies the AWS credentials into the target namespace for the populator pods
+	//   - Creates an Ec2VolumePopulator CR for each snapshot
+	//   - Creates PersistentVolumeClaims referencing the CRs as their data source
+	// This phase advances to PhaseWaitForPopulatorVolumes when the PVCs are created.
+	PhaseCreatePopulatorVolumes = "CreatePopulatorVolumes"
+
+	// PhaseWaitForPopulatorVolumes controls the volume population polling phase.
+	// This phase is only executed in the EBS direct transfer mode.
+	// During this phase, the migrator:
+	//   - Reports the progress of the populator pods reading the snapshot blocks
+	//   - Waits until all PVCs are populated and bound
+	// This phase advances to PhaseFinalize when all PVCs are bound.
+	PhaseWaitForPopulatorVolumes = "WaitForPopulatorVolumes"
+
+	// PhaseCopyChangedBlocks controls the precopy phase of a warm migration.
+	// This phase is only executed in warm migrations, which require the EBS direct transfer mode.
+	// During this phase, the migrator:
+	//   - Creates a delta copy pod reading the blocks changed between the snapshots
+	//     of the previous and the current precopy through the EBS direct APIs
+	//   - Writes the changed blocks into the populated PVCs
+	//   - Removes the snapshots of the previous precopy
+	// This phase returns to CopyingPaused until the next precopy or the cutover.
+	PhaseCopyChangedBlocks = "CopyChangedBlocks"
+
+	// PhaseCopyFinalChangedBlocks controls the final copy of a warm migration.
+	// During this phase, the migrator copies the blocks changed between the last
+	// precopy and the final snapshot, taken once the instance is stopped.
+	// This phase advances to guest conversion or PhaseFinalize.
+	PhaseCopyFinalChangedBlocks = "CopyFinalChangedBlocks"
+
 	// PhaseRemoveSnapshots controls the cleanup phase for EBS snapshots.
 	// During this phase, the migrator:
 	//   - Queries AWS for snapshots tagged with VM name
@@ -78,6 +111,7 @@ const (
 	// CreateSnapshots indicates EBS snapshots are being created and verified.
 	// Corresponds to: PhaseCreateSnapshots, PhaseWaitForSnapshots
 	// This step creates snapshots and waits for them to complete.
+	// In warm migrations it corresponds to PhaseCreateInitialSnapshot and PhaseWaitForInitialSnapshot.
 	CreateSnapshots = "CreateSnapshots"
 
 	// ShareSnapshots indicates EBS snapshots are being shared with the target account.
@@ -89,8 +123,18 @@ const (
 	// Corresponds to: PhaseCreateVolumes, PhaseWaitForVolumes, PhaseCreatePVsAndPVCs
 	// This step creates EBS volumes from snapshots, then creates PV/PVC pairs
 	// with CSI volume sources pointing directly to the EBS volumes.
+	// In the EBS direct transfer mode it corresponds to PhaseCreatePopulatorVolumes
+	// and PhaseWaitForPopulatorVolumes, populating PVCs from the snapshot blocks.
+	// In warm migrations it also covers the precopies: PhaseCopyingPaused,
+	// PhaseCreateSnapshot, PhaseWaitForSnapshot and PhaseCopyChangedBlocks.
 	DiskTransfer = "DiskTransfer"
 
+	// Cutover indicates the final delta of a warm migration is being copied.
+	// Corresponds to: PhaseStorePowerState, PhasePowerOffSource, PhaseWaitForPowerOff,
+	// PhaseCreateFinalSnapshot, PhaseWaitForFinalSnapshot, PhaseCopyFinalChangedBlocks
+	// This step stops the EC2 instance and copies the blocks changed since the last precopy.
+	Cutover = "Cutover"
+
 	// CreateVM indicates the KubeVirt VirtualMachine is being created.
 	// Corresponds to: PhaseFinalize, PhaseCreateVM
 	// This step builds the VirtualMachine spec and creates it in the target cluster.
@@ -102,6 +146,6 @@ const (
 
 	// Cleanup indicates EBS snapshots and temporary resources are being removed.
 	// Corresponds to: PhaseRemoveSnapshots
-	// This step deletes the EBS snapshots after migration.
+	// This step deletes the EBS snapshots, and the volume populators, after migration.
 	Cleanup = "Cleanup"
 )
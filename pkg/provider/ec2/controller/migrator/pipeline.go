@@ -9,6 +9,7 @@ import (
 
 // Pipeline converts itinerary phases into user-facing UI steps with progress tracking.
 // Maps internal phases to steps: Initialize, PrepareSource, CreateSnapshots, ShareSnapshots (cross-account only), DiskTransfer, ImageConversion, CreateVM, Cleanup.
+// Warm migrations replace PrepareSource with a Cutover step following DiskTransfer.
 // Each step includes description, total progress units, and optional sub-tasks for detailed tracking.
 func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error) {
 	itinerary := r.Itinerary(vm)
@@ -36,8 +37,21 @@ func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error)
 				},
 			})
 
-		case api.PhaseStorePowerState, api.PhasePowerOffSource, api.PhaseWaitForPowerOff:
-			if step.Name == api.PhasePowerOffSource {
+		case api.PhaseStorePowerState, api.PhasePowerOffSource, api.PhaseWaitForPowerOff,
+			api.PhaseCreateFinalSnapshot, api.PhaseWaitForFinalSnapshot, PhaseCopyFinalChangedBlocks:
+			// Warm migrations stop the instance at cutover, to copy the final delta
+			if r.Plan.IsWarm() {
+				if step.Name == api.PhaseStorePowerState {
+					pipeline = append(pipeline, &planapi.Step{
+						Task: planapi.Task{
+							Name:        Cutover,
+							Description: "Stop source EC2 instance and copy the final changed blocks.",
+							Progress:    libitr.Progress{Total: 1},
+							Phase:       api.StepPending,
+						},
+					})
+				}
+			} else if step.Name == api.PhasePowerOffSource {
 				pipeline = append(pipeline, &planapi.Step{
 					Task: planapi.Task{
 						Name:        PrepareSource,
@@ -48,8 +62,9 @@ func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error)
 				})
 			}
 
-		case PhaseCreateSnapshots, PhaseWaitForSnapshots:
-			if step.Name == PhaseCreateSnapshots {
+		case PhaseCreateSnapshots, PhaseWaitForSnapshots,
+			api.PhaseCreateInitialSnapshot, api.PhaseWaitForInitialSnapshot:
+			if step.Name == PhaseCreateSnapshots || step.Name == api.PhaseCreateInitialSnapshot {
 				pipeline = append(pipeline, &planapi.Step{
 					Task: planapi.Task{
 						Name:        CreateSnapshots,
@@ -70,9 +85,11 @@ func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error)
 				},
 			})
 
-		case PhaseCreateVolumes, PhaseWaitForVolumes, PhaseCreatePVsAndPVCs:
+		case PhaseCreateVolumes, PhaseWaitForVolumes, PhaseCreatePVsAndPVCs,
+			PhaseCreatePopulatorVolumes, PhaseWaitForPopulatorVolumes,
+			api.PhaseCopyingPaused, api.PhaseCreateSnapshot, api.PhaseWaitForSnapshot, PhaseCopyChangedBlocks:
 			// Only create the DiskTransfer step once (on the first phase)
-			if step.Name == PhaseCreateVolumes {
+			if step.Name == PhaseCreateVolumes || step.Name == PhaseCreatePopulatorVolumes {
 				tasks, pErr := r.builder.Tasks(vm.Ref)
 				if pErr != nil {
 					err = liberr.Wrap(pErr)
@@ -84,10 +101,18 @@ func (r *Migrator) Pipeline(vm planapi.VM) (pipeline []*planapi.Step, err error)
 					total += task.Progress.Total
 				}
 
+				description := "Create EBS volumes and PVCs."
+				if step.Name == PhaseCreatePopulatorVolumes {
+					description = "Copy EBS snapshots into PVCs."
+					if r.Plan.IsWarm() {
+						description = "Copy EBS snapshots into PVCs, then the changed blocks of each precopy."
+					}
+				}
+
 				pipeline = append(pipeline, &planapi.Step{
 					Task: planapi.Task{
 						Name:        DiskTransfer,
-						Description: "Create EBS volumes and PVCs.",
+						Description: description,
 						Progress: libitr.Progress{
 							Total: total,
 						},
@@ -0,0 +1,194 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/controller/builder"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/controller/inventory"
+	core "k8s.io/api/core/v1"
+)
+
+// createPopulatorVolumes creates an Ec2VolumePopulator CR and a PVC referencing it
+// for each snapshot. The populator pods copy the snapshot blocks through the EBS
+// direct APIs, so the PVCs can use any storage class.
+// Returns true when all PVCs are created.
+func (r *Migrator) createPopulatorVolumes(vm *planapi.VMStatus) (bool, error) {
+	r.log.Info("Creating populator volumes for EBS snapshots", "vm", vm.Name)
+	ctx := context.TODO()
+
+	ec2Ensurer := r.getEnsurer()
+	ec2Builder, ok := r.builder.(*builder.Builder)
+	if !ok {
+		return false, liberr.New("builder is not an EC2 builder")
+	}
+
+	snapshotMap, err := r.getSnapshotIDs(vm)
+	if err != nil {
+		r.log.Error(err, "Failed to get snapshot IDs from AWS", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	if len(snapshotMap) == 0 {
+		err := fmt.Errorf("no snapshots found in AWS for VM %s", vm.Name)
+		r.log.Error(err, "No snapshots to populate volumes from", "vm", vm.Name)
+		return false, err
+	}
+
+	secretName, err := ec2Ensurer.EnsurePopulatorSecret(ctx, vm)
+	if err != nil {
+		r.log.Error(err, "Failed to create populator secret", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	awsInstance, err := inventory.GetAWSInstance(ec2Builder.Source.Inventory, vm.Ref)
+	if err != nil {
+		r.log.Error(err, "Failed to get AWS instance from inventory", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	blockDevices, _ := inventory.GetBlockDevices(awsInstance)
+
+	// Iterate over BlockDeviceMappings to preserve disk order from the source VM.
+	volumeInfos := make(map[string]*builder.VolumeInfo)
+	diskIndexes := make(map[string]int)
+	var populators []*api.Ec2VolumePopulator
+	for i, dev := range blockDevices {
+		if dev.Ebs == nil || dev.Ebs.VolumeId == nil {
+			continue
+		}
+
+		originalVolumeID := *dev.Ebs.VolumeId
+		snapshotID, found := snapshotMap[originalVolumeID]
+		if !found {
+			r.log.Info("No snapshot found for original volume, skipping",
+				"vm", vm.Name,
+				"originalVolumeID", originalVolumeID)
+			continue
+		}
+
+		volumeInfo := &builder.VolumeInfo{
+			OriginalVolumeID: originalVolumeID,
+			SnapshotID:       snapshotID,
+			SizeGiB:          ec2Builder.GetVolumeSize(originalVolumeID, snapshotID),
+			VolumeType:       r.getVolumeType(originalVolumeID),
+		}
+		volumeInfos[originalVolumeID] = volumeInfo
+		diskIndexes[originalVolumeID] = i
+		populators = append(populators, ec2Builder.BuildVolumePopulator(vm.Ref, volumeInfo, secretName))
+	}
+
+	populatorNames, err := ec2Ensurer.EnsureVolumePopulators(ctx, vm, populators)
+	if err != nil {
+		r.log.Error(err, "Failed to create volume populators", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	var pvcs []*core.PersistentVolumeClaim
+	for originalVolumeID, volumeInfo := range volumeInfos {
+		populatorName := populatorNames[originalVolumeID]
+		if populatorName == "" {
+			r.log.Error(nil, "Populator name not found for volume",
+				"vm", vm.Name,
+				"originalVolumeID", originalVolumeID)
+			continue
+		}
+
+		pvc, err := ec2Builder.BuildPopulatorPVC(vm.Ref, volumeInfo, populatorName, diskIndexes[originalVolumeID])
+		if err != nil {
+			r.log.Error(err, "Failed to build populator PVC spec",
+				"vm", vm.Name,
+				"originalVolumeID", originalVolumeID)
+			return false, liberr.Wrap(err)
+		}
+		pvcs = append(pvcs, pvc)
+	}
+
+	_, err = ec2Ensurer.EnsureDirectPVCs(ctx, vm, pvcs)
+	if err != nil {
+		r.log.Error(err, "Failed to create populator PVCs", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	r.log.Info("All populator volumes created",
+		"vm", vm.Name,
+		"volumeCount", len(pvcs))
+
+	return true, nil
+}
+
+// waitForPopulatorVolumes reports the progress of the volume populators and
+// checks whether all the populated PVCs are bound.
+// Returns true when all PVCs are bound.
+func (r *Migrator) waitForPopulatorVolumes(vm *planapi.VMStatus) (bool, error) {
+	r.log.Info("Checking populator volumes", "vm", vm.Name)
+	ctx := context.TODO()
+
+	ec2Ensurer := r.getEnsurer()
+
+	populators, err := ec2Ensurer.GetVolumePopulators(ctx, vm)
+	if err != nil {
+		r.log.Error(err, "Failed to get volume populators", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+	r.updatePopulatorProgress(vm, populators)
+
+	allBound, err := ec2Ensurer.CheckPopulatorPVCsBound(ctx, vm)
+	if err != nil {
+		r.log.Error(err, "Failed to check populator PVC status", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+
+	if allBound {
+		r.log.Info("All populator PVCs are bound", "vm", vm.Name)
+		if step, found := vm.FindStep(DiskTransfer); found {
+			for _, task := range step.Tasks {
+				task.Progress.Completed = task.Progress.Total
+				task.MarkCompleted()
+			}
+			step.Progress.Completed = step.Progress.Total
+		}
+	} else {
+		r.log.Info("Waiting for populator PVCs to be bound", "vm", vm.Name)
+	}
+
+	return allBound, nil
+}
+
+// updatePopulatorProgress updates the DiskTransfer tasks from the progress
+// percentage reported on the volume populators.
+func (r *Migrator) updatePopulatorProgress(vm *planapi.VMStatus, populators []api.Ec2VolumePopulator) {
+	step, found := vm.FindStep(DiskTransfer)
+	if !found {
+		return
+	}
+
+	completed := int64(0)
+	for i := range populators {
+		populator := &populators[i]
+		task, found := step.FindTask(populator.Labels["forklift.konveyor.io/volume-id"])
+		if !found {
+			continue
+		}
+		if populator.Status.Progress != "" {
+			percent, err := strconv.ParseFloat(populator.Status.Progress, 64)
+			if err != nil {
+				r.log.V(1).Info("Failed to parse populator progress",
+					"vm", vm.Name,
+					"populator", populator.Name,
+					"progress", populator.Status.Progress)
+			} else {
+				task.Progress.Completed = int64(percent * float64(task.Progress.Total) / 100)
+			}
+		}
+		if !task.MarkedStarted() {
+			task.MarkStarted()
+		}
+		completed += task.Progress.Completed
+	}
+	step.Progress.Completed = completed
+}
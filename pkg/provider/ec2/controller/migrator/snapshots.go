@@ -1,6 +1,7 @@
 package migrator
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -12,8 +13,12 @@ import (
 )
 
 // getSnapshotIDs retrieves snapshot IDs from AWS by querying snapshots tagged with the VM name.
+// Warm migrations keep several snapshots per volume, so the snapshots of the last precopy are used.
 // Returns a map of volumeID -> snapshotID.
 func (r *Migrator) getSnapshotIDs(vm *planapi.VMStatus) (map[string]string, error) {
+	if vm.Warm != nil && len(vm.Warm.Precopies) > 0 {
+		return vm.Warm.Precopies[len(vm.Warm.Precopies)-1].DeltaMap(), nil
+	}
 	ec2Client := r.getEC2Client()
 	return ec2Client.GetSnapshotsForVM(vm.Ref)
 }
@@ -175,6 +180,16 @@ func (r *Migrator) removeSnapshots(vm *planapi.VMStatus) (bool, error) {
 	// Clean up snapshots from AWS
 	r.cleanupSnapshots(vm)
 
+	// Clean up the volume populators and their credentials
+	if r.Source.Provider.IsEC2EBSDirect() {
+		r.cleanupVolumePopulators(vm)
+	}
+
+	// Clean up the pods copying the changed blocks
+	if r.Plan.IsWarm() {
+		r.cleanupDeltaCopyPods(vm)
+	}
+
 	// Clean up created EBS volumes if migration failed
 	// On success, volumes are now backing PVCs and should not be deleted
 	if vm.Error != nil {
@@ -247,6 +262,25 @@ func (r *Migrator) cleanupCreatedVolumes(vm *planapi.VMStatus) {
 	}
 }
 
+// cleanupVolumePopulators removes the Ec2VolumePopulator CRs and the populator secret
+// of the VM. The populated PVCs are kept, as they back the VM disks.
+func (r *Migrator) cleanupVolumePopulators(vm *planapi.VMStatus) {
+	err := r.getEnsurer().DeleteVolumePopulators(context.TODO(), vm)
+	if err != nil {
+		r.log.Error(err, "Failed to remove volume populators", "vm", vm.Name)
+		r.log.Info("Continuing despite populator cleanup error", "vm", vm.Name)
+	}
+}
+
+// cleanupDeltaCopyPods removes the delta copy pods of a warm migration and their spec config maps.
+func (r *Migrator) cleanupDeltaCopyPods(vm *planapi.VMStatus) {
+	err := r.getEnsurer().DeleteDeltaCopyPods(context.TODO(), vm)
+	if err != nil {
+		r.log.Error(err, "Failed to remove delta copy pods", "vm", vm.Name)
+		r.log.Info("Continuing despite delta copy pod cleanup error", "vm", vm.Name)
+	}
+}
+
 // markSnapshotStepRunning updates the CreateSnapshots pipeline step to running status.
 func (r *Migrator) markSnapshotStepRunning(vm *planapi.VMStatus) error {
 	if step, found := vm.FindStep(CreateSnapshots); found {
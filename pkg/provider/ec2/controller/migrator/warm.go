@@ -0,0 +1,380 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	planapi "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1/plan"
+	"github.com/kubev2v/forklift/pkg/lib/deltacopy"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	libitr "github.com/kubev2v/forklift/pkg/lib/itinerary"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/controller/builder"
+	"github.com/kubev2v/forklift/pkg/settings"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// warmItinerary is the EC2 warm migration workflow, only available in the EBS direct transfer mode.
+// Includes: Initialize→PreHook→CreateInitialSnapshot→WaitForInitialSnapshot→CreatePopulatorVolumes→WaitForPopulatorVolumes→
+// CopyingPaused→CreateSnapshot→WaitForSnapshot→CopyChangedBlocks (repeated every precopy interval until cutover)→
+// StorePowerState→PowerOff→CreateFinalSnapshot→WaitForFinalSnapshot→CopyFinalChangedBlocks→
+// CreateGuestConversionPod→ConvertGuest→Finalize→CreateVM→RemoveSnapshots→PostHook→Completed.
+// The instance keeps running until the cutover, when it is stopped only to copy the final delta.
+func (r *Migrator) warmItinerary(vm planapi.VM) *libitr.Itinerary {
+	return &libitr.Itinerary{
+		Name: "EC2 Warm Migration",
+		Pipeline: libitr.Pipeline{
+			{Name: api.PhaseStarted},
+			{Name: api.PhasePreHook, All: PreHookFlag},
+			{Name: api.PhaseCreateInitialSnapshot},
+			{Name: api.PhaseWaitForInitialSnapshot},
+			{Name: PhaseCreatePopulatorVolumes},
+			{Name: PhaseWaitForPopulatorVolumes},
+			{Name: api.PhaseCopyingPaused},
+			{Name: api.PhaseCreateSnapshot},
+			{Name: api.PhaseWaitForSnapshot},
+			{Name: PhaseCopyChangedBlocks},
+			{Name: api.PhaseStorePowerState},
+			{Name: api.PhasePowerOffSource},
+			{Name: api.PhaseWaitForPowerOff},
+			{Name: api.PhaseCreateFinalSnapshot},
+			{Name: api.PhaseWaitForFinalSnapshot},
+			{Name: PhaseCopyFinalChangedBlocks},
+			{Name: api.PhaseCreateGuestConversionPod, All: ConversionFlag},
+			{Name: api.PhaseConvertGuest, All: ConversionFlag},
+			{Name: api.PhaseFinalize},
+			{Name: api.PhaseCreateVM},
+			{Name: PhaseRemoveSnapshots},
+			{Name: api.PhasePostHook, All: PostHookFlag},
+			{Name: api.PhaseCompleted},
+		},
+		Predicate: &EC2Predicate{
+			vm:       &vm,
+			context:  r.Context,
+			migrator: r,
+		},
+	}
+}
+
+// createPrecopySnapshot snapshots the volumes of the running instance and records
+// a new precopy with the comma-separated snapshot IDs.
+// Snapshots already recorded for a precopy that has not started copying are reused.
+func (r *Migrator) createPrecopySnapshot(vm *planapi.VMStatus) error {
+	if vm.Warm == nil {
+		return liberr.New("warm migration status is not initialized", "vm", vm.Name)
+	}
+	if vm.Phase == api.PhaseCreateInitialSnapshot {
+		if err := r.markSnapshotStepRunning(vm); err != nil {
+			return err
+		}
+	}
+
+	n := len(vm.Warm.Precopies)
+	if n > 0 && vm.Warm.Precopies[n-1].End == nil {
+		r.log.Info("Snapshots of the precopy already created",
+			"vm", vm.Name,
+			"precopy", n,
+			"snapshots", vm.Warm.Precopies[n-1].Snapshot)
+		return nil
+	}
+
+	if _, err := r.extractVolumeIDs(vm); err != nil {
+		return err
+	}
+
+	snapshot, _, err := r.adpClient.CreateSnapshot(vm.Ref, nil)
+	if err != nil {
+		r.log.Error(err, "Failed to create precopy snapshots", "vm", vm.Name)
+		return liberr.Wrap(err)
+	}
+
+	now := meta.Now()
+	vm.Warm.Precopies = append(vm.Warm.Precopies, planapi.Precopy{
+		Start:    &now,
+		Snapshot: snapshot,
+	})
+
+	r.log.Info("Precopy snapshots created",
+		"vm", vm.Name,
+		"precopy", len(vm.Warm.Precopies),
+		"snapshots", snapshot)
+
+	if vm.Phase == api.PhaseCreateInitialSnapshot {
+		r.markSnapshotStepComplete(vm)
+	}
+	return nil
+}
+
+// waitForPrecopySnapshot checks whether the snapshots of the current precopy are completed.
+// Once they are, the source volume of each snapshot is recorded in the precopy deltas.
+// Returns true when all snapshots are ready.
+func (r *Migrator) waitForPrecopySnapshot(vm *planapi.VMStatus) (bool, error) {
+	precopy, err := r.currentPrecopy(vm)
+	if err != nil {
+		return false, err
+	}
+
+	ready, _, err := r.adpClient.CheckSnapshotReady(vm.Ref, *precopy, nil)
+	if err != nil {
+		r.log.Error(err, "Failed to check precopy snapshot status", "vm", vm.Name)
+		return false, liberr.Wrap(err)
+	}
+	if !ready {
+		r.log.Info("Precopy snapshots not yet ready", "vm", vm.Name)
+		return false, nil
+	}
+
+	if len(precopy.Deltas) == 0 {
+		deltas, err := r.getEC2Client().GetSnapshotVolumes(vm.Ref, precopy.Snapshot)
+		if err != nil {
+			r.log.Error(err, "Failed to get the volumes of the precopy snapshots", "vm", vm.Name)
+			return false, liberr.Wrap(err)
+		}
+		precopy.WithDeltas(deltas)
+	}
+
+	if vm.Phase == api.PhaseWaitForInitialSnapshot {
+		if step, found := vm.FindStep(CreateSnapshots); found {
+			step.Progress.Completed = 2
+		}
+	}
+
+	r.log.Info("Precopy snapshots ready",
+		"vm", vm.Name,
+		"precopy", len(vm.Warm.Precopies))
+	return true, nil
+}
+
+// copyingPaused waits for the next precopy or for the cutover, whichever comes first.
+func (r *Migrator) copyingPaused(vm *planapi.VMStatus) {
+	cutover := r.Migration.Spec.CutoverFor(vm.Ref)
+	reached := cutover != nil && !cutover.After(time.Now())
+	if reached || r.autoCutover(vm) {
+		if reached {
+			r.log.Info("Cutover time reached", "vm", vm.Name)
+		}
+		vm.Phase = api.PhaseStorePowerState
+		if step, found := vm.FindStep(DiskTransfer); found {
+			step.MarkCompleted()
+			step.Phase = api.StepCompleted
+		}
+		if step, found := vm.FindStep(Cutover); found {
+			step.MarkStarted()
+			step.Phase = api.StepRunning
+		}
+		return
+	}
+	if vm.Warm.NextPrecopyAt != nil && !vm.Warm.NextPrecopyAt.After(time.Now()) {
+		r.NextPhase(vm)
+	}
+}
+
+// autoCutover determines whether the precopies converged as defined by the
+// automatic cutover policy of the plan. The changes of the EBS snapshots are
+// not measured, only the duration of the precopies applies.
+func (r *Migrator) autoCutover(vm *planapi.VMStatus) bool {
+	policy := r.Plan.Spec.AutoCutover
+	if policy == nil || !policy.Converged(vm.Warm.Precopies) {
+		return false
+	}
+	open, err := policy.Open(time.Now())
+	if err != nil {
+		r.log.Error(err, "Automatic cutover windows not valid", "vm", vm.Name)
+		return false
+	}
+	if open {
+		r.log.Info("Precopies converged, starting the cutover", "vm", vm.Name)
+	}
+	return open
+}
+
+// copyChangedBlocks runs the delta copy pod writing the blocks changed since the previous
+// precopy into the populated PVCs. Once the pod succeeds, the snapshots of the previous
+// precopy are removed as they are no longer needed as a base.
+// Returns true when the copy has completed.
+func (r *Migrator) copyChangedBlocks(vm *planapi.VMStatus) (bool, error) {
+	ctx := context.TODO()
+	ec2Ensurer := r.getEnsurer()
+
+	stepName := r.Step(vm)
+	step, found := vm.FindStep(stepName)
+	if !found {
+		return false, liberr.New(fmt.Sprintf("Step '%s' not found", stepName))
+	}
+
+	n := len(vm.Warm.Precopies)
+	if n < 2 {
+		return false, liberr.New("no previous precopy to copy the changed blocks from", "vm", vm.Name)
+	}
+
+	pod, err := ec2Ensurer.GetDeltaCopyPod(ctx, vm, n)
+	if err != nil {
+		return false, liberr.Wrap(err)
+	}
+	if pod == nil {
+		disks, err := r.deltaCopyDisks(vm)
+		if err != nil {
+			return false, err
+		}
+		secretName, err := ec2Ensurer.EnsurePopulatorSecret(ctx, vm)
+		if err != nil {
+			r.log.Error(err, "Failed to create delta copy secret", "vm", vm.Name)
+			return false, liberr.Wrap(err)
+		}
+		err = ec2Ensurer.EnsureDeltaCopyPod(ctx, vm, n, disks, secretName)
+		if err != nil {
+			return false, liberr.Wrap(err)
+		}
+		r.resetPrecopyTasks(vm, step)
+		step.Phase = api.StepPending
+		step.Reason = "Waiting for the delta copy pod"
+		return false, nil
+	}
+
+	switch pod.Status.Phase {
+	case core.PodSucceeded:
+		for _, task := range step.Tasks {
+			task.Progress.Completed = task.Progress.Total
+			task.MarkCompleted()
+		}
+		step.Phase = api.StepRunning
+		step.Reason = ""
+		err = ec2Ensurer.DeleteDeltaCopyPods(ctx, vm)
+		if err != nil {
+			r.log.Error(err, "Failed to remove delta copy pods", "vm", vm.Name)
+		}
+		r.removePreviousSnapshots(vm)
+		r.completePrecopy(vm)
+		if vm.Phase == PhaseCopyFinalChangedBlocks {
+			step.Progress.Completed = step.Progress.Total
+		}
+		return true, nil
+	case core.PodFailed:
+		msg, ok := terminationMessage(pod)
+		if !ok {
+			msg = "Delta copy pod failed."
+		}
+		step.AddError(msg)
+	case core.PodRunning:
+		if len(pod.Status.ContainerStatuses) > 0 {
+			vm.Warm.Failures = int(pod.Status.ContainerStatuses[0].RestartCount)
+		}
+		if vm.Warm.Failures > settings.Settings.ImporterRetry {
+			msg, _ := terminationMessage(pod)
+			step.AddError(fmt.Sprintf("Delta copy pod restarted %d times: %s", vm.Warm.Failures, msg))
+			break
+		}
+		for _, task := range step.Tasks {
+			if !task.MarkedStarted() {
+				task.MarkStarted()
+			}
+			task.Phase = api.StepRunning
+		}
+		step.Phase = api.StepRunning
+		step.Reason = ""
+	default:
+		step.Phase = api.StepPending
+		step.Reason = "Waiting for the delta copy pod"
+	}
+	return false, nil
+}
+
+// deltaCopyDisks returns the disks of the delta copy pod, reading the snapshots of
+// the current precopy on top of the ones of the previous precopy.
+func (r *Migrator) deltaCopyDisks(vm *planapi.VMStatus) ([]deltacopy.Disk, error) {
+	ec2Builder, ok := r.builder.(*builder.Builder)
+	if !ok {
+		return nil, liberr.New("builder is not an EC2 builder")
+	}
+
+	n := len(vm.Warm.Precopies)
+	base := vm.Warm.Precopies[n-2].DeltaMap()
+	current := vm.Warm.Precopies[n-1].DeltaMap()
+
+	var disks []deltacopy.Disk
+	for volumeID, snapshotID := range current {
+		baseSnapshotID, found := base[volumeID]
+		if !found {
+			return nil, liberr.New("no snapshot of the volume in the previous precopy",
+				"vm", vm.Name,
+				"volume", volumeID)
+		}
+		disks = append(disks, deltacopy.Disk{
+			ID:           volumeID,
+			Snapshot:     snapshotID,
+			BaseSnapshot: baseSnapshotID,
+			Capacity:     ec2Builder.GetVolumeSize(volumeID, snapshotID) * 1024 * 1024 * 1024,
+		})
+	}
+	return disks, nil
+}
+
+// completePrecopy records the end of the current precopy and schedules the next one.
+func (r *Migrator) completePrecopy(vm *planapi.VMStatus) {
+	n := len(vm.Warm.Precopies)
+	if n == 0 {
+		return
+	}
+	now := meta.Now()
+	next := meta.NewTime(now.Add(time.Duration(settings.Settings.PrecopyInterval) * time.Minute))
+	vm.Warm.Precopies[n-1].End = &now
+	vm.Warm.NextPrecopyAt = &next
+	vm.Warm.Successes++
+
+	r.log.Info("Precopy completed",
+		"vm", vm.Name,
+		"precopy", n,
+		"nextPrecopyAt", next)
+}
+
+// removePreviousSnapshots removes the snapshots of the previous precopy once the changed
+// blocks of the current precopy have been copied. Errors are only logged, the remaining
+// snapshots are removed with the others at the end of the migration.
+func (r *Migrator) removePreviousSnapshots(vm *planapi.VMStatus) {
+	n := len(vm.Warm.Precopies)
+	if n < 2 {
+		return
+	}
+	precopy := &vm.Warm.Precopies[n-2]
+	taskID, err := r.adpClient.RemoveSnapshot(vm.Ref, precopy.Snapshot, nil)
+	precopy.RemoveTaskId = taskID
+	if err != nil {
+		r.log.Error(err, "Failed to remove previous precopy snapshots",
+			"vm", vm.Name,
+			"snapshots", precopy.Snapshot)
+	}
+}
+
+// currentPrecopy returns the precopy being transferred.
+func (r *Migrator) currentPrecopy(vm *planapi.VMStatus) (*planapi.Precopy, error) {
+	if vm.Warm == nil || len(vm.Warm.Precopies) == 0 {
+		return nil, liberr.New("no precopy recorded", "vm", vm.Name)
+	}
+	return &vm.Warm.Precopies[len(vm.Warm.Precopies)-1], nil
+}
+
+// resetPrecopyTasks restarts the progress of the step tasks for a new precopy.
+func (r *Migrator) resetPrecopyTasks(vm *planapi.VMStatus, step *planapi.Step) {
+	step.Completed = nil
+	for _, task := range step.Tasks {
+		if task.Annotations == nil {
+			task.Annotations = map[string]string{}
+		}
+		task.Annotations["Precopy"] = fmt.Sprintf("%v", len(vm.Warm.Precopies))
+		task.MarkReset()
+		task.MarkStarted()
+	}
+}
+
+// terminationMessage returns the message written by the last failed run of the pod.
+func terminationMessage(pod *core.Pod) (msg string, ok bool) {
+	if len(pod.Status.ContainerStatuses) > 0 &&
+		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated != nil &&
+		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.ExitCode > 0 {
+		msg = pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.Message
+		ok = true
+	}
+	return
+}
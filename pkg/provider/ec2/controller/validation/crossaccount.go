@@ -1,6 +1,8 @@
 package validation
 
 import (
+	"fmt"
+
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 	libcnd "github.com/kubev2v/forklift/pkg/lib/condition"
 	core "k8s.io/api/core/v1"
@@ -9,6 +11,7 @@ import (
 
 const (
 	TargetCredentialsMissing = "TargetCredentialsMissing"
+	TransferModeNotValid     = "TransferModeNotValid"
 	ValidationFailed         = "ValidationFailed"
 )
 
@@ -22,6 +25,29 @@ func (v *Validator) Validate(provider *api.Provider, secret *core.Secret, _ clie
 		return
 	}
 
+	mode := provider.Spec.Settings[api.EC2TransferMode]
+	if mode != "" && mode != api.EC2TransferModeVolume && mode != api.EC2TransferModeEBSDirect {
+		provider.Status.Phase = ValidationFailed
+		provider.Status.SetCondition(libcnd.Condition{
+			Type:     TransferModeNotValid,
+			Status:   libcnd.True,
+			Category: libcnd.Critical,
+			Reason:   "InvalidTransferMode",
+			Message: fmt.Sprintf(
+				"Invalid transferMode '%s'. Allowed values: '%s', '%s', or empty (defaults to %s).",
+				mode, api.EC2TransferModeVolume, api.EC2TransferModeEBSDirect, api.EC2TransferModeVolume),
+		})
+		return
+	}
+	provider.Status.DeleteCondition(TransferModeNotValid)
+
+	// The EBS direct APIs read the snapshots with the source credentials,
+	// no volume is created in the target account.
+	if provider.IsEC2EBSDirect() {
+		provider.Status.DeleteCondition(TargetCredentialsMissing)
+		return
+	}
+
 	_, hasKeyID := secret.Data["targetAccessKeyId"]
 	_, hasSecret := secret.Data["targetSecretAccessKey"]
 
@@ -4,11 +4,23 @@ import (
 	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
 )
 
-// MigrationType validates migration type. EC2 only supports cold migration (or empty/default).
-// Warm/live migration not supported - EC2 requires instance shutdown for consistent EBS snapshots.
+// MigrationType validates migration type. EC2 supports cold migration (or empty/default).
+// Warm migration is only supported in the EBS direct transfer mode, where the changed
+// blocks between successive snapshots are copied before the instance is stopped.
 func (r *Validator) MigrationType() bool {
-	if r.Context.Plan.Spec.Type == "" || r.Context.Plan.Spec.Type == api.MigrationCold {
+	switch r.Context.Plan.Spec.Type {
+	case "", api.MigrationCold:
 		return true
+	case api.MigrationWarm:
+		return r.WarmMigration()
+	default:
+		return false
 	}
-	return false
+}
+
+// WarmMigration returns whether the source provider transfers disks through the EBS
+// direct APIs, the only mode able to copy the changed blocks between snapshots.
+func (r *Validator) WarmMigration() bool {
+	provider := r.Context.Source.Provider
+	return provider != nil && provider.IsEC2EBSDirect()
 }
@@ -72,11 +72,6 @@ func (r *Validator) VMMigrationType(vmRef ref.Ref) (bool, error) {
 	return true, nil
 }
 
-// WarmMigration returns false - EC2 only supports cold migration with instance shutdown.
-func (r *Validator) WarmMigration() bool {
-	return false
-}
-
 // ConsolidationNeeded returns false - snapshot consolidation not applicable for EC2.
 func (r *Validator) ConsolidationNeeded(vmRef ref.Ref) (bool, error) {
 	return false, nil
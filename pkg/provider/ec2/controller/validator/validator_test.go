@@ -184,7 +184,30 @@ var _ = Describe("EC2 Controller Validator", func() {
 				Expect(validator.MigrationType()).To(BeFalse())
 			},
 			table.Entry("warm migration", api.MigrationWarm),
+			table.Entry("live migration", api.MigrationLive),
 		)
+
+		It("should return true for warm migration in the EBS direct transfer mode", func() {
+			provider := testutil.NewProviderBuilder().
+				WithType(api.EC2).
+				WithSetting(api.EC2TransferMode, api.EC2TransferModeEBSDirect).
+				Build()
+			validator.Context.Source.Provider = provider
+			validator.Context.Plan.Spec.Type = api.MigrationWarm
+			Expect(validator.WarmMigration()).To(BeTrue())
+			Expect(validator.MigrationType()).To(BeTrue())
+		})
+
+		It("should return false for warm migration in the volume transfer mode", func() {
+			provider := testutil.NewProviderBuilder().
+				WithType(api.EC2).
+				WithSetting(api.EC2TransferMode, api.EC2TransferModeVolume).
+				Build()
+			validator.Context.Source.Provider = provider
+			validator.Context.Plan.Spec.Type = api.MigrationWarm
+			Expect(validator.WarmMigration()).To(BeFalse())
+			Expect(validator.MigrationType()).To(BeFalse())
+		})
 	})
 
 	Describe("validateStorage", func() {
@@ -0,0 +1,120 @@
+// Package ebs reads EBS snapshots through the EBS direct APIs.
+//
+// The EBS direct APIs expose the blocks of a snapshot over HTTPS, which lets
+// a snapshot be copied into any writable volume rather than only into a new
+// EBS volume. This is what allows EC2 workloads to be migrated to clusters
+// that do not run in AWS.
+//
+// The request and response types mirror the ones of the AWS SDK ebs service
+// so that the API can be backed by the SDK client or by the fake used in
+// unit tests.
+package ebs
+
+import (
+	"context"
+	"io"
+)
+
+// Checksum algorithm of the block data.
+const ChecksumSHA256 = "SHA256"
+
+// API defines the EBS direct operations used to read snapshots.
+// This interface allows for mocking the EBS direct APIs in unit tests.
+type API interface {
+	// List the blocks written in a snapshot.
+	ListSnapshotBlocks(ctx context.Context, params *ListSnapshotBlocksInput) (*ListSnapshotBlocksOutput, error)
+	// List the blocks that differ between two snapshots of the same volume.
+	ListChangedBlocks(ctx context.Context, params *ListChangedBlocksInput) (*ListChangedBlocksOutput, error)
+	// Get the data of a snapshot block.
+	GetSnapshotBlock(ctx context.Context, params *GetSnapshotBlockInput) (*GetSnapshotBlockOutput, error)
+}
+
+// Block of a snapshot.
+type Block struct {
+	// Index of the block, the offset being the index times the block size.
+	BlockIndex *int32 `json:"BlockIndex"`
+	// Token used to get the data of the block.
+	BlockToken *string `json:"BlockToken"`
+}
+
+// ListSnapshotBlocksInput lists the blocks of a snapshot.
+type ListSnapshotBlocksInput struct {
+	// Snapshot ID.
+	SnapshotId *string
+	// Maximum number of blocks returned, 100 to 10000.
+	MaxResults *int32
+	// Token of the page to list.
+	NextToken *string
+	// Index of the block from which to list.
+	StartingBlockIndex *int32
+}
+
+// ListSnapshotBlocksOutput is a page of snapshot blocks.
+type ListSnapshotBlocksOutput struct {
+	// Blocks written in the snapshot, by ascending index.
+	Blocks []Block `json:"Blocks"`
+	// Size of the blocks in bytes.
+	BlockSize *int32 `json:"BlockSize"`
+	// Size of the volume in GiB.
+	VolumeSize *int64 `json:"VolumeSize"`
+	// Token of the next page, nil on the last page.
+	NextToken *string `json:"NextToken"`
+}
+
+// ChangedBlock differs between two snapshots.
+type ChangedBlock struct {
+	// Index of the block, the offset being the index times the block size.
+	BlockIndex *int32 `json:"BlockIndex"`
+	// Token of the block in the first snapshot, nil when not written in it.
+	FirstBlockToken *string `json:"FirstBlockToken"`
+	// Token of the block in the second snapshot, nil when not written in it.
+	SecondBlockToken *string `json:"SecondBlockToken"`
+}
+
+// ListChangedBlocksInput lists the blocks that differ between two snapshots.
+type ListChangedBlocksInput struct {
+	// ID of the earlier snapshot.
+	FirstSnapshotId *string
+	// ID of the later snapshot.
+	SecondSnapshotId *string
+	// Maximum number of blocks returned, 100 to 10000.
+	MaxResults *int32
+	// Token of the page to list.
+	NextToken *string
+	// Index of the block from which to list.
+	StartingBlockIndex *int32
+}
+
+// ListChangedBlocksOutput is a page of changed blocks.
+type ListChangedBlocksOutput struct {
+	// Changed blocks, by ascending index.
+	ChangedBlocks []ChangedBlock `json:"ChangedBlocks"`
+	// Size of the blocks in bytes.
+	BlockSize *int32 `json:"BlockSize"`
+	// Size of the volume in GiB.
+	VolumeSize *int64 `json:"VolumeSize"`
+	// Token of the next page, nil on the last page.
+	NextToken *string `json:"NextToken"`
+}
+
+// GetSnapshotBlockInput gets the data of a snapshot block.
+type GetSnapshotBlockInput struct {
+	// Snapshot ID.
+	SnapshotId *string
+	// Index of the block.
+	BlockIndex *int32
+	// Token of the block returned when listing the blocks.
+	BlockToken *string
+}
+
+// GetSnapshotBlockOutput is the data of a snapshot block.
+type GetSnapshotBlockOutput struct {
+	// Block data. Must be closed by the caller.
+	BlockData io.ReadCloser
+	// Length of the data in bytes.
+	DataLength *int32
+	// Base64 encoded checksum of the data.
+	Checksum *string
+	// Algorithm of the checksum.
+	ChecksumAlgorithm string
+}
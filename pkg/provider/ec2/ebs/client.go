@@ -0,0 +1,206 @@
+package ebs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+)
+
+// Signing name of the EBS direct APIs.
+const service = "ebs"
+
+// SHA-256 of an empty payload, all the requests being GETs.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Client calls the EBS direct APIs with SigV4 signed requests.
+// The client is safe for concurrent use and is not modified by the calls.
+type Client struct {
+	// AWS region.
+	Region string
+	// Credentials used to sign the requests.
+	Credentials aws.CredentialsProvider
+	// Endpoint, defaults to the regional EBS endpoint.
+	Endpoint string
+	// HTTP client.
+	HTTPClient *http.Client
+	// Request signer, shared by the concurrent requests.
+	Signer *v4.Signer
+}
+
+// NewFromConfig creates a client for the region and credentials of an AWS config.
+func NewFromConfig(cfg aws.Config) *Client {
+	return &Client{
+		Region:      cfg.Region,
+		Credentials: cfg.Credentials,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Minute},
+		Signer:      v4.NewSigner(),
+	}
+}
+
+// Compile-time check to ensure *Client implements API
+var _ API = (*Client)(nil)
+
+// ListSnapshotBlocks implements API.
+func (r *Client) ListSnapshotBlocks(ctx context.Context, params *ListSnapshotBlocksInput) (output *ListSnapshotBlocksOutput, err error) {
+	query := url.Values{}
+	if params.MaxResults != nil {
+		query.Set("maxResults", strconv.Itoa(int(*params.MaxResults)))
+	}
+	if params.NextToken != nil {
+		query.Set("pageToken", *params.NextToken)
+	}
+	if params.StartingBlockIndex != nil {
+		query.Set("startingBlockIndex", strconv.Itoa(int(*params.StartingBlockIndex)))
+	}
+	path := fmt.Sprintf("/snapshots/%s/blocks", url.PathEscape(aws.ToString(params.SnapshotId)))
+	response, err := r.get(ctx, path, query)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	output = &ListSnapshotBlocksOutput{}
+	err = json.NewDecoder(response.Body).Decode(output)
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", aws.ToString(params.SnapshotId))
+		output = nil
+	}
+	return
+}
+
+// ListChangedBlocks implements API.
+func (r *Client) ListChangedBlocks(ctx context.Context, params *ListChangedBlocksInput) (output *ListChangedBlocksOutput, err error) {
+	query := url.Values{}
+	if params.FirstSnapshotId != nil {
+		query.Set("firstSnapshotId", *params.FirstSnapshotId)
+	}
+	if params.MaxResults != nil {
+		query.Set("maxResults", strconv.Itoa(int(*params.MaxResults)))
+	}
+	if params.NextToken != nil {
+		query.Set("pageToken", *params.NextToken)
+	}
+	if params.StartingBlockIndex != nil {
+		query.Set("startingBlockIndex", strconv.Itoa(int(*params.StartingBlockIndex)))
+	}
+	path := fmt.Sprintf("/snapshots/%s/changedblocks", url.PathEscape(aws.ToString(params.SecondSnapshotId)))
+	response, err := r.get(ctx, path, query)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	output = &ListChangedBlocksOutput{}
+	err = json.NewDecoder(response.Body).Decode(output)
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", aws.ToString(params.SecondSnapshotId))
+		output = nil
+	}
+	return
+}
+
+// GetSnapshotBlock implements API.
+func (r *Client) GetSnapshotBlock(ctx context.Context, params *GetSnapshotBlockInput) (output *GetSnapshotBlockOutput, err error) {
+	query := url.Values{}
+	query.Set("blockToken", aws.ToString(params.BlockToken))
+	path := fmt.Sprintf(
+		"/snapshots/%s/blocks/%d",
+		url.PathEscape(aws.ToString(params.SnapshotId)),
+		aws.ToInt32(params.BlockIndex))
+	response, err := r.get(ctx, path, query)
+	if err != nil {
+		return
+	}
+	output = &GetSnapshotBlockOutput{
+		BlockData:         response.Body,
+		ChecksumAlgorithm: response.Header.Get("x-amz-Checksum-Algorithm"),
+	}
+	if checksum := response.Header.Get("x-amz-Checksum"); checksum != "" {
+		output.Checksum = aws.String(checksum)
+	}
+	if length := response.Header.Get("x-amz-Data-Length"); length != "" {
+		n, pErr := strconv.ParseInt(length, 10, 32)
+		if pErr != nil {
+			_ = response.Body.Close()
+			err = liberr.Wrap(pErr, "snapshot", aws.ToString(params.SnapshotId))
+			output = nil
+			return
+		}
+		output.DataLength = aws.Int32(int32(n))
+	}
+	return
+}
+
+// Send a signed GET request. The response body must be closed by the
+// caller when no error is returned.
+func (r *Client) get(ctx context.Context, path string, query url.Values) (response *http.Response, err error) {
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://ebs.%s.amazonaws.com", r.Region)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	request.URL.RawQuery = query.Encode()
+	credentials, err := r.Credentials.Retrieve(ctx)
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	signer := r.Signer
+	if signer == nil {
+		signer = v4.NewSigner()
+	}
+	err = signer.SignHTTP(ctx, credentials, request, emptyPayloadHash, service, r.Region, time.Now())
+	if err != nil {
+		err = liberr.Wrap(err)
+		return
+	}
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err = client.Do(request)
+	if err != nil {
+		err = liberr.Wrap(err, "path", path)
+		return
+	}
+	if response.StatusCode != http.StatusOK {
+		err = responseError(response)
+		_ = response.Body.Close()
+		response = nil
+	}
+	return
+}
+
+// Build the error of a failed request from the error document.
+func responseError(response *http.Response) error {
+	document := struct {
+		Message string `json:"Message"`
+		Reason  string `json:"Reason"`
+	}{}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+	_ = json.Unmarshal(body, &document)
+	code := response.Header.Get("x-amzn-ErrorType")
+	if document.Message == "" {
+		document.Message = http.StatusText(response.StatusCode)
+	}
+	return liberr.New(
+		fmt.Sprintf("EBS direct API request failed: %s", document.Message),
+		"status", response.StatusCode,
+		"code", code,
+		"reason", document.Reason)
+}
@@ -0,0 +1,218 @@
+package ebs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+	"golang.org/x/sync/errgroup"
+)
+
+// Defaults of the copy.
+const (
+	// Blocks listed per page, the maximum allowed by the API.
+	DefaultPageSize = 10000
+	// Blocks fetched concurrently.
+	DefaultWorkers = 16
+)
+
+// Progress is called after each page of blocks has been written with
+// the offset reached and the size of the volume, both in bytes.
+type Progress func(offset, size int64)
+
+// Copy writes the blocks of a snapshot into a volume.
+//
+// Only the blocks written in the snapshot are copied, the others are
+// left untouched. The volume must therefore read as zeros, as freshly
+// provisioned volumes and sparse image files do.
+//
+// When a base snapshot is set, the volume must instead hold the content
+// of the base snapshot and only the blocks that changed since the base
+// snapshot are copied. Blocks no longer written are zeroed.
+type Copy struct {
+	// EBS direct API.
+	API API
+	// Snapshot ID.
+	SnapshotID string
+	// Earlier snapshot of the same volume already copied into the volume.
+	BaseSnapshotID string
+	// Target volume.
+	Volume io.WriterAt
+	// Blocks fetched concurrently, defaults to DefaultWorkers.
+	Workers int
+	// Blocks listed per page, defaults to DefaultPageSize.
+	PageSize int32
+	// Called as the copy progresses.
+	Progress Progress
+}
+
+// Run the copy. Returns the number of bytes written.
+func (r *Copy) Run(ctx context.Context) (written int64, err error) {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	pageSize := r.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	var nextToken *string
+	for {
+		var page *ListSnapshotBlocksOutput
+		page, err = r.list(ctx, pageSize, nextToken)
+		if err != nil {
+			err = liberr.Wrap(err, "snapshot", r.SnapshotID, "base", r.BaseSnapshotID)
+			return
+		}
+		blockSize := int64(aws.ToInt32(page.BlockSize))
+		var n int64
+		n, err = r.writePage(ctx, page.Blocks, blockSize, workers)
+		written += n
+		if err != nil {
+			return
+		}
+		if r.Progress != nil {
+			size := aws.ToInt64(page.VolumeSize) * 1024 * 1024 * 1024
+			offset := size
+			if page.NextToken != nil && len(page.Blocks) > 0 {
+				last := page.Blocks[len(page.Blocks)-1]
+				offset = (int64(aws.ToInt32(last.BlockIndex)) + 1) * blockSize
+			}
+			r.Progress(offset, size)
+		}
+		if page.NextToken == nil || *page.NextToken == "" {
+			break
+		}
+		nextToken = page.NextToken
+	}
+	return
+}
+
+// List a page of the blocks to be copied. The blocks that changed since
+// the base snapshot are returned with their token in the snapshot, which
+// is nil for the blocks no longer written.
+func (r *Copy) list(ctx context.Context, pageSize int32, nextToken *string) (page *ListSnapshotBlocksOutput, err error) {
+	if r.BaseSnapshotID == "" {
+		page, err = r.API.ListSnapshotBlocks(ctx, &ListSnapshotBlocksInput{
+			SnapshotId: aws.String(r.SnapshotID),
+			MaxResults: aws.Int32(pageSize),
+			NextToken:  nextToken,
+		})
+		return
+	}
+	changed, err := r.API.ListChangedBlocks(ctx, &ListChangedBlocksInput{
+		FirstSnapshotId:  aws.String(r.BaseSnapshotID),
+		SecondSnapshotId: aws.String(r.SnapshotID),
+		MaxResults:       aws.Int32(pageSize),
+		NextToken:        nextToken,
+	})
+	if err != nil {
+		return
+	}
+	page = &ListSnapshotBlocksOutput{
+		BlockSize:  changed.BlockSize,
+		VolumeSize: changed.VolumeSize,
+		NextToken:  changed.NextToken,
+	}
+	for _, block := range changed.ChangedBlocks {
+		page.Blocks = append(page.Blocks, Block{
+			BlockIndex: block.BlockIndex,
+			BlockToken: block.SecondBlockToken,
+		})
+	}
+	return
+}
+
+// Write the blocks of a page concurrently.
+func (r *Copy) writePage(ctx context.Context, blocks []Block, blockSize int64, workers int) (written int64, err error) {
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	sizes := make([]int64, len(blocks))
+	for i := range blocks {
+		block := blocks[i]
+		i := i
+		group.Go(func() error {
+			n, wErr := r.writeBlock(ctx, block, blockSize)
+			sizes[i] = n
+			return wErr
+		})
+	}
+	err = group.Wait()
+	for _, n := range sizes {
+		written += n
+	}
+	return
+}
+
+// Fetch a block, verify its checksum and write it at its offset.
+// A block without token is zeroed.
+func (r *Copy) writeBlock(ctx context.Context, block Block, blockSize int64) (written int64, err error) {
+	index := aws.ToInt32(block.BlockIndex)
+	if block.BlockToken == nil {
+		n, wErr := r.Volume.WriteAt(make([]byte, blockSize), int64(index)*blockSize)
+		written = int64(n)
+		if wErr != nil {
+			err = liberr.Wrap(wErr, "snapshot", r.SnapshotID, "block", index)
+		}
+		return
+	}
+	output, err := r.API.GetSnapshotBlock(ctx, &GetSnapshotBlockInput{
+		SnapshotId: aws.String(r.SnapshotID),
+		BlockIndex: block.BlockIndex,
+		BlockToken: block.BlockToken,
+	})
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", r.SnapshotID, "block", index)
+		return
+	}
+	defer func() {
+		_ = output.BlockData.Close()
+	}()
+	data, err := io.ReadAll(output.BlockData)
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", r.SnapshotID, "block", index)
+		return
+	}
+	if output.DataLength != nil && int(*output.DataLength) != len(data) {
+		err = liberr.New(
+			fmt.Sprintf("block length %d does not match the data length %d", len(data), *output.DataLength),
+			"snapshot", r.SnapshotID,
+			"block", index)
+		return
+	}
+	err = verify(data, output.Checksum, output.ChecksumAlgorithm)
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", r.SnapshotID, "block", index)
+		return
+	}
+	n, err := r.Volume.WriteAt(data, int64(index)*blockSize)
+	written = int64(n)
+	if err != nil {
+		err = liberr.Wrap(err, "snapshot", r.SnapshotID, "block", index)
+	}
+	return
+}
+
+// Verify the checksum of the block data when one is returned.
+func verify(data []byte, checksum *string, algorithm string) error {
+	if checksum == nil {
+		return nil
+	}
+	if algorithm != ChecksumSHA256 {
+		return liberr.New("unsupported checksum algorithm", "algorithm", algorithm)
+	}
+	if Checksum(data) != *checksum {
+		return liberr.New("block checksum mismatch")
+	}
+	return nil
+}
+
+// Checksum of block data as returned by the API.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
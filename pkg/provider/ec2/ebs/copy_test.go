@@ -0,0 +1,182 @@
+package ebs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/ebs"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/testutil"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEBS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EC2 EBS Direct Suite")
+}
+
+// volume is an in-memory io.WriterAt.
+type volume struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (r *volume) WriteAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy(r.data[off:], p)
+	return len(p), nil
+}
+
+var _ = Describe("EBS direct copy", func() {
+	const snapshotID = "snap-0123"
+	const blockSize = testutil.EBSBlockSize
+
+	var (
+		fake   *testutil.FakeEC2API
+		target *volume
+	)
+
+	calls := func(method testutil.EC2Method) (n int) {
+		for _, call := range fake.Calls {
+			if call.Method == method {
+				n++
+			}
+		}
+		return
+	}
+
+	block := func(b byte) []byte {
+		return bytes.Repeat([]byte{b}, blockSize)
+	}
+
+	BeforeEach(func() {
+		fake = testutil.NewFakeEC2API()
+		fake.AddSnapshot(ec2types.Snapshot{
+			SnapshotId: aws.String(snapshotID),
+			VolumeSize: aws.Int32(1),
+		})
+		target = &volume{data: make([]byte, 1024*1024*1024)}
+	})
+
+	It("should write the snapshot blocks at their offsets", func() {
+		fake.AddSnapshotBlock(snapshotID, 0, block(1))
+		fake.AddSnapshotBlock(snapshotID, 3, block(2))
+		fake.AddSnapshotBlock(snapshotID, 7, block(3))
+
+		copier := &ebs.Copy{API: fake, SnapshotID: snapshotID, Volume: target, PageSize: 2}
+		written, err := copier.Run(context.Background())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(written).To(Equal(int64(3 * blockSize)))
+		Expect(target.data[0:blockSize]).To(Equal(block(1)))
+		Expect(target.data[blockSize : 3*blockSize]).To(Equal(make([]byte, 2*blockSize)))
+		Expect(target.data[3*blockSize : 4*blockSize]).To(Equal(block(2)))
+		Expect(target.data[7*blockSize : 8*blockSize]).To(Equal(block(3)))
+	})
+
+	It("should list the blocks page by page", func() {
+		for i := int32(0); i < 5; i++ {
+			fake.AddSnapshotBlock(snapshotID, i, block(byte(i+1)))
+		}
+
+		var offsets []int64
+		copier := &ebs.Copy{
+			API:        fake,
+			SnapshotID: snapshotID,
+			Volume:     target,
+			PageSize:   2,
+			Progress: func(offset, size int64) {
+				offsets = append(offsets, offset)
+				Expect(size).To(Equal(int64(1024 * 1024 * 1024)))
+			},
+		}
+		_, err := copier.Run(context.Background())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls(testutil.MethodListSnapshotBlocks)).To(Equal(3))
+		Expect(calls(testutil.MethodGetSnapshotBlock)).To(Equal(5))
+		Expect(offsets).To(Equal([]int64{2 * blockSize, 4 * blockSize, 1024 * 1024 * 1024}))
+	})
+
+	It("should succeed without writing anything for an empty snapshot", func() {
+		copier := &ebs.Copy{API: fake, SnapshotID: snapshotID, Volume: target}
+		written, err := copier.Run(context.Background())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(written).To(BeZero())
+	})
+
+	It("should fail when a block cannot be read", func() {
+		fake.AddSnapshotBlock(snapshotID, 0, block(1))
+		fake.Errors[testutil.MethodGetSnapshotBlock] = errors.New("throttled")
+
+		copier := &ebs.Copy{API: fake, SnapshotID: snapshotID, Volume: target}
+		_, err := copier.Run(context.Background())
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("throttled"))
+	})
+
+	Context("with a base snapshot", func() {
+		const baseID = "snap-0122"
+
+		BeforeEach(func() {
+			fake.AddSnapshot(ec2types.Snapshot{
+				SnapshotId: aws.String(baseID),
+				VolumeSize: aws.Int32(1),
+			})
+			fake.AddSnapshotBlock(baseID, 0, block(1))
+			fake.AddSnapshotBlock(baseID, 1, block(2))
+			fake.AddSnapshotBlock(baseID, 2, block(3))
+			copy(target.data, block(1))
+			copy(target.data[blockSize:], block(2))
+			copy(target.data[2*blockSize:], block(3))
+		})
+
+		It("should copy only the changed blocks", func() {
+			fake.AddSnapshotBlock(snapshotID, 0, block(1))
+			fake.AddSnapshotBlock(snapshotID, 1, block(9))
+			fake.AddSnapshotBlock(snapshotID, 2, block(3))
+			fake.AddSnapshotBlock(snapshotID, 5, block(4))
+
+			copier := &ebs.Copy{API: fake, SnapshotID: snapshotID, BaseSnapshotID: baseID, Volume: target}
+			written, err := copier.Run(context.Background())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(written).To(Equal(int64(2 * blockSize)))
+			Expect(calls(testutil.MethodListSnapshotBlocks)).To(BeZero())
+			Expect(calls(testutil.MethodGetSnapshotBlock)).To(Equal(2))
+			Expect(target.data[0:blockSize]).To(Equal(block(1)))
+			Expect(target.data[blockSize : 2*blockSize]).To(Equal(block(9)))
+			Expect(target.data[2*blockSize : 3*blockSize]).To(Equal(block(3)))
+			Expect(target.data[5*blockSize : 6*blockSize]).To(Equal(block(4)))
+		})
+
+		It("should zero the blocks no longer written", func() {
+			fake.AddSnapshotBlock(snapshotID, 0, block(1))
+			fake.AddSnapshotBlock(snapshotID, 2, block(3))
+
+			copier := &ebs.Copy{API: fake, SnapshotID: snapshotID, BaseSnapshotID: baseID, Volume: target}
+			written, err := copier.Run(context.Background())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(written).To(Equal(int64(blockSize)))
+			Expect(calls(testutil.MethodGetSnapshotBlock)).To(BeZero())
+			Expect(target.data[blockSize : 2*blockSize]).To(Equal(make([]byte, blockSize)))
+			Expect(target.data[2*blockSize : 3*blockSize]).To(Equal(block(3)))
+		})
+	})
+
+	It("should fail when the snapshot does not exist", func() {
+		copier := &ebs.Copy{API: fake, SnapshotID: "snap-missing", Volume: target}
+		_, err := copier.Run(context.Background())
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -95,7 +95,7 @@ func (h Handler) ListOptions(ctx *gin.Context) libmodel.ListOptions {
 		detail = model.MaxDetail
 	}
 	return libmodel.ListOptions{
-		Predicate: h.Predicate(ctx),
+		Predicate: h.WithQuery(h.Predicate(ctx)),
 		Detail:    detail,
 		Page:      &h.Page,
 	}
@@ -109,7 +109,7 @@ func (h Handler) ListOptionsWithLabels(ctx *gin.Context) libmodel.ListOptions {
 	}
 
 	return libmodel.ListOptions{
-		Predicate: h.PredicateWithLabels(ctx),
+		Predicate: h.WithQuery(h.PredicateWithLabels(ctx)),
 		Detail:    detail,
 		Page:      &h.Page,
 	}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	api "github.com/kubev2v/forklift/pkg/apis/forklift/v1beta1"
+	"github.com/kubev2v/forklift/pkg/controller/provider/web/base"
+	libmodel "github.com/kubev2v/forklift/pkg/lib/inventory/model"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/inventory/model"
+)
+
+// Search the instances.
+// The instance ID is matched as the UUID.
+// See: base.Searcher.
+func Search(provider *api.Provider, db libmodel.DB, term base.SearchTerm) (hits []base.SearchHit, err error) {
+	var list []model.Instance
+	err = db.List(&list, libmodel.ListOptions{Detail: model.MaxDetail})
+	if err != nil {
+		return
+	}
+	for i := range list {
+		m := &list[i]
+		c := base.SearchCandidate{
+			Name:  m.Name,
+			UUIDs: []string{m.ID},
+		}
+		for _, ip := range []*string{m.Object.PrivateIpAddress, m.Object.PublicIpAddress} {
+			if ip != nil {
+				c.IPs = append(c.IPs, *ip)
+			}
+		}
+		for _, nic := range m.Object.NetworkInterfaces {
+			if nic.MacAddress != nil {
+				c.MACs = append(c.MACs, *nic.MacAddress)
+			}
+			for _, address := range nic.PrivateIpAddresses {
+				if address.PrivateIpAddress != nil {
+					c.IPs = append(c.IPs, *address.PrivateIpAddress)
+				}
+			}
+		}
+		field, matched := term.Match(c)
+		if !matched {
+			continue
+		}
+		hits = append(
+			hits,
+			base.SearchHit{
+				Kind:    "VM",
+				ID:      m.ID,
+				Name:    m.Name,
+				Matched: field,
+				SelfLink: base.Link(
+					VMRoot,
+					base.Params{
+						base.ProviderParam: string(provider.UID),
+						VMParam:            m.ID,
+					}),
+			})
+	}
+
+	return
+}
@@ -46,7 +46,7 @@ func (h *SnapshotHandler) List(ctx *gin.Context) {
 	err = db.List(&list, listOptions)
 	if err != nil {
 		log.Error(err, "Failed to list snapshots")
-		ctx.Status(http.StatusInternalServerError)
+		base.SetListError(ctx, err)
 		return
 	}
 
@@ -45,7 +45,7 @@ func (h *StorageHandler) List(ctx *gin.Context) {
 	err = db.List(&list, h.ListOptions(ctx))
 	if err != nil {
 		log.Error(err, "Failed to list storage types")
-		ctx.Status(http.StatusInternalServerError)
+		base.SetListError(ctx, err)
 		return
 	}
 
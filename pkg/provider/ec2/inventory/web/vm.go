@@ -54,7 +54,7 @@ func (h *VMHandler) List(ctx *gin.Context) {
 	err = db.List(&list, listOptions)
 	if err != nil {
 		log.Error(err, "Failed to list instances")
-		ctx.Status(http.StatusInternalServerError)
+		base.SetListError(ctx, err)
 		return
 	}
 
@@ -3,14 +3,19 @@
 package testutil
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	controllerclient "github.com/kubev2v/forklift/pkg/provider/ec2/controller/client"
+	"github.com/kubev2v/forklift/pkg/provider/ec2/ebs"
 	inventoryclient "github.com/kubev2v/forklift/pkg/provider/ec2/inventory/client"
 )
 
@@ -32,10 +37,17 @@ const (
 	MethodDescribeVpcs            EC2Method = "DescribeVpcs"
 	MethodDescribeSubnets         EC2Method = "DescribeSubnets"
 	MethodDescribeSecurityGroups  EC2Method = "DescribeSecurityGroups"
+	MethodListSnapshotBlocks      EC2Method = "ListSnapshotBlocks"
+	MethodGetSnapshotBlock        EC2Method = "GetSnapshotBlock"
+	MethodListChangedBlocks       EC2Method = "ListChangedBlocks"
 )
 
+// EBSBlockSize is the size of the snapshot blocks served by the fake EBS direct API.
+const EBSBlockSize = 512 * 1024
+
 // FakeEC2API is a fake implementation of the EC2 API for testing.
-// It implements both controller/client.EC2API and inventory/client.EC2API interfaces.
+// It implements the controller/client.EC2API and inventory/client.EC2API interfaces,
+// and the ebs.API interface of the EBS direct APIs.
 // It stores state in memory and allows error injection for testing error handling.
 type FakeEC2API struct {
 	mu sync.Mutex
@@ -51,6 +63,9 @@ type FakeEC2API struct {
 	// Snapshot sharing permissions: snapshotID -> []accountID
 	SnapshotPermissions map[string][]string
 
+	// Snapshot block data served by the EBS direct APIs: snapshotID -> block index -> data
+	SnapshotBlocks map[string]map[int32][]byte
+
 	// Error injection - map of method to error
 	// Example: fake.Errors[MethodCreateSnapshot] = errors.New("failed")
 	Errors map[EC2Method]error
@@ -78,14 +93,16 @@ func NewFakeEC2API() *FakeEC2API {
 		Subnets:             make(map[string]ec2types.Subnet),
 		SecurityGroups:      make(map[string]ec2types.SecurityGroup),
 		SnapshotPermissions: make(map[string][]string),
+		SnapshotBlocks:      make(map[string]map[int32][]byte),
 		Errors:              make(map[EC2Method]error),
 		Calls:               []APICall{},
 	}
 }
 
-// Compile-time checks to ensure FakeEC2API implements both interfaces
+// Compile-time checks to ensure FakeEC2API implements the interfaces
 var _ controllerclient.EC2API = (*FakeEC2API)(nil)
 var _ inventoryclient.EC2API = (*FakeEC2API)(nil)
+var _ ebs.API = (*FakeEC2API)(nil)
 
 // recordCall records an API call for later verification.
 func (f *FakeEC2API) recordCall(method EC2Method, input interface{}) {
@@ -109,6 +126,7 @@ func (f *FakeEC2API) Reset() {
 	f.Subnets = make(map[string]ec2types.Subnet)
 	f.SecurityGroups = make(map[string]ec2types.SecurityGroup)
 	f.SnapshotPermissions = make(map[string][]string)
+	f.SnapshotBlocks = make(map[string]map[int32][]byte)
 	f.Errors = make(map[EC2Method]error)
 	f.Calls = []APICall{}
 	f.idCounter = 0
@@ -141,6 +159,16 @@ func (f *FakeEC2API) AddSnapshot(snapshot ec2types.Snapshot) {
 	}
 }
 
+// AddSnapshotBlock adds a written block to a snapshot, served by the EBS direct APIs.
+func (f *FakeEC2API) AddSnapshotBlock(snapshotID string, index int32, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.SnapshotBlocks[snapshotID] == nil {
+		f.SnapshotBlocks[snapshotID] = make(map[int32][]byte)
+	}
+	f.SnapshotBlocks[snapshotID][index] = data
+}
+
 // AddVpc adds a VPC to the fake state.
 func (f *FakeEC2API) AddVpc(vpc ec2types.Vpc) {
 	f.mu.Lock()
@@ -601,8 +629,171 @@ func (f *FakeEC2API) DescribeSecurityGroups(ctx context.Context, params *ec2.Des
 	}, nil
 }
 
+// ListSnapshotBlocks implements ebs.API.
+// Blocks are listed by ascending index, the page token being the position of the next block.
+func (f *FakeEC2API) ListSnapshotBlocks(ctx context.Context, params *ebs.ListSnapshotBlocksInput) (*ebs.ListSnapshotBlocksOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recordCall(MethodListSnapshotBlocks, params)
+
+	if err := f.getError(MethodListSnapshotBlocks); err != nil {
+		return nil, err
+	}
+
+	snapshotID := aws.ToString(params.SnapshotId)
+	snapshot, ok := f.Snapshots[snapshotID]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: The snapshot '%s' does not exist", snapshotID)
+	}
+
+	blocks := f.SnapshotBlocks[snapshotID]
+	indexes := make([]int32, 0, len(blocks))
+	for index := range blocks {
+		if params.StartingBlockIndex == nil || index >= *params.StartingBlockIndex {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	start := 0
+	if params.NextToken != nil {
+		var err error
+		start, err = strconv.Atoi(*params.NextToken)
+		if err != nil || start > len(indexes) {
+			return nil, fmt.Errorf("ValidationException: invalid page token '%s'", *params.NextToken)
+		}
+	}
+	end := len(indexes)
+	if params.MaxResults != nil && start+int(*params.MaxResults) < end {
+		end = start + int(*params.MaxResults)
+	}
+
+	output := &ebs.ListSnapshotBlocksOutput{
+		BlockSize:  aws.Int32(EBSBlockSize),
+		VolumeSize: aws.Int64(int64(aws.ToInt32(snapshot.VolumeSize))),
+	}
+	for _, index := range indexes[start:end] {
+		output.Blocks = append(output.Blocks, ebs.Block{
+			BlockIndex: aws.Int32(index),
+			BlockToken: aws.String(blockToken(snapshotID, index)),
+		})
+	}
+	if end < len(indexes) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+
+	return output, nil
+}
+
+// ListChangedBlocks implements ebs.API.
+// A block is changed when it is written in only one of the snapshots or
+// when its data differs, the page token being the position of the next block.
+func (f *FakeEC2API) ListChangedBlocks(ctx context.Context, params *ebs.ListChangedBlocksInput) (*ebs.ListChangedBlocksOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recordCall(MethodListChangedBlocks, params)
+
+	if err := f.getError(MethodListChangedBlocks); err != nil {
+		return nil, err
+	}
+
+	firstID := aws.ToString(params.FirstSnapshotId)
+	if _, ok := f.Snapshots[firstID]; !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: The snapshot '%s' does not exist", firstID)
+	}
+	secondID := aws.ToString(params.SecondSnapshotId)
+	snapshot, ok := f.Snapshots[secondID]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException: The snapshot '%s' does not exist", secondID)
+	}
+
+	first := f.SnapshotBlocks[firstID]
+	second := f.SnapshotBlocks[secondID]
+	changed := make(map[int32]bool)
+	for index, data := range first {
+		if other, found := second[index]; !found || !bytes.Equal(data, other) {
+			changed[index] = true
+		}
+	}
+	for index := range second {
+		if _, found := first[index]; !found {
+			changed[index] = true
+		}
+	}
+	indexes := make([]int32, 0, len(changed))
+	for index := range changed {
+		if params.StartingBlockIndex == nil || index >= *params.StartingBlockIndex {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	start := 0
+	if params.NextToken != nil {
+		var err error
+		start, err = strconv.Atoi(*params.NextToken)
+		if err != nil || start > len(indexes) {
+			return nil, fmt.Errorf("ValidationException: invalid page token '%s'", *params.NextToken)
+		}
+	}
+	end := len(indexes)
+	if params.MaxResults != nil && start+int(*params.MaxResults) < end {
+		end = start + int(*params.MaxResults)
+	}
+
+	output := &ebs.ListChangedBlocksOutput{
+		BlockSize:  aws.Int32(EBSBlockSize),
+		VolumeSize: aws.Int64(int64(aws.ToInt32(snapshot.VolumeSize))),
+	}
+	for _, index := range indexes[start:end] {
+		block := ebs.ChangedBlock{BlockIndex: aws.Int32(index)}
+		if _, found := first[index]; found {
+			block.FirstBlockToken = aws.String(blockToken(firstID, index))
+		}
+		if _, found := second[index]; found {
+			block.SecondBlockToken = aws.String(blockToken(secondID, index))
+		}
+		output.ChangedBlocks = append(output.ChangedBlocks, block)
+	}
+	if end < len(indexes) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+
+	return output, nil
+}
+
+// GetSnapshotBlock implements ebs.API.
+func (f *FakeEC2API) GetSnapshotBlock(ctx context.Context, params *ebs.GetSnapshotBlockInput) (*ebs.GetSnapshotBlockOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recordCall(MethodGetSnapshotBlock, params)
+
+	if err := f.getError(MethodGetSnapshotBlock); err != nil {
+		return nil, err
+	}
+
+	snapshotID := aws.ToString(params.SnapshotId)
+	index := aws.ToInt32(params.BlockIndex)
+	data, ok := f.SnapshotBlocks[snapshotID][index]
+	if !ok || aws.ToString(params.BlockToken) != blockToken(snapshotID, index) {
+		return nil, fmt.Errorf("ResourceNotFoundException: block %d of snapshot '%s' not found", index, snapshotID)
+	}
+
+	return &ebs.GetSnapshotBlockOutput{
+		BlockData:         io.NopCloser(bytes.NewReader(data)),
+		DataLength:        aws.Int32(int32(len(data))),
+		Checksum:          aws.String(ebs.Checksum(data)),
+		ChecksumAlgorithm: ebs.ChecksumSHA256,
+	}, nil
+}
+
 // Helper functions
 
+// blockToken returns the token of a snapshot block.
+func blockToken(snapshotID string, index int32) string {
+	return fmt.Sprintf("%s-%d", snapshotID, index)
+}
+
 // generateID generates a unique ID for this fake instance.
 // Must be called while holding f.mu lock.
 func (f *FakeEC2API) generateID() string {
@@ -29,6 +29,7 @@ const (
 	TLSCertificate = "API_TLS_CERTIFICATE"
 	TLSKey         = "API_TLS_KEY"
 	TLSCa          = "API_TLS_CA"
+	Persistent     = "INVENTORY_PERSISTENT"
 )
 
 // CORS
@@ -43,6 +44,8 @@ type Inventory struct {
 	CORS CORS
 	// DB working directory.
 	WorkingDir string
+	// DB files are kept across restarts.
+	Persistent bool
 	// Authorization required.
 	AuthRequired bool
 	// Host.
@@ -84,6 +87,8 @@ func (r *Inventory) Load() error {
 	} else {
 		r.WorkingDir = os.TempDir()
 	}
+	// Persistent
+	r.Persistent = getEnvBool(Persistent, false)
 	// Auth
 	r.AuthRequired = getEnvBool(AuthRequired, true)
 	// Host
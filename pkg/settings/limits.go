@@ -0,0 +1,89 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	liberr "github.com/kubev2v/forklift/pkg/lib/error"
+)
+
+// Keyed limits.
+// A default limit with overrides by key. A limit of 0 is unlimited.
+// Format: "<default>,<key>=<limit>,..." for example: "4,datastore1=2,fast=8".
+// The default may be omitted and is 0 (unlimited).
+type Limits struct {
+	// Limit applied when no key matched.
+	Default int
+	// Limits by key.
+	Keyed map[string]int
+}
+
+// Limit for the first matched key.
+func (r Limits) Limit(keys ...string) int {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if n, found := r.Keyed[key]; found {
+			return n
+		}
+	}
+	return r.Default
+}
+
+// Unlimited when no limit has been set.
+func (r Limits) Unlimited() bool {
+	if r.Default > 0 {
+		return false
+	}
+	for _, n := range r.Keyed {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse limits.
+func ParseLimits(s string) (limits Limits, err error) {
+	limits.Keyed = make(map[string]int)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := ""
+		value := field
+		if i := strings.LastIndex(field, "="); i != -1 {
+			key = strings.TrimSpace(field[:i])
+			value = strings.TrimSpace(field[i+1:])
+			if key == "" {
+				err = liberr.New(fmt.Sprintf("limit %q: key is empty", field))
+				return
+			}
+		}
+		n, pErr := strconv.Atoi(value)
+		if pErr != nil || n < 0 {
+			err = liberr.New(fmt.Sprintf("limit %q: must be a non-negative integer", field))
+			return
+		}
+		if key == "" {
+			limits.Default = n
+		} else {
+			limits.Keyed[key] = n
+		}
+	}
+
+	return
+}
+
+// Get keyed limits from the environment.
+func getEnvLimits(name string) (limits Limits, err error) {
+	limits, err = ParseLimits(os.Getenv(name))
+	if err != nil {
+		err = liberr.Wrap(err, "name", name)
+	}
+	return
+}
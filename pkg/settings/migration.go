@@ -15,6 +15,9 @@ import (
 const (
 	MaxVmInFlight                          = "MAX_VM_INFLIGHT"
 	MaxPopulatorInFlight                   = "MAX_POPULATOR_INFLIGHT"
+	MaxDatastoreDisksInFlight              = "MAX_DATASTORE_DISKS_INFLIGHT"
+	MaxStorageClassDisksInFlight           = "MAX_STORAGE_CLASS_DISKS_INFLIGHT"
+	MaxBytesInFlight                       = "MAX_BYTES_INFLIGHT"
 	HookRetry                              = "HOOK_RETRY"
 	ImporterRetry                          = "IMPORTER_RETRY"
 	VirtV2vImage                           = "VIRT_V2V_IMAGE"
@@ -93,6 +96,12 @@ const DefaultStaleConversionTimeoutSeconds = 180
 type Migration struct {
 	// Max VMs in-flight.
 	MaxInFlight int
+	// Max disks in-flight per source datastore.
+	MaxDatastoreDisksInFlight Limits
+	// Max disks in-flight per target storage class.
+	MaxStorageClassDisksInFlight Limits
+	// Max bytes of disk in-flight. 0 is unlimited.
+	MaxBytesInFlight int64
 	// Hook fail/retry limit.
 	HookRetry int
 	// Importer pod retry limit.
@@ -210,6 +219,20 @@ func (r *Migration) Load() (err error) {
 	if r.MaxInFlight, err = getPositiveEnvLimit(MaxVmInFlight, 20); err != nil {
 		return liberr.Wrap(err)
 	}
+	if r.MaxDatastoreDisksInFlight, err = getEnvLimits(MaxDatastoreDisksInFlight); err != nil {
+		return liberr.Wrap(err)
+	}
+	if r.MaxStorageClassDisksInFlight, err = getEnvLimits(MaxStorageClassDisksInFlight); err != nil {
+		return liberr.Wrap(err)
+	}
+	r.MaxBytesInFlight = 0
+	if val, found := os.LookupEnv(MaxBytesInFlight); found && strings.TrimSpace(val) != "" {
+		quantity, pErr := resource.ParseQuantity(strings.TrimSpace(val))
+		if pErr != nil || quantity.Sign() < 0 {
+			return liberr.New(MaxBytesInFlight + " must be a non-negative quantity")
+		}
+		r.MaxBytesInFlight = quantity.Value()
+	}
 	if r.HookRetry, err = getPositiveEnvLimit(HookRetry, 3); err != nil {
 		return liberr.Wrap(err)
 	}
@@ -469,3 +469,66 @@ func TestMigration_XfsAndStandardImages(t *testing.T) {
 		}
 	})
 }
+
+// TestMigration_InFlightLimits tests the parsing of the datastore,
+// storage class and byte limits.
+func TestMigration_InFlightLimits(t *testing.T) {
+	t.Run("unset is unlimited", func(t *testing.T) {
+		t.Setenv(Roles, InventoryRole)
+
+		var migration Migration
+		err := migration.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !migration.MaxDatastoreDisksInFlight.Unlimited() {
+			t.Errorf("MaxDatastoreDisksInFlight = %v, want unlimited", migration.MaxDatastoreDisksInFlight)
+		}
+		if !migration.MaxStorageClassDisksInFlight.Unlimited() {
+			t.Errorf("MaxStorageClassDisksInFlight = %v, want unlimited", migration.MaxStorageClassDisksInFlight)
+		}
+		if migration.MaxBytesInFlight != 0 {
+			t.Errorf("MaxBytesInFlight = %d, want 0", migration.MaxBytesInFlight)
+		}
+	})
+
+	t.Run("keyed limits", func(t *testing.T) {
+		t.Setenv(Roles, InventoryRole)
+		t.Setenv(MaxDatastoreDisksInFlight, "4, datastore-12=2 ,nfs-slow=1")
+		t.Setenv(MaxStorageClassDisksInFlight, "ceph-rbd=8")
+		t.Setenv(MaxBytesInFlight, "2Ti")
+
+		var migration Migration
+		err := migration.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		tests := []struct {
+			limits Limits
+			keys   []string
+			want   int
+		}{
+			{migration.MaxDatastoreDisksInFlight, []string{"datastore-12", "fast"}, 2},
+			{migration.MaxDatastoreDisksInFlight, []string{"datastore-13", "nfs-slow"}, 1},
+			{migration.MaxDatastoreDisksInFlight, []string{"datastore-14", "fast"}, 4},
+			{migration.MaxStorageClassDisksInFlight, []string{"ceph-rbd"}, 8},
+			{migration.MaxStorageClassDisksInFlight, []string{"gp3"}, 0},
+		}
+		for _, tt := range tests {
+			if got := tt.limits.Limit(tt.keys...); got != tt.want {
+				t.Errorf("Limit(%v) = %d, want %d", tt.keys, got, tt.want)
+			}
+		}
+		if migration.MaxBytesInFlight != 2<<40 {
+			t.Errorf("MaxBytesInFlight = %d, want %d", migration.MaxBytesInFlight, int64(2<<40))
+		}
+	})
+
+	t.Run("not valid", func(t *testing.T) {
+		for _, value := range []string{"x", "ds=-1", "=2"} {
+			if _, err := ParseLimits(value); err == nil {
+				t.Errorf("ParseLimits(%q) expected error", value)
+			}
+		}
+	})
+}
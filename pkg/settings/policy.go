@@ -11,12 +11,16 @@ const (
 	PolicyAgentCA             = "POLICY_AGENT_CA"
 	PolicyAgentWorkerLimit    = "POLICY_AGENT_WORKER_LIMIT"
 	PolicyAgentSearchInterval = "POLICY_AGENT_SEARCH_INTERVAL"
+	PolicyAgentEmbedded       = "POLICY_AGENT_EMBEDDED"
 )
 
 // Policy agent settings.
 type PolicyAgent struct {
 	// URL.
 	URL string
+	// Evaluate the policies in-process
+	// instead of using the policy agent.
+	Embedded bool
 	// TLS
 	TLS struct {
 		// CA path
@@ -36,6 +40,7 @@ func (r *PolicyAgent) Load() (err error) {
 	if s, found := os.LookupEnv(PolicyAgentURL); found {
 		r.URL = s
 	}
+	r.Embedded = getEnvBool(PolicyAgentEmbedded, false)
 	// TLS
 	if s, found := os.LookupEnv(PolicyAgentCA); found {
 		r.TLS.CA = s
@@ -56,5 +61,5 @@ func (r *PolicyAgent) Load() (err error) {
 
 // Enabled.
 func (r *PolicyAgent) Enabled() bool {
-	return r.URL != ""
+	return r.URL != "" || r.Embedded
 }
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/vmware/govmomi/vim25/types"
@@ -92,6 +93,35 @@ func DiskBackingFromDevices(devices []types.BaseVirtualDevice, diskFile string)
 	return &DiskBacking{}, nil
 }
 
+// NAAFromDeviceName returns the lowercase NAA identifier, without the "naa." prefix,
+// of an RDM device name in NAA ("naa.<hex>") or VML ("vml.<hex>") format.
+// In the VML format the NAA identifier is embedded at hex positions 10–42.
+func NAAFromDeviceName(deviceName string) (string, error) {
+	deviceName = strings.ToLower(strings.TrimSpace(deviceName))
+	var naa string
+	switch {
+	case strings.HasPrefix(deviceName, "naa."):
+		naa = strings.TrimPrefix(deviceName, "naa.")
+	case strings.HasPrefix(deviceName, "vml."):
+		vml := strings.TrimPrefix(deviceName, "vml.")
+		if len(vml) < 42 {
+			return "", fmt.Errorf("VML string too short to contain NAA: %s", deviceName)
+		}
+		naa = vml[10:42]
+	default:
+		return "", fmt.Errorf("device %s is neither in NAA nor in VML format", deviceName)
+	}
+	if len(naa) != 32 || !strings.HasPrefix(naa, "6") {
+		return "", fmt.Errorf("device %s does not contain an NAA type 6 identifier", deviceName)
+	}
+	for _, c := range naa {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", fmt.Errorf("device %s does not contain a hexadecimal NAA identifier", deviceName)
+		}
+	}
+	return naa, nil
+}
+
 func diskPathMatches(path1, path2 string) bool {
 	normalize := func(p string) string {
 		p = strings.TrimSpace(p)
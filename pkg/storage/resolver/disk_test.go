@@ -46,3 +46,60 @@ func TestDiskBackingClassify(t *testing.T) {
 		})
 	}
 }
+
+func TestNAAFromDeviceName(t *testing.T) {
+	tests := []struct {
+		name       string
+		deviceName string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "NAA",
+			deviceName: "naa.624a9370b3c9a7f2e8d14a6b00011a2f",
+			want:       "624a9370b3c9a7f2e8d14a6b00011a2f",
+		},
+		{
+			name:       "uppercase NAA",
+			deviceName: "naa.600507681081804CB800000000001074",
+			want:       "600507681081804cb800000000001074",
+		},
+		{
+			name:       "VML",
+			deviceName: "vml.0200010000624a9370b3c9a7f2e8d14a6b00011a2f466c61736841",
+			want:       "624a9370b3c9a7f2e8d14a6b00011a2f",
+		},
+		{
+			name:       "VML too short",
+			deviceName: "vml.0200010000624a9370",
+			wantErr:    true,
+		},
+		{
+			name:       "EUI",
+			deviceName: "eui.0025385b71b0a2c1",
+			wantErr:    true,
+		},
+		{
+			name:       "not NAA type 6",
+			deviceName: "naa.5000c500a1b2c3d4e5f60718293a4b5c",
+			wantErr:    true,
+		},
+		{
+			name:       "not hexadecimal",
+			deviceName: "naa.624a9370b3c9a7f2e8d14a6b0001zzzz",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NAAFromDeviceName(tt.deviceName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NAAFromDeviceName(%q) error = %v, wantErr %v", tt.deviceName, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NAAFromDeviceName(%q) = %q, want %q", tt.deviceName, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package flashsystem
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"k8s.io/klog/v2"
+)
+
+// storageType is the array type of the IBM block storage CSI driver for FlashSystem and SVC.
+const storageType = "SVC"
+
+// FlashSystemImporter implements CsiImportPlugin for IBM FlashSystem via the REST API.
+// The IBM block storage CSI driver adopts an existing volume through a static
+// PersistentVolume whose volume handle is "SVC:<vdisk ID>;<vdisk UID>", with the
+// array address, pool and volume name as volume attributes.
+type FlashSystemImporter struct {
+	baseURL string // e.g. "https://flashsystem.example.com:7443"
+	user    string
+	pass    string
+	client  *http.Client
+}
+
+func NewFlashSystemImporter(host, user, pass string, skipSSL bool) (*FlashSystemImporter, error) {
+	parsedURL, err := url.Parse(host)
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("STORAGE_HOSTNAME must be a full URL with scheme (e.g. https://host:7443), got %q", host)
+	}
+
+	return &FlashSystemImporter{
+		baseURL: strings.TrimRight(host, "/"),
+		user:    user,
+		pass:    pass,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSL}, //nolint:gosec
+			},
+		},
+	}, nil
+}
+
+// vdisk is a volume as listed by lsvdisk.
+type vdisk struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	UID      string `json:"vdisk_UID"`
+	PoolName string `json:"mdisk_grp_name"`
+}
+
+// Resolve returns the PVC annotations with the CSI volume handle and attributes of the source array volume.
+func (i *FlashSystemImporter) Resolve(backing *resolver.DiskBacking) (map[string]string, error) {
+	if backing == nil {
+		return nil, fmt.Errorf("nil disk backing")
+	}
+	switch resolver.DetectDiskType(backing) {
+	case resolver.DiskTypeVVol:
+		klog.V(2).InfoS("FlashSystem VVol CSI import not supported, deferring to xcopy", "vvolID", backing.VVolID)
+		return nil, nil //nolint:nilnil
+	case resolver.DiskTypeRDM:
+		return i.resolveRDM(backing.DeviceName)
+	default:
+		return nil, fmt.Errorf("FlashSystem CSI import does not support VMDK disks")
+	}
+}
+
+// resolveRDM maps a vSphere RDM device name to the vdisk whose UID is the NAA identifier.
+func (i *FlashSystemImporter) resolveRDM(deviceName string) (map[string]string, error) {
+	naa, err := resolver.NAAFromDeviceName(deviceName)
+	if err != nil {
+		return nil, fmt.Errorf("FlashSystem RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	volume, err := i.vdiskByUID(strings.ToUpper(naa))
+	if err != nil {
+		return nil, fmt.Errorf("FlashSystem RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	host, _ := url.Parse(i.baseURL)
+	return map[string]string{
+		resolver.AnnCsiVolumeHandle:                            fmt.Sprintf("%s:%s;%s", storageType, volume.ID, volume.UID),
+		resolver.AnnCsiVolumeAttributePrefix + "array_address": host.Hostname(),
+		resolver.AnnCsiVolumeAttributePrefix + "pool_name":     volume.PoolName,
+		resolver.AnnCsiVolumeAttributePrefix + "storage_type":  storageType,
+		resolver.AnnCsiVolumeAttributePrefix + "volume_name":   volume.Name,
+	}, nil
+}
+
+// vdiskByUID queries POST /rest/v1/lsvdisk filtered by vdisk_UID.
+func (i *FlashSystemImporter) vdiskByUID(uid string) (*vdisk, error) {
+	token, err := i.authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := json.Marshal(map[string]string{"filtervalue": "vdisk_UID=" + uid})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lsvdisk filter: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, i.baseURL+"/rest/v1/lsvdisk", bytes.NewBuffer(filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build FlashSystem request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Auth-Token", token)
+
+	body, err := i.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var vdisks []vdisk
+	if err := json.Unmarshal(body, &vdisks); err != nil {
+		return nil, fmt.Errorf("failed to parse FlashSystem response: %w", err)
+	}
+	if len(vdisks) == 0 {
+		return nil, fmt.Errorf("no FlashSystem volume found for UID %s", uid)
+	}
+	if len(vdisks) > 1 {
+		return nil, fmt.Errorf("multiple FlashSystem volumes (%d) found for UID %s", len(vdisks), uid)
+	}
+	return &vdisks[0], nil
+}
+
+// authenticate sends POST /rest/v1/auth and returns the session token.
+func (i *FlashSystemImporter) authenticate() (string, error) {
+	req, err := http.NewRequest(http.MethodPost, i.baseURL+"/rest/v1/auth", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build auth request: %w", err)
+	}
+	req.Header.Set("X-Auth-Username", i.user)
+	req.Header.Set("X-Auth-Password", i.pass)
+
+	body, err := i.do(req)
+	if err != nil {
+		return "", fmt.Errorf("FlashSystem auth failed at %s: %w", i.baseURL, err)
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse auth response: %w", err)
+	}
+	if result.Token == "" {
+		return "", fmt.Errorf("no session token returned by FlashSystem at %s", i.baseURL)
+	}
+	return result.Token, nil
+}
+
+func (i *FlashSystemImporter) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("FlashSystem REST request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FlashSystem response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FlashSystem REST returned %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
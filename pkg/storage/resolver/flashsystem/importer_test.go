@@ -0,0 +1,136 @@
+package flashsystem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/resolvertest"
+)
+
+// mockFlashSystem creates a test server replaying the recorded FlashSystem REST responses.
+// vdisksByUID maps a vdisk UID to the recorded lsvdisk fixture returned for it.
+func mockFlashSystem(t *testing.T, vdisksByUID map[string]string) (*httptest.Server, *FlashSystemImporter) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/rest/v1/auth":
+			if r.Header.Get("X-Auth-Username") != "test" || r.Header.Get("X-Auth-Password") != "test" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "test-token"})
+
+		case r.Method == "POST" && r.URL.Path == "/rest/v1/lsvdisk":
+			if r.Header.Get("X-Auth-Token") != "test-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var filter map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			name, ok := vdisksByUID[strings.TrimPrefix(filter["filtervalue"], "vdisk_UID=")]
+			if !ok {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_, _ = w.Write(resolvertest.Fixture(t, name))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	imp := &FlashSystemImporter{
+		baseURL: srv.URL,
+		user:    "test",
+		pass:    "test",
+		client:  srv.Client(),
+	}
+	return srv, imp
+}
+
+func TestNewFlashSystemImporter(t *testing.T) {
+	imp, err := NewFlashSystemImporter("https://10.46.2.50:7443/", "user", "pass", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imp.baseURL != "https://10.46.2.50:7443" {
+		t.Errorf("baseURL = %q", imp.baseURL)
+	}
+
+	for _, input := range []string{"10.46.2.50", "host:7443", ""} {
+		if _, err := NewFlashSystemImporter(input, "user", "pass", true); err == nil {
+			t.Errorf("NewFlashSystemImporter(%q) expected error for missing scheme, got nil", input)
+		}
+	}
+}
+
+func TestResolveRDM(t *testing.T) {
+	srv, imp := mockFlashSystem(t, map[string]string{
+		"600507681081804CB800000000001074": "lsvdisk.json",
+	})
+	defer srv.Close()
+
+	annotations, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		resolver.AnnCsiVolumeHandle:                            "SVC:5;600507681081804CB800000000001074",
+		resolver.AnnCsiVolumeAttributePrefix + "array_address": "127.0.0.1",
+		resolver.AnnCsiVolumeAttributePrefix + "pool_name":     "Pool0",
+		resolver.AnnCsiVolumeAttributePrefix + "storage_type":  "SVC",
+		resolver.AnnCsiVolumeAttributePrefix + "volume_name":   "erp-03-rdm",
+	}
+	if len(annotations) != len(want) {
+		t.Errorf("annotations = %v, want %v", annotations, want)
+	}
+	for k, v := range want {
+		if annotations[k] != v {
+			t.Errorf("annotation %s = %q, want %q", k, annotations[k], v)
+		}
+	}
+}
+
+func TestResolveRDMNotFound(t *testing.T) {
+	srv, imp := mockFlashSystem(t, map[string]string{})
+	defer srv.Close()
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "no FlashSystem volume found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveAuthFailed(t *testing.T) {
+	srv, imp := mockFlashSystem(t, map[string]string{})
+	defer srv.Close()
+	imp.pass = "wrong"
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "auth failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveVVol(t *testing.T) {
+	imp := &FlashSystemImporter{}
+	annotations, err := imp.Resolve(&resolver.DiskBacking{VVolID: "rfc4122.1d6f2a9c-8e3b-4c70-a5d1-6b0e9f3c2a48"})
+	if err != nil || annotations != nil {
+		t.Errorf("expected deferral for VVol, got %v, %v", annotations, err)
+	}
+}
+
+func TestResolveVMDK(t *testing.T) {
+	imp := &FlashSystemImporter{}
+	_, err := imp.Resolve(&resolver.DiskBacking{DeviceName: "[ds] vm/vm.vmdk"})
+	if err == nil || !strings.Contains(err.Error(), "does not support VMDK") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package powermax
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"k8s.io/klog/v2"
+)
+
+// unisphereVersion is the version of the Unisphere for PowerMax REST API.
+const unisphereVersion = "100"
+
+// PowerMaxImporter implements CsiImportPlugin for Dell PowerMax via the Unisphere REST API.
+// The PowerMax CSI driver adopts an existing volume through a static PersistentVolume
+// whose volume handle is "<volume identifier>-<symmetrix ID>-<device ID>". The driver
+// checks the identifier against the volume, so the volume must have one.
+type PowerMaxImporter struct {
+	baseURL     string // e.g. "https://unisphere.example.com:8443"
+	user        string
+	pass        string
+	symmetrixID string
+	client      *http.Client
+}
+
+func NewPowerMaxImporter(host, user, pass, symmetrixID string, skipSSL bool) (*PowerMaxImporter, error) {
+	parsedURL, err := url.Parse(host)
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("STORAGE_HOSTNAME must be a full URL with scheme (e.g. https://host:8443), got %q", host)
+	}
+	if symmetrixID == "" {
+		return nil, fmt.Errorf("POWERMAX_SYMMETRIX_ID must not be empty")
+	}
+
+	return &PowerMaxImporter{
+		baseURL:     strings.TrimRight(host, "/"),
+		user:        user,
+		pass:        pass,
+		symmetrixID: symmetrixID,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSL}, //nolint:gosec
+			},
+		},
+	}, nil
+}
+
+// Resolve returns the PVC annotations with the CSI volume handle of the source array volume.
+func (i *PowerMaxImporter) Resolve(backing *resolver.DiskBacking) (map[string]string, error) {
+	if backing == nil {
+		return nil, fmt.Errorf("nil disk backing")
+	}
+	switch resolver.DetectDiskType(backing) {
+	case resolver.DiskTypeVVol:
+		klog.V(2).InfoS("PowerMax VVol CSI import not supported, deferring to xcopy", "vvolID", backing.VVolID)
+		return nil, nil //nolint:nilnil
+	case resolver.DiskTypeRDM:
+		return i.resolveRDM(backing.DeviceName)
+	default:
+		return nil, fmt.Errorf("PowerMax CSI import does not support VMDK disks")
+	}
+}
+
+// resolveRDM maps a vSphere RDM device name to the PowerMax device with the same WWN.
+func (i *PowerMaxImporter) resolveRDM(deviceName string) (map[string]string, error) {
+	naa, err := resolver.NAAFromDeviceName(deviceName)
+	if err != nil {
+		return nil, fmt.Errorf("PowerMax RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	deviceID, err := i.deviceIDByWWN(strings.ToUpper(naa))
+	if err != nil {
+		return nil, fmt.Errorf("PowerMax RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	identifier, err := i.volumeIdentifier(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("PowerMax RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	return map[string]string{
+		resolver.AnnCsiVolumeHandle: strings.Join([]string{identifier, i.symmetrixID, deviceID}, "-"),
+	}, nil
+}
+
+// deviceIDByWWN queries GET .../symmetrix/<id>/volume?wwn=<wwn> and returns the device ID.
+func (i *PowerMaxImporter) deviceIDByWWN(wwn string) (string, error) {
+	var result struct {
+		Count      int `json:"count"`
+		ResultList struct {
+			Result []struct {
+				VolumeID string `json:"volumeId"`
+			} `json:"result"`
+		} `json:"resultList"`
+	}
+	if err := i.get("/volume?wwn="+url.QueryEscape(wwn), &result); err != nil {
+		return "", err
+	}
+	if result.Count == 0 || len(result.ResultList.Result) == 0 {
+		return "", fmt.Errorf("no PowerMax device found for WWN %s on array %s", wwn, i.symmetrixID)
+	}
+	if result.Count > 1 {
+		return "", fmt.Errorf("multiple PowerMax devices (%d) found for WWN %s on array %s", result.Count, wwn, i.symmetrixID)
+	}
+	return result.ResultList.Result[0].VolumeID, nil
+}
+
+// volumeIdentifier queries GET .../symmetrix/<id>/volume/<device> and returns the volume identifier.
+func (i *PowerMaxImporter) volumeIdentifier(deviceID string) (string, error) {
+	var volume struct {
+		VolumeID         string `json:"volumeId"`
+		VolumeIdentifier string `json:"volume_identifier"`
+	}
+	if err := i.get("/volume/"+url.PathEscape(deviceID), &volume); err != nil {
+		return "", err
+	}
+	if volume.VolumeIdentifier == "" {
+		return "", fmt.Errorf("PowerMax device %s has no volume identifier, set one to import it with the CSI driver", deviceID)
+	}
+	return volume.VolumeIdentifier, nil
+}
+
+// get sends a GET request for the path relative to the sloprovisioning resource of the array.
+func (i *PowerMaxImporter) get(path string, result interface{}) error {
+	reqURL := fmt.Sprintf("%s/univmax/restapi/%s/sloprovisioning/symmetrix/%s%s",
+		i.baseURL, unisphereVersion, url.PathEscape(i.symmetrixID), path)
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build Unisphere request: %w", err)
+	}
+	req.SetBasicAuth(i.user, i.pass)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("REST request to Unisphere failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Unisphere response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("REST API of Unisphere returned %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse Unisphere response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package powermax
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/resolvertest"
+)
+
+const symmetrixID = "000120001234"
+
+// mockUnisphere creates a test server replaying the recorded Unisphere REST responses.
+// devicesByWWN maps a device WWN to its device ID, volumes maps a device ID to the
+// recorded volume fixture returned for it.
+func mockUnisphere(t *testing.T, devicesByWWN map[string]string, volumes map[string]string) (*httptest.Server, *PowerMaxImporter) {
+	t.Helper()
+	base := "/univmax/restapi/100/sloprovisioning/symmetrix/" + symmetrixID + "/volume"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "test" || pass != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == base:
+			if _, ok := devicesByWWN[r.URL.Query().Get("wwn")]; !ok {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"count": 0, "resultList": map[string]interface{}{"result": []interface{}{}}})
+				return
+			}
+			_, _ = w.Write(resolvertest.Fixture(t, "volume-list.json"))
+
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, base+"/"):
+			name, ok := volumes[strings.TrimPrefix(r.URL.Path, base+"/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(resolvertest.Fixture(t, name))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	imp := &PowerMaxImporter{
+		baseURL:     srv.URL,
+		user:        "test",
+		pass:        "test",
+		symmetrixID: symmetrixID,
+		client:      srv.Client(),
+	}
+	return srv, imp
+}
+
+func TestNewPowerMaxImporter(t *testing.T) {
+	imp, err := NewPowerMaxImporter("https://10.46.2.40:8443/", "user", "pass", symmetrixID, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imp.baseURL != "https://10.46.2.40:8443" {
+		t.Errorf("baseURL = %q", imp.baseURL)
+	}
+
+	if _, err := NewPowerMaxImporter("10.46.2.40:8443", "user", "pass", symmetrixID, true); err == nil {
+		t.Error("expected error for missing scheme, got nil")
+	}
+	if _, err := NewPowerMaxImporter("https://10.46.2.40:8443", "user", "pass", "", true); err == nil {
+		t.Error("expected error for missing symmetrix ID, got nil")
+	}
+}
+
+func TestResolveRDM(t *testing.T) {
+	srv, imp := mockUnisphere(t,
+		map[string]string{"60000970000120001234533030313241": "0012A"},
+		map[string]string{"0012A": "volume.json"})
+	defer srv.Close()
+
+	annotations, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "db-01-rdm-000120001234-0012A"
+	if annotations[resolver.AnnCsiVolumeHandle] != want {
+		t.Errorf("volume handle = %q, want %q", annotations[resolver.AnnCsiVolumeHandle], want)
+	}
+}
+
+func TestResolveRDMNoIdentifier(t *testing.T) {
+	srv, imp := mockUnisphere(t,
+		map[string]string{"60000970000120001234533030313241": "0012A"},
+		map[string]string{"0012A": "volume-no-identifier.json"})
+	defer srv.Close()
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "has no volume identifier") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveRDMNotFound(t *testing.T) {
+	srv, imp := mockUnisphere(t, map[string]string{}, map[string]string{})
+	defer srv.Close()
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "no PowerMax device found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveVVol(t *testing.T) {
+	imp := &PowerMaxImporter{}
+	annotations, err := imp.Resolve(&resolver.DiskBacking{VVolID: "rfc4122.7e3b9d1a-2c4f-4a8e-b6d0-1f5c9e2a7b34"})
+	if err != nil || annotations != nil {
+		t.Errorf("expected deferral for VVol, got %v, %v", annotations, err)
+	}
+}
+
+func TestResolveVMDK(t *testing.T) {
+	imp := &PowerMaxImporter{}
+	_, err := imp.Resolve(&resolver.DiskBacking{DeviceName: "[ds] vm/vm.vmdk"})
+	if err == nil || !strings.Contains(err.Error(), "does not support VMDK") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
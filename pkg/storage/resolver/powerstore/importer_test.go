@@ -0,0 +1,115 @@
+package powerstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/resolvertest"
+)
+
+// mockPowerStore creates a test server replaying the recorded PowerStore REST responses.
+// volumesByWWN maps a volume WWN to the recorded volume fixture returned for it.
+func mockPowerStore(t *testing.T, volumesByWWN map[string]string) (*httptest.Server, *PowerStoreImporter) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "test" || pass != "test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/rest/volume":
+			name, ok := volumesByWWN[strings.TrimPrefix(r.URL.Query().Get("wwn"), "eq.")]
+			if !ok {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_, _ = w.Write(resolvertest.Fixture(t, name))
+
+		case r.Method == "GET" && r.URL.Path == "/api/rest/cluster":
+			_, _ = w.Write(resolvertest.Fixture(t, "cluster.json"))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	imp := &PowerStoreImporter{
+		baseURL: srv.URL,
+		user:    "test",
+		pass:    "test",
+		client:  srv.Client(),
+	}
+	return srv, imp
+}
+
+func TestNewPowerStoreImporter(t *testing.T) {
+	imp, err := NewPowerStoreImporter("https://10.46.2.30/", "user", "pass", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if imp.baseURL != "https://10.46.2.30" {
+		t.Errorf("baseURL = %q", imp.baseURL)
+	}
+
+	for _, input := range []string{"10.46.2.30", "host:443", ""} {
+		if _, err := NewPowerStoreImporter(input, "user", "pass", true); err == nil {
+			t.Errorf("NewPowerStoreImporter(%q) expected error for missing scheme, got nil", input)
+		}
+	}
+}
+
+func TestResolveRDM(t *testing.T) {
+	srv, imp := mockPowerStore(t, map[string]string{
+		"naa.68ccf09800d3e5b1a7c44f2e9b6a0c18": "volume.json",
+	})
+	defer srv.Close()
+
+	annotations, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "4f2d8c91-7b3e-4a06-9e15-2c8d0b7f6a13/PS4ebb8d4e8488/scsi"
+	if annotations[resolver.AnnCsiVolumeHandle] != want {
+		t.Errorf("volume handle = %q, want %q", annotations[resolver.AnnCsiVolumeHandle], want)
+	}
+}
+
+func TestResolveRDMNotFound(t *testing.T) {
+	srv, imp := mockPowerStore(t, map[string]string{})
+	defer srv.Close()
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "no PowerStore volume found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveUnauthorized(t *testing.T) {
+	srv, imp := mockPowerStore(t, map[string]string{})
+	defer srv.Close()
+	imp.pass = "wrong"
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err == nil || !strings.Contains(err.Error(), "returned 401") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveVVol(t *testing.T) {
+	imp := &PowerStoreImporter{}
+	annotations, err := imp.Resolve(&resolver.DiskBacking{VVolID: "rfc4122.9a1c7e2d-0b4f-4e6a-8d3c-5f2e1b0a7c96"})
+	if err != nil || annotations != nil {
+		t.Errorf("expected deferral for VVol, got %v, %v", annotations, err)
+	}
+}
+
+func TestResolveVMDK(t *testing.T) {
+	imp := &PowerStoreImporter{}
+	_, err := imp.Resolve(&resolver.DiskBacking{DeviceName: "[ds] vm/vm.vmdk"})
+	if err == nil || !strings.Contains(err.Error(), "does not support VMDK") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package pure
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"k8s.io/klog/v2"
+)
+
+// flashProviderID is the IEEE OUI of Pure Storage, the NAA of a FlashArray
+// volume is "naa.624a9370" followed by the volume serial.
+const flashProviderID = "624a9370"
+
+// PureImporter implements CsiImportPlugin for Pure Storage FlashArray via the REST API.
+// The FlashArray CSI driver adopts an existing volume through a static PersistentVolume
+// whose volume handle is the FlashArray volume ID.
+type PureImporter struct {
+	baseURL string // e.g. "https://flasharray.example.com"
+	user    string
+	pass    string
+	client  *http.Client
+}
+
+func NewPureImporter(host, user, pass string, skipSSL bool) (*PureImporter, error) {
+	parsedURL, err := url.Parse(host)
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("STORAGE_HOSTNAME must be a full URL with scheme (e.g. https://host), got %q", host)
+	}
+
+	return &PureImporter{
+		baseURL: strings.TrimRight(host, "/"),
+		user:    user,
+		pass:    pass,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSL}, //nolint:gosec
+			},
+		},
+	}, nil
+}
+
+// Resolve returns the PVC annotations with the CSI volume handle of the source array volume.
+func (i *PureImporter) Resolve(backing *resolver.DiskBacking) (map[string]string, error) {
+	if backing == nil {
+		return nil, fmt.Errorf("nil disk backing")
+	}
+	switch resolver.DetectDiskType(backing) {
+	case resolver.DiskTypeVVol:
+		klog.V(2).InfoS("FlashArray VVol CSI import not supported, deferring to xcopy", "vvolID", backing.VVolID)
+		return nil, nil //nolint:nilnil
+	case resolver.DiskTypeRDM:
+		return i.resolveRDM(backing.DeviceName)
+	default:
+		return nil, fmt.Errorf("FlashArray CSI import does not support VMDK disks")
+	}
+}
+
+// resolveRDM maps a vSphere RDM device name to the FlashArray volume ID.
+// The volume serial is the NAA identifier without the Pure Storage OUI.
+func (i *PureImporter) resolveRDM(deviceName string) (map[string]string, error) {
+	serial, err := extractSerial(deviceName)
+	if err != nil {
+		return nil, fmt.Errorf("FlashArray RDM resolution failed (DeviceName: %s): %w", deviceName, err)
+	}
+	id, err := i.volumeIDBySerial(serial)
+	if err != nil {
+		return nil, fmt.Errorf("FlashArray RDM resolution failed (serial: %s): %w", serial, err)
+	}
+	return map[string]string{resolver.AnnCsiVolumeHandle: id}, nil
+}
+
+// extractSerial returns the uppercase FlashArray volume serial from an NAA or VML device name.
+func extractSerial(deviceName string) (string, error) {
+	naa, err := resolver.NAAFromDeviceName(deviceName)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(naa, flashProviderID) {
+		return "", fmt.Errorf("device %s does not have Pure Storage OUI prefix %s", deviceName, flashProviderID)
+	}
+	return strings.ToUpper(strings.TrimPrefix(naa, flashProviderID)), nil
+}
+
+// volumeIDBySerial queries GET /api/<2.x>/volumes?filter=serial='<serial>' and returns the volume ID.
+func (i *PureImporter) volumeIDBySerial(serial string) (string, error) {
+	v1, v2, err := i.apiVersions()
+	if err != nil {
+		return "", err
+	}
+	authToken, err := i.login(v1, v2)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("filter", fmt.Sprintf("serial='%s'", serial))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/%s/volumes?%s", i.baseURL, v2, params.Encode()), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build FlashArray request: %w", err)
+	}
+	req.Header.Set("x-auth-token", authToken)
+
+	body, err := i.do(req, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Items []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Serial string `json:"serial"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse FlashArray response: %w", err)
+	}
+	if len(result.Items) == 0 {
+		return "", fmt.Errorf("no FlashArray volume found for serial %s", serial)
+	}
+	if len(result.Items) > 1 {
+		return "", fmt.Errorf("multiple FlashArray volumes (%d) found for serial %s", len(result.Items), serial)
+	}
+	if result.Items[0].ID == "" {
+		return "", fmt.Errorf("volume %s has no ID", result.Items[0].Name)
+	}
+	return result.Items[0].ID, nil
+}
+
+// apiVersions returns the latest 1.x and 2.x versions listed by GET /api/api_version.
+// The 1.x API issues the API token, the 2.x API serves the volume queries.
+func (i *PureImporter) apiVersions() (v1, v2 string, err error) {
+	req, err := http.NewRequest(http.MethodGet, i.baseURL+"/api/api_version", nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build FlashArray request: %w", err)
+	}
+	body, err := i.do(req, http.StatusOK)
+	if err != nil {
+		return "", "", err
+	}
+	var result struct {
+		Version []string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", "", fmt.Errorf("failed to parse FlashArray API versions: %w", err)
+	}
+	for _, version := range result.Version {
+		switch {
+		case strings.HasPrefix(version, "1.") && compareVersions(version, v1) > 0:
+			v1 = version
+		case strings.HasPrefix(version, "2.") && compareVersions(version, v2) > 0:
+			v2 = version
+		}
+	}
+	if v1 == "" || v2 == "" {
+		return "", "", fmt.Errorf("REST API versions 1.x and 2.x not supported by the FlashArray at %s: %v", i.baseURL, result.Version)
+	}
+	return v1, v2, nil
+}
+
+// login exchanges the credentials for an API token, then the API token
+// for the session token returned in the x-auth-token header.
+func (i *PureImporter) login(v1, v2 string) (string, error) {
+	credentials, err := json.Marshal(map[string]string{"username": i.user, "password": i.pass})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/%s/auth/apitoken", i.baseURL, v1), bytes.NewBuffer(credentials))
+	if err != nil {
+		return "", fmt.Errorf("failed to build auth request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := i.do(req, http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("auth failed at %s: %w", i.baseURL, err)
+	}
+	var token struct {
+		APIToken string `json:"api_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to parse auth response: %w", err)
+	}
+	if token.APIToken == "" {
+		return "", fmt.Errorf("no API token returned by FlashArray at %s", i.baseURL)
+	}
+
+	req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/%s/login", i.baseURL, v2), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build login request: %w", err)
+	}
+	req.Header.Set("api-token", token.APIToken)
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("login failed at %s: %w", i.baseURL, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login returned %d at %s", resp.StatusCode, i.baseURL)
+	}
+	authToken := resp.Header.Get("x-auth-token")
+	if authToken == "" {
+		return "", fmt.Errorf("no x-auth-token returned by FlashArray at %s", i.baseURL)
+	}
+	return authToken, nil
+}
+
+func (i *PureImporter) do(req *http.Request, status int) ([]byte, error) {
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("REST request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FlashArray response: %w", err)
+	}
+	if resp.StatusCode != status {
+		return nil, fmt.Errorf("REST API returned %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// compareVersions compares dotted version strings numerically, an empty version is the lowest.
+func compareVersions(v1, v2 string) int {
+	parts1 := strings.Split(v1, ".")
+	parts2 := strings.Split(v2, ".")
+	for n := 0; n < len(parts1) || n < len(parts2); n++ {
+		var p1, p2 int
+		if n < len(parts1) {
+			p1, _ = strconv.Atoi(parts1[n])
+		}
+		if n < len(parts2) {
+			p2, _ = strconv.Atoi(parts2[n])
+		}
+		if p1 != p2 {
+			return p1 - p2
+		}
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+package pure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/kubev2v/forklift/pkg/storage/resolver/resolvertest"
+)
+
+// mockFlashArray creates a test server replaying the recorded FlashArray REST responses.
+// volumesBySerial maps a volume serial to the recorded volumes fixture returned for it.
+func mockFlashArray(t *testing.T, volumesBySerial map[string]string) (*httptest.Server, *PureImporter) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/api_version":
+			_, _ = w.Write(resolvertest.Fixture(t, "api_version.json"))
+
+		case r.Method == "POST" && r.URL.Path == "/api/1.19/auth/apitoken":
+			_ = json.NewEncoder(w).Encode(map[string]string{"api_token": "test-api-token"})
+
+		case r.Method == "POST" && r.URL.Path == "/api/2.21/login":
+			if r.Header.Get("api-token") != "test-api-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("x-auth-token", "test-auth-token")
+
+		case r.Method == "GET" && r.URL.Path == "/api/2.21/volumes":
+			if r.Header.Get("x-auth-token") != "test-auth-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			serial := strings.TrimSuffix(strings.TrimPrefix(r.URL.Query().Get("filter"), "serial='"), "'")
+			name, ok := volumesBySerial[serial]
+			if !ok {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": []interface{}{}, "total_item_count": 0})
+				return
+			}
+			_, _ = w.Write(resolvertest.Fixture(t, name))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	imp := &PureImporter{
+		baseURL: srv.URL,
+		user:    "test",
+		pass:    "test",
+		client:  srv.Client(),
+	}
+	return srv, imp
+}
+
+func TestNewPureImporter(t *testing.T) {
+	validTests := []struct {
+		input string
+		want  string
+	}{
+		{"https://host", "https://host"},
+		{"https://10.46.2.20/", "https://10.46.2.20"},
+	}
+	for _, tt := range validTests {
+		imp, err := NewPureImporter(tt.input, "user", "pass", true)
+		if err != nil {
+			t.Fatalf("NewPureImporter(%q) unexpected error: %v", tt.input, err)
+		}
+		if imp.baseURL != tt.want {
+			t.Errorf("NewPureImporter(%q) baseURL = %q, want %q", tt.input, imp.baseURL, tt.want)
+		}
+	}
+
+	for _, input := range []string{"10.46.2.20", "host:443", ""} {
+		if _, err := NewPureImporter(input, "user", "pass", true); err == nil {
+			t.Errorf("NewPureImporter(%q) expected error for missing scheme, got nil", input)
+		}
+	}
+}
+
+func TestResolveRDM(t *testing.T) {
+	srv, imp := mockFlashArray(t, map[string]string{
+		"B3C9A7F2E8D14A6B00011A2F": "volumes.json",
+	})
+	defer srv.Close()
+
+	annotations, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if annotations[resolver.AnnCsiVolumeHandle] != "0c6a3e6f-5d0e-2f4b-8a1c-2e0b5b7d3f19" {
+		t.Errorf("unexpected annotations: %v", annotations)
+	}
+}
+
+func TestResolveRDMNotFound(t *testing.T) {
+	srv, imp := mockFlashArray(t, map[string]string{})
+	defer srv.Close()
+
+	_, err := imp.Resolve(resolvertest.LoadRDMBacking(t, "rdm-backing-naa.json"))
+	if err == nil || !strings.Contains(err.Error(), "no FlashArray volume found for serial B3C9A7F2E8D14A6B00011A30") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveRDMOtherVendor(t *testing.T) {
+	srv, imp := mockFlashArray(t, map[string]string{})
+	defer srv.Close()
+
+	backing := &resolver.DiskBacking{IsRDM: true, DeviceName: "naa.60002ac0000000000000182d00021f6b"}
+	_, err := imp.Resolve(backing)
+	if err == nil || !strings.Contains(err.Error(), "OUI prefix") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveVVol(t *testing.T) {
+	imp := &PureImporter{}
+	annotations, err := imp.Resolve(&resolver.DiskBacking{VVolID: "rfc4122.3b8e1f4c-5a6d-4e2b-9c7f-0d1e2f3a4b5c"})
+	if err != nil || annotations != nil {
+		t.Errorf("expected deferral for VVol, got %v, %v", annotations, err)
+	}
+}
+
+func TestResolveVMDK(t *testing.T) {
+	imp := &PureImporter{}
+	_, err := imp.Resolve(&resolver.DiskBacking{DeviceName: "[ds] vm/vm.vmdk"})
+	if err == nil || !strings.Contains(err.Error(), "does not support VMDK") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		v1, v2 string
+		want   int
+	}{
+		{"2.10", "2.9", 1},
+		{"1.19", "1.19", 0},
+		{"2.4", "2.21", -1},
+		{"1.0", "", 1},
+	}
+	for _, tt := range tests {
+		got := compareVersions(tt.v1, tt.v2)
+		if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", tt.v1, tt.v2, got, tt.want)
+		}
+	}
+}
@@ -9,3 +9,14 @@ type CsiImportPlugin interface {
 	// cannot handle this disk type (caller should fall through to other migration paths).
 	Resolve(backing *DiskBacking) (map[string]string, error)
 }
+
+const (
+	// AnnCsiVolumeHandle is returned by vendors whose CSI driver adopts an existing
+	// array volume through static provisioning rather than an import annotation.
+	// The controller pre-creates a PersistentVolume with this volume handle, bound
+	// to the PVC, using the provisioner of the destination StorageClass as driver.
+	AnnCsiVolumeHandle = "forklift.konveyor.io/csi-volume-handle"
+	// AnnCsiVolumeAttributePrefix prefixes the annotations carrying the volume
+	// attributes (volume context) of the pre-created PersistentVolume.
+	AnnCsiVolumeAttributePrefix = "csi-volume-attribute.forklift.konveyor.io/"
+)
@@ -0,0 +1,39 @@
+// Package resolvertest provides the helpers shared by the unit tests
+// of the vendor importers, reading the recorded fixtures of testdata.
+package resolvertest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubev2v/forklift/pkg/storage/resolver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// LoadRDMBacking reads a recorded vSphere RDM backing from testdata and
+// returns the disk backing detected for it.
+func LoadRDMBacking(t *testing.T, name string) *resolver.DiskBacking {
+	t.Helper()
+	rdm := &types.VirtualDiskRawDiskMappingVer1BackingInfo{}
+	if err := json.Unmarshal(Fixture(t, name), rdm); err != nil {
+		t.Fatal(err)
+	}
+	devices := []types.BaseVirtualDevice{&types.VirtualDisk{VirtualDevice: types.VirtualDevice{Backing: rdm}}}
+	backing, err := resolver.DiskBackingFromDevices(devices, rdm.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backing
+}
+
+// Fixture reads a recorded response from testdata.
+func Fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
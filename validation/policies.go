@@ -0,0 +1,10 @@
+// Package validation embeds the rego policies evaluated
+// in-process by the embedded policy evaluator.
+package validation
+
+import "embed"
+
+// Policies is the tree of rego policies rooted at policies/.
+//
+//go:embed policies
+var Policies embed.FS